@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ageUnits are the suffixes --newer-than and --older-than accept beyond
+// those of time.ParseDuration
+var ageUnits = map[byte]time.Duration{
+	'd': 24 * time.Hour,
+	'w': 7 * 24 * time.Hour,
+	'y': 365 * 24 * time.Hour,
+}
+
+// timeBoundLayouts are the absolute forms of --newer-than and --older-than
+var timeBoundLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"}
+
+// parseTimeBound parses an age such as "30d", "2w" or "12h", counted back
+// from now, or a date such as "2024-01-31" or an RFC 3339 time. Dates
+// without a zone are local. An empty string is the zero time, meaning no
+// bound.
+func parseTimeBound(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	for _, layout := range timeBoundLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	if unit, ok := ageUnits[s[len(s)-1]]; ok {
+		if n, err := strconv.ParseFloat(s[:len(s)-1], 64); err == nil && n >= 0 {
+			return now.Add(-time.Duration(n * float64(unit))), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+
+	return time.Time{}, fmt.Errorf("%q is neither an age such as 30d, 2w or 12h nor a date such as 2024-01-31", s)
+}
+
+// timeWindow is the span of modification times a file must fall in
+type timeWindow struct {
+	after  time.Time // Files modified before this are too old; zero for no bound
+	before time.Time // Files modified after this are too new; zero for no bound
+}
+
+// newTimeWindow returns nil when neither bound is set. Bounds are checked
+// by validateConfig, so an invalid one is never seen here.
+func newTimeWindow(config *Config) *timeWindow {
+	if config.NewerThan == "" && config.OlderThan == "" {
+		return nil
+	}
+	now := time.Now()
+	after, _ := parseTimeBound(config.NewerThan, now)
+	before, _ := parseTimeBound(config.OlderThan, now)
+	return &timeWindow{after: after, before: before}
+}
+
+// ageReason returns why a file is left out by when it was last changed, or
+// "" to keep it. With GitDates that is the date of the last commit that
+// touched it; files without one, such as untracked files, fall back to
+// their modification time.
+func (p *fileProcessor) ageReason(relPath, path string) string {
+	if p.window == nil {
+		return ""
+	}
+
+	modified, ok := p.commitTime(relPath)
+	if !ok {
+		info, err := os.Stat(path)
+		if err != nil {
+			return ""
+		}
+		modified = info.ModTime()
+	}
+
+	switch {
+	case !p.window.after.IsZero() && modified.Before(p.window.after):
+		return "too old"
+	case !p.window.before.IsZero() && modified.After(p.window.before):
+		return "too new"
+	}
+	return ""
+}
+
+// commitTime returns the time of the last commit that touched relPath
+// when GitDates is set. The whole history is read once, on first use.
+func (p *fileProcessor) commitTime(relPath string) (time.Time, bool) {
+	if !p.config.GitDates {
+		return time.Time{}, false
+	}
+	if p.commitTimes == nil {
+		p.commitTimes = readCommitTimes(p.config.InputDir)
+	}
+	t, ok := p.commitTimes[filepath.ToSlash(relPath)]
+	return t, ok
+}
+
+// readCommitTimes maps each file under dir to the time of the last commit
+// that touched it, by path relative to dir. Outside a repository the map
+// is empty.
+func readCommitTimes(dir string) map[string]time.Time {
+	times := make(map[string]time.Time)
+	out, err := runGit(dir, "-c", "core.quotePath=false", "log", "--relative", "--no-renames",
+		"--format=%x00%ct", "--name-only", "--", ".")
+	if err != nil {
+		return times
+	}
+
+	// Commits are listed newest first, so the first time seen for a path is its last change
+	var current time.Time
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case strings.HasPrefix(line, "\x00"):
+			if sec, err := strconv.ParseInt(line[1:], 10, 64); err == nil {
+				current = time.Unix(sec, 0)
+			}
+		case line != "":
+			if _, seen := times[line]; !seen {
+				times[line] = current
+			}
+		}
+	}
+	return times
+}
+
+// validateTimeWindow checks the --newer-than and --older-than bounds
+func validateTimeWindow(config *Config) error {
+	now := time.Now()
+	if _, err := parseTimeBound(config.NewerThan, now); err != nil {
+		return fmt.Errorf("invalid --newer-than: %w", err)
+	}
+	if _, err := parseTimeBound(config.OlderThan, now); err != nil {
+		return fmt.Errorf("invalid --older-than: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// noteMarker opens the annotation line written after a file's start marker
+const noteMarker = "--- NOTE: "
+
+// loadAnnotations reads the annotations file: a JSON object mapping paths or
+// globs, relative to the input directory, to notes about the files
+func (p *fileProcessor) loadAnnotations() error {
+	if p.config.AnnotationsFile == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(p.config.AnnotationsFile)
+	if err != nil {
+		return fmt.Errorf("error reading annotations file: %w", err)
+	}
+
+	annotations := make(map[string]string)
+	if err := json.Unmarshal(data, &annotations); err != nil {
+		return fmt.Errorf("error parsing annotations file: %w", err)
+	}
+
+	p.annotations = make(map[string]string, len(annotations))
+	for pattern, note := range annotations {
+		// Notes must stay on the single header line
+		note = strings.Join(strings.Fields(note), " ")
+		if note != "" {
+			p.annotations[filepath.ToSlash(filepath.Clean(pattern))] = note
+		}
+	}
+	return nil
+}
+
+// annotation returns the note for relPath. An exact path wins; otherwise
+// the notes of every matching glob are joined in pattern order.
+func (p *fileProcessor) annotation(relPath string) string {
+	if len(p.annotations) == 0 {
+		return ""
+	}
+
+	relPath = filepath.ToSlash(relPath)
+	if note, ok := p.annotations[relPath]; ok {
+		return note
+	}
+
+	patterns := make([]string, 0, len(p.annotations))
+	for pattern := range p.annotations {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	var notes []string
+	for _, pattern := range patterns {
+		if matched, err := matchPathPattern(pattern, relPath); err == nil && matched {
+			notes = append(notes, p.annotations[pattern])
+		}
+	}
+	return strings.Join(notes, "; ")
+}
+
+// startSeparator returns the marker that opens a file, followed by its
+// annotation and last commit lines when it has them
+func (p *fileProcessor) startSeparator(relPath, name string) string {
+	separator := fmt.Sprintf("%s%s%s\n", startMarker, name, markerClose)
+	if note := p.annotation(relPath); note != "" {
+		separator += noteMarker + note + markerClose + "\n"
+	}
+	if commit := p.lastCommit(relPath); commit != nil {
+		separator += lastCommitMarker + commit.String() + markerClose + "\n"
+	}
+	return separator
+}
@@ -0,0 +1,278 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// PathAnonymizer replaces a sensitive directory name with a stable substitute.
+// Implementations must return the same substitute for the same name.
+type PathAnonymizer interface {
+	Anonymize(name string) string
+}
+
+// pathAnonymizers holds the available anonymize modes keyed by name
+var pathAnonymizers = map[string]func(salt string) PathAnonymizer{
+	"hash":  func(salt string) PathAnonymizer { return &hashAnonymizer{salt: salt} },
+	"alias": func(string) PathAnonymizer { return &aliasAnonymizer{aliases: make(map[string]string)} },
+}
+
+// RegisterPathAnonymizer makes a custom anonymizer available as an anonymize mode
+func RegisterPathAnonymizer(mode string, factory func(salt string) PathAnonymizer) {
+	pathAnonymizers[mode] = factory
+}
+
+// hashAnonymizer replaces names with a salted hash prefix
+type hashAnonymizer struct {
+	salt string
+}
+
+func (a *hashAnonymizer) Anonymize(name string) string {
+	sum := sha256.Sum256([]byte(a.salt + name))
+	return "dir-" + hex.EncodeToString(sum[:])[:10]
+}
+
+// aliasAnonymizer numbers names in the order they are first seen
+type aliasAnonymizer struct {
+	aliases map[string]string
+}
+
+func (a *aliasAnonymizer) Anonymize(name string) string {
+	if alias, ok := a.aliases[name]; ok {
+		return alias
+	}
+	alias := fmt.Sprintf("dir-%d", len(a.aliases)+1)
+	a.aliases[name] = alias
+	return alias
+}
+
+// pathMapper rewrites the directory components of relative paths that match
+// the anonymize patterns and remembers the mapping for reversal. Under
+// --anonymize it also rewrites the emails and hostnames in file content.
+type pathMapper struct {
+	patterns   []string
+	anonymizer PathAnonymizer
+	mapping    map[string]string // alias -> original name
+
+	content bool // Rewrite emails and hostnames in file content
+	mode    string
+	salt    string
+	values  map[string]string // kind and value -> alias
+	counts  map[string]int    // aliases handed out per kind, for alias mode
+}
+
+// anonymizesPaths reports whether a config hides any directory names, which
+// the repository name and remote URL could give away
+func anonymizesPaths(config *Config) bool {
+	return config.Anonymize || len(config.AnonymizeDirs) > 0
+}
+
+// newPathMapper returns nil when nothing is anonymized
+func newPathMapper(config *Config) *pathMapper {
+	patterns := config.AnonymizeDirs
+	if len(patterns) == 0 && config.Anonymize {
+		// Every directory name may be proprietary
+		patterns = []string{"*"}
+	}
+	if len(patterns) == 0 {
+		return nil
+	}
+
+	mode := config.AnonymizeMode
+	if mode == "" {
+		mode = "hash"
+	}
+	factory, ok := pathAnonymizers[mode]
+	if !ok {
+		return nil
+	}
+
+	return &pathMapper{
+		patterns:   patterns,
+		anonymizer: factory(config.AnonymizeSalt),
+		mapping:    make(map[string]string),
+		content:    config.Anonymize,
+		mode:       mode,
+		salt:       config.AnonymizeSalt,
+		values:     make(map[string]string),
+		counts:     make(map[string]int),
+	}
+}
+
+// mapPath anonymizes every directory component of relPath that matches a pattern
+func (m *pathMapper) mapPath(relPath string) string {
+	original := strings.Split(filepath.ToSlash(relPath), "/")
+	parts := make([]string, len(original))
+	copy(parts, original)
+
+	// The last component is the file name, which is left untouched
+	for i := 0; i < len(parts)-1; i++ {
+		dirPath := strings.Join(original[:i+1], "/")
+		for _, pattern := range m.patterns {
+			matched, err := matchPathPattern(pattern, dirPath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error matching anonymize pattern %s: %v\n", pattern, err)
+				continue
+			}
+			if matched {
+				alias := m.anonymizer.Anonymize(parts[i])
+				m.mapping[alias] = parts[i]
+				parts[i] = alias
+				break
+			}
+		}
+	}
+
+	return strings.Join(parts, "/")
+}
+
+// Patterns for the values --anonymize finds in file content
+var (
+	emailRegex   = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@(?:[A-Za-z0-9](?:[A-Za-z0-9\-]*[A-Za-z0-9])?\.)+[A-Za-z]{2,}`)
+	urlHostRegex = regexp.MustCompile(`[A-Za-z][A-Za-z0-9+.\-]*://(?:[^@/\s]+@)?((?:[A-Za-z0-9](?:[A-Za-z0-9\-]*[A-Za-z0-9])?\.)+[A-Za-z][A-Za-z0-9\-]*)`)
+	// Bare names are only taken for top-level domains that rarely end an identifier
+	hostRegex = regexp.MustCompile(`(?i)\b(?:[a-z0-9](?:[a-z0-9\-]*[a-z0-9])?\.)+(?:com|net|org|io|dev|cloud|internal|corp|lan|intranet)\b`)
+)
+
+// publicHosts are left alone, along with their subdomains: they say nothing
+// about the code's owner and keep module paths and links readable
+var publicHosts = []string{
+	"example.com", "example.net", "example.org",
+	"github.com", "gitlab.com", "bitbucket.org",
+	"golang.org", "go.dev", "npmjs.com", "npmjs.org", "pypi.org", "crates.io", "docker.io",
+}
+
+// isPublicHost reports whether host is one of publicHosts or below one
+func isPublicHost(host string) bool {
+	host = strings.ToLower(host)
+	for _, public := range publicHosts {
+		if host == public || strings.HasSuffix(host, "."+public) {
+			return true
+		}
+	}
+	return false
+}
+
+// valueAlias returns the stable alias of a content value of the given kind,
+// "user" or "host", recording it for reversal
+func (m *pathMapper) valueAlias(kind, value string) string {
+	key := kind + ":" + value
+	if alias, ok := m.values[key]; ok {
+		return alias
+	}
+
+	var alias string
+	if m.mode == "alias" {
+		m.counts[kind]++
+		alias = fmt.Sprintf("%s-%d", kind, m.counts[kind])
+	} else {
+		sum := sha256.Sum256([]byte(m.salt + key))
+		alias = kind + "-" + hex.EncodeToString(sum[:])[:10]
+	}
+	if kind == "host" {
+		// Reserved for documentation, so an alias never resolves
+		alias += ".example"
+	}
+
+	m.values[key] = alias
+	m.mapping[alias] = value
+	return alias
+}
+
+// hostAlias returns the alias of a hostname, or the name itself when it is
+// public or already an alias
+func (m *pathMapper) hostAlias(host string) string {
+	if _, ok := m.mapping[host]; ok || isPublicHost(host) {
+		return host
+	}
+	return m.valueAlias("host", strings.ToLower(host))
+}
+
+// mapContent replaces the emails and hostnames in content with their
+// aliases. An email's user and domain are replaced separately, so a domain
+// reads the same in an address as in a URL.
+func (m *pathMapper) mapContent(content []byte) []byte {
+	if m == nil || !m.content {
+		return content
+	}
+
+	content = emailRegex.ReplaceAllFunc(content, func(email []byte) []byte {
+		at := bytes.LastIndexByte(email, '@')
+		user, host := string(email[:at]), string(email[at+1:])
+		if isPublicHost(host) {
+			return email
+		}
+		return []byte(m.valueAlias("user", user) + "@" + m.hostAlias(host))
+	})
+
+	var b bytes.Buffer
+	last := 0
+	for _, match := range urlHostRegex.FindAllSubmatchIndex(content, -1) {
+		b.Write(content[last:match[2]])
+		b.WriteString(m.hostAlias(string(content[match[2]:match[3]])))
+		last = match[3]
+	}
+	b.Write(content[last:])
+	content = b.Bytes()
+
+	b = bytes.Buffer{}
+	last = 0
+	for _, match := range hostRegex.FindAllIndex(content, -1) {
+		if partOfName(content, match[0], match[1]) {
+			continue
+		}
+		b.Write(content[last:match[0]])
+		b.WriteString(m.hostAlias(string(content[match[0]:match[1]])))
+		last = match[1]
+	}
+	b.Write(content[last:])
+	return b.Bytes()
+}
+
+// partOfName reports whether the bare hostname at content[start:end] is
+// really a piece of a longer dotted name or a call, such as java.net.URL or
+// config.dev(), rather than a host
+func partOfName(content []byte, start, end int) bool {
+	if start > 0 && strings.IndexByte("._-@", content[start-1]) >= 0 {
+		return true
+	}
+	if end < len(content) {
+		switch next := content[end]; {
+		case next == '(':
+			return true
+		case next == '.' && end+1 < len(content):
+			// A sentence may end with a hostname, but not continue it
+			after := content[end+1]
+			return after != ' ' && after != '\n' && after != '\r' && after != '\t'
+		}
+	}
+	return false
+}
+
+// writeMapping saves the alias to original name mapping as JSON
+func (m *pathMapper) writeMapping(path string) error {
+	data, err := json.MarshalIndent(m.mapping, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding anonymize mapping: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("error writing anonymize mapping: %w", err)
+	}
+	return nil
+}
+
+// displayPath returns the path to show in the corpus for a selected file,
+// with forward slashes on every OS so corpora are portable
+func (p *fileProcessor) displayPath(relPath string) string {
+	if p.paths == nil {
+		return filepath.ToSlash(relPath)
+	}
+	return p.paths.mapPath(relPath)
+}
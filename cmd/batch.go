@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Batch is a set of corpora packed in one run, read from a batch file
+type Batch struct {
+	Parallel int // Jobs run at once; 1 runs them in order
+	Jobs     []BatchJob
+}
+
+// BatchJob is one corpus of a batch
+type BatchJob struct {
+	Name   string
+	Config Config
+}
+
+// BatchResult reports how one job of a batch went
+type BatchResult struct {
+	Name       string
+	OutputFile string
+	Duration   time.Duration
+	Err        error
+}
+
+var (
+	batchParallel int
+
+	batchCmd = &cobra.Command{
+		Use:   "batch <batch-file>",
+		Short: "Pack several directories or repositories from one batch file",
+		Long: `Pack one corpus per job of a YAML or JSON batch file. Each job takes the
+same settings as a config file, laid over the file's defaults:
+
+  parallel: 4
+  defaults:
+    excludeGlobs: ["**/*_test.go"]
+  jobs:
+    - name: api
+      inputDir: ../api
+      outputFile: corpora/api.txt
+      includeGlobs: ["**/*.go"]
+    - inputDir: ../web
+      outputFile: corpora/web.txt.gz
+      gzip: true
+
+Relative paths are resolved against the batch file's directory. A job
+without a name is named after its input directory, and one without an
+output file writes <name>.txt. Every job runs even when others fail.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			batch, err := LoadBatch(args[0])
+			if err != nil {
+				return err
+			}
+			if cmd.Flags().Changed("parallel") {
+				batch.Parallel = batchParallel
+			}
+			if err := validateBatchProfiles(batch); err != nil {
+				return err
+			}
+
+			results := RunBatch(batch)
+			return writeBatchResults(cmd.OutOrStdout(), results)
+		},
+	}
+)
+
+func init() {
+	batchCmd.Flags().IntVarP(&batchParallel, "parallel", "j", 1,
+		"Jobs to run at once (overrides the batch file's parallel)")
+	rootCmd.AddCommand(batchCmd)
+}
+
+// LoadBatch reads a YAML or JSON batch file
+func LoadBatch(path string) (*Batch, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading batch file: %w", err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yml", ".yaml", ".json":
+	default:
+		return nil, fmt.Errorf("unsupported batch file format: %s", ext)
+	}
+
+	// JSON is a subset of YAML, so both formats decode the same way
+	var file struct {
+		Parallel int         `yaml:"parallel"`
+		Defaults yaml.Node   `yaml:"defaults"`
+		Jobs     []yaml.Node `yaml:"jobs"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("error parsing batch file: %w", err)
+	}
+	if len(file.Jobs) == 0 {
+		return nil, fmt.Errorf("batch file %s has no jobs", path)
+	}
+
+	base := filepath.Dir(path)
+	batch := &Batch{Parallel: file.Parallel}
+	outputs := make(map[string]string)
+	for i, node := range file.Jobs {
+		var job struct {
+			Name string `yaml:"name"`
+		}
+		var config Config
+		if !file.Defaults.IsZero() {
+			if err := file.Defaults.Decode(&config); err != nil {
+				return nil, fmt.Errorf("error parsing batch defaults: %w", err)
+			}
+		}
+		if err := node.Decode(&config); err != nil {
+			return nil, fmt.Errorf("error parsing batch job %d: %w", i+1, err)
+		}
+		if err := node.Decode(&job); err != nil {
+			return nil, fmt.Errorf("error parsing batch job %d: %w", i+1, err)
+		}
+		if err := expandConfigEnv(&config); err != nil {
+			return nil, fmt.Errorf("error expanding batch job %d: %w", i+1, err)
+		}
+
+		if config.InputDir == "" {
+			return nil, fmt.Errorf("batch job %d has no inputDir", i+1)
+		}
+		if job.Name == "" {
+			job.Name = filepath.Base(filepath.Clean(config.InputDir))
+		}
+		if config.OutputFile == "" {
+			config.OutputFile = job.Name + ".txt"
+		}
+		resolveBatchPaths(&config, base)
+
+		if other, ok := outputs[config.OutputFile]; ok {
+			return nil, fmt.Errorf("batch jobs %s and %s both write %s", other, job.Name, config.OutputFile)
+		}
+		outputs[config.OutputFile] = job.Name
+
+		batch.Jobs = append(batch.Jobs, BatchJob{Name: job.Name, Config: config})
+	}
+
+	if err := validateBatchProfiles(batch); err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// resolveBatchPaths makes the relative paths of a job's config relative to
+// the batch file's directory
+func resolveBatchPaths(config *Config, base string) {
+	for _, path := range []*string{
+		&config.InputDir, &config.OutputFile, &config.ReportFile, &config.ManifestFile,
+		&config.AnnotationsFile, &config.AnonymizeMapFile,
+	} {
+		if *path != "" {
+			*path = resolvePath(base, *path)
+		}
+	}
+}
+
+// validateBatchProfiles rejects --pprof on jobs that run at once: the Go
+// runtime keeps one CPU profile or trace per process
+func validateBatchProfiles(batch *Batch) error {
+	if batch.Parallel <= 1 {
+		return nil
+	}
+	for _, job := range batch.Jobs {
+		if job.Config.Pprof == ProfileCPU || job.Config.Pprof == ProfileTrace {
+			return fmt.Errorf("batch job %s sets pprof %s, which needs parallel 1", job.Name, job.Config.Pprof)
+		}
+	}
+	return nil
+}
+
+// RunBatch packs every job of the batch, Parallel at a time, and returns
+// their results in job order
+func RunBatch(batch *Batch) []BatchResult {
+	parallel := batch.Parallel
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	results := make([]BatchResult, len(batch.Jobs))
+	slots := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, job := range batch.Jobs {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, job BatchJob) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			start := time.Now()
+			err := ProcessDirectory(job.Config)
+			results[i] = BatchResult{
+				Name:       job.Name,
+				OutputFile: job.Config.OutputFile,
+				Duration:   time.Since(start),
+				Err:        err,
+			}
+		}(i, job)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// writeBatchResults prints one line per job and returns an error if any
+// job failed
+func writeBatchResults(w io.Writer, results []BatchResult) error {
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			fmt.Fprintf(w, "FAIL  %s: %v\n", result.Name, result.Err)
+			continue
+		}
+		fmt.Fprintf(w, "ok    %s -> %s (%s)\n", result.Name, result.OutputFile, result.Duration.Round(time.Millisecond))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d batch jobs failed", failed, len(results))
+	}
+	return nil
+}
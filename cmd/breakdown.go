@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// summaryGroup totals the packed files of one directory or language
+type summaryGroup struct {
+	name   string
+	files  int
+	bytes  int64
+	tokens int
+}
+
+// countForSummary records a packed file for the breakdowns of a verbose
+// summary. Tokens cover content before cpack's transforms, as the budget's do.
+func (p *fileProcessor) countForSummary(name, language string, size int64, tokens int) {
+	if language == "" {
+		language = "other"
+	}
+	p.summary.files = append(p.summary.files, FileStats{Path: name, Language: language, Bytes: size, Tokens: tokens})
+}
+
+// formatBreakdowns returns the summary lines totaling packed files by
+// top-level directory and by language, heaviest first, then their size
+// histogram and the largest of them, or "" when no file was packed
+func (p *fileProcessor) formatBreakdowns() string {
+	if len(p.summary.files) == 0 {
+		return ""
+	}
+
+	byDir := func(f FileStats) string {
+		if dir, _, ok := strings.Cut(f.Path, "/"); ok {
+			return dir + "/"
+		}
+		return "."
+	}
+	byLanguage := func(f FileStats) string { return f.Language }
+
+	var b strings.Builder
+	b.WriteString("\nBy Directory:\n" + p.formatBreakdown(byDir))
+	b.WriteString("\nBy Language:\n" + p.formatBreakdown(byLanguage))
+	b.WriteString("\nSize Histogram:\n")
+	writeHistogram(&b, sizeHistogram(p.summary.files))
+	b.WriteString("\nLargest Files:\n")
+	writeLargest(&b, largestFiles(p.summary.files, largestFilesCount))
+	return b.String()
+}
+
+// formatBreakdown groups packed files by key and writes a line per group
+// with its share of the corpus's files, bytes and tokens
+func (p *fileProcessor) formatBreakdown(key func(FileStats) string) string {
+	groups := make(map[string]*summaryGroup)
+	var totalBytes int64
+	totalTokens := 0
+	for _, f := range p.summary.files {
+		name := key(f)
+		if groups[name] == nil {
+			groups[name] = &summaryGroup{name: name}
+		}
+		groups[name].files++
+		groups[name].bytes += f.Bytes
+		groups[name].tokens += f.Tokens
+		totalBytes += f.Bytes
+		totalTokens += f.Tokens
+	}
+
+	sorted := make([]*summaryGroup, 0, len(groups))
+	width := 0
+	for _, g := range groups {
+		sorted = append(sorted, g)
+		width = max(width, len(g.name))
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].bytes != sorted[j].bytes {
+			return sorted[i].bytes > sorted[j].bytes
+		}
+		return sorted[i].name < sorted[j].name
+	})
+
+	percent := func(part, total int64) float64 {
+		if total == 0 {
+			return 0
+		}
+		return float64(part) * 100 / float64(total)
+	}
+
+	var b strings.Builder
+	for _, g := range sorted {
+		fmt.Fprintf(&b, "%-*s  %d files (%.1f%%), %d bytes (%.1f%%), %d tokens (%.1f%%)\n",
+			width, g.name,
+			g.files, percent(int64(g.files), int64(len(p.summary.files))),
+			g.bytes, percent(g.bytes, totalBytes),
+			g.tokens, percent(int64(g.tokens), int64(totalTokens)))
+	}
+	return b.String()
+}
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// applyBudget keeps files in emission order until their token count would
+// exceed MaxTokens, and skips the rest. With the default estimate tokenizer
+// counts come from file sizes; either way they cover content before
+// compression, capped at MaxFileTokens. Budget shares split MaxTokens into
+// groups that are filled on their own.
+func (p *fileProcessor) applyBudget() {
+	if p.config.MaxTokens <= 0 {
+		return
+	}
+
+	groups := p.budgetGroups()
+	kept := p.files[:0]
+	for _, entry := range p.files {
+		tokens, ok := p.fileTokens(entry.absPath)
+		if ok {
+			// The cap comes from the file's own config, as when packing
+			if maxFileTokens := p.configFor(entry.relPath).MaxFileTokens; maxFileTokens > 0 && tokens > maxFileTokens {
+				tokens = maxFileTokens
+			}
+			group := p.budgetGroup(groups, entry.relPath)
+			if group.used+tokens > group.limit {
+				p.summary.SkippedFiles = append(p.summary.SkippedFiles,
+					SkippedFile{Path: p.displayPath(entry.relPath), Reason: "over budget"})
+				continue
+			}
+			group.used += tokens
+		}
+		kept = append(kept, entry)
+	}
+	p.files = kept
+}
+
+// budgetGroup is a share of MaxTokens and the tokens its files use
+type budgetGroup struct {
+	pattern string // "" for files matching no share
+	limit   int
+	used    int
+}
+
+// budgetGroups returns a group per budget share, most specific pattern
+// first, and a last group holding what the shares leave for the other files
+func (p *fileProcessor) budgetGroups() []*budgetGroup {
+	patterns := make([]string, 0, len(p.config.Budget))
+	for pattern := range p.config.Budget {
+		patterns = append(patterns, pattern)
+	}
+	sortBySpecificity(patterns)
+
+	groups := make([]*budgetGroup, 0, len(patterns)+1)
+	rest := p.config.MaxTokens
+	for _, pattern := range patterns {
+		// Shares were checked by validateBudget
+		share, _ := parseBudgetShare(p.config.Budget[pattern])
+		limit := int(float64(p.config.MaxTokens) * share / 100)
+		groups = append(groups, &budgetGroup{pattern: pattern, limit: limit})
+		rest -= limit
+	}
+	return append(groups, &budgetGroup{limit: rest})
+}
+
+// sortBySpecificity orders glob patterns so that src/core/** comes before
+// src/**: by the number of literal characters, then by length, then
+// lexically
+func sortBySpecificity(patterns []string) {
+	sort.Slice(patterns, func(i, j int) bool {
+		a, b := globLiterals(patterns[i]), globLiterals(patterns[j])
+		if a != b {
+			return a > b
+		}
+		if len(patterns[i]) != len(patterns[j]) {
+			return len(patterns[i]) > len(patterns[j])
+		}
+		return patterns[i] < patterns[j]
+	})
+}
+
+// globLiterals counts the characters of a pattern that match only themselves
+func globLiterals(pattern string) int {
+	n := 0
+	for _, r := range pattern {
+		if !strings.ContainsRune("*?[]{}", r) {
+			n++
+		}
+	}
+	return n
+}
+
+// budgetGroup returns the group of the most specific share matching
+// relPath, or the last group when none does
+func (p *fileProcessor) budgetGroup(groups []*budgetGroup, relPath string) *budgetGroup {
+	for _, group := range groups[:len(groups)-1] {
+		matched, err := matchPathPattern(group.pattern, relPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error matching budget pattern %s: %v\n", group.pattern, err)
+			continue
+		}
+		if matched {
+			return group
+		}
+	}
+	return groups[len(groups)-1]
+}
+
+// parseBudgetShare parses a percentage such as "60%" or "12.5"
+func parseBudgetShare(s string) (float64, error) {
+	share, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "%")), 64)
+	if err != nil || share <= 0 || share > 100 {
+		return 0, fmt.Errorf("invalid budget share %q: want a percentage above 0 and up to 100", s)
+	}
+	return share, nil
+}
+
+// validateBudget checks that budget shares are percentages of a token
+// budget that add up to at most 100
+func validateBudget(config *Config) error {
+	if len(config.Budget) == 0 {
+		return nil
+	}
+	if config.MaxTokens <= 0 {
+		return fmt.Errorf("budget shares need a token budget; set --max-tokens")
+	}
+
+	total := 0.0
+	for pattern, value := range config.Budget {
+		share, err := parseBudgetShare(value)
+		if err != nil {
+			return fmt.Errorf("error in budget for %s: %w", pattern, err)
+		}
+		total += share
+	}
+	if total > 100 {
+		return fmt.Errorf("budget shares add up to %g%%, more than 100%%", total)
+	}
+	return nil
+}
+
+// fileTokens counts a file's tokens, reading it only when the tokenizer
+// needs its content
+func (p *fileProcessor) fileTokens(path string) (int, bool) {
+	if p.config.Tokenizer == "" || p.config.Tokenizer == TokenizerEstimate {
+		info, err := os.Stat(path)
+		if err != nil {
+			return 0, false
+		}
+		return int((info.Size() + 3) / 4), true
+	}
+
+	if d, ok := p.digests[path]; ok && d.counted {
+		return d.tokens, true
+	}
+	return p.config.cache.count(path, p.config.Tokenizer, func() (int, bool) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return 0, false
+		}
+		return p.countTokens(content), true
+	})
+}
+
+// truncateTokens cuts content after its first n tokens, at the last line
+// break that fits when there is one, and notes how many tokens were left out
+func (p *fileProcessor) truncateTokens(content []byte, n int) []byte {
+	total := p.countTokens(content)
+	if total <= n {
+		return content
+	}
+
+	// Token counts grow with the prefix, so search for the longest one that fits
+	lines := bytes.SplitAfter(content, []byte("\n"))
+	offsets := make([]int, len(lines))
+	end := 0
+	for i, line := range lines {
+		end += len(line)
+		offsets[i] = end
+	}
+	fits := sort.Search(len(offsets), func(i int) bool {
+		return p.countTokens(content[:offsets[i]]) > n
+	})
+
+	var kept []byte
+	if fits > 0 {
+		kept = content[:offsets[fits-1]]
+	} else {
+		// Not even the first line fits, so cut it at a character boundary
+		// The line break ending the cut counts towards n too
+		cut := sort.Search(len(lines[0]), func(i int) bool {
+			return p.countTokens(append(content[:i+1:i+1], '\n')) > n
+		})
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		kept = append(content[:cut:cut], '\n')
+	}
+
+	note := fmt.Sprintf("... [truncated: %d of %d tokens omitted]", total-p.countTokens(kept), total)
+	return append(kept[:len(kept):len(kept)], note...)
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"path/filepath"
+	"sort"
+)
+
+// SkippedFile is a file left out of the corpus. Reason is empty for files
+// excluded by the selection rules.
+type SkippedFile struct {
+	Path   string
+	Reason string
+}
+
+// String formats the file as it appears in summaries and reports
+func (s SkippedFile) String() string {
+	if s.Reason == "" {
+		return s.Path
+	}
+	return s.Path + " (" + s.Reason + ")"
+}
+
+// collationKey is what summary lists are ordered by: the path with forward
+// slashes, compared byte-wise, so the order is the same on every platform
+// and locale
+func collationKey(path string) string {
+	return filepath.ToSlash(path)
+}
+
+// sortPaths sorts paths by their collation key
+func sortPaths(paths []string) {
+	sort.SliceStable(paths, func(i, j int) bool {
+		return collationKey(paths[i]) < collationKey(paths[j])
+	})
+}
+
+// sortSkipped groups skipped files by reason, filtered files first, and
+// orders each group by collation key
+func sortSkipped(skipped []SkippedFile) {
+	sort.SliceStable(skipped, func(i, j int) bool {
+		if skipped[i].Reason != skipped[j].Reason {
+			return skipped[i].Reason < skipped[j].Reason
+		}
+		return collationKey(skipped[i].Path) < collationKey(skipped[j].Path)
+	})
+}
+
+// skippedStrings returns the skipped files in collation order as strings
+func skippedStrings(skipped []SkippedFile) []string {
+	sorted := append([]SkippedFile{}, skipped...)
+	sortSkipped(sorted)
+
+	lines := make([]string, len(sorted))
+	for i, s := range sorted {
+		lines[i] = s.String()
+	}
+	return lines
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -15,23 +16,195 @@ type Config struct {
 	InputDir     string   `yaml:"inputDir" json:"inputDir"`
 	OutputFile   string   `yaml:"outputFile" json:"outputFile"`
 	IncludeGlobs []string `yaml:"includeGlobs" json:"includeGlobs"`
+	IncludeNames []string `yaml:"includeNames" json:"includeNames"`
+	IncludeLangs []string `yaml:"includeLanguages" json:"includeLanguages"`
 	ExcludeGlobs []string `yaml:"excludeGlobs" json:"excludeGlobs"`
 	Verbose      bool     `yaml:"verbose" json:"verbose"`
 	Compress     bool     `yaml:"compress" json:"compress"`
 	MaxCompress  bool     `yaml:"maxCompress" json:"maxCompress"`
 	Gzip         bool     `yaml:"gzip" json:"gzip"`
 	Base64       bool     `yaml:"base64" json:"base64"`
+	OutputFormat string   `yaml:"outputFormat" json:"outputFormat"`
+	LinkMode     string   `yaml:"linkMode" json:"linkMode"`
+	LowMemory    bool     `yaml:"lowMemory" json:"lowMemory"`
+	FlushPerFile bool     `yaml:"flushPerFile" json:"flushPerFile"`
+	Durable      bool     `yaml:"durable" json:"durable"`
+	Verify       bool     `yaml:"verify" json:"verify"`
+	Frontmatter  bool     `yaml:"frontmatter" json:"frontmatter"`
+	HeadLines    int      `yaml:"headLines" json:"headLines"`
+	Rules        []Rule   `yaml:"rules" json:"rules"`
+
+	Anonymize        bool     `yaml:"anonymize" json:"anonymize"`
+	AnonymizeDirs    []string `yaml:"anonymizeDirs" json:"anonymizeDirs"`
+	AnonymizeMode    string   `yaml:"anonymizeMode" json:"anonymizeMode"`
+	AnonymizeSalt    string   `yaml:"anonymizeSalt" json:"anonymizeSalt"`
+	AnonymizeMapFile string   `yaml:"anonymizeMapFile" json:"anonymizeMapFile"`
+
+	PriorityGlobs []string `yaml:"priorityGlobs" json:"priorityGlobs"`
+	EntryFiles    []string `yaml:"entry" json:"entry"`
+	SortOrder     string   `yaml:"sort" json:"sort"`
+
+	ReportFile      string `yaml:"reportFile" json:"reportFile"`
+	ManifestFile    string `yaml:"manifestFile" json:"manifestFile"`
+	AnnotationsFile string `yaml:"annotations" json:"annotations"`
+
+	Preset        string `yaml:"preset" json:"preset"`
+	SkipGenerated bool   `yaml:"skipGenerated" json:"skipGenerated"`
+	NoTests       bool   `yaml:"noTests" json:"noTests"`
+	TestsOnly     bool   `yaml:"testsOnly" json:"testsOnly"`
+	MaxTokens     int    `yaml:"maxTokens" json:"maxTokens"`
+	MaxFileTokens int    `yaml:"maxFileTokens" json:"maxFileTokens"`
+	Tokenizer     string `yaml:"tokenizer" json:"tokenizer"`
+	Instructions  string `yaml:"instructions" json:"instructions"`
+
+	Budget map[string]string `yaml:"budget" json:"budget"`
+
+	ScanSecrets string `yaml:"scanSecrets" json:"scanSecrets"`
+
+	NoGitAttributes bool   `yaml:"noGitAttributes" json:"noGitAttributes"`
+	Hidden          string `yaml:"hidden" json:"hidden"`
+	SymlinkPolicy   string `yaml:"symlinkPolicy" json:"symlinkPolicy"`
+
+	IncludeSubmodules bool `yaml:"includeSubmodules" json:"includeSubmodules"`
+
+	NormalizeEOL       string `yaml:"normalizeEol" json:"normalizeEol"`
+	StripTrailingSpace bool   `yaml:"stripTrailingSpace" json:"stripTrailingSpace"`
+	TabsToSpaces       int    `yaml:"tabsToSpaces" json:"tabsToSpaces"`
+	CollapseBlankLines int    `yaml:"collapseBlankLines" json:"collapseBlankLines"`
+
+	NormalizeUnicode bool `yaml:"normalizeUnicode" json:"normalizeUnicode"`
+	StripInvisible   bool `yaml:"stripInvisible" json:"stripInvisible"`
+
+	FenceLanguages map[string]string `yaml:"fenceLanguages" json:"fenceLanguages"`
+
+	ChunkTokens  int `yaml:"chunkTokens" json:"chunkTokens"`
+	ChunkOverlap int `yaml:"chunkOverlap" json:"chunkOverlap"`
+
+	Grep  string `yaml:"grep" json:"grep"`
+	GrepV string `yaml:"grepV" json:"grepV"`
+
+	NewerThan string `yaml:"newerThan" json:"newerThan"`
+	OlderThan string `yaml:"olderThan" json:"olderThan"`
+	GitDates  bool   `yaml:"gitDates" json:"gitDates"`
+
+	ExtractDocs bool   `yaml:"extractDocs" json:"extractDocs"`
+	Minified    string `yaml:"minified" json:"minified"`
+
+	PerWorkspace bool `yaml:"perWorkspace" json:"perWorkspace"`
+	SplitByDir   int  `yaml:"splitByDir" json:"splitByDir"`
+
+	MaxFilesPerDir int `yaml:"maxFilesPerDir" json:"maxFilesPerDir"`
+	MaxDepth       int `yaml:"maxDepth" json:"maxDepth"`
+
+	MaxFiles     int    `yaml:"maxFiles" json:"maxFiles"`
+	MaxTotalSize string `yaml:"maxTotalSize" json:"maxTotalSize"`
+	Strict       bool   `yaml:"strict" json:"strict"`
+
+	MinSize string `yaml:"minSize" json:"minSize"`
+	MaxSize string `yaml:"maxSize" json:"maxSize"`
+
+	MaxMemory string `yaml:"maxMemory" json:"maxMemory"`
+
+	IOWorkers    int `yaml:"ioWorkers" json:"ioWorkers"`
+	HashWorkers  int `yaml:"hashWorkers" json:"hashWorkers"`
+	TokenWorkers int `yaml:"tokenWorkers" json:"tokenWorkers"`
+
+	Pprof  string       `yaml:"pprof" json:"pprof"`
+	phases *phaseTimes  // Phase timings of --pprof, shared by every corpus of a run
+	cache  *digestCache // File hashes and token counts kept by cpack daemon
+
+	runOutputs []string // Every corpus of a --per-workspace run, none of which is packed
+
+	MetricsFile string `yaml:"metricsFile" json:"metricsFile"`
+
+	Append bool `yaml:"append" json:"append"`
+
+	NoGitHeader bool `yaml:"noGitHeader" json:"noGitHeader"`
+	GitMeta     bool `yaml:"gitMeta" json:"gitMeta"`
+
+	GitLog       int  `yaml:"gitLog" json:"gitLog"`
+	GitLogScoped bool `yaml:"gitLogScoped" json:"gitLogScoped"`
+
+	Upload string `yaml:"upload" json:"upload"`
+
+	PostURL         string `yaml:"postUrl" json:"postUrl"`
+	PostToken       string `yaml:"-" json:"-"` // Only from --post-token or CPACK_POST_TOKEN, never a config file
+	PostContentType string `yaml:"postContentType" json:"postContentType"`
+
+	GzipJobs int `yaml:"gzipJobs" json:"gzipJobs"`
+
+	Encoding string `yaml:"encoding" json:"encoding"`
+	Wrap     bool   `yaml:"wrap" json:"wrap"`
+
+	Deterministic bool `yaml:"deterministic" json:"deterministic"`
+
+	Symbols bool `yaml:"symbols" json:"symbols"`
+
+	ExportedOnly bool `yaml:"exportedOnly" json:"exportedOnly"`
+	DocsOnly     bool `yaml:"docsOnly" json:"docsOnly"`
+
+	ImportGraph        bool `yaml:"importGraph" json:"importGraph"`
+	ImportGraphMermaid bool `yaml:"importGraphMermaid" json:"importGraphMermaid"`
+
+	Hooks      Hooks    `yaml:"hooks" json:"hooks"`
+	Plugins    []string `yaml:"plugins" json:"plugins"` // Transformer plugins, see TransformFunc
+	AllowHooks bool     `yaml:"-" json:"-"`             // Set by the caller, never by a config file
+
+	ConfigFile string `yaml:"-" json:"-"` // Overrides the config file search
+	Profile    string `yaml:"-" json:"-"` // Selected from the config file's profiles section
+
+	CostModel string             `yaml:"costModel" json:"costModel"`
+	Prices    map[string]float64 `yaml:"prices" json:"prices"`
+
+	NoLockfiles   bool     `yaml:"noLockfiles" json:"noLockfiles"`
+	LockfileGlobs []string `yaml:"lockfileGlobs" json:"lockfileGlobs"`
+}
+
+// Rule overrides per-file options for files matching Glob. Unset fields
+// inherit from the top-level config; later matching rules win.
+type Rule struct {
+	Glob        string `yaml:"glob" json:"glob"`
+	Compress    *bool  `yaml:"compress,omitempty" json:"compress,omitempty"`
+	MaxCompress *bool  `yaml:"maxCompress,omitempty" json:"maxCompress,omitempty"`
+	HeadLines   *int   `yaml:"headLines,omitempty" json:"headLines,omitempty"`
+
+	MaxFilesPerDir *int `yaml:"maxFilesPerDir,omitempty" json:"maxFilesPerDir,omitempty"`
 }
 
+// Output formats
+const (
+	FormatText       = "text"       // Concatenated corpus file (default)
+	FormatLinkFarm   = "linkfarm"   // Directory tree of links to the selected files
+	FormatMarkdown   = "markdown"   // Corpus file with a heading and code fence per file
+	FormatHTML       = "html"       // Self-contained page with a file tree and highlighted code
+	FormatYAML       = "yaml"       // YAML document with an entry per file
+	FormatPB         = "pb"         // Protobuf Corpus message, see schemas/corpus.v1.proto
+	FormatParquet    = "parquet"    // Parquet table with a row per file
+	FormatRAGJSONL   = "rag-jsonl"  // JSON lines, one per overlapping chunk of each file
+	FormatLangChain  = "langchain"  // JSON lines, one LangChain Document per file
+	FormatLlamaIndex = "llamaindex" // JSON lines, one LlamaIndex Document per file
+)
+
+// Link modes for the linkfarm output format
+const (
+	LinkModeSymlink = "symlink"
+	LinkModeCopy    = "copy"
+)
+
 // DefaultConfig returns a Config with sensible defaults
 func DefaultConfig() Config {
 	return Config{
-		InputDir:   ".", // Current directory
-		OutputFile: "corpus-out.txt",
-		Verbose:    false,
-		Compress:   false,
-		Gzip:       false,
-		Base64:     false,
+		InputDir:     ".", // Current directory
+		OutputFile:   "corpus-out.txt",
+		Verbose:      false,
+		Compress:     false,
+		Gzip:         false,
+		Base64:       false,
+		OutputFormat: FormatText,
+		LinkMode:     LinkModeSymlink,
+		SortOrder:    SortWalk,
+		Tokenizer:    TokenizerEstimate,
+		Hidden:       HiddenInclude,
 		IncludeGlobs: []string{
 			"**/*.go",         // Go source files
 			"**/*.js",         // JavaScript
@@ -61,6 +234,30 @@ func DefaultConfig() Config {
 			"**/*.{xls,xlsx}", // Excel
 			"**/*.pdf",        // PDF
 		},
+		IncludeNames: []string{
+			"Makefile",       // Make
+			"GNUmakefile",    // GNU Make
+			"Dockerfile",     // Docker
+			"Dockerfile.*",   // Docker variants
+			"Containerfile",  // Podman
+			"Jenkinsfile",    // Jenkins
+			"Vagrantfile",    // Vagrant
+			"Procfile",       // Process types
+			"Gemfile",        // Ruby dependencies
+			"Rakefile",       // Ruby tasks
+			"Justfile",       // just
+			"LICENSE",        // License
+			"LICENCE",        // License (British spelling)
+			"COPYING",        // License (GNU)
+			"NOTICE",         // Attribution notices
+			"CODEOWNERS",     // Code owners
+			"go.mod",         // Go module
+			"go.work",        // Go workspace
+			".env.example",   // Environment template
+			".dockerignore",  // Docker ignore rules
+			".editorconfig",  // Editor settings
+			".gitattributes", // Git attributes
+		},
 		ExcludeGlobs: []string{
 			"**/vendor/**",       // Vendor directories
 			"**/.git/**",         // Git directories
@@ -78,11 +275,19 @@ func DefaultConfig() Config {
 			"**/*.map",           // Source maps
 			"**/*.generated.*",   // Generated files
 		},
+		SymlinkPolicy: SymlinkFollow,
+		Minified:      MinifiedSkip,
 	}
 }
 
 // LoadConfigFromFile loads configuration from a YAML or JSON file
 func LoadConfigFromFile(configPath string) (*Config, error) {
+	return LoadConfigProfile(configPath, "")
+}
+
+// LoadConfigProfile loads configuration from a YAML or JSON file with the
+// named profile, if any, laid over the file's top-level settings
+func LoadConfigProfile(configPath, profile string) (*Config, error) {
 	if configPath == "" {
 		return nil, fmt.Errorf("config file path is empty")
 	}
@@ -97,17 +302,26 @@ func LoadConfigFromFile(configPath string) (*Config, error) {
 	var config Config
 
 	if len(data) == 0 {
+		if profile != "" {
+			return nil, fmt.Errorf("unknown profile: %s (the config file is empty)", profile)
+		}
 		// For empty files, return default config
 		defaultConfig := DefaultConfig()
 		return &defaultConfig, nil
 	}
 
+	if ext == ".yml" || ext == ".yaml" || ext == ".json" {
+		data, ext, err = resolveExtends(configPath, data, ext)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if ext == ".yml" || ext == ".yaml" {
 		err = yaml.Unmarshal(data, &config)
 		if err != nil {
 			return nil, fmt.Errorf("error parsing YAML config: %w", err)
 		}
-		fmt.Println("YAML config loaded successfully", config)
 	} else if ext == ".json" {
 		err = json.Unmarshal(data, &config)
 		if err != nil {
@@ -117,6 +331,16 @@ func LoadConfigFromFile(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("unsupported config file format: %s", ext)
 	}
 
+	if profile != "" {
+		if err := applyProfile(&config, data, ext, profile); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := expandConfigEnv(&config); err != nil {
+		return nil, fmt.Errorf("error expanding config file: %w", err)
+	}
+
 	return &config, nil
 }
 
@@ -162,6 +386,14 @@ func MergeConfig(config Config, autoConfig *Config) Config {
 		mergedConfig.OutputFile = autoConfig.OutputFile
 	}
 
+	if mergedConfig.OutputFormat == "" {
+		mergedConfig.OutputFormat = autoConfig.OutputFormat
+	}
+
+	if mergedConfig.LinkMode == "" {
+		mergedConfig.LinkMode = autoConfig.LinkMode
+	}
+
 	// For globs, if the config has patterns, use them as-is
 	// Otherwise use the auto-config's patterns
 	if len(mergedConfig.IncludeGlobs) == 0 {
@@ -172,19 +404,386 @@ func MergeConfig(config Config, autoConfig *Config) Config {
 		mergedConfig.ExcludeGlobs = autoConfig.ExcludeGlobs
 	}
 
+	if len(mergedConfig.IncludeNames) == 0 {
+		mergedConfig.IncludeNames = autoConfig.IncludeNames
+	}
+
+	if len(mergedConfig.IncludeLangs) == 0 {
+		mergedConfig.IncludeLangs = autoConfig.IncludeLangs
+	}
+
+	if len(mergedConfig.PriorityGlobs) == 0 {
+		mergedConfig.PriorityGlobs = autoConfig.PriorityGlobs
+	}
+
+	if len(mergedConfig.EntryFiles) == 0 {
+		mergedConfig.EntryFiles = autoConfig.EntryFiles
+	}
+
+	if mergedConfig.SortOrder == "" {
+		mergedConfig.SortOrder = autoConfig.SortOrder
+	}
+
+	if mergedConfig.ReportFile == "" {
+		mergedConfig.ReportFile = autoConfig.ReportFile
+	}
+
+	if mergedConfig.ManifestFile == "" {
+		mergedConfig.ManifestFile = autoConfig.ManifestFile
+	}
+
+	if mergedConfig.Preset == "" {
+		mergedConfig.Preset = autoConfig.Preset
+	}
+
+	if mergedConfig.MaxTokens == 0 {
+		mergedConfig.MaxTokens = autoConfig.MaxTokens
+	}
+
+	if mergedConfig.MaxFileTokens == 0 {
+		mergedConfig.MaxFileTokens = autoConfig.MaxFileTokens
+	}
+
+	if mergedConfig.HeadLines == 0 {
+		mergedConfig.HeadLines = autoConfig.HeadLines
+	}
+
+	if len(mergedConfig.Budget) == 0 {
+		mergedConfig.Budget = autoConfig.Budget
+	}
+
+	if mergedConfig.ScanSecrets == "" {
+		mergedConfig.ScanSecrets = autoConfig.ScanSecrets
+	}
+
+	if len(mergedConfig.LockfileGlobs) == 0 {
+		mergedConfig.LockfileGlobs = autoConfig.LockfileGlobs
+	}
+
+	if mergedConfig.MaxFilesPerDir == 0 {
+		mergedConfig.MaxFilesPerDir = autoConfig.MaxFilesPerDir
+	}
+
+	if mergedConfig.MaxDepth == 0 {
+		mergedConfig.MaxDepth = autoConfig.MaxDepth
+	}
+
+	if mergedConfig.MaxFiles == 0 {
+		mergedConfig.MaxFiles = autoConfig.MaxFiles
+	}
+
+	if mergedConfig.MaxTotalSize == "" {
+		mergedConfig.MaxTotalSize = autoConfig.MaxTotalSize
+	}
+
+	if mergedConfig.MinSize == "" {
+		mergedConfig.MinSize = autoConfig.MinSize
+	}
+
+	if mergedConfig.MaxMemory == "" {
+		mergedConfig.MaxMemory = autoConfig.MaxMemory
+	}
+	if mergedConfig.IOWorkers == 0 {
+		mergedConfig.IOWorkers = autoConfig.IOWorkers
+	}
+	if mergedConfig.HashWorkers == 0 {
+		mergedConfig.HashWorkers = autoConfig.HashWorkers
+	}
+	if mergedConfig.TokenWorkers == 0 {
+		mergedConfig.TokenWorkers = autoConfig.TokenWorkers
+	}
+	if mergedConfig.Pprof == "" {
+		mergedConfig.Pprof = autoConfig.Pprof
+	}
+	if mergedConfig.MetricsFile == "" {
+		mergedConfig.MetricsFile = autoConfig.MetricsFile
+	}
+
+	if mergedConfig.MaxSize == "" {
+		mergedConfig.MaxSize = autoConfig.MaxSize
+	}
+
+	if mergedConfig.SplitByDir == 0 {
+		mergedConfig.SplitByDir = autoConfig.SplitByDir
+	}
+
+	if mergedConfig.GitLog == 0 {
+		mergedConfig.GitLog = autoConfig.GitLog
+	}
+
+	if mergedConfig.Upload == "" {
+		mergedConfig.Upload = autoConfig.Upload
+	}
+
+	if mergedConfig.PostURL == "" {
+		mergedConfig.PostURL = autoConfig.PostURL
+	}
+
+	if mergedConfig.PostContentType == "" {
+		mergedConfig.PostContentType = autoConfig.PostContentType
+	}
+
+	if mergedConfig.GzipJobs == 0 {
+		mergedConfig.GzipJobs = autoConfig.GzipJobs
+	}
+
+	if mergedConfig.Encoding == "" {
+		mergedConfig.Encoding = autoConfig.Encoding
+	}
+
+	if mergedConfig.Hidden == "" {
+		mergedConfig.Hidden = autoConfig.Hidden
+	}
+
+	if mergedConfig.SymlinkPolicy == "" {
+		mergedConfig.SymlinkPolicy = autoConfig.SymlinkPolicy
+	}
+
+	if mergedConfig.NormalizeEOL == "" {
+		mergedConfig.NormalizeEOL = autoConfig.NormalizeEOL
+	}
+
+	if mergedConfig.TabsToSpaces == 0 {
+		mergedConfig.TabsToSpaces = autoConfig.TabsToSpaces
+	}
+
+	if mergedConfig.CollapseBlankLines == 0 {
+		mergedConfig.CollapseBlankLines = autoConfig.CollapseBlankLines
+	}
+
+	if mergedConfig.Minified == "" {
+		mergedConfig.Minified = autoConfig.Minified
+	}
+
+	if len(mergedConfig.FenceLanguages) == 0 {
+		mergedConfig.FenceLanguages = autoConfig.FenceLanguages
+	}
+
+	if mergedConfig.ChunkTokens == 0 {
+		mergedConfig.ChunkTokens = autoConfig.ChunkTokens
+	}
+
+	if mergedConfig.ChunkOverlap == 0 {
+		mergedConfig.ChunkOverlap = autoConfig.ChunkOverlap
+	}
+
+	if mergedConfig.Grep == "" {
+		mergedConfig.Grep = autoConfig.Grep
+	}
+
+	if mergedConfig.GrepV == "" {
+		mergedConfig.GrepV = autoConfig.GrepV
+	}
+
+	if mergedConfig.NewerThan == "" {
+		mergedConfig.NewerThan = autoConfig.NewerThan
+	}
+
+	if mergedConfig.OlderThan == "" {
+		mergedConfig.OlderThan = autoConfig.OlderThan
+	}
+
+	if mergedConfig.Tokenizer == "" {
+		mergedConfig.Tokenizer = autoConfig.Tokenizer
+	}
+
+	if mergedConfig.CostModel == "" {
+		mergedConfig.CostModel = autoConfig.CostModel
+	}
+
+	if len(mergedConfig.Prices) == 0 {
+		mergedConfig.Prices = autoConfig.Prices
+	}
+
+	if mergedConfig.Instructions == "" {
+		mergedConfig.Instructions = autoConfig.Instructions
+	}
+
+	if mergedConfig.AnnotationsFile == "" {
+		mergedConfig.AnnotationsFile = autoConfig.AnnotationsFile
+	}
+
+	if len(mergedConfig.Rules) == 0 {
+		mergedConfig.Rules = autoConfig.Rules
+	}
+
+	if mergedConfig.Hooks.PreFile == "" {
+		mergedConfig.Hooks.PreFile = autoConfig.Hooks.PreFile
+	}
+
+	if mergedConfig.Hooks.PostPack == "" {
+		mergedConfig.Hooks.PostPack = autoConfig.Hooks.PostPack
+	}
+
+	if len(mergedConfig.Plugins) == 0 {
+		mergedConfig.Plugins = autoConfig.Plugins
+	}
+
+	if len(mergedConfig.AnonymizeDirs) == 0 {
+		mergedConfig.AnonymizeDirs = autoConfig.AnonymizeDirs
+	}
+
+	if mergedConfig.AnonymizeMode == "" {
+		mergedConfig.AnonymizeMode = autoConfig.AnonymizeMode
+	}
+
+	if mergedConfig.AnonymizeSalt == "" {
+		mergedConfig.AnonymizeSalt = autoConfig.AnonymizeSalt
+	}
+
+	if mergedConfig.AnonymizeMapFile == "" {
+		mergedConfig.AnonymizeMapFile = autoConfig.AnonymizeMapFile
+	}
+
 	return mergedConfig
 }
 
+// applyProfile decodes the named entry of the file's profiles section over
+// config, so the profile inherits every setting it does not name
+func applyProfile(config *Config, data []byte, ext, profile string) error {
+	var names []string
+	if ext == ".json" {
+		var doc struct {
+			Profiles map[string]json.RawMessage `json:"profiles"`
+		}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("error parsing JSON config: %w", err)
+		}
+		if raw, ok := doc.Profiles[profile]; ok {
+			if err := json.Unmarshal(raw, config); err != nil {
+				return fmt.Errorf("error parsing profile %s: %w", profile, err)
+			}
+			return nil
+		}
+		for name := range doc.Profiles {
+			names = append(names, name)
+		}
+	} else {
+		var doc struct {
+			Profiles map[string]yaml.Node `yaml:"profiles"`
+		}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("error parsing YAML config: %w", err)
+		}
+		if node, ok := doc.Profiles[profile]; ok {
+			if err := node.Decode(config); err != nil {
+				return fmt.Errorf("error parsing profile %s: %w", profile, err)
+			}
+			return nil
+		}
+		for name := range doc.Profiles {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	if len(names) == 0 {
+		return fmt.Errorf("unknown profile: %s (the config file defines no profiles)", profile)
+	}
+	return fmt.Errorf("unknown profile: %s (available: %s)", profile, strings.Join(names, ", "))
+}
+
 // isEmptyConfig checks if a config is effectively empty (all zero values)
 func isEmptyConfig(config Config) bool {
 	return config.OutputFile == "" &&
 		len(config.IncludeGlobs) == 0 &&
 		len(config.ExcludeGlobs) == 0 &&
+		len(config.IncludeNames) == 0 &&
+		len(config.IncludeLangs) == 0 &&
+		len(config.PriorityGlobs) == 0 &&
+		len(config.EntryFiles) == 0 &&
+		config.SortOrder == "" &&
+		config.ReportFile == "" &&
+		config.ManifestFile == "" &&
+		config.AnnotationsFile == "" &&
+		config.Preset == "" &&
+		!config.SkipGenerated &&
+		!config.NoTests &&
+		!config.TestsOnly &&
+		!config.NoGitAttributes &&
+		config.Hidden == "" &&
+		config.SymlinkPolicy == "" &&
+		!config.IncludeSubmodules &&
+		config.NormalizeEOL == "" &&
+		!config.StripTrailingSpace &&
+		config.TabsToSpaces == 0 &&
+		config.CollapseBlankLines == 0 &&
+		!config.NormalizeUnicode &&
+		!config.StripInvisible &&
+		!config.ExtractDocs &&
+		config.Minified == "" &&
+		len(config.FenceLanguages) == 0 &&
+		config.ChunkTokens == 0 &&
+		config.ChunkOverlap == 0 &&
+		config.Grep == "" &&
+		config.GrepV == "" &&
+		config.NewerThan == "" &&
+		config.OlderThan == "" &&
+		!config.GitDates &&
+		!config.PerWorkspace &&
+		config.SplitByDir == 0 &&
+		!config.NoLockfiles &&
+		config.MaxFilesPerDir == 0 &&
+		config.MaxDepth == 0 &&
+		config.MaxFiles == 0 &&
+		config.MaxTotalSize == "" &&
+		config.MinSize == "" &&
+		config.MaxMemory == "" &&
+		config.IOWorkers == 0 &&
+		config.HashWorkers == 0 &&
+		config.TokenWorkers == 0 &&
+		config.Pprof == "" &&
+		config.MetricsFile == "" &&
+		config.MaxSize == "" &&
+		!config.Strict &&
+		!config.Append &&
+		!config.NoGitHeader &&
+		!config.GitMeta &&
+		config.GitLog == 0 &&
+		!config.GitLogScoped &&
+		config.Upload == "" &&
+		config.PostURL == "" &&
+		config.PostToken == "" &&
+		config.PostContentType == "" &&
+		config.GzipJobs == 0 &&
+		config.Encoding == "" &&
+		!config.Wrap &&
+		!config.Deterministic &&
+		!config.Symbols &&
+		!config.ExportedOnly &&
+		!config.DocsOnly &&
+		!config.ImportGraph &&
+		!config.ImportGraphMermaid &&
+		config.Hooks == (Hooks{}) &&
+		len(config.Plugins) == 0 &&
+		len(config.LockfileGlobs) == 0 &&
+		config.MaxTokens == 0 &&
+		config.MaxFileTokens == 0 &&
+		len(config.Budget) == 0 &&
+		config.ScanSecrets == "" &&
+		config.Tokenizer == "" &&
+		config.Instructions == "" &&
+		config.CostModel == "" &&
+		len(config.Prices) == 0 &&
 		!config.Verbose &&
 		!config.Compress &&
 		!config.MaxCompress &&
 		!config.Gzip &&
-		!config.Base64
+		!config.Base64 &&
+		!config.LowMemory &&
+		!config.FlushPerFile &&
+		!config.Durable &&
+		!config.Verify &&
+		!config.Frontmatter &&
+		config.HeadLines == 0 &&
+		len(config.Rules) == 0 &&
+		!config.Anonymize &&
+		len(config.AnonymizeDirs) == 0 &&
+		config.AnonymizeMode == "" &&
+		config.AnonymizeSalt == "" &&
+		config.AnonymizeMapFile == "" &&
+		config.OutputFormat == "" &&
+		config.LinkMode == ""
 }
 
 // ApplyDefaults applies default values to empty fields in the config
@@ -195,6 +794,8 @@ func ApplyDefaults(config Config) Config {
 	if config.InputDir == "" {
 		config.InputDir = defaults.InputDir
 	}
+	config.InputDir = stripLongPathPrefix(config.InputDir)
+	config.OutputFile = stripLongPathPrefix(config.OutputFile)
 
 	// Handle output file name and gzip extension
 	if config.OutputFile == "" {
@@ -204,17 +805,32 @@ func ApplyDefaults(config Config) Config {
 			config.OutputFile = "corpus-out.txt"
 		}
 	} else if config.Gzip && !strings.HasSuffix(config.OutputFile, ".gz") &&
-		!strings.Contains(config.OutputFile, ".gz.") {
+		!strings.Contains(config.OutputFile, ".gz.") && !isPipeOutput(config.OutputFile) {
 		config.OutputFile += ".gz"
 	}
 
+	if config.OutputFormat == "" {
+		config.OutputFormat = defaults.OutputFormat
+	}
+	if config.LinkMode == "" {
+		config.LinkMode = defaults.LinkMode
+	}
+
+	// A preset takes the place of the default globs
+	config = applyPreset(config)
+
 	// Apply default globs if empty
 	if config.IncludeGlobs == nil {
 		config.IncludeGlobs = defaults.IncludeGlobs
+		// Default names only accompany the default globs
+		if config.IncludeNames == nil {
+			config.IncludeNames = defaults.IncludeNames
+		}
 	}
 	if config.ExcludeGlobs == nil {
 		config.ExcludeGlobs = defaults.ExcludeGlobs
 	}
+	config = applyLockfileExcludes(config)
 
-	return config
+	return normalizeGlobs(config)
 }
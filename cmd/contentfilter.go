@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// contentFilter selects files by what they contain: --grep keeps only files
+// matching a pattern, --grep-v drops files matching one
+type contentFilter struct {
+	match   *regexp.Regexp
+	exclude *regexp.Regexp
+}
+
+// newContentFilter returns nil when no content pattern is set. Patterns are
+// checked by validateConfig, so an invalid one is never compiled here.
+func newContentFilter(config *Config) *contentFilter {
+	if config.Grep == "" && config.GrepV == "" {
+		return nil
+	}
+
+	f := &contentFilter{}
+	if config.Grep != "" {
+		f.match, _ = regexp.Compile(config.Grep)
+	}
+	if config.GrepV != "" {
+		f.exclude, _ = regexp.Compile(config.GrepV)
+	}
+	return f
+}
+
+// reason returns why the file is left out by its content, or "" to keep
+// it. A file that cannot be read is kept, so packing reports the error.
+func (f *contentFilter) reason(relPath, path string) string {
+	if f == nil {
+		return ""
+	}
+	if f.match != nil {
+		if matched, err := contentMatches(f.match, relPath, path); err == nil && !matched {
+			return "no --grep match"
+		}
+	}
+	if f.exclude != nil {
+		if matched, err := contentMatches(f.exclude, relPath, path); err == nil && matched {
+			return "--grep-v match"
+		}
+	}
+	return ""
+}
+
+// contentMatches reports whether re matches the file's content. Documents
+// are matched by the text that would be packed.
+func contentMatches(re *regexp.Regexp, relPath, path string) (bool, error) {
+	if isDocument(relPath) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return false, err
+		}
+		text, err := extractDocumentText(relPath, content)
+		if err != nil {
+			return false, err
+		}
+		return re.MatchString(text), nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	return re.MatchReader(bufio.NewReader(file)), nil
+}
+
+// validateContentFilter checks that the --grep and --grep-v patterns compile
+func validateContentFilter(config *Config) error {
+	if config.Grep != "" {
+		if _, err := regexp.Compile(config.Grep); err != nil {
+			return fmt.Errorf("invalid --grep pattern: %w", err)
+		}
+	}
+	if config.GrepV != "" {
+		if _, err := regexp.Compile(config.GrepV); err != nil {
+			return fmt.Errorf("invalid --grep-v pattern: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Separator markers written around every packed file
+const (
+	startMarker = "--- START OF FILE: "
+	endMarker   = "--- END OF FILE: "
+	markerClose = " ---"
+)
+
+// Markers around the instructions block at the top of a corpus
+const (
+	instructionsMarker    = "--- INSTRUCTIONS ---\n"
+	endInstructionsMarker = "\n--- END OF INSTRUCTIONS ---"
+)
+
+// Corpus is a decoded corpus with the location of every embedded file
+type Corpus struct {
+	Data  []byte
+	Files []CorpusFile
+}
+
+// CorpusFile locates one embedded file's content within Corpus.Data
+type CorpusFile struct {
+	ID     int    `json:"id"`
+	Path   string `json:"path"`
+	Offset int64  `json:"offset"`
+	Length int64  `json:"length"`
+	Note   string `json:"note,omitempty"`
+
+	LastCommit string `json:"lastCommit,omitempty"`
+}
+
+// Content returns the embedded file's content
+func (c *Corpus) Content(f CorpusFile) []byte {
+	return c.Data[f.Offset : f.Offset+f.Length]
+}
+
+// Find returns the embedded file with the given path
+func (c *Corpus) Find(path string) (CorpusFile, bool) {
+	for _, f := range c.Files {
+		if f.Path == path {
+			return f, true
+		}
+	}
+	return CorpusFile{}, false
+}
+
+// LoadCorpus reads a corpus file, transparently decoding base64, gzip and
+// zstd, and converting YAML and pb corpora to text
+func LoadCorpus(path string) (*Corpus, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading corpus: %w", err)
+	}
+
+	data, err = decodeCorpus(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case isYAMLCorpus(data):
+		data, err = yamlToText(data)
+	case isPBCorpus(data):
+		data, err = pbToText(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseCorpus(data), nil
+}
+
+// decodeCorpus undoes the --base64 or --encoding and --gzip writer stages if
+// present, and
+// zstd compression applied to a corpus after packing
+func decodeCorpus(data []byte) ([]byte, error) {
+	if !isGzip(data) && !isZstd(data) {
+		data = decodeText(data)
+	}
+
+	if isZstd(data) {
+		decoder, err := zstd.NewReader(nil)
+		if err != nil {
+			return nil, fmt.Errorf("error opening zstd corpus: %w", err)
+		}
+		defer decoder.Close()
+
+		if data, err = decoder.DecodeAll(data, nil); err != nil {
+			return nil, fmt.Errorf("error decompressing corpus: %w", err)
+		}
+	}
+
+	if isGzip(data) {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("error opening gzip corpus: %w", err)
+		}
+		defer reader.Close()
+
+		if data, err = io.ReadAll(reader); err != nil {
+			return nil, fmt.Errorf("error decompressing corpus: %w", err)
+		}
+	}
+
+	return data, nil
+}
+
+func isGzip(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+}
+
+func isZstd(data []byte) bool {
+	return bytes.HasPrefix(data, []byte{0x28, 0xb5, 0x2f, 0xfd})
+}
+
+// corpusEntry is one file of a corpus read from a structured format
+type corpusEntry struct {
+	path       string
+	note       string
+	lastCommit string
+	content    []byte
+}
+
+// textCorpus lays out files in the text format between header and footer
+// text, as packing them would have
+func textCorpus(header string, files []corpusEntry, footer string) []byte {
+	var b bytes.Buffer
+	b.WriteString(header)
+	for _, f := range files {
+		fmt.Fprintf(&b, "%s%s%s\n", startMarker, f.path, markerClose)
+		if f.note != "" {
+			fmt.Fprintf(&b, "%s%s%s\n", noteMarker, f.note, markerClose)
+		}
+		if f.lastCommit != "" {
+			fmt.Fprintf(&b, "%s%s%s\n", lastCommitMarker, f.lastCommit, markerClose)
+		}
+		b.Write(f.content)
+		fmt.Fprintf(&b, "\n%s%s%s\n\n", endMarker, f.path, markerClose)
+	}
+	b.WriteString(footer)
+	return b.Bytes()
+}
+
+// ParseCorpus locates the embedded files in decoded corpus data. Both the
+// plain and the --compress separator layouts are recognized.
+func ParseCorpus(data []byte) *Corpus {
+	corpus := &Corpus{Data: data}
+
+	pos := 0
+	for {
+		start := bytes.Index(data[pos:], []byte(startMarker))
+		if start < 0 {
+			break
+		}
+		start += pos
+
+		nameStart := start + len(startMarker)
+		nameLen := bytes.Index(data[nameStart:], []byte(markerClose))
+		if nameLen < 0 {
+			break
+		}
+		path := string(data[nameStart : nameStart+nameLen])
+
+		// Content follows the newline (or, when compressed, the space) after the marker
+		contentStart := nameStart + nameLen + len(markerClose)
+		if contentStart < len(data) && (data[contentStart] == '\n' || data[contentStart] == ' ') {
+			contentStart++
+		}
+
+		// Annotation and last commit lines may follow the start marker
+		note, contentStart := headerLine(data, contentStart, noteMarker)
+		lastCommit, contentStart := headerLine(data, contentStart, lastCommitMarker)
+
+		end := bytes.Index(data[contentStart:], []byte(endMarker+path+markerClose))
+		if end < 0 {
+			// Unterminated file; skip past the marker and keep scanning
+			pos = contentStart
+			continue
+		}
+		end += contentStart
+
+		// Drop the newline (or space) that precedes the end marker
+		contentEnd := end
+		if contentEnd > contentStart && (data[contentEnd-1] == '\n' || data[contentEnd-1] == ' ') {
+			contentEnd--
+		}
+
+		corpus.Files = append(corpus.Files, CorpusFile{
+			ID:     len(corpus.Files) + 1,
+			Path:   path,
+			Offset: int64(contentStart),
+			Length: int64(contentEnd - contentStart),
+			Note:   note,
+
+			LastCommit: lastCommit,
+		})
+
+		pos = end + len(endMarker) + len(path) + len(markerClose)
+	}
+
+	return corpus
+}
+
+// corpusMeta returns the text of a corpus before its first file and after
+// its last, without the version line and index
+func corpusMeta(corpus *Corpus) (head, tail []byte) {
+	if len(corpus.Files) == 0 {
+		return stripBookkeeping(corpus.Data, nil)
+	}
+
+	first := corpus.Files[0]
+	head = corpus.Data[:bytes.LastIndex(corpus.Data[:first.Offset], []byte(startMarker))]
+
+	last := corpus.Files[len(corpus.Files)-1]
+	end := int(last.Offset + last.Length)
+	closing := []byte(endMarker + last.Path + markerClose)
+	if i := bytes.Index(corpus.Data[end:], closing); i >= 0 {
+		end += i + len(closing)
+	}
+	return stripBookkeeping(head, corpus.Data[end:])
+}
+
+// headerLine reads the header line opened by marker at pos, returning its
+// value and the position after it, or pos unchanged if there is none
+func headerLine(data []byte, pos int, marker string) (string, int) {
+	if !bytes.HasPrefix(data[pos:], []byte(marker)) {
+		return "", pos
+	}
+	valueStart := pos + len(marker)
+	valueLen := bytes.Index(data[valueStart:], []byte(markerClose))
+	if valueLen < 0 {
+		return "", pos
+	}
+
+	next := valueStart + valueLen + len(markerClose)
+	if next < len(data) && (data[next] == '\n' || data[next] == ' ') {
+		next++
+	}
+	return string(data[valueStart : valueStart+valueLen]), next
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// modelPrices are list prices in USD per million input tokens. Prices change;
+// the prices config map overrides them and adds models.
+var modelPrices = map[string]float64{
+	"gpt-4o":           2.50,
+	"gpt-4o-mini":      0.15,
+	"gpt-4.1":          2.00,
+	"gpt-4.1-mini":     0.40,
+	"o3":               2.00,
+	"claude-opus":      15.00,
+	"claude-sonnet":    3.00,
+	"claude-haiku":     0.80,
+	"gemini-2.5-pro":   1.25,
+	"gemini-2.5-flash": 0.30,
+}
+
+// modelPrice returns the input price per million tokens for model, preferring
+// the configured prices over the built-in ones
+func modelPrice(config *Config, model string) (float64, bool) {
+	if price, ok := config.Prices[model]; ok {
+		return price, true
+	}
+	price, ok := modelPrices[model]
+	return price, ok
+}
+
+// CostModelNames returns the built-in and configured model names in sorted order
+func CostModelNames(config *Config) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, prices := range []map[string]float64{modelPrices, config.Prices} {
+		for name := range prices {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// costSummary returns the summary lines estimating the corpus's input cost
+// under the configured cost model, or "" when none is set
+func (p *fileProcessor) costSummary() string {
+	if p.config.CostModel == "" {
+		return ""
+	}
+	price, _ := modelPrice(p.config, p.config.CostModel)
+	cost := float64(p.summary.TotalTokens) * price / 1e6
+	return fmt.Sprintf("Estimated Tokens: %d\nEstimated Input Cost (%s at $%.2f/M tokens): $%.4f\n",
+		p.summary.TotalTokens, p.config.CostModel, price, cost)
+}
+
+// validateCostModel checks that the cost model has a price
+func validateCostModel(config *Config) error {
+	if config.CostModel == "" {
+		return nil
+	}
+	if _, ok := modelPrice(config, config.CostModel); !ok {
+		return fmt.Errorf("unknown cost model: %s (available: %s; add others under prices in the config)",
+			config.CostModel, strings.Join(CostModelNames(config), ", "))
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// digestCache keeps file hashes and token counts between the packs of a
+// daemon. Entries are keyed by absolute path and dropped when the file's
+// size or modification time changes.
+type digestCache struct {
+	mu      sync.Mutex
+	entries map[string]*cachedDigest
+}
+
+// cachedDigest is what a daemon knows about one version of a file
+type cachedDigest struct {
+	size    int64
+	modTime time.Time
+	sha256  string
+	tokens  map[string]int // By tokenizer
+}
+
+func newDigestCache() *digestCache {
+	return &digestCache{entries: make(map[string]*cachedDigest)}
+}
+
+// lookup returns the entry for the current version of a file, creating an
+// empty one when the file is new or has changed. It returns nil for a file
+// that cannot be stat'ed or for a nil cache.
+func (c *digestCache) lookup(path string) *cachedDigest {
+	if c == nil {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[path]
+	if !ok || entry.size != info.Size() || !entry.modTime.Equal(info.ModTime()) {
+		entry = &cachedDigest{size: info.Size(), modTime: info.ModTime(), tokens: make(map[string]int)}
+		c.entries[path] = entry
+	}
+	return entry
+}
+
+// hash returns the cached SHA-256 of a file, computing it from content
+func (c *digestCache) hash(path string, content []byte, compute func() string) string {
+	entry := c.lookup(path)
+	if entry == nil || entry.size != int64(len(content)) {
+		return compute()
+	}
+	c.mu.Lock()
+	sum := entry.sha256
+	c.mu.Unlock()
+	if sum == "" {
+		sum = compute()
+		c.mu.Lock()
+		entry.sha256 = sum
+		c.mu.Unlock()
+	}
+	return sum
+}
+
+// count returns the cached token count of a file under tokenizer, calling
+// compute on a miss
+func (c *digestCache) count(path, tokenizer string, compute func() (int, bool)) (int, bool) {
+	entry := c.lookup(path)
+	if entry == nil {
+		return compute()
+	}
+	c.mu.Lock()
+	tokens, ok := entry.tokens[tokenizer]
+	c.mu.Unlock()
+	if ok {
+		return tokens, true
+	}
+	tokens, ok = compute()
+	if ok {
+		c.mu.Lock()
+		entry.tokens[tokenizer] = tokens
+		c.mu.Unlock()
+	}
+	return tokens, ok
+}
+
+// DaemonPackResponse is the JSON a daemon answers a pack request with
+type DaemonPackResponse struct {
+	OK         bool   `json:"ok"`
+	OutputFile string `json:"outputFile,omitempty"`
+	DurationMS int64  `json:"durationMs"`
+	Error      string `json:"error,omitempty"`
+}
+
+// NewDaemonHandler returns the HTTP handler of cpack daemon. POST /pack
+// takes a JSON config, as in a config file, and packs it with a file hash
+// and token cache kept warm across requests.
+func NewDaemonHandler() http.Handler {
+	cache := newDigestCache()
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /pack", func(w http.ResponseWriter, r *http.Request) {
+		metrics.recordRequest("POST /pack")
+		w.Header().Set("Content-Type", "application/json")
+
+		var config Config
+		decoder := json.NewDecoder(r.Body)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&config); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(DaemonPackResponse{Error: fmt.Sprintf("error parsing pack request: %v", err)})
+			return
+		}
+		// A CPU profile or trace is per process, and requests run at once
+		if config.Pprof != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(DaemonPackResponse{Error: "pprof is not supported by the daemon; profile the daemon process instead"})
+			return
+		}
+		// Hooks and plugins run code, which a request may not enable
+		config.AllowHooks = false
+		config.cache = cache
+
+		start := time.Now()
+		err := ProcessDirectory(config)
+		response := DaemonPackResponse{OK: err == nil, OutputFile: config.OutputFile, DurationMS: time.Since(start).Milliseconds()}
+		if err != nil {
+			response.Error = err.Error()
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		}
+		json.NewEncoder(w).Encode(response)
+	})
+
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, "{\"ok\":true,\"version\":%q}\n", Version)
+	})
+	mux.Handle("GET /metrics", MetricsHandler())
+	return mux
+}
+
+// defaultDaemonSocket is $XDG_RUNTIME_DIR/cpack.sock, or a per-user
+// socket in the temporary directory
+func defaultDaemonSocket() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "cpack.sock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("cpack-%d.sock", os.Getuid()))
+}
+
+// listenDaemon listens on a unix socket only its user can connect to,
+// replacing a socket left behind by a daemon that is no longer running
+func listenDaemon(socket string) (net.Listener, error) {
+	if conn, err := net.Dial("unix", socket); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("a daemon is already listening on %s", socket)
+	}
+	if err := os.Remove(socket); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("error removing stale socket: %w", err)
+	}
+
+	listener, err := listenPrivate(socket)
+	if err != nil {
+		return nil, fmt.Errorf("error listening on %s: %w", socket, err)
+	}
+	if err := os.Chmod(socket, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("error securing %s: %w", socket, err)
+	}
+	return listener, nil
+}
+
+var (
+	daemonSocket string
+
+	daemonCmd = &cobra.Command{
+		Use:   "daemon",
+		Short: "Serve pack requests over a unix socket with a warm cache",
+		Long: `Run cpack as a daemon that packs on request, for editor integrations that
+pack the same tree again and again. File hashes and token counts are kept
+between requests and recomputed only for files whose size or modification
+time changed, and tokenizers stay loaded. Endpoints, as HTTP over the socket:
+
+  POST /pack     pack the JSON config in the body, as in a config file
+  GET  /health   daemon version
+  GET  /metrics  pack counters in the Prometheus text format
+
+For example:
+
+  curl --unix-socket "$XDG_RUNTIME_DIR/cpack.sock" http://cpack/pack \
+    -d '{"inputDir": "/src/app", "outputFile": "/tmp/app.txt"}'
+
+Hooks and plugins never run for a request. Relative paths are resolved
+against the daemon's working directory.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			listener, err := listenDaemon(daemonSocket)
+			if err != nil {
+				return err
+			}
+			defer os.Remove(daemonSocket)
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			server := &http.Server{Handler: NewDaemonHandler()}
+			go func() {
+				<-ctx.Done()
+				// Let packs in progress finish
+				shutdown, cancel := context.WithTimeout(context.Background(), time.Minute)
+				defer cancel()
+				server.Shutdown(shutdown)
+			}()
+
+			fmt.Fprintf(cmd.OutOrStdout(), "cpack daemon listening on %s\n", daemonSocket)
+			if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				return fmt.Errorf("error serving daemon: %w", err)
+			}
+			return nil
+		},
+	}
+)
+
+func init() {
+	daemonCmd.Flags().StringVar(&daemonSocket, "socket", defaultDaemonSocket(), "Unix socket to listen on")
+	rootCmd.AddCommand(daemonCmd)
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// AddDeprecatedFlags registers the flags of earlier releases. They are
+// hidden from help and mapped onto the glob selection by
+// ApplyDeprecatedFlags, so existing scripts keep working.
+func AddDeprecatedFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.StringSlice("valid-extensions", nil, "File extensions to include, with or without a leading dot")
+	flags.StringSlice("valid-dirs", nil, "Directories to include, relative to the input directory")
+	flags.StringSlice("ignore-dirs", nil, "Directory names to exclude wherever they appear")
+	flags.StringSlice("ignore-patterns", nil, "Glob patterns to exclude")
+	flags.Bool("aggressive-compress", false, "Remove comments and all unnecessary whitespace")
+
+	flags.MarkDeprecated("valid-extensions", "use --ext instead")
+	flags.MarkDeprecated("valid-dirs", "use --include 'dir/**' instead")
+	flags.MarkDeprecated("ignore-dirs", "use --exclude '**/dir/**' instead")
+	flags.MarkDeprecated("ignore-patterns", "use --exclude instead")
+	flags.MarkDeprecated("aggressive-compress", "use --max-compress instead")
+}
+
+// ApplyDeprecatedFlags maps the deprecated flags given on the command line
+// onto c. Valid extensions and directories become include globs, combined
+// when both are given; ignored directories and patterns extend the exclude
+// globs.
+func ApplyDeprecatedFlags(cmd *cobra.Command, c *Config) {
+	flags := cmd.Flags()
+
+	var extensions, dirs []string
+	if flags.Changed("valid-extensions") {
+		extensions, _ = flags.GetStringSlice("valid-extensions")
+	}
+	if flags.Changed("valid-dirs") {
+		dirs, _ = flags.GetStringSlice("valid-dirs")
+	}
+	if globs := legacyIncludeGlobs(extensions, dirs); len(globs) > 0 {
+		c.IncludeGlobs = append(c.IncludeGlobs, globs...)
+	}
+
+	var excludes []string
+	if flags.Changed("ignore-dirs") {
+		ignoreDirs, _ := flags.GetStringSlice("ignore-dirs")
+		for _, dir := range ignoreDirs {
+			excludes = append(excludes, "**/"+legacyDir(dir)+"/**")
+		}
+	}
+	if flags.Changed("ignore-patterns") {
+		patterns, _ := flags.GetStringSlice("ignore-patterns")
+		excludes = append(excludes, patterns...)
+	}
+	if len(excludes) > 0 {
+		if c.ExcludeGlobs == nil {
+			c.ExcludeGlobs = DefaultConfig().ExcludeGlobs
+		}
+		c.ExcludeGlobs = append(append([]string{}, c.ExcludeGlobs...), excludes...)
+	}
+
+	if aggressive, _ := flags.GetBool("aggressive-compress"); aggressive {
+		c.MaxCompress = true
+	}
+}
+
+// legacyIncludeGlobs returns the include globs for the deprecated valid
+// extensions and directories
+func legacyIncludeGlobs(extensions, dirs []string) []string {
+	if len(extensions) == 0 && len(dirs) == 0 {
+		return nil
+	}
+
+	names := []string{"*"}
+	if len(extensions) > 0 {
+		names = nil
+		for _, ext := range extensions {
+			names = append(names, "*."+strings.TrimPrefix(ext, "."))
+		}
+	}
+
+	prefixes := []string{"**"}
+	if len(dirs) > 0 {
+		prefixes = nil
+		for _, dir := range dirs {
+			if dir = legacyDir(dir); dir == "" {
+				prefixes = append(prefixes, "**")
+			} else {
+				prefixes = append(prefixes, dir+"/**")
+			}
+		}
+	}
+
+	var globs []string
+	for _, prefix := range prefixes {
+		for _, name := range names {
+			globs = append(globs, prefix+"/"+name)
+		}
+	}
+	return globs
+}
+
+// legacyDir cleans a directory given to a deprecated flag into a
+// slash-separated relative path, "" for the input directory itself
+func legacyDir(dir string) string {
+	return strings.Trim(path.Clean("/"+filepath.ToSlash(dir)), "/")
+}
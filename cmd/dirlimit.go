@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// limitFilesPerDir keeps at most MaxFilesPerDir files from each directory:
+// the first and last files in emission order, with a note standing in for
+// the ones between. Rules can set a different limit for the files they match.
+func (p *fileProcessor) limitFilesPerDir() {
+	type group struct {
+		dir   string
+		limit int
+	}
+
+	members := make(map[group][]int)
+	var groups []group
+	for i, entry := range p.files {
+		limit := p.configFor(entry.relPath).MaxFilesPerDir
+		if limit <= 0 {
+			continue
+		}
+		g := group{dir: filepath.Dir(entry.relPath), limit: limit}
+		if _, ok := members[g]; !ok {
+			groups = append(groups, g)
+		}
+		members[g] = append(members[g], i)
+	}
+
+	drop := make(map[int]bool)
+	for _, g := range groups {
+		indexes := members[g]
+		if len(indexes) <= g.limit {
+			continue
+		}
+
+		head, tail := (g.limit+1)/2, g.limit/2
+		omitted := indexes[head : len(indexes)-tail]
+		for _, i := range omitted {
+			drop[i] = true
+			p.summary.SkippedFiles = append(p.summary.SkippedFiles,
+				SkippedFile{Path: p.displayPath(p.files[i].relPath), Reason: "directory limit"})
+		}
+		p.files[indexes[head-1]].omitted += len(omitted)
+	}
+
+	if len(drop) == 0 {
+		return
+	}
+	kept := p.files[:0]
+	for i, entry := range p.files {
+		if !drop[i] {
+			kept = append(kept, entry)
+		}
+	}
+	p.files = kept
+}
+
+// writeOmittedNote records, after the last file kept from the start of a
+// directory, how many of its files were left out
+func (p *fileProcessor) writeOmittedNote(entry fileEntry) error {
+	dir := p.displayPath(filepath.Dir(entry.relPath))
+	note := fmt.Sprintf("--- %d MORE FILES IN %s OMITTED ---\n\n", entry.omitted, filepath.ToSlash(dir))
+
+	if p.contentBuffer != nil {
+		_, err := p.contentBuffer.WriteString(note)
+		return err
+	}
+	return writeString(p.outputFile, note)
+}
@@ -0,0 +1,8 @@
+//go:build !(linux || darwin || freebsd)
+
+package cmd
+
+// diskFree is unavailable without statfs; the space check is skipped
+func diskFree(dir string) (int64, bool) {
+	return 0, false
+}
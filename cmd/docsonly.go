@@ -0,0 +1,254 @@
+package cmd
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// proseExtensions are documentation files, which --docs-only packs whole
+var proseExtensions = map[string]bool{
+	".md": true, ".markdown": true, ".mdx": true, ".rst": true, ".adoc": true, ".txt": true,
+}
+
+// Languages whose doc comments are /** */ blocks or /// lines, and whose
+// definitions open with a line starting with #
+var (
+	blockDocLanguages = map[string]bool{
+		"javascript": true, "jsx": true, "typescript": true, "tsx": true, "java": true,
+		"kotlin": true, "csharp": true, "c": true, "cpp": true, "rust": true, "swift": true,
+		"scala": true, "php": true, "objective-c": true,
+	}
+	hashDocLanguages = map[string]bool{"ruby": true, "bash": true, "sh": true, "perl": true}
+)
+
+// pythonDefRegex matches the line that opens a Python function or class
+var pythonDefRegex = regexp.MustCompile(`^\s*(?:async\s+)?(?:def|class)\s`)
+
+// isProse reports whether a file is documentation rather than code
+func isProse(relPath string) bool {
+	if proseExtensions[strings.ToLower(filepath.Ext(relPath))] || isDocument(relPath) {
+		return true
+	}
+	return strings.HasPrefix(strings.ToUpper(filepath.Base(relPath)), "README")
+}
+
+// docsOnly reduces a file to its documentation for --docs-only. Prose is
+// kept whole; code keeps its doc comments and docstrings, each followed
+// by the signature it documents, and loses everything else. Code in a
+// language without a known doc comment style yields nothing.
+func docsOnly(relPath string, text []byte) []byte {
+	if isProse(relPath) {
+		return text
+	}
+
+	language := DetectLanguage(relPath, text)
+	switch {
+	case language == "go":
+		return goDocs(text)
+	case language == "python":
+		return pythonDocs(text)
+	case blockDocLanguages[language]:
+		return commentDocs(text, isBlockDoc)
+	case hashDocLanguages[language]:
+		return commentDocs(text, func(line string) (bool, bool) {
+			return strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "#!"), false
+		})
+	}
+	return nil
+}
+
+// goDocs returns the package doc and the documented declarations of Go
+// source, each as its doc comment and the first line of its declaration.
+// Source that does not parse falls back to its // comment runs.
+func goDocs(src []byte) []byte {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return commentDocs(src, func(line string) (bool, bool) { return strings.HasPrefix(line, "//"), false })
+	}
+	text := func(from, to token.Pos) string {
+		return string(src[fset.Position(from).Offset:fset.Position(to).Offset])
+	}
+
+	var chunks []string
+	add := func(doc *ast.CommentGroup, decl string) {
+		if doc != nil {
+			chunks = append(chunks, dedent(text(doc.Pos(), doc.End()))+"\n"+signatureLine(decl))
+		}
+	}
+
+	add(file.Doc, "package "+file.Name.Name)
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			end := d.End()
+			if d.Body != nil {
+				end = d.Body.Lbrace
+			}
+			add(d.Doc, text(d.Pos(), end))
+		case *ast.GenDecl:
+			add(d.Doc, text(d.Pos(), d.End()))
+			if d.Lparen.IsValid() {
+				for _, spec := range d.Specs {
+					doc, _ := specComments(spec)
+					add(doc, text(spec.Pos(), spec.End()))
+				}
+			}
+		}
+	}
+	return joinDocs(chunks)
+}
+
+// pythonDocs returns the module docstring and the docstrings of functions
+// and classes, each after the line that opens its definition
+func pythonDocs(src []byte) []byte {
+	lines := strings.Split(string(src), "\n")
+	var chunks []string
+
+	// The module docstring is the first statement
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if doc, _, ok := docstring(lines, i); ok {
+			chunks = append(chunks, doc)
+		}
+		break
+	}
+
+	for i := 0; i < len(lines); i++ {
+		if !pythonDefRegex.MatchString(lines[i]) {
+			continue
+		}
+		// Signatures may span lines until the colon that opens the body
+		start := i
+		for i < len(lines)-1 && !strings.HasSuffix(strings.TrimSpace(lines[i]), ":") {
+			i++
+		}
+		next := i + 1
+		for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+			next++
+		}
+		if doc, end, ok := docstring(lines, next); ok {
+			chunks = append(chunks, strings.Join(lines[start:i+1], "\n")+"\n"+doc)
+			i = end
+		}
+	}
+	return joinDocs(chunks)
+}
+
+// docstring returns the triple-quoted string starting on lines[i] and the
+// index of its last line
+func docstring(lines []string, i int) (string, int, bool) {
+	if i >= len(lines) {
+		return "", i, false
+	}
+	trimmed := strings.TrimLeft(strings.TrimSpace(lines[i]), "rRuUbB")
+	var quote string
+	switch {
+	case strings.HasPrefix(trimmed, `"""`):
+		quote = `"""`
+	case strings.HasPrefix(trimmed, "'''"):
+		quote = "'''"
+	default:
+		return "", i, false
+	}
+
+	if strings.Contains(trimmed[len(quote):], quote) {
+		return lines[i], i, true
+	}
+	for end := i + 1; end < len(lines); end++ {
+		if strings.Contains(lines[end], quote) {
+			return strings.Join(lines[i:end+1], "\n"), end, true
+		}
+	}
+	return strings.Join(lines[i:], "\n"), len(lines) - 1, true
+}
+
+// isBlockDoc reports whether a line, without indentation, is part of a
+// /** */ block or /// and //! doc comment run, and whether it opens a
+// block that continues until */
+func isBlockDoc(line string) (doc, opensBlock bool) {
+	switch {
+	case strings.HasPrefix(line, "/**"):
+		return true, !strings.Contains(line[3:], "*/")
+	case strings.HasPrefix(line, "///"), strings.HasPrefix(line, "//!"):
+		return true, false
+	}
+	return false, false
+}
+
+// commentDocs returns each run of doc comment lines with the line after
+// it, which is the definition the comment documents
+func commentDocs(src []byte, isDoc func(line string) (doc, opensBlock bool)) []byte {
+	lines := strings.Split(string(src), "\n")
+	var chunks []string
+	for i := 0; i < len(lines); i++ {
+		doc, block := isDoc(strings.TrimSpace(lines[i]))
+		if !doc {
+			continue
+		}
+		start := i
+		for {
+			if block {
+				for i < len(lines)-1 && !strings.Contains(lines[i], "*/") {
+					i++
+				}
+			}
+			if i+1 >= len(lines) {
+				break
+			}
+			if doc, block = isDoc(strings.TrimSpace(lines[i+1])); !doc {
+				break
+			}
+			i++
+		}
+
+		chunk := dedent(strings.Join(lines[start:i+1], "\n"))
+		if i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+			i++
+			chunk += "\n" + signatureLine(lines[i])
+		}
+		chunks = append(chunks, chunk)
+	}
+	return joinDocs(chunks)
+}
+
+// signatureLine returns the first line of a declaration, without the brace
+// or parenthesis that opens its body
+func signatureLine(decl string) string {
+	line, _, _ := strings.Cut(decl, "\n")
+	line = strings.TrimSpace(line)
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(line, "{"), "("))
+}
+
+// dedent removes the indentation of every line
+func dedent(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimLeft(line, " \t")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// joinDocs separates extracted docs by blank lines
+func joinDocs(chunks []string) []byte {
+	if len(chunks) == 0 {
+		return nil
+	}
+	var b bytes.Buffer
+	for i, chunk := range chunks {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(chunk)
+		b.WriteString("\n")
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,566 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// documentExtractors turn office documents into plain text, by extension
+var documentExtractors = map[string]func(data []byte) (string, error){
+	".pdf":  extractPDFText,
+	".docx": extractDOCXText,
+	".pptx": extractPPTXText,
+	".xlsx": extractXLSXText,
+}
+
+// binaryDocuments are the legacy office formats, which have no text
+// extractor and are never packed
+var binaryDocuments = map[string]bool{".doc": true, ".ppt": true, ".xls": true}
+
+// isDocument reports whether relPath is a PDF or office document rather
+// than text
+func isDocument(relPath string) bool {
+	ext := strings.ToLower(filepath.Ext(relPath))
+	_, ok := documentExtractors[ext]
+	return ok || binaryDocuments[ext]
+}
+
+// documentReason returns why a document is skipped, or "" if it is packed
+func (p *fileProcessor) documentReason(relPath string) string {
+	ext := strings.ToLower(filepath.Ext(relPath))
+	switch {
+	case binaryDocuments[ext]:
+		return "binary document"
+	case documentExtractors[ext] != nil && !p.config.ExtractDocs:
+		return "document, use --extract-docs"
+	}
+	return ""
+}
+
+// extractDocumentText returns the text of a PDF, DOCX, PPTX or XLSX file
+func extractDocumentText(relPath string, data []byte) (string, error) {
+	extract, ok := documentExtractors[strings.ToLower(filepath.Ext(relPath))]
+	if !ok {
+		return "", fmt.Errorf("unsupported document format")
+	}
+	text, err := extract(data)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(text), nil
+}
+
+// openOfficeZip opens an Office Open XML package and indexes its parts by name
+func openOfficeZip(data []byte) (map[string]*zip.File, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("error opening document: %w", err)
+	}
+	parts := make(map[string]*zip.File, len(reader.File))
+	for _, f := range reader.File {
+		parts[f.Name] = f
+	}
+	return parts, nil
+}
+
+// readPart returns the content of a package part
+func readPart(parts map[string]*zip.File, name string) ([]byte, error) {
+	f, ok := parts[name]
+	if !ok {
+		return nil, fmt.Errorf("document has no %s", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", name, err)
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// xmlText collects the character data of the textElement elements of an
+// Office XML part. Paragraphs end in newlines and breaks and tabs are kept.
+func xmlText(data []byte, textElement, paragraphElement string) (string, error) {
+	var b strings.Builder
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	inText := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return b.String(), nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("error parsing document XML: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case textElement:
+				inText = true
+			case "tab":
+				b.WriteString("\t")
+			case "br", "cr":
+				b.WriteString("\n")
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case textElement:
+				inText = false
+			case paragraphElement:
+				b.WriteString("\n")
+			}
+		case xml.CharData:
+			if inText {
+				b.Write(t)
+			}
+		}
+	}
+}
+
+// extractDOCXText returns the paragraphs of a Word document's body
+func extractDOCXText(data []byte) (string, error) {
+	parts, err := openOfficeZip(data)
+	if err != nil {
+		return "", err
+	}
+	body, err := readPart(parts, "word/document.xml")
+	if err != nil {
+		return "", err
+	}
+	return xmlText(body, "t", "p")
+}
+
+// numberedParts returns the parts matching prefix + N + ".xml", in order of N
+func numberedParts(parts map[string]*zip.File, prefix string) []string {
+	pattern := regexp.MustCompile("^" + regexp.QuoteMeta(prefix) + `(\d+)\.xml$`)
+	var names []string
+	numbers := make(map[string]int)
+	for name := range parts {
+		if m := pattern.FindStringSubmatch(name); m != nil {
+			numbers[name], _ = strconv.Atoi(m[1])
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool { return numbers[names[i]] < numbers[names[j]] })
+	return names
+}
+
+// extractPPTXText returns the text of each slide of a presentation under a
+// heading naming the slide
+func extractPPTXText(data []byte) (string, error) {
+	parts, err := openOfficeZip(data)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for i, name := range numberedParts(parts, "ppt/slides/slide") {
+		slide, err := readPart(parts, name)
+		if err != nil {
+			return "", err
+		}
+		text, err := xmlText(slide, "t", "p")
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "# Slide %d\n\n%s\n", i+1, strings.TrimSpace(text))
+	}
+	return b.String(), nil
+}
+
+// extractXLSXText returns each sheet of a workbook under a heading naming
+// it, with one row per line and cells separated by tabs
+func extractXLSXText(data []byte) (string, error) {
+	parts, err := openOfficeZip(data)
+	if err != nil {
+		return "", err
+	}
+
+	var shared []string
+	if _, ok := parts["xl/sharedStrings.xml"]; ok {
+		sharedData, err := readPart(parts, "xl/sharedStrings.xml")
+		if err != nil {
+			return "", err
+		}
+		if shared, err = xlsxSharedStrings(sharedData); err != nil {
+			return "", err
+		}
+	}
+
+	var b strings.Builder
+	for _, sheet := range xlsxSheets(parts) {
+		sheetData, err := readPart(parts, sheet.part)
+		if err != nil {
+			return "", err
+		}
+		rows, err := xlsxRows(sheetData, shared)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "# Sheet %s\n\n%s\n", sheet.name, rows)
+	}
+	return b.String(), nil
+}
+
+// xlsxSheet is a worksheet and the package part holding it
+type xlsxSheet struct {
+	name string
+	part string
+}
+
+// xlsxSheets returns the worksheets in workbook order, falling back to the
+// numbered sheet parts when the workbook cannot be read
+func xlsxSheets(parts map[string]*zip.File) []xlsxSheet {
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+
+	workbookData, err := readPart(parts, "xl/workbook.xml")
+	if err == nil {
+		err = xml.Unmarshal(workbookData, &workbook)
+	}
+	relsData, relsErr := readPart(parts, "xl/_rels/workbook.xml.rels")
+	if relsErr == nil {
+		relsErr = xml.Unmarshal(relsData, &rels)
+	}
+
+	var sheets []xlsxSheet
+	if err == nil && relsErr == nil {
+		targets := make(map[string]string)
+		for _, rel := range rels.Relationships {
+			target := strings.TrimPrefix(rel.Target, "/")
+			if !strings.HasPrefix(target, "xl/") {
+				target = path.Join("xl", target)
+			}
+			targets[rel.ID] = target
+		}
+		for _, sheet := range workbook.Sheets {
+			if target, ok := targets[sheet.ID]; ok && parts[target] != nil {
+				sheets = append(sheets, xlsxSheet{name: sheet.Name, part: target})
+			}
+		}
+	}
+	if len(sheets) > 0 {
+		return sheets
+	}
+
+	for i, name := range numberedParts(parts, "xl/worksheets/sheet") {
+		sheets = append(sheets, xlsxSheet{name: strconv.Itoa(i + 1), part: name})
+	}
+	return sheets
+}
+
+// xlsxSharedStrings returns the shared string table of a workbook
+func xlsxSharedStrings(data []byte) ([]string, error) {
+	var table struct {
+		Items []struct {
+			Text string `xml:"t"`
+			Runs []struct {
+				Text string `xml:"t"`
+			} `xml:"r"`
+		} `xml:"si"`
+	}
+	if err := xml.Unmarshal(data, &table); err != nil {
+		return nil, fmt.Errorf("error parsing shared strings: %w", err)
+	}
+
+	strs := make([]string, len(table.Items))
+	for i, item := range table.Items {
+		text := item.Text
+		for _, run := range item.Runs {
+			text += run.Text
+		}
+		strs[i] = text
+	}
+	return strs, nil
+}
+
+// xlsxRows renders the rows of a worksheet, resolving shared strings
+func xlsxRows(data []byte, shared []string) (string, error) {
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal(data, &sheet); err != nil {
+		return "", fmt.Errorf("error parsing worksheet: %w", err)
+	}
+
+	var b strings.Builder
+	for _, row := range sheet.Rows {
+		cells := make([]string, 0, len(row.Cells))
+		for _, cell := range row.Cells {
+			value := cell.Value
+			switch cell.Type {
+			case "s":
+				if i, err := strconv.Atoi(value); err == nil && i >= 0 && i < len(shared) {
+					value = shared[i]
+				}
+			case "inlineStr":
+				value = cell.Inline
+			}
+			cells = append(cells, value)
+		}
+		b.WriteString(strings.Join(cells, "\t") + "\n")
+	}
+	return b.String(), nil
+}
+
+// pdfStreamRegex matches a PDF stream with the dictionary before it
+var pdfStreamRegex = regexp.MustCompile(`(?s)<<((?:[^<>]|<[^<]|>[^>])*)>>\s*stream\r?\n`)
+
+// extractPDFText returns the text shown by the content streams of a PDF.
+// Streams must be uncompressed or Flate-compressed, and strings are read as
+// single-byte text, so PDFs with embedded CID fonts or scanned pages give
+// little or no text.
+func extractPDFText(data []byte) (string, error) {
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		return "", fmt.Errorf("not a PDF file")
+	}
+
+	var b strings.Builder
+	for _, m := range pdfStreamRegex.FindAllSubmatchIndex(data, -1) {
+		dict := data[m[2]:m[3]]
+		start := m[1]
+		end := bytes.Index(data[start:], []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		stream := data[start : start+end]
+
+		if bytes.Contains(dict, []byte("/Filter")) {
+			if !bytes.Contains(dict, []byte("/FlateDecode")) || bytes.Contains(dict, []byte("/DecodeParms")) {
+				continue
+			}
+			decoded, err := inflate(stream)
+			if err != nil {
+				continue
+			}
+			stream = decoded
+		}
+		if bytes.Contains(stream, []byte("BT")) {
+			b.WriteString(pdfContentText(stream))
+		}
+	}
+	return b.String(), nil
+}
+
+// inflate decompresses zlib data, keeping what was read before any error
+// in a truncated stream
+func inflate(data []byte) ([]byte, error) {
+	reader, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	out, err := io.ReadAll(reader)
+	if len(out) > 0 {
+		return out, nil
+	}
+	return nil, err
+}
+
+// pdfContentText runs the text operators of a content stream. Strings shown
+// by Tj, TJ, ' and " are written in order; line moves and text blocks end
+// lines, and large TJ kerning gaps become spaces.
+func pdfContentText(stream []byte) string {
+	var (
+		b        strings.Builder
+		operands []string // Strings since the last operator
+		spaced   []bool   // Whether a gap precedes each string, for TJ arrays
+		inArray  bool
+		gap      bool
+	)
+	newline := func() {
+		if b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+			b.WriteString("\n")
+		}
+	}
+
+	for i := 0; i < len(stream); {
+		c := stream[i]
+		switch {
+		case c == '(':
+			s, n := pdfLiteralString(stream[i:])
+			operands = append(operands, s)
+			spaced = append(spaced, gap)
+			gap = false
+			i += n
+		case c == '<' && i+1 < len(stream) && stream[i+1] != '<':
+			end := bytes.IndexByte(stream[i:], '>')
+			if end < 0 {
+				return b.String()
+			}
+			operands = append(operands, pdfHexString(stream[i+1:i+end]))
+			spaced = append(spaced, gap)
+			gap = false
+			i += end + 1
+		case c == '[':
+			inArray = true
+			i++
+		case c == ']':
+			inArray = false
+			i++
+		case c == '%':
+			for i < len(stream) && stream[i] != '\n' && stream[i] != '\r' {
+				i++
+			}
+		case c == '-' || c == '.' || (c >= '0' && c <= '9'):
+			start := i
+			for i < len(stream) && (stream[i] == '-' || stream[i] == '.' || (stream[i] >= '0' && stream[i] <= '9')) {
+				i++
+			}
+			// A kerning gap wider than a fifth of the font size reads as a space
+			if n, err := strconv.ParseFloat(string(stream[start:i]), 64); err == nil && inArray && n < -200 {
+				gap = true
+			}
+		case isPDFRegular(c):
+			start := i
+			for i < len(stream) && isPDFRegular(stream[i]) {
+				i++
+			}
+			switch string(stream[start:i]) {
+			case "Tj", "TJ":
+				for j, s := range operands {
+					if spaced[j] && j > 0 {
+						b.WriteString(" ")
+					}
+					b.WriteString(s)
+				}
+			case "'", "\"":
+				newline()
+				for _, s := range operands {
+					b.WriteString(s)
+				}
+			case "T*", "Td", "TD":
+				newline()
+			case "ET":
+				newline()
+			}
+			operands, spaced, gap = nil, nil, false
+		default:
+			i++
+		}
+	}
+	return b.String()
+}
+
+// isPDFRegular reports whether c can be part of a PDF operator name
+func isPDFRegular(c byte) bool {
+	return c == '*' || c == '\'' || c == '"' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// pdfLiteralString decodes the literal string starting at data[0] == '('
+// and returns it with the number of bytes it spans
+func pdfLiteralString(data []byte) (string, int) {
+	var raw []byte
+	depth := 0
+	i := 0
+	for ; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case c == '\\' && i+1 < len(data):
+			i++
+			switch e := data[i]; e {
+			case 'n':
+				raw = append(raw, '\n')
+			case 'r':
+				raw = append(raw, '\r')
+			case 't':
+				raw = append(raw, '\t')
+			case 'b', 'f':
+			case '\r', '\n':
+				// A line continuation
+			default:
+				if e >= '0' && e <= '7' {
+					n := 0
+					for j := 0; j < 3 && i < len(data) && data[i] >= '0' && data[i] <= '7'; j++ {
+						n = n*8 + int(data[i]-'0')
+						i++
+					}
+					i--
+					raw = append(raw, byte(n))
+				} else {
+					raw = append(raw, e)
+				}
+			}
+		case c == '(':
+			if depth > 0 {
+				raw = append(raw, c)
+			}
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return pdfBytesText(raw), i + 1
+			}
+			raw = append(raw, c)
+		default:
+			raw = append(raw, c)
+		}
+	}
+	return pdfBytesText(raw), i
+}
+
+// pdfHexString decodes the body of a hex string
+func pdfHexString(hex []byte) string {
+	var raw []byte
+	var digits []byte
+	for _, c := range hex {
+		if _, err := strconv.ParseUint(string(c), 16, 8); err == nil {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	for i := 0; i < len(digits); i += 2 {
+		n, _ := strconv.ParseUint(string(digits[i:i+2]), 16, 8)
+		raw = append(raw, byte(n))
+	}
+	return pdfBytesText(raw)
+}
+
+// pdfBytesText reads string bytes as UTF-8 when they are valid and as
+// Latin-1 otherwise, dropping control characters
+func pdfBytesText(raw []byte) string {
+	var b strings.Builder
+	if utf8.Valid(raw) {
+		for _, r := range string(raw) {
+			if r >= ' ' || r == '\t' || r == '\n' {
+				b.WriteRune(r)
+			}
+		}
+		return b.String()
+	}
+	for _, c := range raw {
+		if c >= ' ' || c == '\t' || c == '\n' {
+			b.WriteRune(rune(c))
+		}
+	}
+	return b.String()
+}
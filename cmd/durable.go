@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// fileOverhead approximates the separators written around each file,
+// beyond its path
+const fileOverhead = 48
+
+// estimateOutputSize estimates the corpus size from the selected files'
+// sizes. Gzip rarely shrinks text below a tenth, so compressed output is
+// estimated at that, erring towards packs that fit.
+func (p *fileProcessor) estimateOutputSize() int64 {
+	var size int64
+	for _, entry := range p.files {
+		info, err := os.Stat(entry.absPath)
+		if err != nil {
+			continue
+		}
+		size += info.Size() + int64(2*len(entry.relPath)+fileOverhead)
+	}
+	if p.config.Gzip {
+		size /= 10
+	}
+	if outputEncoding(p.config) != "" {
+		// Base64 turns every 3 bytes into 4
+		size = size * 4 / 3
+	}
+	return size
+}
+
+// checkDiskSpace fails before anything is packed when the filesystem of
+// the output lacks room for the estimated corpus. Pipes and object stores
+// are not checked, nor are filesystems whose free space is unknown.
+func (p *fileProcessor) checkDiskSpace() error {
+	output := p.config.OutputFile
+	if isObjectURL(output) || isPipeOutput(output) {
+		return nil
+	}
+	free, ok := diskFree(filepath.Dir(output))
+	if !ok {
+		return nil
+	}
+	if need := p.estimateOutputSize(); need > free {
+		return fmt.Errorf("not enough disk space for %s: the corpus needs about %s, but only %s is free",
+			output, formatSize(need), formatSize(free))
+	}
+	return nil
+}
+
+// syncOutput flushes a written corpus to stable storage for --durable,
+// so a pipeline reading it straight after the pack sees every byte
+func syncOutput(output string) error {
+	if isObjectURL(output) || isPipeOutput(output) {
+		return nil
+	}
+	// Windows only flushes handles opened for writing
+	f, err := os.OpenFile(output, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("error opening output file for sync: %w", err)
+	}
+	defer f.Close()
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("error syncing output file: %w", err)
+	}
+	return nil
+}
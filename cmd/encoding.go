@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/ascii85"
+	"encoding/base64"
+	"fmt"
+	"io"
+)
+
+// Text encodings of a gzipped corpus
+const (
+	EncodingBase64    = "base64"    // Standard base64, as --base64 writes
+	EncodingBase64URL = "base64url" // URL- and filename-safe base64
+	EncodingBase85    = "base85"    // Ascii85, a quarter smaller than base64
+)
+
+// mimeLineLength is the column --wrap breaks encoded lines at, as in MIME
+const mimeLineLength = 76
+
+// outputEncoding returns the text encoding of a config's output, or ""
+// for raw output. --base64 is shorthand for --encoding base64.
+func outputEncoding(config *Config) string {
+	if config.Encoding != "" {
+		return config.Encoding
+	}
+	if config.Base64 {
+		return EncodingBase64
+	}
+	return ""
+}
+
+// newTextEncoder returns a writer that encodes into w, broken into lines
+// of mimeLineLength when wrap is set. Closing it flushes the encoding but
+// does not close w.
+func newTextEncoder(w io.Writer, encoding string, wrap bool) io.WriteCloser {
+	lines := &lineWrapper{w: w}
+	if wrap {
+		w = lines
+	}
+
+	var encoder io.WriteCloser
+	switch encoding {
+	case EncodingBase64URL:
+		encoder = base64.NewEncoder(base64.URLEncoding, w)
+	case EncodingBase85:
+		encoder = ascii85.NewEncoder(w)
+	default:
+		encoder = base64.NewEncoder(base64.StdEncoding, w)
+	}
+	if !wrap {
+		return encoder
+	}
+	return &wrappedEncoder{encoder: encoder, lines: lines}
+}
+
+// lineWrapper inserts a newline every mimeLineLength bytes
+type lineWrapper struct {
+	w      io.Writer
+	column int
+}
+
+func (l *lineWrapper) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		if l.column == mimeLineLength {
+			if _, err := l.w.Write([]byte("\n")); err != nil {
+				return 0, err
+			}
+			l.column = 0
+		}
+		take := mimeLineLength - l.column
+		if take > len(p) {
+			take = len(p)
+		}
+		if _, err := l.w.Write(p[:take]); err != nil {
+			return 0, err
+		}
+		l.column += take
+		p = p[take:]
+	}
+	return n, nil
+}
+
+// wrappedEncoder ends the last wrapped line with a newline on Close
+type wrappedEncoder struct {
+	encoder io.WriteCloser
+	lines   *lineWrapper
+}
+
+func (e *wrappedEncoder) Write(p []byte) (int, error) {
+	return e.encoder.Write(p)
+}
+
+func (e *wrappedEncoder) Close() error {
+	if err := e.encoder.Close(); err != nil {
+		return err
+	}
+	if e.lines.column > 0 {
+		_, err := e.lines.w.Write([]byte("\n"))
+		return err
+	}
+	return nil
+}
+
+// decodeText reverses any of the text encodings of a corpus, returning data
+// unchanged when it is not one. Line breaks are ignored.
+func decodeText(data []byte) []byte {
+	trimmed := bytes.TrimSpace(data)
+	// Plain corpora are recognized before any decode buffer is allocated,
+	// which would otherwise cost several times their size
+	if bytes.HasPrefix(trimmed, []byte(versionMarker)) || bytes.HasPrefix(trimmed, []byte(startMarker)) || !inEncodedAlphabet(trimmed) {
+		return data
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding} {
+		decoded := make([]byte, enc.DecodedLen(len(trimmed)))
+		if n, err := enc.Decode(decoded, trimmed); err == nil && isEncodedCorpus(decoded[:n]) {
+			return decoded[:n]
+		}
+	}
+
+	// A z stands for four zero bytes
+	decoded := make([]byte, 4*len(trimmed))
+	if n, _, err := ascii85.Decode(decoded, trimmed, true); err == nil && isEncodedCorpus(decoded[:n]) {
+		return decoded[:n]
+	}
+	return data
+}
+
+// inEncodedAlphabet reports whether every byte of data can appear in
+// base64, base64url or Ascii85 text, including line breaks and spaces
+func inEncodedAlphabet(data []byte) bool {
+	for _, b := range data {
+		if (b < '!' || b > 'z') && b != ' ' && b != '\t' && b != '\n' && b != '\r' && b != '\v' && b != '\f' {
+			return false
+		}
+	}
+	return true
+}
+
+// isEncodedCorpus reports whether decoded text is a compressed or plain
+// corpus. Plain text can happen to be valid Ascii85, and then decodes to
+// noise, so a plain corpus must hold a file.
+func isEncodedCorpus(decoded []byte) bool {
+	return isGzip(decoded) || isZstd(decoded) || bytes.Contains(decoded, []byte(startMarker))
+}
+
+// validateEncoding checks --encoding and --wrap
+func validateEncoding(config *Config) error {
+	switch config.Encoding {
+	case "", EncodingBase64, EncodingBase64URL, EncodingBase85:
+	default:
+		return fmt.Errorf("unsupported encoding: %s (expected base64, base64url or base85)", config.Encoding)
+	}
+	if config.Base64 && config.Encoding != "" && config.Encoding != EncodingBase64 {
+		return fmt.Errorf("--base64 conflicts with --encoding %s", config.Encoding)
+	}
+	if config.Wrap && outputEncoding(config) == "" {
+		return fmt.Errorf("--wrap requires --base64 or --encoding")
+	}
+	return nil
+}
+
+// encodingFlag names the flag that chose the output encoding, for errors
+func encodingFlag(config *Config) string {
+	if config.Encoding != "" {
+		return "encoding " + config.Encoding
+	}
+	return "base64"
+}
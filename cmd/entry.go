@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Reference patterns for following an entrypoint through a web app
+var (
+	htmlRefRegex = regexp.MustCompile(`(?i)<(?:script|link|img|source)\b[^>]*?\s(?:src|href)\s*=\s*["']([^"']+)["']`)
+	jsRefRegex   = regexp.MustCompile(`(?:\bimport\s*(?:[\w*{}\s,$]+\s*from\s*)?|\bexport\s+[\w*{}\s,$]+\s*from\s*|\brequire\s*\(\s*|\bimport\s*\(\s*)["']([^"']+)["']`)
+	cssRefRegex  = regexp.MustCompile(`@import\s+(?:url\(\s*)?["']?([^"')\s;]+)|url\(\s*["']?([^"')\s]+)`)
+)
+
+// moduleSuffixes are tried in order when a JS/TS import omits its extension
+var moduleSuffixes = []string{
+	"", ".js", ".mjs", ".cjs", ".ts", ".tsx", ".jsx", ".css",
+	"/index.js", "/index.ts", "/index.tsx", "/index.jsx",
+}
+
+// filterReachable keeps only selected files reachable from the configured
+// entrypoints. Entrypoints themselves are always packed.
+func (p *fileProcessor) filterReachable() error {
+	if len(p.config.EntryFiles) == 0 {
+		return nil
+	}
+
+	absInputDir, err := filepath.Abs(p.config.InputDir)
+	if err != nil {
+		return fmt.Errorf("error resolving input directory path: %w", err)
+	}
+
+	reachable := make(map[string]bool)
+	var entries, queue []string
+	for _, entry := range p.config.EntryFiles {
+		relPath := filepath.Clean(entry)
+		if filepath.IsAbs(entry) {
+			if relPath, err = filepath.Rel(absInputDir, entry); err != nil {
+				return fmt.Errorf("error resolving entrypoint %s: %w", entry, err)
+			}
+		}
+		relPath = filepath.ToSlash(relPath)
+		if _, err := os.Stat(filepath.Join(absInputDir, relPath)); err != nil {
+			return fmt.Errorf("entrypoint does not exist: %s", entry)
+		}
+		entries = append(entries, relPath)
+		if !reachable[relPath] {
+			reachable[relPath] = true
+			queue = append(queue, relPath)
+		}
+	}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		content, err := os.ReadFile(filepath.Join(absInputDir, current))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", current, err)
+			continue
+		}
+
+		for _, ref := range extractReferences(current, string(content)) {
+			target := resolveReference(absInputDir, current, ref)
+			if target != "" && !reachable[target] {
+				reachable[target] = true
+				queue = append(queue, target)
+			}
+		}
+	}
+
+	selected := make(map[string]bool, len(p.files))
+	var files []fileEntry
+	for _, entry := range p.files {
+		selected[filepath.ToSlash(entry.relPath)] = true
+		if reachable[filepath.ToSlash(entry.relPath)] {
+			files = append(files, entry)
+			continue
+		}
+		p.summary.SkippedFiles = append(p.summary.SkippedFiles, SkippedFile{Path: p.displayPath(entry.relPath), Reason: "unreachable"})
+	}
+
+	// Entrypoints are packed even when the include globs do not cover them
+	for i := len(entries) - 1; i >= 0; i-- {
+		relPath := entries[i]
+		if !selected[relPath] {
+			selected[relPath] = true
+			files = append([]fileEntry{{
+				relPath: filepath.FromSlash(relPath),
+				absPath: filepath.Join(absInputDir, relPath),
+			}}, files...)
+		}
+	}
+
+	p.files = files
+	return nil
+}
+
+// extractReferences finds the local references made by a file based on its type
+func extractReferences(relPath, content string) []string {
+	var refs []string
+	collect := func(matches [][]string) {
+		for _, match := range matches {
+			for _, group := range match[1:] {
+				if group != "" {
+					refs = append(refs, group)
+				}
+			}
+		}
+	}
+
+	switch strings.ToLower(filepath.Ext(relPath)) {
+	case ".html", ".htm":
+		collect(htmlRefRegex.FindAllStringSubmatch(content, -1))
+		// Inline module scripts can import too
+		collect(jsRefRegex.FindAllStringSubmatch(content, -1))
+	case ".js", ".mjs", ".cjs", ".jsx", ".ts", ".tsx", ".vue", ".svelte":
+		collect(jsRefRegex.FindAllStringSubmatch(content, -1))
+	case ".css", ".scss", ".less":
+		collect(cssRefRegex.FindAllStringSubmatch(content, -1))
+	}
+
+	return refs
+}
+
+// resolveReference maps a reference to a slash-separated path relative to the
+// input directory, or "" when it points outside the project or nowhere
+func resolveReference(absInputDir, from, ref string) string {
+	// Drop query strings and fragments
+	if i := strings.IndexAny(ref, "?#"); i >= 0 {
+		ref = ref[:i]
+	}
+
+	// Skip external URLs, data URIs and bare package specifiers
+	if ref == "" || strings.Contains(ref, "://") || strings.HasPrefix(ref, "//") ||
+		strings.HasPrefix(ref, "data:") || strings.HasPrefix(ref, "mailto:") {
+		return ""
+	}
+
+	var candidate string
+	ext := strings.ToLower(path.Ext(from))
+	switch {
+	case strings.HasPrefix(ref, "/"):
+		candidate = path.Clean(strings.TrimPrefix(ref, "/"))
+	case strings.HasPrefix(ref, "./") || strings.HasPrefix(ref, "../"):
+		candidate = path.Join(path.Dir(from), ref)
+	case ext == ".html" || ext == ".htm" || ext == ".css" || ext == ".scss" || ext == ".less":
+		// HTML and CSS treat plain paths as relative
+		candidate = path.Join(path.Dir(from), ref)
+	default:
+		return ""
+	}
+
+	if candidate == ".." || strings.HasPrefix(candidate, "../") {
+		return ""
+	}
+
+	for _, suffix := range moduleSuffixes {
+		target := candidate + suffix
+		info, err := os.Stat(filepath.Join(absInputDir, filepath.FromSlash(target)))
+		if err == nil && !info.IsDir() {
+			return target
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// envPrefix starts the name of every environment variable cpack reads
+const envPrefix = "CPACK_"
+
+// envAliases are extra variable names for flags whose config key reads
+// differently from the flag, so CPACK_INCLUDE_GLOBS works as well as
+// CPACK_INCLUDE
+var envAliases = map[string]string{
+	"dir":      "CPACK_INPUT_DIR",
+	"output":   "CPACK_OUTPUT_FILE",
+	"format":   "CPACK_OUTPUT_FORMAT",
+	"include":  "CPACK_INCLUDE_GLOBS",
+	"exclude":  "CPACK_EXCLUDE_GLOBS",
+	"priority": "CPACK_PRIORITY_GLOBS",
+}
+
+// envIgnored are flags of cobra itself, which no environment variable sets:
+// CPACK_VERSION commonly holds a version number, not a request to print one
+var envIgnored = map[string]bool{
+	"help":    true,
+	"version": true,
+}
+
+// envName returns the environment variable for a flag: CPACK_ followed by
+// the flag name in upper case with dashes as underscores
+func envName(flag string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// ApplyEnvFlags sets every flag of cmd that was not given on the command
+// line from its CPACK_* environment variable. Values are parsed as the flag
+// would parse them, so lists are comma-separated and booleans accept
+// true/false/1/0. Flags win over the environment, and the environment wins
+// over config files and defaults.
+func ApplyEnvFlags(cmd *cobra.Command) error {
+	var err error
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if err != nil || flag.Changed || envIgnored[flag.Name] {
+			return
+		}
+
+		name := envName(flag.Name)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			if alias, hasAlias := envAliases[flag.Name]; hasAlias {
+				name = alias
+				value, ok = os.LookupEnv(alias)
+			}
+		}
+		if !ok {
+			return
+		}
+
+		if setErr := cmd.Flags().Set(flag.Name, value); setErr != nil {
+			err = fmt.Errorf("error parsing %s: %w", name, setErr)
+		}
+	})
+	return err
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// envRefRegex matches ${VAR} and ${VAR:-default}; $${VAR} escapes a literal
+var envRefRegex = regexp.MustCompile(`\$(\$?)\{([A-Za-z_][A-Za-z0-9_]*)(:-[^}]*)?\}`)
+
+// expandEnv replaces ${VAR} references in s with environment variables.
+// A variable that is unset and has no default is an error, so a missing CI
+// variable cannot silently turn a path into "".
+func expandEnv(s string) (string, error) {
+	var missing string
+	expanded := envRefRegex.ReplaceAllStringFunc(s, func(ref string) string {
+		m := envRefRegex.FindStringSubmatch(ref)
+		if m[1] != "" {
+			return ref[1:]
+		}
+		if value, ok := os.LookupEnv(m[2]); ok {
+			return value
+		}
+		if m[3] != "" {
+			return m[3][2:]
+		}
+		if missing == "" {
+			missing = m[2]
+		}
+		return ""
+	})
+	if missing != "" {
+		return "", fmt.Errorf("environment variable %s is not set (use ${%s:-default} for a fallback)", missing, missing)
+	}
+	return expanded, nil
+}
+
+// expandConfigEnv expands ${VAR} references in the config's paths, globs
+// and salt
+func expandConfigEnv(config *Config) error {
+	strs := []*string{
+		&config.InputDir, &config.OutputFile, &config.ReportFile, &config.ManifestFile,
+		&config.AnnotationsFile, &config.AnonymizeMapFile, &config.AnonymizeSalt,
+	}
+	lists := [][]string{
+		config.IncludeGlobs, config.IncludeNames, config.ExcludeGlobs, config.PriorityGlobs,
+		config.EntryFiles, config.AnonymizeDirs, config.LockfileGlobs,
+	}
+	for _, list := range lists {
+		for i := range list {
+			strs = append(strs, &list[i])
+		}
+	}
+	for i := range config.Rules {
+		strs = append(strs, &config.Rules[i].Glob)
+	}
+
+	for _, s := range strs {
+		expanded, err := expandEnv(*s)
+		if err != nil {
+			return err
+		}
+		*s = expanded
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+)
+
+// langChainDocument is a LangChain Document as read by
+// JSONLoader(file, jq_schema=".", content_key="page_content", json_lines=True)
+// or built directly with Document(**record)
+type langChainDocument struct {
+	PageContent string                 `json:"page_content"`
+	Metadata    map[string]interface{} `json:"metadata"`
+	Type        string                 `json:"type"`
+}
+
+// llamaIndexDocument is a LlamaIndex Document as built with
+// Document(**record), with the metadata keys SimpleDirectoryReader sets
+type llamaIndexDocument struct {
+	ID       string                 `json:"id_"`
+	Text     string                 `json:"text"`
+	Metadata map[string]interface{} `json:"metadata"`
+}
+
+// renderLangChain writes a corpus as JSON lines, one LangChain Document per
+// file, with the path as the conventional "source" metadata key
+func renderLangChain(w io.Writer, corpus *Corpus, config *Config, title string) error {
+	return writeDocuments(w, corpus, func(f CorpusFile, content []byte) interface{} {
+		metadata := documentMetadata(f, content)
+		metadata["source"] = f.Path
+		return langChainDocument{PageContent: string(content), Metadata: metadata, Type: "Document"}
+	})
+}
+
+// renderLlamaIndex writes a corpus as JSON lines, one LlamaIndex Document
+// per file, identified by its path
+func renderLlamaIndex(w io.Writer, corpus *Corpus, config *Config, title string) error {
+	return writeDocuments(w, corpus, func(f CorpusFile, content []byte) interface{} {
+		metadata := documentMetadata(f, content)
+		metadata["file_path"] = f.Path
+		metadata["file_name"] = path.Base(f.Path)
+		metadata["file_size"] = len(content)
+		return llamaIndexDocument{ID: f.Path, Text: string(content), Metadata: metadata}
+	})
+}
+
+// writeDocuments writes the record built for each file as a JSON line
+func writeDocuments(w io.Writer, corpus *Corpus, record func(f CorpusFile, content []byte) interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	for _, f := range corpus.Files {
+		if err := encoder.Encode(record(f, corpus.Content(f))); err != nil {
+			return fmt.Errorf("error writing output file: %w", err)
+		}
+	}
+	return nil
+}
+
+// documentMetadata returns the metadata both frameworks share: the
+// language and, when present, the annotation and last commit
+func documentMetadata(f CorpusFile, content []byte) map[string]interface{} {
+	metadata := map[string]interface{}{}
+	if language := DetectLanguage(f.Path, content); language != "" {
+		metadata["language"] = language
+	}
+	if f.Note != "" {
+		metadata["note"] = f.Note
+	}
+	if f.LastCommit != "" {
+		metadata["last_commit"] = f.LastCommit
+	}
+	return metadata
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// extendsTimeout bounds fetching a base config from a URL
+const extendsTimeout = 30 * time.Second
+
+// resolveExtends follows the extends chain of the config file at location.
+// Each base is deep-merged under the file that extends it: maps merge key by
+// key, while lists and scalars from the extending file replace the base's.
+// The merged document is returned as YAML; files without extends are
+// returned unchanged.
+func resolveExtends(location string, data []byte, ext string) ([]byte, string, error) {
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil || doc["extends"] == nil {
+		return data, ext, nil
+	}
+
+	merged, err := loadExtends(location, doc, map[string]bool{location: true})
+	if err != nil {
+		return nil, "", err
+	}
+
+	out, err := yaml.Marshal(merged)
+	if err != nil {
+		return nil, "", fmt.Errorf("error merging config file: %w", err)
+	}
+	return out, ".yaml", nil
+}
+
+// loadExtends merges doc over the base config it extends, recursively.
+// seen holds the locations already on the chain, to stop cycles.
+func loadExtends(location string, doc map[string]interface{}, seen map[string]bool) (map[string]interface{}, error) {
+	ref, ok := doc["extends"]
+	delete(doc, "extends")
+	if !ok || ref == nil {
+		return doc, nil
+	}
+
+	refStr, ok := ref.(string)
+	if !ok {
+		return nil, fmt.Errorf("error in %s: extends must be a path or URL", location)
+	}
+	refStr, err := expandEnv(refStr)
+	if err != nil {
+		return nil, fmt.Errorf("error in %s: %w", location, err)
+	}
+
+	base, err := resolveConfigRef(location, refStr)
+	if err != nil {
+		return nil, fmt.Errorf("error in %s: %w", location, err)
+	}
+	if seen[base] {
+		return nil, fmt.Errorf("error in %s: extends cycle through %s", location, base)
+	}
+	seen[base] = true
+
+	data, err := readConfigSource(base)
+	if err != nil {
+		return nil, fmt.Errorf("error reading base config %s: %w", base, err)
+	}
+
+	var baseDoc map[string]interface{}
+	if err := yaml.Unmarshal(data, &baseDoc); err != nil {
+		return nil, fmt.Errorf("error parsing base config %s: %w", base, err)
+	}
+	if baseDoc == nil {
+		baseDoc = make(map[string]interface{})
+	}
+
+	baseDoc, err = loadExtends(base, baseDoc, seen)
+	if err != nil {
+		return nil, err
+	}
+	return deepMerge(baseDoc, doc), nil
+}
+
+// resolveConfigRef resolves an extends reference against the location of
+// the file that declares it. Paths in a config fetched from a URL resolve
+// against that URL.
+func resolveConfigRef(location, ref string) (string, error) {
+	if isURL(ref) {
+		return ref, nil
+	}
+	if isURL(location) {
+		baseURL, err := url.Parse(location)
+		if err != nil {
+			return "", fmt.Errorf("error parsing URL %s: %w", location, err)
+		}
+		refURL, err := url.Parse(filepath.ToSlash(ref))
+		if err != nil {
+			return "", fmt.Errorf("error parsing extends %s: %w", ref, err)
+		}
+		return baseURL.ResolveReference(refURL).String(), nil
+	}
+	if filepath.IsAbs(ref) {
+		return filepath.Clean(ref), nil
+	}
+	return filepath.Join(filepath.Dir(location), ref), nil
+}
+
+// readConfigSource reads a config file from a path or an http(s) URL
+func readConfigSource(location string) ([]byte, error) {
+	if !isURL(location) {
+		return os.ReadFile(location)
+	}
+
+	client := &http.Client{Timeout: extendsTimeout}
+	resp, err := client.Get(location)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// isURL reports whether location is an http or https URL
+func isURL(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// deepMerge returns base with override merged over it. Nested maps merge
+// key by key; any other override value replaces the base value.
+func deepMerge(base, override map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(override))
+	for key, value := range base {
+		merged[key] = value
+	}
+
+	for key, value := range override {
+		overrideMap, ok := value.(map[string]interface{})
+		baseMap, baseOK := merged[key].(map[string]interface{})
+		if ok && baseOK {
+			merged[key] = deepMerge(baseMap, overrideMap)
+			continue
+		}
+		merged[key] = value
+	}
+	return merged
+}
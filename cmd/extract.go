@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	extractOutput string
+
+	extractCmd = &cobra.Command{
+		Use:   "extract <corpus> <path|id>",
+		Short: "Print a single file embedded in a corpus",
+		Long: `Print one file embedded in a corpus, found by the path in its separators or
+by its corpus ID (as listed by 'cpack serve' at /files). The corpus may be
+plain, gzipped or base64- or base85-encoded. Use --output to write the file instead.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			corpus, err := LoadCorpus(args[0])
+			if err != nil {
+				return err
+			}
+
+			file, ok := corpus.Lookup(args[1])
+			if !ok {
+				return fmt.Errorf("no file %s in %s (%d files)", args[1], args[0], len(corpus.Files))
+			}
+
+			if extractOutput != "" {
+				if err := os.WriteFile(extractOutput, corpus.Content(file), 0644); err != nil {
+					return fmt.Errorf("error writing %s: %w", extractOutput, err)
+				}
+				return nil
+			}
+			_, err = cmd.OutOrStdout().Write(corpus.Content(file))
+			return err
+		},
+	}
+)
+
+func init() {
+	extractCmd.Flags().StringVarP(&extractOutput, "output", "o", "", "Write the file here instead of to stdout")
+	rootCmd.AddCommand(extractCmd)
+}
+
+// Lookup finds an embedded file by path, falling back to its corpus ID when
+// ref is a number that is not also a path
+func (c *Corpus) Lookup(ref string) (CorpusFile, bool) {
+	if f, ok := c.Find(ref); ok {
+		return f, true
+	}
+	if id, err := strconv.Atoi(ref); err == nil && id >= 1 && id <= len(c.Files) {
+		return c.Files[id-1], true
+	}
+	return CorpusFile{}, false
+}
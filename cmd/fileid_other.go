@@ -0,0 +1,10 @@
+//go:build !unix
+
+package cmd
+
+import "os"
+
+// fileID is unavailable without inode numbers; callers fall back to paths
+func fileID(info os.FileInfo) (dirID, bool) {
+	return dirID{}, false
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package cmd
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileID returns the device and inode of a file
+func fileID(info os.FileInfo) (dirID, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return dirID{}, false
+	}
+	return dirID{dev: uint64(stat.Dev), ino: uint64(stat.Ino)}, true
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+)
+
+// PackedFile is a selected file with its content as it would be packed
+type PackedFile struct {
+	// Path is the file's path in the corpus, relative to the input directory
+	Path string
+	// Language is the detected language of the original file
+	Language string
+	// Size is the size of the original file in bytes
+	Size int64
+	// Content is the text that would be packed, after every transform
+	Content []byte
+}
+
+// EachFile selects files the way ProcessDirectory does and calls fn with
+// each one's packed content, in corpus order, without writing any output.
+// A file that cannot be read, or whose preFile hook or plugins fail, is passed to fn
+// with its error, and files with no text, such as empty documents or code
+// without docs under DocsOnly, are left out. Iteration stops when fn returns false or ctx is done;
+// selection errors, and secrets found in fail mode, are returned.
+func EachFile(ctx context.Context, config Config, fn func(PackedFile, error) bool) error {
+	processor, err := selectFiles(config)
+	if err != nil {
+		return err
+	}
+	processor.orderFiles()
+	processor.limitFilesPerDir()
+	processor.applyBudget()
+	if err := processor.applyLimits(); err != nil {
+		return err
+	}
+	if err := processor.scanSecrets(); err != nil {
+		return err
+	}
+	if err := processor.loadPlugins(); err != nil {
+		return err
+	}
+
+	for _, entry := range processor.files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		file, ok, err := processor.packedFile(entry.relPath, entry.absPath)
+		if !ok && err == nil {
+			continue
+		}
+		if !fn(file, err) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// packedFile reads a file and packs its content. A file with nothing to
+// pack is not ok, with an error if it could not be read.
+func (p *fileProcessor) packedFile(relPath, path string) (PackedFile, bool, error) {
+	file := PackedFile{Path: p.displayPath(relPath)}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return file, false, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	file.Size = int64(len(content))
+	file.Language = DetectLanguage(relPath, content)
+
+	text := content
+	if isDocument(relPath) {
+		extracted, err := extractDocumentText(relPath, content)
+		if err != nil {
+			return file, false, fmt.Errorf("error extracting text from %s: %w", path, err)
+		}
+		if extracted == "" {
+			return file, false, nil
+		}
+		text = []byte(extracted)
+	}
+	if p.minified[relPath] {
+		text = minifiedStub(len(content))
+	} else if text, err = p.customTransforms(relPath, text); err != nil {
+		return file, false, err
+	}
+	if p.config.DocsOnly {
+		if text = docsOnly(relPath, text); len(bytes.TrimSpace(text)) == 0 {
+			return file, false, nil
+		}
+	}
+
+	file.Content = p.packContent(relPath, text, p.configFor(relPath))
+	return file, true, nil
+}
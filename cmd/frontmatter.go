@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// frontmatterMarker opens and closes the YAML frontmatter of a corpus
+const frontmatterMarker = "---\n"
+
+// corpusFrontmatter is the metadata --frontmatter puts at the top of a corpus
+type corpusFrontmatter struct {
+	Repo       string // Remote URL, or the repository name without one
+	Commit     string
+	Generated  time.Time // Zero for deterministic output
+	ConfigHash string
+	Files      int
+	Tokens     int
+	Tokenizer  string
+}
+
+// format returns the frontmatter block, leaving out unknown fields
+func (f corpusFrontmatter) format() string {
+	var b strings.Builder
+	b.WriteString(frontmatterMarker)
+	fmt.Fprintf(&b, "cpack: %s\n", strconv.Quote(Version))
+	if f.Repo != "" {
+		fmt.Fprintf(&b, "repo: %s\n", strconv.Quote(f.Repo))
+	}
+	if f.Commit != "" {
+		fmt.Fprintf(&b, "commit: %s\n", strconv.Quote(f.Commit))
+	}
+	if !f.Generated.IsZero() {
+		fmt.Fprintf(&b, "generated: %s\n", f.Generated.UTC().Format(time.RFC3339))
+	}
+	fmt.Fprintf(&b, "configHash: %s\n", strconv.Quote(f.ConfigHash))
+	fmt.Fprintf(&b, "files: %d\n", f.Files)
+	fmt.Fprintf(&b, "tokens: %d\n", f.Tokens)
+	fmt.Fprintf(&b, "tokenizer: %s\n", strconv.Quote(f.Tokenizer))
+	b.WriteString(frontmatterMarker)
+	b.WriteString("\n")
+	return b.String()
+}
+
+// frontmatter describes the corpus whose body is buffered in body
+func (p *fileProcessor) frontmatter(body *spillBuffer) (corpusFrontmatter, error) {
+	f := corpusFrontmatter{
+		ConfigHash: configHash(p.config),
+		Files:      len(p.summary.ProcessedFiles),
+		Tokenizer:  p.config.Tokenizer,
+	}
+	if f.Tokenizer == "" {
+		f.Tokenizer = TokenizerEstimate
+	}
+	if !p.config.Deterministic {
+		f.Generated = p.summary.EndTime
+	}
+	// Anonymized corpora do not name their repository
+	if !anonymizesPaths(p.config) {
+		if info, ok := ReadGitInfo(p.config.InputDir); ok {
+			f.Repo = info.Remote
+			if f.Repo == "" {
+				f.Repo = info.Repository
+			}
+			f.Commit = info.Commit
+		}
+	}
+
+	err := body.chunks(func(chunk []byte) {
+		f.Tokens += p.countTokens(chunk)
+	})
+	return f, err
+}
+
+// hashedSettings are the config fields that decide which files a corpus
+// holds and how their text is transformed. Paths, which differ between
+// machines, secrets such as the anonymize salt, and options that only
+// change how the output is written or shipped are left out.
+type hashedSettings struct {
+	IncludeGlobs       []string
+	IncludeNames       []string
+	IncludeLangs       []string
+	ExcludeGlobs       []string
+	PriorityGlobs      []string
+	EntryFiles         []string
+	SortOrder          string
+	Compress           bool
+	MaxCompress        bool
+	HeadLines          int
+	Rules              []Rule
+	Anonymize          bool
+	AnonymizeDirs      []string
+	AnonymizeMode      string
+	SkipGenerated      bool
+	NoTests            bool
+	TestsOnly          bool
+	MaxTokens          int
+	MaxFileTokens      int
+	Tokenizer          string
+	Instructions       string
+	Budget             map[string]string
+	NoGitAttributes    bool
+	Hidden             string
+	SymlinkPolicy      string
+	IncludeSubmodules  bool
+	NormalizeEOL       string
+	StripTrailingSpace bool
+	TabsToSpaces       int
+	CollapseBlankLines int
+	NormalizeUnicode   bool
+	StripInvisible     bool
+	Grep               string
+	GrepV              string
+	NewerThan          string
+	OlderThan          string
+	GitDates           bool
+	ExtractDocs        bool
+	Minified           string
+	MaxFilesPerDir     int
+	MaxDepth           int
+	MaxFiles           int
+	MaxTotalSize       string
+	MinSize            string
+	MaxSize            string
+	Symbols            bool
+	ExportedOnly       bool
+	DocsOnly           bool
+	ImportGraph        bool
+	ImportGraphMermaid bool
+	NoLockfiles        bool
+	LockfileGlobs      []string
+}
+
+// configHash identifies the settings of a pack: the SHA-256 of its
+// hashedSettings as JSON, so the same settings hash the same on every
+// machine
+func configHash(config *Config) string {
+	settings := hashedSettings{
+		IncludeGlobs:       config.IncludeGlobs,
+		IncludeNames:       config.IncludeNames,
+		IncludeLangs:       config.IncludeLangs,
+		ExcludeGlobs:       config.ExcludeGlobs,
+		PriorityGlobs:      config.PriorityGlobs,
+		EntryFiles:         config.EntryFiles,
+		SortOrder:          config.SortOrder,
+		Compress:           config.Compress,
+		MaxCompress:        config.MaxCompress,
+		HeadLines:          config.HeadLines,
+		Rules:              config.Rules,
+		Anonymize:          config.Anonymize,
+		AnonymizeDirs:      config.AnonymizeDirs,
+		AnonymizeMode:      config.AnonymizeMode,
+		SkipGenerated:      config.SkipGenerated,
+		NoTests:            config.NoTests,
+		TestsOnly:          config.TestsOnly,
+		MaxTokens:          config.MaxTokens,
+		MaxFileTokens:      config.MaxFileTokens,
+		Tokenizer:          config.Tokenizer,
+		Instructions:       config.Instructions,
+		Budget:             config.Budget,
+		NoGitAttributes:    config.NoGitAttributes,
+		Hidden:             config.Hidden,
+		SymlinkPolicy:      config.SymlinkPolicy,
+		IncludeSubmodules:  config.IncludeSubmodules,
+		NormalizeEOL:       config.NormalizeEOL,
+		StripTrailingSpace: config.StripTrailingSpace,
+		TabsToSpaces:       config.TabsToSpaces,
+		CollapseBlankLines: config.CollapseBlankLines,
+		NormalizeUnicode:   config.NormalizeUnicode,
+		StripInvisible:     config.StripInvisible,
+		Grep:               config.Grep,
+		GrepV:              config.GrepV,
+		NewerThan:          config.NewerThan,
+		OlderThan:          config.OlderThan,
+		GitDates:           config.GitDates,
+		ExtractDocs:        config.ExtractDocs,
+		Minified:           config.Minified,
+		MaxFilesPerDir:     config.MaxFilesPerDir,
+		MaxDepth:           config.MaxDepth,
+		MaxFiles:           config.MaxFiles,
+		MaxTotalSize:       config.MaxTotalSize,
+		MinSize:            config.MinSize,
+		MaxSize:            config.MaxSize,
+		Symbols:            config.Symbols,
+		ExportedOnly:       config.ExportedOnly,
+		DocsOnly:           config.DocsOnly,
+		ImportGraph:        config.ImportGraph,
+		ImportGraphMermaid: config.ImportGraphMermaid,
+		NoLockfiles:        config.NoLockfiles,
+		LockfileGlobs:      config.LockfileGlobs,
+	}
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// stripFrontmatter removes a leading frontmatter block and the blank line
+// after it
+func stripFrontmatter(data []byte) []byte {
+	if !bytes.HasPrefix(data, []byte(frontmatterMarker)) {
+		return data
+	}
+	end := bytes.Index(data[len(frontmatterMarker):], []byte("\n"+frontmatterMarker))
+	if end < 0 {
+		return data
+	}
+	return bytes.TrimLeft(data[len(frontmatterMarker)+end+1+len(frontmatterMarker):], "\n")
+}
+
+// validateFrontmatter checks that --frontmatter can describe the whole
+// corpus, which it holds back until every file is packed
+func validateFrontmatter(config *Config) error {
+	if !config.Frontmatter {
+		return nil
+	}
+	switch {
+	case !stampsCorpus(config):
+		return fmt.Errorf("--frontmatter opens text corpora; it cannot be combined with --format %s", config.OutputFormat)
+	case config.Append:
+		return fmt.Errorf("--frontmatter cannot describe a corpus that --append adds to")
+	case config.FlushPerFile:
+		return fmt.Errorf("--frontmatter holds the corpus back until it is packed; it cannot be combined with --flush-per-file")
+	}
+	return nil
+}
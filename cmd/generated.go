@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"regexp"
+)
+
+// generatedSniffLen is how much of each end of a file is searched for markers
+const generatedSniffLen = 1024
+
+// goGeneratedRegex is the marker the Go toolchain recognizes
+// (https://go.dev/s/generatedcode)
+var goGeneratedRegex = regexp.MustCompile(`(?m)^// Code generated .* DO NOT EDIT\.$`)
+
+// generatedMarker reports which generated-file marker content carries, or ""
+func generatedMarker(head, tail []byte) string {
+	switch {
+	case goGeneratedRegex.Match(head):
+		return "Code generated"
+	case bytes.Contains(head, []byte("@generated")):
+		return "@generated"
+	case bytes.Contains(head, []byte("DO NOT EDIT")):
+		return "DO NOT EDIT"
+	case bytes.Contains(tail, []byte("# sourceMappingURL=")):
+		return "sourceMappingURL"
+	}
+	return ""
+}
+
+// isGeneratedFile reads the start and end of a file and reports whether it
+// carries a generated-file marker
+func isGeneratedFile(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	head := make([]byte, generatedSniffLen)
+	n, _ := io.ReadFull(f, head)
+	head = head[:n]
+
+	// Source map comments sit at the very end of bundled output
+	tail := head
+	if info, err := f.Stat(); err == nil && info.Size() > generatedSniffLen {
+		tail = make([]byte, generatedSniffLen)
+		n, _ := f.ReadAt(tail, info.Size()-generatedSniffLen)
+		tail = tail[:n]
+	}
+
+	return generatedMarker(head, tail) != ""
+}
@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Markers around the git block at the top of a corpus
+const (
+	gitMarker    = "--- GIT ---\n"
+	endGitMarker = "--- END OF GIT ---\n\n"
+)
+
+// Markers around the git log section at the end of a corpus
+const (
+	gitLogMarker    = "--- GIT LOG ---\n"
+	endGitLogMarker = "--- END OF GIT LOG ---\n"
+)
+
+// lastCommitMarker opens the line written after a file's start marker
+// with --git-meta
+const lastCommitMarker = "--- LAST COMMIT: "
+
+// GitInfo describes the git snapshot a corpus was packed from
+type GitInfo struct {
+	Repository string // Name of the repository's top-level directory
+	Directory  string // Input directory relative to the top level, "." for the top level itself
+	Branch     string // Empty for a detached HEAD
+	Commit     string
+	Dirty      bool   // Tracked files differ from the commit
+	Remote     string // URL of origin with any credentials removed, if there is one
+}
+
+// runGit runs git in dir and returns its trimmed output
+func runGit(dir string, args ...string) (string, error) {
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// ReadGitInfo returns the git snapshot dir belongs to. It returns false when
+// dir is not inside a work tree with at least one commit, or git is not
+// installed.
+func ReadGitInfo(dir string) (GitInfo, bool) {
+	top, err := runGit(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return GitInfo{}, false
+	}
+	commit, err := runGit(dir, "rev-parse", "HEAD")
+	if err != nil {
+		return GitInfo{}, false
+	}
+
+	info := GitInfo{Repository: filepath.Base(top), Directory: ".", Commit: commit}
+	if prefix, err := runGit(dir, "rev-parse", "--show-prefix"); err == nil && prefix != "" {
+		info.Directory = strings.TrimSuffix(prefix, "/")
+	}
+	if branch, err := runGit(dir, "symbolic-ref", "--short", "-q", "HEAD"); err == nil {
+		info.Branch = branch
+	}
+	// Untracked files are not counted, or the corpus being written would
+	// mark every repository dirty
+	if status, err := runGit(dir, "status", "--porcelain", "--untracked-files=no"); err == nil {
+		info.Dirty = status != ""
+	}
+	if remote, err := runGit(dir, "remote", "get-url", "origin"); err == nil {
+		info.Remote = redactRemote(remote)
+	}
+
+	return info, true
+}
+
+// redactRemote removes the user and password from a remote URL, so tokens
+// embedded in CI clone URLs never reach a corpus. scp-style remotes such as
+// git@github.com:org/repo.git are returned unchanged.
+func redactRemote(remote string) string {
+	u, err := url.Parse(remote)
+	if err != nil || u.User == nil || u.Scheme == "" {
+		return remote
+	}
+	u.User = nil
+	return u.String()
+}
+
+// formatGitInfo renders the git block that opens a corpus
+func formatGitInfo(info GitInfo) string {
+	var b strings.Builder
+	b.WriteString(gitMarker)
+	fmt.Fprintf(&b, "Repository: %s\n", info.Repository)
+	if info.Directory != "." {
+		fmt.Fprintf(&b, "Directory: %s\n", info.Directory)
+	}
+	if info.Branch != "" {
+		fmt.Fprintf(&b, "Branch: %s\n", info.Branch)
+	} else {
+		b.WriteString("Branch: (detached HEAD)\n")
+	}
+	fmt.Fprintf(&b, "Commit: %s\n", info.Commit)
+	fmt.Fprintf(&b, "Dirty: %t\n", info.Dirty)
+	if info.Remote != "" {
+		fmt.Fprintf(&b, "Remote: %s\n", info.Remote)
+	}
+	b.WriteString(endGitMarker)
+	return b.String()
+}
+
+// CommitInfo identifies the last commit that touched a file
+type CommitInfo struct {
+	Hash   string `json:"hash"`
+	Author string `json:"author"`
+	Date   string `json:"date"`
+}
+
+// String renders the commit as written in a file's header
+func (c CommitInfo) String() string {
+	return fmt.Sprintf("%s %s %s", c.Hash, c.Date, c.Author)
+}
+
+// lastCommit returns the last commit that touched relPath, or nil when
+// GitMeta is off or the file has no history, such as an untracked file
+func (p *fileProcessor) lastCommit(relPath string) *CommitInfo {
+	if !p.config.GitMeta {
+		return nil
+	}
+	if commit, ok := p.commits[relPath]; ok {
+		return commit
+	}
+	if p.commits == nil {
+		p.commits = make(map[string]*CommitInfo)
+	}
+
+	var commit *CommitInfo
+	out, err := runGit(p.config.InputDir, "log", "-1", "--format=%h%x00%an%x00%ad", "--date=short",
+		"--", ":(literal)"+filepath.ToSlash(relPath))
+	if fields := strings.Split(out, "\x00"); err == nil && len(fields) == 3 {
+		commit = &CommitInfo{Hash: fields[0], Author: fields[1], Date: fields[2]}
+	}
+	p.commits[relPath] = commit
+	return commit
+}
+
+// gitLog returns the last GitLog commits of the input directory's repository
+// with their full messages. With GitLogScoped only commits that touched the
+// packed files are listed; the paths are passed on stdin, since a large
+// selection would not fit on the command line.
+func (p *fileProcessor) gitLog() (string, error) {
+	args := []string{"-C", p.config.InputDir, "log", "-n", strconv.Itoa(p.config.GitLog), "--date=short",
+		"--format=commit %h%nAuthor: %an%nDate: %ad%n%n%w(0,4,4)%B"}
+
+	var stdin strings.Builder
+	if p.config.GitLogScoped {
+		args = append(args, "--stdin", "HEAD")
+		stdin.WriteString("--\n")
+		for _, entry := range p.files {
+			stdin.WriteString(":(literal)" + filepath.ToSlash(entry.relPath) + "\n")
+		}
+	}
+
+	command := exec.Command("git", args...)
+	command.Stdin = strings.NewReader(stdin.String())
+	out, err := command.Output()
+	if err != nil {
+		return "", err
+	}
+
+	// The message indent leaves blank message lines as bare spaces
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// writeGitLog appends the git log section when GitLog is set and the input
+// directory is in a repository with history
+func (p *fileProcessor) writeGitLog(w io.Writer) error {
+	if p.config.GitLog <= 0 || (p.config.GitLogScoped && len(p.files) == 0) {
+		return nil
+	}
+
+	log, err := p.gitLog()
+	if err != nil || log == "" {
+		return nil
+	}
+	if err := writeString(w, gitLogMarker+log+"\n"+endGitLogMarker); err != nil {
+		return fmt.Errorf("error writing git log: %w", err)
+	}
+	return nil
+}
+
+// nestedRepoReason returns why dir is skipped as an embedded repository:
+// "submodule" when its .git is a file pointing into the parent repository,
+// "nested repository" when it is a directory, or "" when dir has no .git
+func nestedRepoReason(dir string) string {
+	info, err := os.Lstat(filepath.Join(dir, ".git"))
+	switch {
+	case err != nil:
+		return ""
+	case info.IsDir():
+		return "nested repository"
+	default:
+		return "submodule"
+	}
+}
+
+// skipNestedRepo reports whether the directory at absPath is an embedded
+// repository that IncludeSubmodules leaves out, and records it as skipped.
+// The input directory itself is always walked.
+func (p *fileProcessor) skipNestedRepo(absPath, relPath string) bool {
+	if relPath == "." || p.config.IncludeSubmodules {
+		return false
+	}
+	reason := nestedRepoReason(absPath)
+	if reason == "" {
+		return false
+	}
+	p.summary.SkippedFiles = append(p.summary.SkippedFiles, SkippedFile{Path: p.displayPath(relPath) + "/", Reason: reason})
+	return true
+}
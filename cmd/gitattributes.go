@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// linguistAttrs are the .gitattributes attributes GitHub uses to hide files
+// from diffs and language statistics
+var linguistAttrs = []string{"linguist-generated", "linguist-vendored"}
+
+// attrRule is one .gitattributes line setting or unsetting linguist attributes
+type attrRule struct {
+	base    string // Directory holding the .gitattributes file, slash-separated
+	pattern string
+	attrs   map[string]bool
+}
+
+// loadGitAttributes reads the linguist rules from dir's .gitattributes file,
+// if it has one. relDir is dir relative to the input directory.
+func (p *fileProcessor) loadGitAttributes(dir, relDir string) {
+	f, err := os.Open(filepath.Join(dir, ".gitattributes"))
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	base := filepath.ToSlash(relDir)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		attrs := make(map[string]bool)
+		for _, field := range fields[1:] {
+			name, value, hasValue := strings.Cut(field, "=")
+			set := true
+			if strings.HasPrefix(name, "-") || strings.HasPrefix(name, "!") {
+				name, set = name[1:], false
+			} else if hasValue {
+				set = value != "false"
+			}
+			for _, attr := range linguistAttrs {
+				if name == attr {
+					attrs[attr] = set
+				}
+			}
+		}
+
+		if len(attrs) > 0 {
+			p.attrRules = append(p.attrRules, attrRule{base: base, pattern: fields[0], attrs: attrs})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", filepath.Join(dir, ".gitattributes"), err)
+	}
+}
+
+// linguistReason returns the linguist attribute set for relPath, or "".
+// Later rules win, and rules from deeper directories are loaded later.
+func (p *fileProcessor) linguistReason(relPath string) string {
+	relPath = filepath.ToSlash(relPath)
+	state := make(map[string]bool)
+
+	for _, rule := range p.attrRules {
+		rel := relPath
+		if rule.base != "." {
+			if !strings.HasPrefix(relPath, rule.base+"/") {
+				continue
+			}
+			rel = strings.TrimPrefix(relPath, rule.base+"/")
+		}
+
+		// Patterns without a slash match the name at any depth
+		pattern := strings.TrimPrefix(rule.pattern, "/")
+		target := rel
+		if !strings.Contains(rule.pattern, "/") {
+			target = path.Base(rel)
+		}
+
+		matched, err := matchGlobPattern(pattern, target)
+		if err != nil || !matched {
+			continue
+		}
+		for attr, set := range rule.attrs {
+			state[attr] = set
+		}
+	}
+
+	for _, attr := range linguistAttrs {
+		if state[attr] {
+			return attr
+		}
+	}
+	return ""
+}
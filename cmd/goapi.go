@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"bytes"
+	"go/ast"
+	"go/build/constraint"
+	"go/parser"
+	"go/token"
+)
+
+// exportedOnly reduces Go source to its public surface: build constraints,
+// the package clause, imports and exported declarations with their doc
+// comments, in source order and as written. Unexported functions, methods,
+// types, constants and variables are dropped, as are methods of unexported
+// types. Source that does not parse is returned unchanged.
+func exportedOnly(src []byte) []byte {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return src
+	}
+	text := func(from, to token.Pos) []byte {
+		return src[fset.Position(from).Offset:fset.Position(to).Offset]
+	}
+
+	var b bytes.Buffer
+	// Build constraints say which builds the declarations belong to
+	for _, group := range file.Comments {
+		if group.Pos() >= file.Package {
+			break
+		}
+		for _, c := range group.List {
+			if constraint.IsGoBuild(c.Text) || constraint.IsPlusBuild(c.Text) {
+				b.WriteString(c.Text + "\n")
+			}
+		}
+	}
+	if b.Len() > 0 {
+		b.WriteString("\n")
+	}
+
+	start := file.Package
+	if file.Doc != nil {
+		start = file.Doc.Pos()
+	}
+	b.Write(text(start, file.Name.End()))
+	b.WriteString("\n")
+
+	for _, decl := range file.Decls {
+		var kept []byte
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if exportedFunc(d) {
+				kept = text(withDoc(d.Doc, d.Pos()), d.End())
+			}
+		case *ast.GenDecl:
+			kept = exportedGenDecl(d, text)
+		}
+		if kept != nil {
+			b.WriteString("\n")
+			b.Write(kept)
+			b.WriteString("\n")
+		}
+	}
+	return b.Bytes()
+}
+
+// exportedFunc reports whether a function, or a method and its receiver
+// type, are exported
+func exportedFunc(d *ast.FuncDecl) bool {
+	if !d.Name.IsExported() {
+		return false
+	}
+	if d.Recv != nil && len(d.Recv.List) > 0 {
+		return token.IsExported(receiverName(d.Recv.List[0].Type))
+	}
+	return true
+}
+
+// exportedGenDecl returns the source of the exported specs of an import,
+// const, var or type declaration, or nil if none is exported. Imports are
+// kept whole, and so are const groups that repeat an implicit value, since
+// dropping a spec would change what iota gives the rest.
+func exportedGenDecl(d *ast.GenDecl, text func(from, to token.Pos) []byte) []byte {
+	whole := text(withDoc(d.Doc, d.Pos()), d.End())
+	if d.Tok == token.IMPORT {
+		return whole
+	}
+
+	var kept []ast.Spec
+	implicit := false
+	for _, spec := range d.Specs {
+		if exportedSpec(spec) {
+			kept = append(kept, spec)
+		}
+		if v, ok := spec.(*ast.ValueSpec); ok && v.Values == nil && d.Tok == token.CONST {
+			implicit = true
+		}
+	}
+	switch {
+	case len(kept) == 0:
+		return nil
+	case len(kept) == len(d.Specs) || implicit:
+		return whole
+	}
+
+	var b bytes.Buffer
+	b.Write(text(withDoc(d.Doc, d.Pos()), d.Lparen+1))
+	b.WriteString("\n")
+	for _, spec := range kept {
+		doc, comment := specComments(spec)
+		end := spec.End()
+		if comment != nil {
+			end = comment.End()
+		}
+		b.WriteString("\t")
+		b.Write(text(withDoc(doc, spec.Pos()), end))
+		b.WriteString("\n")
+	}
+	b.WriteString(")")
+	return b.Bytes()
+}
+
+// exportedSpec reports whether a type is exported, or any name of a const
+// or var spec is
+func exportedSpec(spec ast.Spec) bool {
+	switch s := spec.(type) {
+	case *ast.TypeSpec:
+		return s.Name.IsExported()
+	case *ast.ValueSpec:
+		for _, name := range s.Names {
+			if name.IsExported() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// specComments returns the doc comment and line comment of a spec
+func specComments(spec ast.Spec) (doc, comment *ast.CommentGroup) {
+	switch s := spec.(type) {
+	case *ast.TypeSpec:
+		return s.Doc, s.Comment
+	case *ast.ValueSpec:
+		return s.Doc, s.Comment
+	}
+	return nil, nil
+}
+
+// withDoc returns where a declaration starts, counting its doc comment
+func withDoc(doc *ast.CommentGroup, pos token.Pos) token.Pos {
+	if doc != nil {
+		return doc.Pos()
+	}
+	return pos
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	grepIgnoreCase bool
+	grepFixed      bool
+	grepFilesOnly  bool
+
+	grepCmd = &cobra.Command{
+		Use:   "grep <pattern> <corpus>",
+		Short: "Search the files embedded in a corpus",
+		Long: `Search the files embedded in a corpus without unpacking it. Matches are
+reported as path:line:text, with the path and line number of the originating
+file. The pattern is a Go regular expression unless --fixed-strings is set.
+The corpus may be plain, gzipped, zstd-compressed or base64- or base85-encoded.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pattern := args[0]
+			if grepFixed {
+				pattern = regexp.QuoteMeta(pattern)
+			}
+			if grepIgnoreCase {
+				pattern = "(?i)" + pattern
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("invalid pattern: %w", err)
+			}
+
+			corpus, err := LoadCorpus(args[1])
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			lastPath := ""
+			for _, match := range corpus.Grep(re) {
+				if grepFilesOnly {
+					if match.Path != lastPath {
+						fmt.Fprintln(out, match.Path)
+					}
+					lastPath = match.Path
+					continue
+				}
+				fmt.Fprintf(out, "%s:%d:%s\n", match.Path, match.Line, match.Text)
+			}
+			return nil
+		},
+	}
+)
+
+func init() {
+	grepCmd.Flags().BoolVarP(&grepIgnoreCase, "ignore-case", "i", false, "Match case-insensitively")
+	grepCmd.Flags().BoolVarP(&grepFixed, "fixed-strings", "F", false, "Treat the pattern as a literal string")
+	grepCmd.Flags().BoolVarP(&grepFilesOnly, "files-with-matches", "l", false, "Print only the paths of files with matches")
+	rootCmd.AddCommand(grepCmd)
+}
+
+// GrepMatch is one line of an embedded file that matched a pattern
+type GrepMatch struct {
+	Path string
+	Line int // 1-based, within the embedded file
+	Text string
+}
+
+// Grep returns the lines of the embedded files that match re, in corpus order
+func (c *Corpus) Grep(re *regexp.Regexp) []GrepMatch {
+	var matches []GrepMatch
+	for _, f := range c.Files {
+		content := c.Content(f)
+		for line := 1; len(content) > 0; line++ {
+			text := content
+			if i := bytes.IndexByte(content, '\n'); i >= 0 {
+				text, content = content[:i], content[i+1:]
+			} else {
+				content = nil
+			}
+			if re.Match(text) {
+				matches = append(matches, GrepMatch{Path: f.Path, Line: line, Text: string(bytes.TrimSuffix(text, []byte("\r")))})
+			}
+		}
+	}
+	return matches
+}
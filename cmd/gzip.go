@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"runtime"
+)
+
+const (
+	gzipBlockSize = 1 << 20  // Uncompressed bytes each parallel worker compresses
+	flateWindow   = 32 << 10 // Bytes of earlier data a deflate block may refer back to
+)
+
+// gzipHeader opens a gzip member with no name or modification time, as
+// gzip.Writer writes it
+var gzipHeader = []byte{0x1f, 0x8b, 8, 0, 0, 0, 0, 0, 0, 0xff}
+
+// gzipStream is a gzip writer whose output can be flushed mid-stream
+type gzipStream interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// gzipJobs returns the number of gzip workers of a config
+func gzipJobs(config *Config) int {
+	if config.GzipJobs > 0 {
+		return config.GzipJobs
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// newGzipStream returns a gzip writer with the config's number of workers.
+// One worker is the standard library writer, except for deterministic
+// output, whose bytes must not depend on how many CPUs packed it.
+func newGzipStream(w io.Writer, config *Config) gzipStream {
+	jobs := gzipJobs(config)
+	if jobs <= 1 && !config.Deterministic {
+		return gzip.NewWriter(w)
+	}
+	return &parallelGzipWriter{w: w, jobs: jobs}
+}
+
+// gzipBlock is one block being compressed by a worker
+type gzipBlock struct {
+	out  bytes.Buffer
+	err  error
+	done chan struct{}
+}
+
+// parallelGzipWriter compresses blocks of gzipBlockSize on up to jobs
+// workers at once. Each block is primed with the 32KB before it and ends
+// on a byte boundary, so the blocks join into a single gzip member that
+// any reader decompresses, only slightly larger than a serial one.
+type parallelGzipWriter struct {
+	w       io.Writer
+	jobs    int
+	buf     []byte       // Data not yet handed to a worker
+	window  []byte       // The last flateWindow bytes handed to a worker
+	pending []*gzipBlock // Blocks being compressed, in output order
+	crc     uint32       // Checksum of the data handed to workers
+	size    uint32
+	started bool
+	err     error
+}
+
+func (z *parallelGzipWriter) Write(p []byte) (int, error) {
+	if z.err != nil {
+		return 0, z.err
+	}
+	n := len(p)
+	for len(p) > 0 {
+		take := gzipBlockSize - len(z.buf)
+		if take > len(p) {
+			take = len(p)
+		}
+		z.buf = append(z.buf, p[:take]...)
+		p = p[take:]
+		if len(z.buf) == gzipBlockSize {
+			if err := z.dispatch(false); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// Flush compresses everything written so far and writes it out, ending on
+// a byte boundary like gzip.Writer.Flush
+func (z *parallelGzipWriter) Flush() error {
+	if z.err != nil {
+		return z.err
+	}
+	if len(z.buf) > 0 {
+		if err := z.dispatch(false); err != nil {
+			return err
+		}
+	}
+	return z.drain(0)
+}
+
+// Close compresses the rest of the data and writes the gzip trailer. It
+// does not close the underlying writer.
+func (z *parallelGzipWriter) Close() error {
+	if z.err != nil {
+		return z.err
+	}
+	if err := z.dispatch(true); err != nil {
+		return err
+	}
+	if err := z.drain(0); err != nil {
+		return err
+	}
+	trailer := make([]byte, 8)
+	binary.LittleEndian.PutUint32(trailer[:4], z.crc)
+	binary.LittleEndian.PutUint32(trailer[4:], z.size)
+	return z.write(trailer)
+}
+
+// dispatch hands the buffered data to a worker, waiting for the oldest
+// block first when every worker is busy. The last block closes the deflate
+// stream; the others end with a sync flush.
+func (z *parallelGzipWriter) dispatch(last bool) error {
+	data, window := z.buf, z.window
+	z.buf = make([]byte, 0, gzipBlockSize)
+	z.crc = crc32.Update(z.crc, crc32.IEEETable, data)
+	z.size += uint32(len(data))
+
+	// The next block may refer back into this one and the window before it
+	if len(data) >= flateWindow {
+		z.window = data[len(data)-flateWindow:]
+	} else {
+		joined := append(append([]byte{}, window...), data...)
+		if len(joined) > flateWindow {
+			joined = joined[len(joined)-flateWindow:]
+		}
+		z.window = joined
+	}
+
+	if err := z.drain(z.jobs - 1); err != nil {
+		return err
+	}
+	block := &gzipBlock{done: make(chan struct{})}
+	z.pending = append(z.pending, block)
+	go block.compress(data, window, last)
+	return nil
+}
+
+// compress deflates data primed with window into the block's output
+func (b *gzipBlock) compress(data, window []byte, last bool) {
+	defer close(b.done)
+	fw, err := flate.NewWriterDict(&b.out, flate.DefaultCompression, window)
+	if err != nil {
+		b.err = err
+		return
+	}
+	if _, err := fw.Write(data); err != nil {
+		b.err = err
+		return
+	}
+	if last {
+		b.err = fw.Close()
+	} else {
+		b.err = fw.Flush()
+	}
+}
+
+// drain writes out finished blocks in order until at most keep are pending
+func (z *parallelGzipWriter) drain(keep int) error {
+	for len(z.pending) > keep {
+		block := z.pending[0]
+		z.pending = z.pending[1:]
+		<-block.done
+		if block.err != nil {
+			z.err = fmt.Errorf("error compressing output: %w", block.err)
+			return z.err
+		}
+		if err := z.write(block.out.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// write writes compressed bytes, opening the member with its header first
+func (z *parallelGzipWriter) write(p []byte) error {
+	if !z.started {
+		z.started = true
+		if _, err := z.w.Write(gzipHeader); err != nil {
+			z.err = err
+			return err
+		}
+	}
+	if _, err := z.w.Write(p); err != nil {
+		z.err = err
+		return err
+	}
+	return nil
+}
+
+// validateGzipJobs checks the --gzip-jobs worker count
+func validateGzipJobs(config *Config) error {
+	if config.GzipJobs < 0 {
+		return fmt.Errorf("--gzip-jobs must not be negative, got %d", config.GzipJobs)
+	}
+	return nil
+}
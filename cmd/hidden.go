@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Hidden file policies
+const (
+	HiddenInclude = "include" // Dotfiles and dot-directories are traversed like any other path
+	HiddenExclude = "exclude" // Only hidden paths an include glob or name spells out are traversed
+)
+
+// isHidden reports whether any component of relPath starts with a dot
+func isHidden(relPath string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(relPath), "/") {
+		if len(part) > 1 && part[0] == '.' && part != ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// hiddenExcluded reports whether the hidden policy keeps relPath out of the
+// walk. A hidden path stays in when an include pattern names its hidden
+// components explicitly, such as ".github/workflows/*.yml" or ".env.example".
+func (p *fileProcessor) hiddenExcluded(relPath string, isDir bool) bool {
+	if p.config.Hidden != HiddenExclude || !isHidden(relPath) {
+		return false
+	}
+
+	relPath = filepath.ToSlash(relPath)
+	for _, pattern := range p.config.IncludeGlobs {
+		pattern = filepath.ToSlash(pattern)
+		if !isHidden(pattern) {
+			continue
+		}
+		if isDir {
+			if strings.HasPrefix(pattern, relPath+"/") {
+				return false
+			}
+			continue
+		}
+		if matched, err := matchPathPattern(pattern, relPath); err == nil && matched {
+			return false
+		}
+	}
+
+	if !isDir && !isHidden(filepath.Dir(relPath)) {
+		for _, name := range p.config.IncludeNames {
+			if !strings.HasPrefix(name, ".") {
+				continue
+			}
+			if matched, err := matchGlobPattern(name, filepath.Base(relPath)); err == nil && matched {
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// largestFilesCount is how many files the largest-files tables list
+const largestFilesCount = 20
+
+// sizeBucketBounds are the token counts that separate the size histogram's
+// buckets; the last bucket has no upper bound
+var sizeBucketBounds = []int{100, 1000, 10000, 100000}
+
+// histogramBarWidth is the length of the bar of the fullest bucket
+const histogramBarWidth = 30
+
+// FileStats counts one file
+type FileStats struct {
+	Path     string `json:"path"`
+	Language string `json:"language"`
+	Bytes    int64  `json:"bytes"`
+	Tokens   int    `json:"tokens"`
+}
+
+// SizeBucket totals the files with at least Min and fewer than Max tokens.
+// Max is 0 for the last bucket.
+type SizeBucket struct {
+	Min    int   `json:"min"`
+	Max    int   `json:"max,omitempty"`
+	Files  int   `json:"files"`
+	Bytes  int64 `json:"bytes"`
+	Tokens int   `json:"tokens"`
+}
+
+// label returns the bucket's token range, such as "100-999" or "100000+"
+func (b SizeBucket) label() string {
+	if b.Max == 0 {
+		return fmt.Sprintf("%d+", b.Min)
+	}
+	return fmt.Sprintf("%d-%d", b.Min, b.Max-1)
+}
+
+// sizeHistogram buckets files by token count, up to the last bucket that
+// holds a file
+func sizeHistogram(files []FileStats) []SizeBucket {
+	buckets := make([]SizeBucket, len(sizeBucketBounds)+1)
+	for i := range buckets {
+		if i > 0 {
+			buckets[i].Min = sizeBucketBounds[i-1]
+		}
+		if i < len(sizeBucketBounds) {
+			buckets[i].Max = sizeBucketBounds[i]
+		}
+	}
+
+	last := -1
+	for _, f := range files {
+		i := sort.SearchInts(sizeBucketBounds, f.Tokens+1)
+		buckets[i].Files++
+		buckets[i].Bytes += f.Bytes
+		buckets[i].Tokens += f.Tokens
+		last = max(last, i)
+	}
+	return buckets[:last+1]
+}
+
+// largestFiles returns the n files with the most tokens, then bytes, largest first
+func largestFiles(files []FileStats, n int) []FileStats {
+	sorted := append([]FileStats(nil), files...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Tokens != sorted[j].Tokens {
+			return sorted[i].Tokens > sorted[j].Tokens
+		}
+		if sorted[i].Bytes != sorted[j].Bytes {
+			return sorted[i].Bytes > sorted[j].Bytes
+		}
+		return sorted[i].Path < sorted[j].Path
+	})
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// writeHistogram writes a line per bucket with a bar scaled to the fullest one
+func writeHistogram(w io.Writer, buckets []SizeBucket) {
+	fullest := 0
+	for _, b := range buckets {
+		fullest = max(fullest, b.Files)
+	}
+
+	fmt.Fprintf(w, "%-16s %7s %12s %10s\n", "TOKENS PER FILE", "FILES", "BYTES", "TOKENS")
+	for _, b := range buckets {
+		line := fmt.Sprintf("%-16s %7d %12d %10d", b.label(), b.Files, b.Bytes, b.Tokens)
+		if b.Files > 0 {
+			line += "  " + strings.Repeat("#", max(1, b.Files*histogramBarWidth/fullest))
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// writeLargest writes a line per file, path last so long paths stay aligned
+func writeLargest(w io.Writer, files []FileStats) {
+	fmt.Fprintf(w, "%10s %12s  %s\n", "TOKENS", "BYTES", "PATH")
+	for _, f := range files {
+		fmt.Fprintf(w, "%10d %12d  %s\n", f.Tokens, f.Bytes, f.Path)
+	}
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Hooks are shell commands run around packing. PreFile runs once per file
+// with the file's path as $1 and its content on stdin, and its stdout is
+// packed instead. PostPack runs once per corpus written, with the output
+// path as $1. Both run in the input directory.
+type Hooks struct {
+	PreFile  string `yaml:"preFile" json:"preFile"`
+	PostPack string `yaml:"postPack" json:"postPack"`
+}
+
+// validateHooks checks that hooks may run. A config file in a packed
+// directory can declare hooks, so they only run when the caller allows
+// them; a config file cannot allow its own hooks.
+func validateHooks(config *Config) error {
+	if config.Hooks == (Hooks{}) {
+		return nil
+	}
+	if !config.AllowHooks {
+		return fmt.Errorf("the config declares hooks, which run commands; pass --allow-hooks to run them")
+	}
+	if config.Hooks.PreFile != "" && config.OutputFormat == FormatLinkFarm {
+		return fmt.Errorf("a preFile hook cannot be used with the linkfarm format, which links files unchanged")
+	}
+	return nil
+}
+
+// hookCommand returns a command running script in the platform's shell,
+// with args as its positional parameters
+func hookCommand(script, dir string, args ...string) *exec.Cmd {
+	var command *exec.Cmd
+	if runtime.GOOS == "windows" {
+		command = exec.Command("cmd", append([]string{"/C", script}, args...)...)
+	} else {
+		command = exec.Command("sh", append([]string{"-c", script, "cpack-hook"}, args...)...)
+	}
+	command.Dir = dir
+	return command
+}
+
+// runPreFile passes a file's text through the preFile hook. A failing hook
+// fails the pack, so a scanner can stop a file from being packed.
+func (p *fileProcessor) runPreFile(relPath string, text []byte) ([]byte, error) {
+	script := p.config.Hooks.PreFile
+	if script == "" {
+		return text, nil
+	}
+
+	path := filepath.ToSlash(relPath)
+	command := hookCommand(script, p.config.InputDir, path)
+	command.Env = append(os.Environ(), "CPACK_FILE="+path)
+	command.Stdin = bytes.NewReader(text)
+	var stderr bytes.Buffer
+	command.Stderr = &stderr
+	out, err := command.Output()
+	if err != nil {
+		return nil, fmt.Errorf("error running preFile hook on %s: %w%s", path, err, hookOutput(stderr.String()))
+	}
+	return out, nil
+}
+
+// runPostPack runs the postPack hook on a written corpus. Its output goes
+// to stderr, since stdout may carry an upload id.
+func runPostPack(config *Config, output string) error {
+	script := config.Hooks.PostPack
+	if script == "" {
+		return nil
+	}
+
+	command := hookCommand(script, config.InputDir, output)
+	command.Env = append(os.Environ(), "CPACK_OUTPUT="+output)
+	command.Stdout = os.Stderr
+	command.Stderr = os.Stderr
+	if err := command.Run(); err != nil {
+		return fmt.Errorf("error running postPack hook on %s: %w", output, err)
+	}
+	return nil
+}
+
+// hookOutput formats a hook's stderr for an error message
+func hookOutput(stderr string) string {
+	if stderr = strings.TrimSpace(stderr); stderr == "" {
+		return ""
+	}
+	return ": " + stderr
+}
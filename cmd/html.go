@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// htmlStyle is the chroma style used to highlight code in HTML output
+const htmlStyle = "github"
+
+// htmlPageCSS lays out the sidebar and file sections of an HTML corpus
+const htmlPageCSS = `
+body { margin: 0; font: 14px/1.5 -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #1f2328; }
+nav { position: fixed; top: 0; bottom: 0; left: 0; width: 300px; overflow: auto; padding: 16px; box-sizing: border-box; background: #f6f8fa; border-right: 1px solid #d0d7de; }
+nav h1 { font-size: 16px; margin: 0 0 4px; }
+nav p { margin: 0 0 12px; color: #59636e; }
+nav ul { list-style: none; margin: 0; padding-left: 14px; }
+nav > ul { padding-left: 0; }
+nav a { color: #0969da; text-decoration: none; word-break: break-all; }
+nav a:hover { text-decoration: underline; }
+nav summary { cursor: pointer; }
+main { margin-left: 300px; padding: 16px 24px; }
+section { margin-bottom: 32px; }
+section h2 { font-size: 15px; font-family: ui-monospace, SFMono-Regular, Menlo, monospace; margin: 0 0 8px; padding-top: 8px; }
+section h2 a { color: inherit; text-decoration: none; }
+blockquote { margin: 0 0 8px; padding: 0 12px; color: #59636e; border-left: 3px solid #d0d7de; }
+pre { margin: 0; padding: 12px; overflow: auto; border: 1px solid #d0d7de; border-radius: 6px; font: 12px/1.45 ui-monospace, SFMono-Regular, Menlo, monospace; }
+pre.meta { background: #f6f8fa; margin-bottom: 32px; }
+`
+
+// renderHTML writes a corpus as an HTML page: a sidebar with the file
+// tree, then every file as an anchored, highlighted section. Text before
+// the first file and after the last, such as instructions and the git
+// log, is kept as preformatted blocks.
+func renderHTML(w io.Writer, corpus *Corpus, config *Config, title string) error {
+	style := styles.Get(htmlStyle)
+	var css bytes.Buffer
+	if err := chromahtml.New(chromahtml.WithClasses(true)).WriteCSS(&css, style); err != nil {
+		return fmt.Errorf("error writing highlight styles: %w", err)
+	}
+
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&b, "<title>%s</title>\n", html.EscapeString(title))
+	fmt.Fprintf(&b, "<style>%s\n%s</style>\n</head>\n<body>\n", htmlPageCSS, css.String())
+
+	b.WriteString("<nav>\n")
+	fmt.Fprintf(&b, "<h1>%s</h1>\n<p>%d files</p>\n", html.EscapeString(title), len(corpus.Files))
+	writeFileTree(&b, corpus.Files)
+	b.WriteString("</nav>\n<main>\n")
+
+	head, tail := corpusMeta(corpus)
+	writeMetaBlock(&b, head)
+	for _, f := range corpus.Files {
+		if err := writeFileSection(&b, f, corpus.Content(f), style); err != nil {
+			return err
+		}
+	}
+	writeMetaBlock(&b, tail)
+	b.WriteString("</main>\n</body>\n</html>\n")
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("error writing output file: %w", err)
+	}
+	return nil
+}
+
+// fileAnchor is the id of a file's section in an HTML corpus
+func fileAnchor(f CorpusFile) string {
+	return fmt.Sprintf("file-%d", f.ID)
+}
+
+// writeMetaBlock writes text outside any file as a preformatted block
+func writeMetaBlock(b *strings.Builder, text []byte) {
+	text = bytes.TrimSpace(text)
+	if len(text) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "<pre class=\"meta\">%s</pre>\n", html.EscapeString(string(text)))
+}
+
+// writeFileSection writes one file's heading, notes and highlighted content
+func writeFileSection(b *strings.Builder, f CorpusFile, content []byte, style *chroma.Style) error {
+	id := fileAnchor(f)
+	fmt.Fprintf(b, "<section id=\"%s\">\n<h2><a href=\"#%s\">%s</a></h2>\n", id, id, html.EscapeString(f.Path))
+	if f.Note != "" {
+		fmt.Fprintf(b, "<blockquote>Note: %s</blockquote>\n", html.EscapeString(f.Note))
+	}
+	if f.LastCommit != "" {
+		fmt.Fprintf(b, "<blockquote>Last commit: %s</blockquote>\n", html.EscapeString(f.LastCommit))
+	}
+
+	lexer := htmlLexer(f.Path, content)
+	tokens, err := lexer.Tokenise(nil, string(content))
+	if err != nil {
+		return fmt.Errorf("error highlighting %s: %w", f.Path, err)
+	}
+	// Line numbers link as #file-N-L42
+	formatter := chromahtml.New(chromahtml.WithClasses(true), chromahtml.WithLineNumbers(true),
+		chromahtml.WithLinkableLineNumbers(true, id+"-L"))
+	if err := formatter.Format(b, style, tokens); err != nil {
+		return fmt.Errorf("error highlighting %s: %w", f.Path, err)
+	}
+	b.WriteString("</section>\n")
+	return nil
+}
+
+// htmlLexer picks the chroma lexer for a file: by the language cpack
+// detects, then by chroma's own file name and content rules, then plain text
+func htmlLexer(relPath string, content []byte) chroma.Lexer {
+	lexer := lexers.Get(DetectLanguage(relPath, content))
+	if lexer == nil {
+		lexer = lexers.Match(path.Base(relPath))
+	}
+	if lexer == nil {
+		lexer = lexers.Analyse(string(content))
+	}
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	return chroma.Coalesce(lexer)
+}
+
+// htmlTreeNode is a directory of the sidebar file tree
+type htmlTreeNode struct {
+	dirs  map[string]*htmlTreeNode
+	files []CorpusFile
+}
+
+// writeFileTree writes the sidebar's nested list of directories and files,
+// each file linking to its section
+func writeFileTree(b *strings.Builder, files []CorpusFile) {
+	root := &htmlTreeNode{dirs: make(map[string]*htmlTreeNode)}
+	for _, f := range files {
+		node := root
+		parts := strings.Split(f.Path, "/")
+		for _, dir := range parts[:len(parts)-1] {
+			child, ok := node.dirs[dir]
+			if !ok {
+				child = &htmlTreeNode{dirs: make(map[string]*htmlTreeNode)}
+				node.dirs[dir] = child
+			}
+			node = child
+		}
+		node.files = append(node.files, f)
+	}
+	root.write(b)
+}
+
+// write renders the node's directories, then its files, each by name
+func (n *htmlTreeNode) write(b *strings.Builder) {
+	b.WriteString("<ul>\n")
+	names := make([]string, 0, len(n.dirs))
+	for name := range n.dirs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(b, "<li><details open><summary>%s/</summary>\n", html.EscapeString(name))
+		n.dirs[name].write(b)
+		b.WriteString("</details></li>\n")
+	}
+
+	sort.Slice(n.files, func(i, j int) bool { return n.files[i].Path < n.files[j].Path })
+	for _, f := range n.files {
+		fmt.Fprintf(b, "<li><a href=\"#%s\">%s</a></li>\n", fileAnchor(f), html.EscapeString(path.Base(f.Path)))
+	}
+	b.WriteString("</ul>\n")
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Markers of the import graph written by --import-graph
+const (
+	importGraphMarker    = "--- IMPORT GRAPH ---\n"
+	endImportGraphMarker = "--- END OF IMPORT GRAPH ---\n"
+)
+
+// formatImportGraph returns the import graph of the selected Go packages
+// as an adjacency list, one package per line as "dir -> dep, dep", and
+// with mermaid a diagram of the same graph. Only imports of packages in
+// the module are drawn. Without Go files it returns "".
+func (p *fileProcessor) formatImportGraph(mermaid bool) string {
+	pkgs := p.readGoPackages()
+	if len(pkgs.files) == 0 {
+		return ""
+	}
+
+	// Anonymized packs show package directories as they show file paths
+	name := func(dir string) string {
+		if dir == "." {
+			return dir
+		}
+		return path.Dir(filepath.ToSlash(p.displayPath(dir + "/_")))
+	}
+
+	dirs := make([]string, 0, len(pkgs.files))
+	for dir := range pkgs.files {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	var b strings.Builder
+	b.WriteString(importGraphMarker)
+	edges := make(map[string][]string, len(dirs))
+	for _, dir := range dirs {
+		deps := make([]string, 0, len(pkgs.imports[dir]))
+		for dep := range pkgs.imports[dir] {
+			deps = append(deps, name(dep))
+		}
+		sort.Strings(deps)
+		edges[name(dir)] = deps
+
+		line := name(dir)
+		if pkgs.main[dir] {
+			line += " (main)"
+		}
+		if len(deps) > 0 {
+			line += " -> " + strings.Join(deps, ", ")
+		}
+		b.WriteString(line + "\n")
+	}
+
+	if mermaid {
+		b.WriteString("\n```mermaid\ngraph TD\n")
+		ids := make(map[string]string)
+		id := func(pkg string) string {
+			if _, ok := ids[pkg]; !ok {
+				ids[pkg] = fmt.Sprintf("p%d", len(ids))
+				fmt.Fprintf(&b, "  %s[%q]\n", ids[pkg], pkg)
+			}
+			return ids[pkg]
+		}
+		for _, dir := range dirs {
+			from := id(name(dir))
+			for _, dep := range edges[name(dir)] {
+				to := id(dep)
+				fmt.Fprintf(&b, "  %s --> %s\n", from, to)
+			}
+		}
+		b.WriteString("```\n")
+	}
+
+	b.WriteString(endImportGraphMarker + "\n")
+	return b.String()
+}
@@ -0,0 +1,284 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// dependencyDirs are directory names that hold vendored dependencies, build
+// output or caches rather than project sources
+var dependencyDirs = map[string]string{
+	"vendor": "vendored dependencies", "node_modules": "Node.js modules", "bower_components": "Bower packages",
+	".venv": "Python virtualenv", "venv": "Python virtualenv", "__pycache__": "Python cache",
+	"target": "build output", "build": "build output", "dist": "build output", "out": "build output",
+	"bin": "binaries", "obj": "object files", "coverage": "coverage reports", ".next": "Next.js build",
+	".nuxt": "Nuxt build", ".terraform": "Terraform providers", ".gradle": "Gradle cache",
+	".tox": "tox environments", ".mypy_cache": "mypy cache", ".pytest_cache": "pytest cache",
+	".idea": "IDE settings", ".vscode": "editor settings",
+}
+
+// languagePresets maps a repository's main language to the preset for it
+var languagePresets = map[string]string{
+	"go": "go", "python": "python", "javascript": "web", "typescript": "web", "tsx": "web",
+	"jsx": "web", "vue": "web", "svelte": "web", "hcl": "infra",
+}
+
+// testDirs are directory names that usually hold tests
+var testDirs = map[string]bool{"test": true, "tests": true, "__tests__": true, "spec": true, "e2e": true}
+
+// RepoProfile is what cpack init found in a repository
+type RepoProfile struct {
+	Languages  map[string]int // Files per detected language
+	Extensions map[string]int // Files per extension with a known language
+	Names      []string       // Well-known extension-less files present
+	DepDirs    []string       // Dependency and build directory names present
+	TestGlobs  []string       // Globs matching the test files present
+	Preset     string         // Preset matching the main language, if any
+}
+
+var (
+	initForce  bool
+	initOutput string
+
+	initCmd = &cobra.Command{
+		Use:   "init [directory]",
+		Short: "Write a cpack.yaml tailored to the repository",
+		Long: `Inspect a repository (languages present, vendored and build directories,
+test layout) and write a commented cpack.yaml with include and exclude globs
+tailored to it, as a starting point that is smaller and clearer than the
+built-in defaults. Existing config files are never overwritten without --force.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := "."
+			if len(args) > 0 {
+				dir = args[0]
+			}
+
+			output := initOutput
+			if output == "" {
+				output = filepath.Join(dir, "cpack.yaml")
+				for _, name := range []string{"cpack.yml", "cpack.yaml", "cpack.json"} {
+					if _, err := os.Stat(filepath.Join(dir, name)); err == nil && !initForce {
+						return fmt.Errorf("%s already exists (use --force to replace it with cpack.yaml)", filepath.Join(dir, name))
+					}
+				}
+			} else if _, err := os.Stat(output); err == nil && !initForce {
+				return fmt.Errorf("%s already exists (use --force to overwrite it)", output)
+			}
+
+			profile, err := InspectRepo(dir)
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(output, []byte(renderInitConfig(profile)), 0644); err != nil {
+				return fmt.Errorf("error writing config file: %w", err)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Wrote %s (%s)\n", output, profile.describe())
+			return nil
+		},
+	}
+)
+
+func init() {
+	initCmd.Flags().BoolVar(&initForce, "force", false, "Overwrite an existing config file")
+	initCmd.Flags().StringVarP(&initOutput, "output", "o", "", "Config file to write (default: cpack.yaml in the directory)")
+	rootCmd.AddCommand(initCmd)
+}
+
+// InspectRepo walks dir and profiles the files worth packing
+func InspectRepo(dir string) (*RepoProfile, error) {
+	profile := &RepoProfile{Languages: make(map[string]int), Extensions: make(map[string]int)}
+	knownNames := make(map[string]bool)
+	for _, name := range DefaultConfig().IncludeNames {
+		knownNames[name] = true
+	}
+	names := make(map[string]bool)
+	depDirs := make(map[string]bool)
+	testGlobs := make(map[string]bool)
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path == dir {
+				return nil
+			}
+			if name == ".git" {
+				return filepath.SkipDir
+			}
+			if _, ok := dependencyDirs[name]; ok {
+				depDirs[name] = true
+				return filepath.SkipDir
+			}
+			if testDirs[name] {
+				testGlobs["**/"+name+"/**"] = true
+			}
+			return nil
+		}
+		// Earlier configs and corpora say nothing about the repository
+		if !d.Type().IsRegular() || isPreviousCorpus(path) || name == "cpack.yml" || name == "cpack.yaml" || name == "cpack.json" {
+			return nil
+		}
+
+		if knownNames[name] {
+			names[name] = true
+		}
+		if glob := testFileGlob(name); glob != "" {
+			testGlobs[glob] = true
+		}
+
+		ext := strings.ToLower(filepath.Ext(name))
+		language := DetectLanguage(name, nil)
+		if language == "" && ext == "" {
+			language = detectFileLanguage(path)
+		}
+		if language == "" {
+			return nil
+		}
+		profile.Languages[language]++
+		if _, ok := extensionLanguages[ext]; ok {
+			profile.Extensions[ext]++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error inspecting %s: %w", dir, err)
+	}
+
+	profile.Names = sortedKeys(names)
+	profile.DepDirs = sortedKeys(depDirs)
+	profile.TestGlobs = sortedKeys(testGlobs)
+	profile.Preset = languagePresets[profile.mainLanguage()]
+	return profile, nil
+}
+
+// testFileGlob returns the glob for a test file naming convention name
+// follows, or ""
+func testFileGlob(name string) string {
+	switch {
+	case strings.HasSuffix(name, "_test.go"):
+		return "**/*_test.go"
+	case strings.HasPrefix(name, "test_") && strings.HasSuffix(name, ".py"):
+		return "**/test_*.py"
+	case strings.Contains(name, ".test."):
+		return "**/*.test.*"
+	case strings.Contains(name, ".spec."):
+		return "**/*.spec.*"
+	}
+	return ""
+}
+
+// mainLanguage returns the language with the most files, ignoring docs and
+// data formats
+func (r *RepoProfile) mainLanguage() string {
+	best, count := "", 0
+	for language, n := range r.Languages {
+		switch language {
+		case "markdown", "json", "yaml", "toml", "xml":
+			continue
+		}
+		if n > count || (n == count && language < best) {
+			best, count = language, n
+		}
+	}
+	return best
+}
+
+// describe summarizes the profile in one line
+func (r *RepoProfile) describe() string {
+	main := r.mainLanguage()
+	if main == "" {
+		return "no source files detected"
+	}
+	return fmt.Sprintf("mostly %s, %s, %s excluded", main, plural(len(r.Languages), "language"), plural(len(r.DepDirs), "directory"))
+}
+
+// renderInitConfig writes the profile as a commented cpack.yaml
+func renderInitConfig(r *RepoProfile) string {
+	var b strings.Builder
+	b.WriteString("# cpack configuration, generated by 'cpack init' from the files in this\n")
+	b.WriteString("# repository. Edit freely; see the README for every option.\n\n")
+	b.WriteString("outputFile: corpus-out.txt\n\n")
+
+	if r.Preset != "" {
+		fmt.Fprintf(&b, "# Most files are %s; 'preset: %s' is a curated alternative to the\n", r.mainLanguage(), r.Preset)
+		b.WriteString("# globs below.\n")
+		fmt.Fprintf(&b, "# preset: %s\n\n", r.Preset)
+	}
+
+	// Extensions, most common first
+	exts := make([]string, 0, len(r.Extensions))
+	for ext := range r.Extensions {
+		exts = append(exts, ext)
+	}
+	sort.Slice(exts, func(i, j int) bool {
+		if r.Extensions[exts[i]] != r.Extensions[exts[j]] {
+			return r.Extensions[exts[i]] > r.Extensions[exts[j]]
+		}
+		return exts[i] < exts[j]
+	})
+
+	b.WriteString("# Source files found in the repository, most common first\n")
+	b.WriteString("includeGlobs:\n")
+	if len(exts) == 0 {
+		b.WriteString("  - \"**/*.md\"\n")
+	}
+	for _, ext := range exts {
+		fmt.Fprintf(&b, "  - \"**/*%s\"  # %s, %s\n", ext, extensionLanguages[ext], plural(r.Extensions[ext], "file"))
+	}
+
+	if len(r.Names) > 0 {
+		b.WriteString("\n# Well-known files without a matching extension\n")
+		b.WriteString("includeNames:\n")
+		for _, name := range r.Names {
+			fmt.Fprintf(&b, "  - %q\n", name)
+		}
+	}
+
+	b.WriteString("\nexcludeGlobs:\n")
+	b.WriteString("  - \"**/.git/**\"\n")
+	for _, dir := range r.DepDirs {
+		fmt.Fprintf(&b, "  - \"**/%s/**\"  # %s\n", dir, dependencyDirs[dir])
+	}
+	b.WriteString("  - \"**/*.min.*\"  # minified files\n")
+	b.WriteString("  - \"**/*.map\"  # source maps\n")
+	if len(r.TestGlobs) > 0 {
+		b.WriteString("  # Tests found in the repository; uncomment to leave them out\n")
+		for _, glob := range r.TestGlobs {
+			fmt.Fprintf(&b, "  # - %q\n", glob)
+		}
+	}
+
+	b.WriteString("\n# Skip files marked as generated ('Code generated ... DO NOT EDIT').\n")
+	b.WriteString("skipGenerated: true\n")
+	return b.String()
+}
+
+// plural formats a count with a singular or plural noun
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	if strings.HasSuffix(noun, "y") {
+		return fmt.Sprintf("%d %sies", n, strings.TrimSuffix(noun, "y"))
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// sniffLen is how much of a file is read to detect its language from content
+const sniffLen = 1024
+
+// extensionLanguages maps unambiguous file extensions to language names,
+// which double as Markdown code fence tags
+var extensionLanguages = map[string]string{
+	".go": "go", ".py": "python", ".pyi": "python", ".rb": "ruby", ".rs": "rust",
+	".js": "javascript", ".mjs": "javascript", ".cjs": "javascript", ".jsx": "jsx",
+	".ts": "typescript", ".tsx": "tsx", ".java": "java", ".kt": "kotlin", ".swift": "swift",
+	".c": "c", ".cc": "cpp", ".cpp": "cpp", ".cxx": "cpp", ".hpp": "cpp", ".hh": "cpp",
+	".cs": "csharp", ".php": "php", ".scala": "scala", ".lua": "lua", ".r": "r",
+	".sh": "bash", ".bash": "bash", ".zsh": "zsh", ".fish": "fish", ".ps1": "powershell",
+	".html": "html", ".htm": "html", ".css": "css", ".scss": "scss", ".less": "less",
+	".vue": "vue", ".svelte": "svelte", ".json": "json", ".yaml": "yaml", ".yml": "yaml",
+	".toml": "toml", ".xml": "xml", ".md": "markdown", ".sql": "sql", ".proto": "protobuf",
+	".tf": "hcl", ".hcl": "hcl", ".mk": "makefile", ".dockerfile": "dockerfile",
+}
+
+// ambiguousExtensions are shared by several languages, so their content is
+// checked before settling on one
+var ambiguousExtensions = map[string]bool{".h": true, ".m": true, ".pl": true}
+
+// nameLanguages maps well-known extension-less file names to languages
+var nameLanguages = map[string]string{
+	"Makefile": "makefile", "GNUmakefile": "makefile", "Dockerfile": "dockerfile",
+	"Containerfile": "dockerfile", "Jenkinsfile": "groovy", "Vagrantfile": "ruby",
+	"Gemfile": "ruby", "Rakefile": "ruby", "Justfile": "just", "go.mod": "go-mod",
+}
+
+// interpreterLanguages maps shebang interpreters, without version suffixes,
+// to languages
+var interpreterLanguages = map[string]string{
+	"sh": "sh", "dash": "sh", "ash": "sh", "bash": "bash", "zsh": "zsh", "ksh": "ksh",
+	"fish": "fish", "python": "python", "pypy": "python", "node": "javascript",
+	"nodejs": "javascript", "deno": "typescript", "ts-node": "typescript", "bun": "javascript",
+	"ruby": "ruby", "perl": "perl", "php": "php", "lua": "lua", "rscript": "r",
+	"awk": "awk", "gawk": "awk", "tclsh": "tcl", "pwsh": "powershell", "groovy": "groovy",
+}
+
+var interpreterVersionRegex = regexp.MustCompile(`[0-9.]+$`)
+
+// DetectLanguage names the language of a file from its extension or name,
+// falling back to its shebang line and content for extension-less or
+// ambiguous files. It returns "" when the language is unknown.
+func DetectLanguage(name string, content []byte) string {
+	base := filepath.Base(name)
+	ext := strings.ToLower(filepath.Ext(base))
+
+	if lang, ok := extensionLanguages[ext]; ok {
+		return lang
+	}
+	if lang, ok := nameLanguages[base]; ok {
+		return lang
+	}
+	if strings.HasPrefix(base, "Dockerfile.") {
+		return "dockerfile"
+	}
+	if lang := shebangLanguage(content); lang != "" {
+		return lang
+	}
+
+	switch ext {
+	case ".h":
+		if containsAny(content, "@interface", "@implementation", "#import") {
+			return "objectivec"
+		}
+		if containsAny(content, "class ", "namespace ", "template<", "template <", "std::") {
+			return "cpp"
+		}
+		return "c"
+	case ".m":
+		if containsAny(content, "@interface", "@implementation", "#import") {
+			return "objectivec"
+		}
+		return "matlab"
+	case ".pl":
+		if containsAny(content, ":- ", ":-\n") {
+			return "prolog"
+		}
+		return "perl"
+	case "":
+		return contentLanguage(content)
+	}
+
+	return ""
+}
+
+// shebangLanguage detects the language named by a #! interpreter line
+func shebangLanguage(content []byte) string {
+	if !bytes.HasPrefix(content, []byte("#!")) {
+		return ""
+	}
+	line := string(content[2:])
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+	interpreter := filepath.Base(fields[0])
+	// "#!/usr/bin/env -S python3 -u" names the interpreter after env's options
+	if interpreter == "env" {
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") && !strings.Contains(field, "=") {
+				interpreter = field
+				break
+			}
+		}
+	}
+
+	interpreter = strings.ToLower(interpreterVersionRegex.ReplaceAllString(interpreter, ""))
+	return interpreterLanguages[interpreter]
+}
+
+// contentLanguage guesses the language of an extension-less file from
+// distinctive opening content
+func contentLanguage(content []byte) string {
+	head := bytes.TrimSpace(content)
+	switch {
+	case bytes.HasPrefix(head, []byte("<?php")):
+		return "php"
+	case bytes.HasPrefix(head, []byte("<?xml")):
+		return "xml"
+	case hasPrefixFold(head, "<!doctype html") || hasPrefixFold(head, "<html"):
+		return "html"
+	case bytes.HasPrefix(head, []byte("package ")) && bytes.Contains(head, []byte("\nfunc ")):
+		return "go"
+	}
+	return ""
+}
+
+// detectFileLanguage detects a file's language, reading its opening bytes
+// only when the name alone is not enough
+func detectFileLanguage(path string) string {
+	if !ambiguousExtensions[strings.ToLower(filepath.Ext(path))] {
+		if lang := DetectLanguage(path, nil); lang != "" {
+			return lang
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	head := make([]byte, sniffLen)
+	n, _ := io.ReadFull(f, head)
+	return DetectLanguage(path, head[:n])
+}
+
+func containsAny(content []byte, substrs ...string) bool {
+	for _, s := range substrs {
+		if bytes.Contains(content, []byte(s)) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPrefixFold(b []byte, prefix string) bool {
+	return len(b) >= len(prefix) && strings.EqualFold(string(b[:len(prefix)]), prefix)
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// sizeUnits maps size suffixes to multipliers; K, M and G are binary
+var sizeUnits = []struct {
+	suffix string
+	scale  int64
+}{
+	{"KIB", 1 << 10}, {"MIB", 1 << 20}, {"GIB", 1 << 30},
+	{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30},
+	{"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30},
+	{"B", 1},
+}
+
+// parseSize parses a byte count such as "1048576", "500KB" or "2G".
+// An empty string is 0, meaning no limit.
+func parseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "" {
+		return 0, nil
+	}
+
+	scale := int64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(s, unit.suffix) {
+			s, scale = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix)), unit.scale
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size: %q (expected bytes or a KB, MB or GB suffix)", s)
+	}
+	return int64(n * float64(scale)), nil
+}
+
+// formatSize renders a byte count with the largest unit that keeps it above 1
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1fGB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1fMB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1fKB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%dB", n)
+}
+
+// sizeReason returns why a file is left out by its size under --min-size
+// and --max-size, or "" to keep it
+func (p *fileProcessor) sizeReason(path string) string {
+	if p.config.MinSize == "" && p.config.MaxSize == "" {
+		return ""
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+
+	// Both sizes were checked by validateConfig
+	minSize, _ := parseSize(p.config.MinSize)
+	maxSize, _ := parseSize(p.config.MaxSize)
+	switch {
+	case info.Size() < minSize:
+		return "too small"
+	case maxSize > 0 && info.Size() > maxSize:
+		return "too large"
+	}
+	return ""
+}
+
+// applyLimits stops packing at the first file that would take the corpus
+// past MaxFiles files or MaxTotalSize bytes, skipping it and every file
+// after it. In strict mode going past either limit is an error instead.
+func (p *fileProcessor) applyLimits() error {
+	maxSize, err := parseSize(p.config.MaxTotalSize)
+	if err != nil {
+		return err
+	}
+	if p.config.MaxFiles <= 0 && maxSize <= 0 {
+		return nil
+	}
+
+	var total int64
+	reason := ""
+	kept := p.files[:0]
+	for _, entry := range p.files {
+		var size int64
+		if info, err := os.Stat(entry.absPath); err == nil {
+			size = info.Size()
+		}
+
+		if reason == "" {
+			switch {
+			case p.config.MaxFiles > 0 && len(kept) >= p.config.MaxFiles:
+				reason = "file limit"
+			case maxSize > 0 && total+size > maxSize:
+				reason = "size limit"
+			}
+		}
+		if reason != "" {
+			p.summary.SkippedFiles = append(p.summary.SkippedFiles,
+				SkippedFile{Path: p.displayPath(entry.relPath), Reason: reason})
+			continue
+		}
+
+		total += size
+		kept = append(kept, entry)
+	}
+
+	skipped := len(p.files) - len(kept)
+	if skipped == 0 {
+		return nil
+	}
+
+	selected := len(p.files)
+	p.files = kept
+	limit := fmt.Sprintf("--max-files %d", p.config.MaxFiles)
+	if reason == "size limit" {
+		limit = "--max-total-size " + formatSize(maxSize)
+	}
+	if p.config.Strict {
+		return fmt.Errorf("%d selected files exceed %s; %d would be skipped (rerun without --strict to pack the first %d)",
+			selected, limit, skipped, len(kept))
+	}
+	fmt.Fprintf(os.Stderr, "Warning: stopped packing at %s: packed %d of %d selected files (%s), skipped %d (%s)\n",
+		limit, len(kept), selected, formatSize(total), skipped, reason)
+	return nil
+}
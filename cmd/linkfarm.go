@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// processLinkFarm mirrors the selected files into the output directory as
+// symlinks or copies, preserving their paths relative to the input directory
+func processLinkFarm(config Config) error {
+	outputDir := config.OutputFile
+
+	if info, err := os.Stat(outputDir); err == nil && !info.IsDir() {
+		return fmt.Errorf("linkfarm output must be a directory: %s", outputDir)
+	}
+
+	processor := newFileProcessor(&config)
+	// Never descend into a farm left over from a previous run
+	processor.skipPath(outputDir)
+	if config.AnonymizeMapFile != "" {
+		processor.skipPath(config.AnonymizeMapFile)
+	}
+
+	if err := processor.collectFiles(); err != nil {
+		return err
+	}
+	if err := processor.applyLimits(); err != nil {
+		return err
+	}
+	if err := processor.scanSecrets(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("error creating output directory: %w", err)
+	}
+
+	for _, entry := range processor.files {
+		dest := filepath.Join(outputDir, processor.displayPath(entry.relPath))
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return fmt.Errorf("error creating output directory: %w", err)
+		}
+
+		// Replace stale entries so repeated runs are idempotent
+		if err := os.Remove(dest); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error removing %s: %w", dest, err)
+		}
+
+		switch config.LinkMode {
+		case LinkModeCopy:
+			if err := copyFile(entry.absPath, dest); err != nil {
+				return fmt.Errorf("error copying %s: %w", entry.relPath, err)
+			}
+		case LinkModeSymlink, "":
+			if err := os.Symlink(entry.absPath, dest); err != nil {
+				return fmt.Errorf("error linking %s: %w", entry.relPath, err)
+			}
+		default:
+			return fmt.Errorf("unsupported link mode: %s", config.LinkMode)
+		}
+	}
+
+	if config.AnonymizeMapFile != "" && processor.paths != nil {
+		return processor.paths.writeMapping(config.AnonymizeMapFile)
+	}
+
+	return nil
+}
+
+// copyFile copies the contents and permissions of src to dest
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
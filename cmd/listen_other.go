@@ -0,0 +1,11 @@
+//go:build !unix
+
+package cmd
+
+import "net"
+
+// listenPrivate listens on a unix socket; without a umask the socket is
+// only secured by the chmod after it is created
+func listenPrivate(socket string) (net.Listener, error) {
+	return net.Listen("unix", socket)
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package cmd
+
+import (
+	"net"
+	"syscall"
+)
+
+// listenPrivate listens on a unix socket created with mode 0600, so no
+// other user can connect to it before it is chmodded. The umask is process
+// wide, which is safe while the daemon starts and nothing else creates files.
+func listenPrivate(socket string) (net.Listener, error) {
+	old := syscall.Umask(0177)
+	defer syscall.Umask(old)
+	return net.Listen("unix", socket)
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// minFenceLen is the length of a code fence around content without backticks
+const minFenceLen = 3
+
+// fenceLanguage returns the code fence tag of a file in a markdown corpus:
+// the tag of the first fenceLanguages pattern, in sorted order, that
+// matches it, or else its detected language
+func (p *fileProcessor) fenceLanguage(relPath string, head []byte) string {
+	if len(p.config.FenceLanguages) > 0 {
+		patterns := make([]string, 0, len(p.config.FenceLanguages))
+		for pattern := range p.config.FenceLanguages {
+			patterns = append(patterns, pattern)
+		}
+		sort.Strings(patterns)
+		for _, pattern := range patterns {
+			if matched, err := matchPathPattern(pattern, relPath); err == nil && matched {
+				return p.config.FenceLanguages[pattern]
+			}
+		}
+	}
+	return DetectLanguage(relPath, head)
+}
+
+// longestBacktickRun returns the longest run of backticks read from r
+func longestBacktickRun(r io.ByteReader) int {
+	longest, run := 0, 0
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return longest
+		}
+		if c != '`' {
+			run = 0
+			continue
+		}
+		if run++; run > longest {
+			longest = run
+		}
+	}
+}
+
+// markdownFence returns a fence longer than any run of backticks in
+// content, so the content can never close it early
+func markdownFence(content []byte) string {
+	return fenceFor(longestBacktickRun(bytes.NewReader(content)))
+}
+
+// fileFence scans a file for its fence without holding it in memory
+func fileFence(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return fenceFor(longestBacktickRun(bufio.NewReader(f))), nil
+}
+
+// fenceFor returns a fence that outlasts a backtick run of the given length
+func fenceFor(run int) string {
+	if run < minFenceLen {
+		return strings.Repeat("`", minFenceLen)
+	}
+	return strings.Repeat("`", run+1)
+}
+
+// markdownStart returns the heading and opening fence of a file in a
+// markdown corpus, with its annotation and last commit as quoted lines
+func (p *fileProcessor) markdownStart(relPath, name, fence, language string) string {
+	var quotes strings.Builder
+	if note := p.annotation(relPath); note != "" {
+		fmt.Fprintf(&quotes, "> Note: %s\n", note)
+	}
+	if commit := p.lastCommit(relPath); commit != nil {
+		fmt.Fprintf(&quotes, "> Last commit: %s\n", commit.String())
+	}
+	if quotes.Len() > 0 {
+		quotes.WriteString("\n")
+	}
+	return fmt.Sprintf("## %s\n\n%s%s%s\n", name, quotes.String(), fence, language)
+}
+
+// markdownEnd closes a file's fence, on a line of its own whether or not
+// the content ends with a newline
+func markdownEnd(fence string, endsWithNewline bool) string {
+	if endsWithNewline {
+		return fence + "\n\n"
+	}
+	return "\n" + fence + "\n\n"
+}
+
+// lastByteWriter remembers the last byte written through it
+type lastByteWriter struct {
+	b byte
+}
+
+func (w *lastByteWriter) Write(data []byte) (int, error) {
+	if len(data) > 0 {
+		w.b = data[len(data)-1]
+	}
+	return len(data), nil
+}
@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	mergeGzip   bool
+	mergeBase64 bool
+
+	mergeCmd = &cobra.Command{
+		Use:   "merge <output> <corpus>...",
+		Short: "Merge corpora into one, deduplicating files by path",
+		Long: `Merge corpora into a single corpus, so multi-repo corpora can be assembled
+incrementally. Files keep the position of their first appearance; when a
+path appears in several corpora, the content from the last one wins. File IDs
+are renumbered in the merged order. The first instructions block found is
+kept and verbose summaries are dropped. Inputs may be gzipped or base64
+encoded, and the output may be one of the inputs.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			corpora := make([]*Corpus, 0, len(args)-1)
+			for _, path := range args[1:] {
+				corpus, err := LoadCorpus(path)
+				if err != nil {
+					return err
+				}
+				corpora = append(corpora, corpus)
+			}
+
+			merged := MergeCorpora(corpora...)
+			if err := writeCorpus(args[0], merged.Data, mergeGzip, mergeEncoding(), false); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Merged %d files from %d corpora into %s\n", len(merged.Files), len(corpora), args[0])
+			return nil
+		},
+	}
+)
+
+func init() {
+	mergeCmd.Flags().BoolVarP(&mergeGzip, "gzip", "z", false, "Compress the merged corpus using gzip")
+	mergeCmd.Flags().BoolVarP(&mergeBase64, "base64", "b", false, "Base64 encode the merged corpus (use with --gzip)")
+	rootCmd.AddCommand(mergeCmd)
+}
+
+// Instructions returns the text of the corpus's instructions block, or ""
+func (c *Corpus) Instructions() string {
+	head := c.Data
+	if i := bytes.Index(head, []byte(startMarker)); i >= 0 {
+		head = head[:i]
+	}
+
+	start := bytes.Index(head, []byte(instructionsMarker))
+	if start < 0 {
+		return ""
+	}
+	start += len(instructionsMarker)
+	end := bytes.Index(head[start:], []byte(endInstructionsMarker))
+	if end < 0 {
+		return ""
+	}
+	return string(head[start : start+end])
+}
+
+// MergeCorpora combines corpora into one in the plain separator layout.
+// Files are deduplicated by path: each keeps the position of its first
+// appearance and the content of its last.
+func MergeCorpora(corpora ...*Corpus) *Corpus {
+	type source struct {
+		corpus *Corpus
+		file   CorpusFile
+	}
+
+	var (
+		sources      []source
+		index        = make(map[string]int)
+		instructions string
+	)
+	for _, corpus := range corpora {
+		if instructions == "" {
+			instructions = corpus.Instructions()
+		}
+		for _, file := range corpus.Files {
+			if i, ok := index[file.Path]; ok {
+				sources[i] = source{corpus, file}
+				continue
+			}
+			index[file.Path] = len(sources)
+			sources = append(sources, source{corpus, file})
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(formatVersionLine())
+	if instructions != "" {
+		buf.WriteString(formatInstructions(instructions))
+	}
+
+	merged := &Corpus{}
+	var packed []indexEntry
+	for i, src := range sources {
+		buf.WriteString(startMarker + src.file.Path + markerClose + "\n")
+		if src.file.Note != "" {
+			buf.WriteString(noteMarker + src.file.Note + markerClose + "\n")
+		}
+		if src.file.LastCommit != "" {
+			buf.WriteString(lastCommitMarker + src.file.LastCommit + markerClose + "\n")
+		}
+
+		offset := buf.Len()
+		buf.Write(src.corpus.Content(src.file))
+		merged.Files = append(merged.Files, CorpusFile{
+			ID:     i + 1,
+			Path:   src.file.Path,
+			Offset: int64(offset),
+			Length: int64(buf.Len() - offset),
+			Note:   src.file.Note,
+
+			LastCommit: src.file.LastCommit,
+		})
+
+		buf.WriteString("\n" + endMarker + src.file.Path + markerClose + "\n\n")
+		packed = append(packed, newIndexEntry(src.file.Path, src.corpus.Content(src.file)))
+	}
+	buf.WriteString(formatIndex(packed))
+
+	merged.Data = buf.Bytes()
+	return merged
+}
+
+// mergeEncoding returns the text encoding the merge command writes
+func mergeEncoding() string {
+	if mergeBase64 {
+		return EncodingBase64
+	}
+	return ""
+}
+
+// writeCorpus writes decoded corpus data to path through the same gzip and
+// base64 stages ProcessDirectory uses
+func writeCorpus(path string, data []byte, useGzip bool, encoding string, wrap bool) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating output file: %w", err)
+	}
+	defer f.Close()
+
+	var (
+		writer       io.Writer = f
+		encodeWriter io.WriteCloser
+		gzipWriter   *gzip.Writer
+	)
+	if encoding != "" {
+		encodeWriter = newTextEncoder(f, encoding, wrap)
+		writer = encodeWriter
+	}
+	if useGzip {
+		gzipWriter = gzip.NewWriter(writer)
+		writer = gzipWriter
+	}
+
+	if _, err := writer.Write(data); err != nil {
+		return fmt.Errorf("error writing corpus: %w", err)
+	}
+	if gzipWriter != nil {
+		if err := gzipWriter.Close(); err != nil {
+			return fmt.Errorf("error closing gzip writer: %w", err)
+		}
+	}
+	if encodeWriter != nil {
+		if err := encodeWriter.Close(); err != nil {
+			return fmt.Errorf("error closing %s encoder: %w", encoding, err)
+		}
+	}
+	return f.Close()
+}
+
+// loadExistingCorpus loads the corpus at path for --append, or returns nil
+// when there is none yet
+func loadExistingCorpus(path string) (*Corpus, error) {
+	corpus, err := LoadCorpus(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return corpus, err
+}
+
+// appendToCorpus merges the corpus just written to path after existing, so
+// files packed by this run replace the existing files with the same path
+func appendToCorpus(path string, existing *Corpus, useGzip bool, encoding string, wrap bool) error {
+	fresh, err := LoadCorpus(path)
+	if err != nil {
+		return err
+	}
+	return writeCorpus(path, MergeCorpora(existing, fresh).Data, useGzip, encoding, wrap)
+}
@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// packDurationBuckets are the upper bounds, in seconds, of the pack
+// duration histogram
+var packDurationBuckets = []float64{0.1, 0.5, 1, 5, 10, 30, 60, 300}
+
+// packMetrics counts the packs of this process, for the /metrics endpoint
+// and --metrics-file
+type packMetrics struct {
+	mu sync.Mutex
+
+	packs         map[string]int // By result: ok or error
+	durationCount []int          // Packs at or under each bucket bound
+	durationSum   float64
+	corpora       int
+	files         int64
+	bytes         int64
+	skipped       int64
+
+	lastPack     time.Time
+	lastSuccess  time.Time
+	lastDuration time.Duration
+	lastOK       bool
+
+	requests map[string]int // Served requests by route
+}
+
+var metrics = &packMetrics{
+	packs:         make(map[string]int),
+	durationCount: make([]int, len(packDurationBuckets)),
+	requests:      make(map[string]int),
+}
+
+// recordPack counts a finished pack and how long it took
+func (m *packMetrics) recordPack(duration time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	m.packs[result]++
+	seconds := duration.Seconds()
+	for i, bound := range packDurationBuckets {
+		if seconds <= bound {
+			m.durationCount[i]++
+		}
+	}
+	m.durationSum += seconds
+
+	m.lastPack = time.Now()
+	m.lastDuration = duration
+	m.lastOK = err == nil
+	if err == nil {
+		m.lastSuccess = m.lastPack
+	}
+}
+
+// recordCorpus counts the files of a written corpus
+func (m *packMetrics) recordCorpus(summary *Summary) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.corpora++
+	m.files += int64(len(summary.ProcessedFiles))
+	m.bytes += summary.TotalBytes
+	m.skipped += int64(len(summary.SkippedFiles))
+}
+
+// recordRequest counts a request served on route
+func (m *packMetrics) recordRequest(route string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[route]++
+}
+
+// write renders the metrics in the Prometheus text exposition format
+func (m *packMetrics) write(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+	metric := func(name, kind, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+	value := func(name string, v float64) {
+		fmt.Fprintf(&b, "%s %s\n", name, strconv.FormatFloat(v, 'g', -1, 64))
+	}
+	unix := func(t time.Time) float64 {
+		if t.IsZero() {
+			return 0
+		}
+		return float64(t.UnixNano()) / 1e9
+	}
+
+	metric("cpack_packs_total", "counter", "Packs run, by result.")
+	for _, result := range []string{"ok", "error"} {
+		value(fmt.Sprintf(`cpack_packs_total{result=%q}`, result), float64(m.packs[result]))
+	}
+
+	metric("cpack_pack_duration_seconds", "histogram", "Time taken by each pack.")
+	total := m.packs["ok"] + m.packs["error"]
+	for i, bound := range packDurationBuckets {
+		value(fmt.Sprintf(`cpack_pack_duration_seconds_bucket{le="%s"}`, strconv.FormatFloat(bound, 'g', -1, 64)), float64(m.durationCount[i]))
+	}
+	value(`cpack_pack_duration_seconds_bucket{le="+Inf"}`, float64(total))
+	value("cpack_pack_duration_seconds_sum", m.durationSum)
+	value("cpack_pack_duration_seconds_count", float64(total))
+
+	metric("cpack_corpora_written_total", "counter", "Corpora written, one per workspace member or directory group.")
+	value("cpack_corpora_written_total", float64(m.corpora))
+	metric("cpack_files_packed_total", "counter", "Files packed into corpora.")
+	value("cpack_files_packed_total", float64(m.files))
+	metric("cpack_bytes_packed_total", "counter", "Bytes of source files packed into corpora.")
+	value("cpack_bytes_packed_total", float64(m.bytes))
+	metric("cpack_files_skipped_total", "counter", "Selected files left out of corpora.")
+	value("cpack_files_skipped_total", float64(m.skipped))
+
+	metric("cpack_last_pack_timestamp_seconds", "gauge", "When the last pack finished, as a Unix time.")
+	value("cpack_last_pack_timestamp_seconds", unix(m.lastPack))
+	metric("cpack_last_pack_duration_seconds", "gauge", "Time taken by the last pack.")
+	value("cpack_last_pack_duration_seconds", m.lastDuration.Seconds())
+	metric("cpack_last_pack_success", "gauge", "1 if the last pack succeeded, 0 otherwise.")
+	success := 0.0
+	if m.lastOK {
+		success = 1
+	}
+	value("cpack_last_pack_success", success)
+	metric("cpack_last_success_timestamp_seconds", "gauge", "When the last successful pack finished, as a Unix time.")
+	value("cpack_last_success_timestamp_seconds", unix(m.lastSuccess))
+
+	if len(m.requests) > 0 {
+		routes := make([]string, 0, len(m.requests))
+		for route := range m.requests {
+			routes = append(routes, route)
+		}
+		sort.Strings(routes)
+		metric("cpack_http_requests_total", "counter", "HTTP requests served, by route.")
+		for _, route := range routes {
+			value(fmt.Sprintf(`cpack_http_requests_total{route=%q}`, route), float64(m.requests[route]))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// MetricsHandler serves the metrics of this process in the Prometheus
+// text exposition format
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		metrics.write(w)
+	})
+}
+
+// writeMetricsFile writes the metrics for a textfile collector, such as
+// node_exporter's. The file is replaced in one rename, so a scrape never
+// reads it half written.
+func writeMetricsFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating metrics directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".cpack-metrics-*")
+	if err != nil {
+		return fmt.Errorf("error creating metrics file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := metrics.write(tmp); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing metrics file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing metrics file: %w", err)
+	}
+	// Temporary files are private, but collectors may run as another user
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("error writing metrics file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("error writing metrics file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Minified file policies
+const (
+	MinifiedSkip = "skip" // Minified files are left out and listed as skipped
+	MinifiedStub = "stub" // A one-line placeholder is packed in place of the content
+	MinifiedKeep = "keep" // Minified files are packed like any other file
+)
+
+// Thresholds for telling minified code from source by its content
+const (
+	minifiedSniffLen      = 64 * 1024 // How much of a file is inspected
+	minifiedMinSize       = 1024      // Smaller files are never treated as minified
+	minifiedAvgLineLen    = 250       // Average line length above which a file is minified
+	minifiedLongLineLen   = 1000      // Length of a line that is minified if it is also dense
+	minifiedMaxWhitespace = 0.1       // Whitespace share below which a long line is dense
+	minifiedMinSyntax     = 0.05      // Share of ;{}(),: a dense line needs to read as code
+)
+
+// minifiedExtensions are the file types bundlers emit minified
+var minifiedExtensions = map[string]bool{".js": true, ".mjs": true, ".cjs": true, ".css": true}
+
+// looksMinified reports whether content reads like minified code: its
+// lines are very long on average, or one very long line is packed with
+// code punctuation and has almost no whitespace. A long string or data
+// literal has little punctuation, so it does not count.
+func looksMinified(content []byte) bool {
+	if len(content) < minifiedMinSize {
+		return false
+	}
+
+	lines := bytes.Split(content, []byte("\n"))
+	if len(content)/len(lines) > minifiedAvgLineLen {
+		return true
+	}
+	for _, line := range lines {
+		if len(line) > minifiedLongLineLen && byteShare(line, " \t\r") < minifiedMaxWhitespace &&
+			byteShare(line, ";{}(),:") > minifiedMinSyntax {
+			return true
+		}
+	}
+	return false
+}
+
+// byteShare returns the fraction of bytes in line that are one of chars
+func byteShare(line []byte, chars string) float64 {
+	count := 0
+	for _, c := range line {
+		if strings.IndexByte(chars, c) >= 0 {
+			count++
+		}
+	}
+	return float64(count) / float64(len(line))
+}
+
+// isMinifiedFile reads the start of a JavaScript or CSS file and reports
+// whether it is minified, whatever its name
+func isMinifiedFile(relPath, path string) bool {
+	if !minifiedExtensions[strings.ToLower(filepath.Ext(relPath))] {
+		return false
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	head := make([]byte, minifiedSniffLen)
+	n, _ := io.ReadFull(f, head)
+	return looksMinified(head[:n])
+}
+
+// minifiedStub is packed in place of a minified file under MinifiedStub
+func minifiedStub(size int) []byte {
+	return []byte(fmt.Sprintf("/* minified file omitted (%d bytes) */", size))
+}
@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// objectStore is an object-store URL scheme --output accepts, written
+// through the store's own CLI, which streams stdin as a multipart upload
+// and handles credentials the way CI jobs already configure them
+type objectStore struct {
+	tool string
+	args func(url string) []string
+}
+
+// objectStores are the supported object stores by URL scheme
+var objectStores = map[string]objectStore{
+	"s3": {tool: "aws", args: func(url string) []string {
+		return []string{"s3", "cp", "--only-show-errors", "-", url}
+	}},
+	"gs": {tool: "gcloud", args: func(url string) []string {
+		return []string{"storage", "cp", "-", url}
+	}},
+	// az://account/container/blob, uploaded with azcopy to the account's blob endpoint
+	"az": {tool: "azcopy", args: func(url string) []string {
+		account, blob, _ := strings.Cut(strings.TrimPrefix(url, "az://"), "/")
+		return []string{"copy", fmt.Sprintf("https://%s.blob.core.windows.net/%s", account, blob),
+			"--from-to", "PipeBlob", "--log-level", "ERROR"}
+	}},
+}
+
+// objectStoreOf returns the object store an output URL names
+func objectStoreOf(output string) (objectStore, bool) {
+	scheme, rest, ok := strings.Cut(output, "://")
+	if !ok || rest == "" {
+		return objectStore{}, false
+	}
+	store, ok := objectStores[scheme]
+	return store, ok
+}
+
+// isObjectURL reports whether output is an object-store URL rather than a path
+func isObjectURL(output string) bool {
+	_, ok := objectStoreOf(output)
+	return ok
+}
+
+// objectWriter streams a corpus into an object store through its CLI. The
+// object only appears once Finish succeeds; Close without Finish aborts the
+// upload, so a failed pack never publishes a partial corpus.
+type objectWriter struct {
+	url      string
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	stderr   bytes.Buffer
+	finished bool
+}
+
+// newObjectWriter starts the upload of the object at url
+func newObjectWriter(url string) (*objectWriter, error) {
+	store, _ := objectStoreOf(url)
+	w := &objectWriter{url: url, cmd: exec.Command(store.tool, store.args(url)...)}
+	w.cmd.Stderr = &w.stderr
+	stdin, err := w.cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("error starting upload to %s: %w", url, err)
+	}
+	w.stdin = stdin
+	if err := w.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("error starting upload to %s: %w", url, err)
+	}
+	return w, nil
+}
+
+func (w *objectWriter) Write(data []byte) (int, error) {
+	n, err := w.stdin.Write(data)
+	if err != nil {
+		return n, fmt.Errorf("error uploading to %s: %w", w.url, w.failure(err))
+	}
+	return n, nil
+}
+
+// Finish ends the stream and waits for the upload to complete
+func (w *objectWriter) Finish() error {
+	w.finished = true
+	w.stdin.Close()
+	if err := w.cmd.Wait(); err != nil {
+		return fmt.Errorf("error uploading to %s: %w", w.url, w.failure(err))
+	}
+	return nil
+}
+
+// Close aborts the upload unless it was finished
+func (w *objectWriter) Close() error {
+	if w.finished {
+		return nil
+	}
+	w.finished = true
+	w.cmd.Process.Kill()
+	w.stdin.Close()
+	w.cmd.Wait()
+	return nil
+}
+
+// failure describes a failed upload by what the CLI printed, if anything
+func (w *objectWriter) failure(err error) error {
+	if msg := strings.TrimSpace(w.stderr.String()); msg != "" {
+		return fmt.Errorf("%w: %s", err, msg)
+	}
+	return err
+}
+
+// createOutput opens the corpus output: a file, created with its directory,
+// or an upload to an object store. A regular file is written beside the
+// target and only replaces it in finishOutput, so a failed pack leaves an
+// earlier corpus at the path in place.
+func createOutput(output string) (io.WriteCloser, error) {
+	if isObjectURL(output) {
+		return newObjectWriter(output)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+		// If it's a read-only filesystem error, wrap it as an output file error
+		if strings.Contains(err.Error(), "read-only file system") {
+			return nil, fmt.Errorf("error creating output file: %w", err)
+		}
+		return nil, fmt.Errorf("error creating output directory: %w", err)
+	}
+	if isPipeOutput(output) {
+		// Write-only, unlike os.Create, so opening a named pipe waits for its reader
+		file, err := os.OpenFile(output, os.O_WRONLY|os.O_TRUNC, 0666)
+		if err != nil {
+			return nil, fmt.Errorf("error creating output file: %w", err)
+		}
+		return file, nil
+	}
+	return newReplaceFile(output)
+}
+
+// finishOutput completes an object-store upload, or moves a written file
+// over its target. Pipes need nothing more than the deferred Close.
+func finishOutput(output io.WriteCloser) error {
+	switch w := output.(type) {
+	case *objectWriter:
+		return w.Finish()
+	case *replaceFile:
+		return w.commit()
+	}
+	return nil
+}
+
+// replaceFile is a temporary file in the directory of target that commit
+// renames over it. Closing it without a commit removes it.
+type replaceFile struct {
+	*os.File
+	target    string
+	committed bool
+}
+
+func newReplaceFile(target string) (*replaceFile, error) {
+	file, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("error creating output file: %w", err)
+	}
+	return &replaceFile{File: file, target: target}, nil
+}
+
+// commit replaces target with the file, keeping the mode of the file it
+// replaces
+func (f *replaceFile) commit() error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(f.target); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := f.File.Chmod(mode); err != nil {
+		return fmt.Errorf("error setting output file mode: %w", err)
+	}
+	if err := f.File.Close(); err != nil {
+		return fmt.Errorf("error closing output file: %w", err)
+	}
+	if err := os.Rename(f.Name(), f.target); err != nil {
+		return fmt.Errorf("error replacing %s: %w", f.target, err)
+	}
+	f.committed = true
+	return nil
+}
+
+// Close removes the file unless it was committed
+func (f *replaceFile) Close() error {
+	if f.committed {
+		return nil
+	}
+	f.File.Close()
+	return os.Remove(f.Name())
+}
+
+// validateObjectOutput checks that an object-store output can be written:
+// its CLI is installed and the corpus is a single streamed file
+func validateObjectOutput(config *Config) error {
+	store, ok := objectStoreOf(config.OutputFile)
+	if !ok {
+		return nil
+	}
+	if _, err := exec.LookPath(store.tool); err != nil {
+		return fmt.Errorf("writing to %s requires the %s CLI on PATH", config.OutputFile, store.tool)
+	}
+	switch {
+	case config.Append:
+		return fmt.Errorf("--append cannot be used with object-store output %s", config.OutputFile)
+	case config.OutputFormat == FormatLinkFarm || config.PerWorkspace || config.SplitByDir > 0:
+		return fmt.Errorf("object-store output %s requires a single corpus file; it cannot be combined with linkfarm, --per-workspace or --split-by-dir", config.OutputFile)
+	case config.Upload != "":
+		return fmt.Errorf("--upload cannot be combined with object-store output %s", config.OutputFile)
+	}
+	return nil
+}
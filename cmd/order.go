@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Sort orders
+const (
+	SortWalk  = "walk"  // Directory walk order (default)
+	SortDeps  = "deps"  // Go packages in import dependency order
+	SortSmart = "smart" // READMEs, manifests and entrypoints, then by import fan-in
+)
+
+// orderFiles arranges the selected files for emission. The sort order is
+// applied first, then files matching an earlier priority glob move to the front.
+// Deterministic output starts from path order rather than walk order.
+func (p *fileProcessor) orderFiles() {
+	if p.config.Deterministic {
+		sort.SliceStable(p.files, func(i, j int) bool {
+			return filepath.ToSlash(p.files[i].relPath) < filepath.ToSlash(p.files[j].relPath)
+		})
+	}
+	if p.config.SortOrder == SortDeps {
+		p.sortByDeps()
+	}
+	if p.config.SortOrder == SortSmart {
+		p.smartOrder()
+	}
+
+	if len(p.config.PriorityGlobs) == 0 {
+		return
+	}
+
+	rank := make(map[string]int, len(p.files))
+	for _, entry := range p.files {
+		rank[entry.relPath] = p.priorityRank(entry.relPath)
+	}
+
+	sort.SliceStable(p.files, func(i, j int) bool {
+		return rank[p.files[i].relPath] < rank[p.files[j].relPath]
+	})
+}
+
+// priorityRank returns the index of the first priority glob matching relPath,
+// or the number of priority globs when none match
+func (p *fileProcessor) priorityRank(relPath string) int {
+	for i, pattern := range p.config.PriorityGlobs {
+		matched, err := matchPathPattern(pattern, relPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error matching priority pattern %s: %v\n", pattern, err)
+			continue
+		}
+		if matched {
+			return i
+		}
+	}
+	return len(p.config.PriorityGlobs)
+}
+
+// goPackages are the selected Go files grouped by package directory, with
+// the module-local imports of each package
+type goPackages struct {
+	files   map[string][]fileEntry     // package dir -> files
+	imports map[string]map[string]bool // package dir -> imported package dirs
+	main    map[string]bool            // package dirs of main packages
+	other   []fileEntry                // Selected files that are not Go
+}
+
+// readGoPackages parses the imports of the selected Go files
+func (p *fileProcessor) readGoPackages() goPackages {
+	modulePath := readModulePath(filepath.Join(p.config.InputDir, "go.mod"))
+	pkgs := goPackages{
+		files:   make(map[string][]fileEntry),
+		imports: make(map[string]map[string]bool),
+		main:    make(map[string]bool),
+	}
+
+	fset := token.NewFileSet()
+	for _, entry := range p.files {
+		if filepath.Ext(entry.relPath) != ".go" {
+			pkgs.other = append(pkgs.other, entry)
+			continue
+		}
+
+		dir := filepath.ToSlash(filepath.Dir(entry.relPath))
+		pkgs.files[dir] = append(pkgs.files[dir], entry)
+		if pkgs.imports[dir] == nil {
+			pkgs.imports[dir] = make(map[string]bool)
+		}
+
+		file, err := parser.ParseFile(fset, entry.absPath, nil, parser.ImportsOnly)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing imports of %s: %v\n", entry.relPath, err)
+			continue
+		}
+		if file.Name.Name == "main" {
+			pkgs.main[dir] = true
+		}
+		for _, spec := range file.Imports {
+			importPath, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				continue
+			}
+			if dep, ok := localPackageDir(modulePath, importPath); ok && dep != dir {
+				pkgs.imports[dir][dep] = true
+			}
+		}
+	}
+	return pkgs
+}
+
+// sortByDeps orders Go files so that packages come after the packages they
+// import. Non-Go files keep their walk order ahead of the Go sources.
+func (p *fileProcessor) sortByDeps() {
+	pkgs := p.readGoPackages()
+	packages, imports, mainPackages := pkgs.files, pkgs.imports, pkgs.main
+
+	// Kahn's algorithm, preferring library packages and then name order
+	pending := make(map[string]int, len(packages))
+	dependents := make(map[string][]string)
+	for dir, deps := range imports {
+		for dep := range deps {
+			if _, ok := packages[dep]; ok {
+				pending[dir]++
+				dependents[dep] = append(dependents[dep], dir)
+			}
+		}
+	}
+
+	var ready, ordered []string
+	for dir := range packages {
+		if pending[dir] == 0 {
+			ready = append(ready, dir)
+		}
+	}
+	less := func(a, b string) bool {
+		if mainPackages[a] != mainPackages[b] {
+			return !mainPackages[a]
+		}
+		return a < b
+	}
+
+	for len(ready) > 0 {
+		sort.Slice(ready, func(i, j int) bool { return less(ready[i], ready[j]) })
+		dir := ready[0]
+		ready = ready[1:]
+		ordered = append(ordered, dir)
+		for _, dependent := range dependents[dir] {
+			pending[dependent]--
+			if pending[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+
+	// Import cycles cannot compile, but keep any leftovers rather than drop them
+	if len(ordered) < len(packages) {
+		var leftover []string
+		for dir := range packages {
+			if pending[dir] > 0 {
+				leftover = append(leftover, dir)
+			}
+		}
+		sort.Slice(leftover, func(i, j int) bool { return less(leftover[i], leftover[j]) })
+		ordered = append(ordered, leftover...)
+	}
+
+	files := pkgs.other
+	for _, dir := range ordered {
+		files = append(files, packages[dir]...)
+	}
+	p.files = files
+}
+
+// readModulePath returns the module path declared in a go.mod file, or ""
+func readModulePath(goModPath string) string {
+	data, err := os.ReadFile(goModPath)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`)
+		}
+	}
+	return ""
+}
+
+// localPackageDir maps an import path inside the module to its directory
+func localPackageDir(modulePath, importPath string) (string, bool) {
+	if modulePath == "" {
+		return "", false
+	}
+	if importPath == modulePath {
+		return ".", true
+	}
+	if strings.HasPrefix(importPath, modulePath+"/") {
+		return strings.TrimPrefix(importPath, modulePath+"/"), true
+	}
+	return "", false
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// parquetMagic opens and closes every Parquet file
+const parquetMagic = "PAR1"
+
+// Parquet enum values used by the writer, from parquet.thrift
+const (
+	parquetInt64     = 2 // Type
+	parquetByteArray = 6 // Type
+	parquetRequired  = 0 // FieldRepetitionType
+	parquetUTF8      = 0 // ConvertedType
+	parquetDataPage  = 0 // PageType
+	parquetPlain     = 0 // Encoding
+	parquetRLE       = 3 // Encoding
+	parquetNoCodec   = 0 // CompressionCodec
+)
+
+// parquetColumn is one column of the table written by --format parquet,
+// holding its PLAIN encoded values
+type parquetColumn struct {
+	name   string
+	kind   int32
+	text   bool
+	values []byte
+}
+
+// renderParquet writes a corpus as a Parquet table with a row per file:
+// path, language, size, tokens and content. The table is one row group of
+// uncompressed, PLAIN encoded columns, which every reader supports, so it
+// is written by hand without a Parquet library.
+func renderParquet(w io.Writer, corpus *Corpus, config *Config, title string) error {
+	counter := newFileProcessor(config)
+	columns := []*parquetColumn{
+		{name: "path", kind: parquetByteArray, text: true},
+		{name: "language", kind: parquetByteArray, text: true},
+		{name: "size", kind: parquetInt64},
+		{name: "tokens", kind: parquetInt64},
+		{name: "content", kind: parquetByteArray, text: true},
+	}
+	for _, f := range corpus.Files {
+		content := corpus.Content(f)
+		columns[0].appendBytes([]byte(f.Path))
+		columns[1].appendBytes([]byte(DetectLanguage(f.Path, content)))
+		columns[2].appendInt64(int64(len(content)))
+		columns[3].appendInt64(int64(counter.countTokens(content)))
+		columns[4].appendBytes(content)
+	}
+	rows := int64(len(corpus.Files))
+
+	out := []byte(parquetMagic)
+	var chunks [][]byte
+	var groupSize int64
+	for _, c := range columns {
+		offset := int64(len(out))
+		page := thriftStruct{}
+		page.i32(1, parquetDataPage)
+		page.i32(2, int32(len(c.values)))
+		page.i32(3, int32(len(c.values)))
+		header := thriftStruct{}
+		header.i32(1, int32(rows))
+		header.i32(2, parquetPlain)
+		header.i32(3, parquetRLE)
+		header.i32(4, parquetRLE)
+		page.structField(5, header.end())
+		out = append(out, page.end()...)
+		out = append(out, c.values...)
+		size := int64(len(out)) - offset
+		groupSize += size
+
+		meta := thriftStruct{}
+		meta.i32(1, c.kind)
+		meta.list(2, thriftI32, [][]byte{thriftVarint(parquetPlain)})
+		meta.list(3, thriftBinary, [][]byte{thriftString(c.name)})
+		meta.i32(4, parquetNoCodec)
+		meta.i64(5, rows)
+		meta.i64(6, size)
+		meta.i64(7, size)
+		meta.i64(9, offset)
+		chunk := thriftStruct{}
+		chunk.i64(2, offset)
+		chunk.structField(3, meta.end())
+		chunks = append(chunks, chunk.end())
+	}
+
+	root := thriftStruct{}
+	root.binary(4, "schema")
+	root.i32(5, int32(len(columns)))
+	schema := [][]byte{root.end()}
+	for _, c := range columns {
+		field := thriftStruct{}
+		field.i32(1, c.kind)
+		field.i32(3, parquetRequired)
+		field.binary(4, c.name)
+		if c.text {
+			field.i32(6, parquetUTF8)
+		}
+		schema = append(schema, field.end())
+	}
+
+	group := thriftStruct{}
+	group.list(1, thriftStructType, chunks)
+	group.i64(2, groupSize)
+	group.i64(3, rows)
+
+	file := thriftStruct{}
+	file.i32(1, 1)
+	file.list(2, thriftStructType, schema)
+	file.i64(3, rows)
+	file.list(4, thriftStructType, [][]byte{group.end()})
+	file.binary(6, "cpack")
+	footer := file.end()
+
+	out = append(out, footer...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(footer)))
+	out = append(out, parquetMagic...)
+	if _, err := w.Write(out); err != nil {
+		return fmt.Errorf("error writing output file: %w", err)
+	}
+	return nil
+}
+
+// appendBytes adds a PLAIN encoded byte array value
+func (c *parquetColumn) appendBytes(value []byte) {
+	c.values = binary.LittleEndian.AppendUint32(c.values, uint32(len(value)))
+	c.values = append(c.values, value...)
+}
+
+// appendInt64 adds a PLAIN encoded int64 value
+func (c *parquetColumn) appendInt64(value int64) {
+	c.values = binary.LittleEndian.AppendUint64(c.values, uint64(value))
+}
+
+// Thrift compact protocol types
+const (
+	thriftI32        = 5
+	thriftI64        = 6
+	thriftBinary     = 8
+	thriftList       = 9
+	thriftStructType = 12
+)
+
+// thriftStruct encodes a struct in the Thrift compact protocol, which
+// Parquet uses for its page headers and footer. Fields must be added in
+// increasing id order.
+type thriftStruct struct {
+	b    []byte
+	last int
+}
+
+func (s *thriftStruct) field(id int, kind byte) {
+	// Ids are always close enough to be written as a delta
+	s.b = append(s.b, byte(id-s.last)<<4|kind)
+	s.last = id
+}
+
+func (s *thriftStruct) i32(id int, v int32) {
+	s.field(id, thriftI32)
+	s.b = append(s.b, thriftVarint(int64(v))...)
+}
+
+func (s *thriftStruct) i64(id int, v int64) {
+	s.field(id, thriftI64)
+	s.b = append(s.b, thriftVarint(v)...)
+}
+
+func (s *thriftStruct) binary(id int, v string) {
+	s.field(id, thriftBinary)
+	s.b = append(s.b, thriftString(v)...)
+}
+
+func (s *thriftStruct) structField(id int, encoded []byte) {
+	s.field(id, thriftStructType)
+	s.b = append(s.b, encoded...)
+}
+
+// list adds a list field of already encoded elements of one type
+func (s *thriftStruct) list(id int, kind byte, elems [][]byte) {
+	s.field(id, thriftList)
+	if len(elems) < 15 {
+		s.b = append(s.b, byte(len(elems))<<4|kind)
+	} else {
+		s.b = binary.AppendUvarint(append(s.b, 0xf0|kind), uint64(len(elems)))
+	}
+	for _, e := range elems {
+		s.b = append(s.b, e...)
+	}
+}
+
+// end returns the encoded struct, closed by its stop byte
+func (s *thriftStruct) end() []byte {
+	return append(s.b, 0)
+}
+
+// thriftVarint encodes an integer as a zigzag varint
+func thriftVarint(v int64) []byte {
+	return binary.AppendUvarint(nil, uint64(v<<1)^uint64(v>>63))
+}
+
+// thriftString encodes a length-prefixed string
+func thriftString(v string) []byte {
+	return append(binary.AppendUvarint(nil, uint64(len(v))), v...)
+}
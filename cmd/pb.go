@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// pbCorpusFormat is the first field of every pb corpus, naming its schema
+const pbCorpusFormat = "cpack.corpus.v1"
+
+// Field numbers of the messages in schemas/corpus.v1.proto
+const (
+	pbCorpusFormatField = 1
+	pbCorpusHeaderField = 2
+	pbCorpusFilesField  = 3
+	pbCorpusFooterField = 4
+
+	pbFilePathField       = 1
+	pbFileLanguageField   = 2
+	pbFileNoteField       = 3
+	pbFileLastCommitField = 4
+	pbFileContentField    = 5
+)
+
+// Protobuf wire types
+const (
+	pbVarint  = 0
+	pbFixed64 = 1
+	pbBytes   = 2
+	pbFixed32 = 5
+)
+
+// renderPB writes a corpus as a protobuf Corpus message. The schema has
+// only string, bytes and message fields, so it is encoded by hand rather
+// than with generated code.
+func renderPB(w io.Writer, corpus *Corpus, config *Config, title string) error {
+	head, tail := corpusMeta(corpus)
+	b := appendPBField(nil, pbCorpusFormatField, []byte(pbCorpusFormat))
+	b = appendPBField(b, pbCorpusHeaderField, []byte(metaText(head)))
+	for _, f := range corpus.Files {
+		content := corpus.Content(f)
+		var file []byte
+		file = appendPBField(file, pbFilePathField, []byte(f.Path))
+		file = appendPBField(file, pbFileLanguageField, []byte(DetectLanguage(f.Path, content)))
+		file = appendPBField(file, pbFileNoteField, []byte(f.Note))
+		file = appendPBField(file, pbFileLastCommitField, []byte(f.LastCommit))
+		file = appendPBField(file, pbFileContentField, content)
+		// An empty file is still an entry
+		b = binary.AppendUvarint(append(b, pbCorpusFilesField<<3|pbBytes), uint64(len(file)))
+		b = append(b, file...)
+	}
+	b = appendPBField(b, pbCorpusFooterField, []byte(metaText(tail)))
+
+	if _, err := w.Write(b); err != nil {
+		return fmt.Errorf("error writing output file: %w", err)
+	}
+	return nil
+}
+
+// appendPBField appends a length-delimited field, leaving it out when empty
+// as proto3 does
+func appendPBField(b []byte, field int, value []byte) []byte {
+	if len(value) == 0 {
+		return b
+	}
+	b = binary.AppendUvarint(b, uint64(field<<3|pbBytes))
+	b = binary.AppendUvarint(b, uint64(len(value)))
+	return append(b, value...)
+}
+
+// isPBCorpus reports whether decoded corpus data is a pb corpus
+func isPBCorpus(data []byte) bool {
+	return bytes.HasPrefix(data, appendPBField(nil, pbCorpusFormatField, []byte(pbCorpusFormat)))
+}
+
+// ReadPBCorpus reads a corpus written with --format pb. The files are laid
+// out as in a text corpus, so the result is used like any loaded corpus.
+func ReadPBCorpus(r io.Reader) (*Corpus, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading corpus: %w", err)
+	}
+	if !isPBCorpus(data) {
+		return nil, fmt.Errorf("error parsing pb corpus: not a %s message", pbCorpusFormat)
+	}
+	if data, err = pbToText(data); err != nil {
+		return nil, err
+	}
+	return ParseCorpus(data), nil
+}
+
+// pbToText converts a pb corpus to the text format
+func pbToText(data []byte) ([]byte, error) {
+	var header, footer string
+	var files []corpusEntry
+	err := readPBFields(data, func(field int, value []byte) error {
+		switch field {
+		case pbCorpusHeaderField:
+			header = string(value)
+		case pbCorpusFooterField:
+			footer = string(value)
+		case pbCorpusFilesField:
+			var f corpusEntry
+			err := readPBFields(value, func(field int, value []byte) error {
+				switch field {
+				case pbFilePathField:
+					f.path = string(value)
+				case pbFileNoteField:
+					f.note = string(value)
+				case pbFileLastCommitField:
+					f.lastCommit = string(value)
+				case pbFileContentField:
+					f.content = value
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			files = append(files, f)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error parsing pb corpus: %w", err)
+	}
+	return textCorpus(header, files, footer), nil
+}
+
+// readPBFields calls fn with the number and value of every length-delimited
+// field of a message. Fields of other wire types, which later versions of
+// the schema may add, are skipped.
+func readPBFields(data []byte, fn func(field int, value []byte) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return fmt.Errorf("truncated field key")
+		}
+		data = data[n:]
+
+		var size uint64
+		switch key & 7 {
+		case pbVarint:
+			if _, n = binary.Uvarint(data); n <= 0 {
+				return fmt.Errorf("truncated varint")
+			}
+			data = data[n:]
+			continue
+		case pbFixed64:
+			size = 8
+		case pbFixed32:
+			size = 4
+		case pbBytes:
+			if size, n = binary.Uvarint(data); n <= 0 {
+				return fmt.Errorf("truncated length")
+			}
+			data = data[n:]
+		default:
+			return fmt.Errorf("unsupported wire type %d", key&7)
+		}
+		if size > uint64(len(data)) {
+			return fmt.Errorf("truncated field %d", key>>3)
+		}
+		if key&7 == pbBytes {
+			if err := fn(int(key>>3), data[:size]); err != nil {
+				return err
+			}
+		}
+		data = data[size:]
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+)
+
+// isPipeOutput reports whether output is a named pipe, socket or device
+// such as /dev/stdout. These are written once, front to back, and cannot be
+// read back.
+func isPipeOutput(output string) bool {
+	info, err := os.Stat(output)
+	return err == nil && info.Mode()&(os.ModeNamedPipe|os.ModeSocket|os.ModeCharDevice) != 0
+}
+
+// validatePipeOutput rejects options that read the output back when it is
+// a pipe, and checks --flush-per-file
+func validatePipeOutput(config *Config) error {
+	if isPipeOutput(config.OutputFile) {
+		switch {
+		case config.Append:
+			return fmt.Errorf("--append cannot read back the pipe %s", config.OutputFile)
+		case config.Upload != "" || config.PostURL != "":
+			return fmt.Errorf("--upload and --post-url cannot read back the pipe %s", config.OutputFile)
+		}
+	}
+
+	if config.FlushPerFile {
+		if outputEncoding(config) != "" {
+			// The encoding holds back up to two bytes until the end
+			return fmt.Errorf("--flush-per-file cannot be combined with --base64 or --encoding")
+		}
+		if config.Verbose && !config.LowMemory {
+			return fmt.Errorf("--flush-per-file with --verbose requires --low-memory, since the summary otherwise holds back every file until the end")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"plugin"
+	"strings"
+)
+
+// TransformFunc is the signature of the Transform function a transformer
+// plugin exports. It receives a file's path in the corpus and its content,
+// and returns the content to pack.
+type TransformFunc = func(path string, content []byte) ([]byte, error)
+
+// transformSymbol is the name a transformer plugin exports its TransformFunc under
+const transformSymbol = "Transform"
+
+// transformer is a loaded transformer plugin
+type transformer struct {
+	path      string
+	transform TransformFunc
+}
+
+// validatePlugins checks that transformer plugins may be loaded. Plugins
+// run code from the config like hooks do, so they need the same consent.
+func validatePlugins(config *Config) error {
+	if len(config.Plugins) == 0 {
+		return nil
+	}
+	if !config.AllowHooks {
+		return fmt.Errorf("the config declares plugins, which run code; pass --allow-hooks to load them")
+	}
+	if config.OutputFormat == FormatLinkFarm {
+		return fmt.Errorf("plugins cannot be used with the linkfarm format, which links files unchanged")
+	}
+	for _, path := range config.Plugins {
+		if strings.EqualFold(filepath.Ext(path), ".wasm") {
+			return fmt.Errorf("unsupported plugin %s: WASI modules are not supported; build the transformer as a Go plugin or run it as a preFile hook", path)
+		}
+	}
+	return nil
+}
+
+// loadPlugins opens the config's transformer plugins, in config order
+func (p *fileProcessor) loadPlugins() error {
+	for _, path := range p.config.Plugins {
+		plug, err := plugin.Open(path)
+		if err != nil {
+			return fmt.Errorf("error loading plugin %s: %w", path, err)
+		}
+		sym, err := plug.Lookup(transformSymbol)
+		if err != nil {
+			return fmt.Errorf("error loading plugin %s: %w", path, err)
+		}
+
+		// Transform may be declared as a function or as a function variable
+		var transform TransformFunc
+		switch fn := sym.(type) {
+		case TransformFunc:
+			transform = fn
+		case *TransformFunc:
+			transform = *fn
+		default:
+			return fmt.Errorf("error loading plugin %s: %s is a %T, not a func(string, []byte) ([]byte, error)", path, transformSymbol, sym)
+		}
+		p.transformers = append(p.transformers, transformer{path: path, transform: transform})
+	}
+	return nil
+}
+
+// runPlugins passes a file's text through each transformer plugin in
+// turn. A failing plugin fails the pack, as a failing preFile hook does.
+func (p *fileProcessor) runPlugins(relPath string, text []byte) ([]byte, error) {
+	path := filepath.ToSlash(relPath)
+	for _, t := range p.transformers {
+		out, err := t.transform(path, text)
+		if err != nil {
+			return nil, fmt.Errorf("error running plugin %s on %s: %w", t.path, path, err)
+		}
+		text = out
+	}
+	return text, nil
+}
+
+// customTransforms applies the preFile hook and then the transformer
+// plugins, which see a file before cpack's own transforms
+func (p *fileProcessor) customTransforms(relPath string, text []byte) ([]byte, error) {
+	text, err := p.runPreFile(relPath, text)
+	if err != nil {
+		return nil, err
+	}
+	return p.runPlugins(relPath, text)
+}
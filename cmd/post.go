@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// postTimeout bounds the request made by --post-url
+const postTimeout = 10 * time.Minute
+
+// formatContentTypes are the media types corpora are posted as, by format
+var formatContentTypes = map[string]string{
+	FormatText:       "text/plain; charset=utf-8",
+	FormatMarkdown:   "text/markdown; charset=utf-8",
+	FormatHTML:       "text/html; charset=utf-8",
+	FormatYAML:       "application/yaml",
+	FormatPB:         "application/x-protobuf",
+	FormatParquet:    "application/vnd.apache.parquet",
+	FormatRAGJSONL:   "application/x-ndjson",
+	FormatLangChain:  "application/x-ndjson",
+	FormatLlamaIndex: "application/x-ndjson",
+}
+
+// PostOptions controls PostCorpus
+type PostOptions struct {
+	URL         string // Endpoint the corpus is posted to
+	Path        string // Corpus file to post
+	Token       string // Bearer token, no Authorization header if empty
+	ContentType string // Content-Type of the request body
+}
+
+// PostCorpus streams a corpus file as the body of an HTTP POST. Any 2xx
+// response is success.
+func PostCorpus(opts PostOptions) error {
+	file, err := os.Open(opts.Path)
+	if err != nil {
+		return fmt.Errorf("error reading corpus: %w", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("error reading corpus: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, opts.URL, file)
+	if err != nil {
+		return fmt.Errorf("error posting corpus: %w", err)
+	}
+	req.ContentLength = info.Size()
+	req.Header.Set("Content-Type", opts.ContentType)
+	if opts.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+opts.Token)
+	}
+
+	client := &http.Client{Timeout: postTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error posting corpus: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("error posting corpus to %s: unexpected status %s: %s", opts.URL, resp.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
+
+// postContentType returns the Content-Type a corpus is posted with: the
+// configured one, or else the type of the output format and encoding
+func postContentType(config *Config) string {
+	switch {
+	case config.PostContentType != "":
+		return config.PostContentType
+	case outputEncoding(config) != "":
+		return "text/plain; charset=us-ascii"
+	case config.Gzip:
+		return "application/gzip"
+	}
+	if t, ok := formatContentTypes[config.OutputFormat]; ok {
+		return t
+	}
+	return formatContentTypes[FormatText]
+}
+
+// validatePost checks that --post-url is an http or https URL and that a
+// single corpus file is written to post
+func validatePost(config *Config) error {
+	if config.PostURL == "" {
+		if config.PostToken != "" || config.PostContentType != "" {
+			return fmt.Errorf("--post-token and --post-content-type require --post-url")
+		}
+		return nil
+	}
+	u, err := url.Parse(config.PostURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("--post-url must be an http or https URL, got %q", config.PostURL)
+	}
+	if config.OutputFormat == FormatLinkFarm || config.PerWorkspace || config.SplitByDir > 0 || isObjectURL(config.OutputFile) {
+		return fmt.Errorf("--post-url requires a single local corpus file; it cannot be combined with linkfarm, --per-workspace, --split-by-dir or object-store output")
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import "sort"
+
+// preset is a curated selection for one kind of project
+type preset struct {
+	includeGlobs []string
+	includeNames []string
+	excludeGlobs []string // Added to the default exclude globs
+}
+
+var presets = map[string]preset{
+	"go": {
+		includeGlobs: []string{"**/*.go", "**/*.md", "**/*.proto", "**/*.sql"},
+		includeNames: []string{"go.mod", "go.work", "Makefile", "Dockerfile", ".golangci.yml", ".golangci.yaml"},
+		excludeGlobs: []string{"**/*.pb.go", "**/*_mock.go", "**/mock_*.go"},
+	},
+	"python": {
+		includeGlobs: []string{"**/*.py", "**/*.pyi", "**/*.md", "**/*.rst", "**/*.toml", "**/*.cfg", "**/*.ini"},
+		includeNames: []string{"requirements*.txt", "Pipfile", "Makefile", "Dockerfile", "tox.ini", "setup.py"},
+		excludeGlobs: []string{"**/.venv/**", "**/venv/**", "**/*.egg-info/**", "**/.tox/**",
+			"**/.mypy_cache/**", "**/.pytest_cache/**", "**/.ruff_cache/**"},
+	},
+	"web": {
+		includeGlobs: []string{"**/*.js", "**/*.mjs", "**/*.cjs", "**/*.ts", "**/*.tsx", "**/*.jsx",
+			"**/*.vue", "**/*.svelte", "**/*.html", "**/*.css", "**/*.scss", "**/*.less", "**/*.md"},
+		includeNames: []string{"package.json", "tsconfig*.json", "vite.config.*", "webpack.config.*",
+			"next.config.*", ".eslintrc*", ".prettierrc*"},
+		excludeGlobs: []string{"**/.next/**", "**/.nuxt/**", "**/.svelte-kit/**", "**/coverage/**",
+			"**/.cache/**", "**/public/**"},
+	},
+	"docs": {
+		includeGlobs: []string{"**/*.md", "**/*.mdx", "**/*.rst", "**/*.adoc", "**/*.txt"},
+		includeNames: []string{"README*", "LICENSE", "CHANGELOG*", "CONTRIBUTING*", "NOTICE"},
+		excludeGlobs: []string{"**/requirements*.txt", "**/CMakeLists.txt"},
+	},
+	"infra": {
+		includeGlobs: []string{"**/*.tf", "**/*.tfvars", "**/*.hcl", "**/*.yaml", "**/*.yml",
+			"**/*.sh", "**/*.nix", "**/*.cue", "**/*.jsonnet", "**/*.libsonnet"},
+		includeNames: []string{"Dockerfile", "Dockerfile.*", "*.dockerfile", "Containerfile",
+			"Jenkinsfile", "Makefile", "Vagrantfile", "Procfile", ".dockerignore"},
+		excludeGlobs: []string{"**/.terraform/**", "**/*.tfstate", "**/*.tfstate.*", "**/charts/*/charts/**"},
+	},
+}
+
+// lockfileGlobs match dependency lockfiles
+var lockfileGlobs = []string{
+	"**/package-lock.json", "**/npm-shrinkwrap.json", "**/yarn.lock", "**/pnpm-lock.yaml",
+	"**/bun.lockb", "**/go.sum", "**/Cargo.lock", "**/poetry.lock", "**/Pipfile.lock",
+	"**/uv.lock", "**/composer.lock", "**/Gemfile.lock", "**/mix.lock", "**/pubspec.lock",
+	"**/Podfile.lock", "**/flake.lock",
+}
+
+// DefaultLockfileGlobs returns the files --no-lockfiles excludes: dependency
+// lockfiles and binary assets, which cost many tokens and carry little meaning
+func DefaultLockfileGlobs() []string {
+	return append(append([]string{}, lockfileGlobs...),
+		// Images
+		"**/*.png", "**/*.jpg", "**/*.jpeg", "**/*.gif", "**/*.bmp", "**/*.ico", "**/*.webp",
+		"**/*.avif", "**/*.tiff", "**/*.psd",
+		// Fonts
+		"**/*.woff", "**/*.woff2", "**/*.ttf", "**/*.otf", "**/*.eot",
+		// Binaries and archives
+		"**/*.exe", "**/*.dll", "**/*.so", "**/*.dylib", "**/*.a", "**/*.o", "**/*.class",
+		"**/*.jar", "**/*.wasm", "**/*.zip", "**/*.tar", "**/*.gz", "**/*.tgz", "**/*.7z",
+		"**/*.mp3", "**/*.mp4", "**/*.mov", "**/*.sqlite", "**/*.db",
+	)
+}
+
+// PresetNames returns the available preset names in sorted order
+func PresetNames() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyLockfileExcludes adds the lockfile and asset globs to the exclude
+// globs when NoLockfiles is set. LockfileGlobs replaces the built-in set.
+func applyLockfileExcludes(config Config) Config {
+	if !config.NoLockfiles {
+		return config
+	}
+
+	globs := config.LockfileGlobs
+	if globs == nil {
+		globs = DefaultLockfileGlobs()
+	}
+	config.ExcludeGlobs = append(append([]string{}, config.ExcludeGlobs...), globs...)
+	return config
+}
+
+// applyPreset fills unset selection fields from the named preset. Preset
+// exclude globs extend the default exclude globs.
+func applyPreset(config Config) Config {
+	p, ok := presets[config.Preset]
+	if !ok {
+		return config
+	}
+
+	if config.IncludeGlobs == nil {
+		config.IncludeGlobs = append([]string{}, p.includeGlobs...)
+		if config.IncludeNames == nil {
+			config.IncludeNames = append([]string{}, p.includeNames...)
+		}
+	}
+	if config.ExcludeGlobs == nil {
+		config.ExcludeGlobs = append(DefaultConfig().ExcludeGlobs, p.excludeGlobs...)
+	}
+
+	return config
+}
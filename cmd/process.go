@@ -1,15 +1,16 @@
 package cmd
 
 import (
+	"bufio"
 	"bytes"
-	"compress/gzip"
-	"encoding/base64"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
-	"sort"
 	"strings"
 	"time"
 )
@@ -18,116 +19,409 @@ import (
 type Summary struct {
 	TotalFiles     int
 	ProcessedFiles []string
-	SkippedFiles   []string
+	SkippedFiles   []SkippedFile
 	TotalBytes     int64
+	TotalTokens    int // Counted only when a cost model is set
 	StartTime      time.Time
 	EndTime        time.Time
+
+	files []FileStats // Packed files for the breakdowns of a verbose summary
 }
 
 type fileProcessor struct {
 	config         *Config
 	outputFile     io.Writer
-	contentBuffer  *bytes.Buffer
+	contentBuffer  *spillBuffer
 	processedFiles map[string]bool
 	summary        *Summary
+	files          []fileEntry
+	skipPaths      map[string]bool
+	paths          *pathMapper
+	manifest       []ManifestEntry
+	index          []indexEntry // Files as packed, for the index that closes a text corpus
+	attrRules      []attrRule
+	annotations    map[string]string
+	commits        map[string]*CommitInfo // Last commit per file, filled as files are written
+	visitedDirs    map[dirID]bool
+	group          *dirGroup       // Files packed by this --split-by-dir output, all files if nil
+	minified       map[string]bool // Files packed as a stub under MinifiedStub
+	content        *contentFilter
+	window         *timeWindow            // Modification times a file must fall in, any if nil
+	commitTimes    map[string]time.Time   // Last commit per file for --git-dates, read on first use
+	transformers   []transformer          // Transformer plugins, in config order
+	symbols        []fileSymbols          // Definitions per file for --symbols
+	digests        map[string]*fileDigest // Hashes and token counts worked out ahead, by absolute path
+	queue          chan *fileDigest       // Prefetched files in corpus order, for the packer to take
+}
+
+// fileEntry is a file selected for packing
+type fileEntry struct {
+	relPath string
+	absPath string
+	omitted int // Files left out of this file's directory after it
 }
 
 // ProcessDirectory processes files in the given directory according to the config
+//
+// Deprecated: library code should pack with cpack.New, whose options can
+// grow without changing this Config. ProcessDirectory stays for the CLI.
 func ProcessDirectory(config Config) error {
+	config, err := prepareConfig(config)
+	if err != nil {
+		return err
+	}
+
+	if config.Pprof != "" {
+		stopProfile, err := startProfile(&config)
+		if err != nil {
+			return err
+		}
+		defer stopProfile()
+	}
+
+	// A run counts as one pack, however many corpora it writes
+	start := time.Now()
+	err = packDirectory(config)
+	metrics.recordPack(time.Since(start), err)
+	if config.MetricsFile != "" {
+		if metricsErr := writeMetricsFile(config.MetricsFile); metricsErr != nil && err == nil {
+			err = metricsErr
+		}
+	}
+	return err
+}
+
+// prepareConfig merges the auto-detected config file, applies defaults,
+// resolves the output path and validates the result
+func prepareConfig(config Config) (Config, error) {
 	// Try to load default config file if it exists
-	if autoConfig, err := tryLoadDefaultConfig(config.InputDir); err == nil {
-		config = MergeConfig(config, autoConfig)
+	config, err := mergeAutoConfig(config)
+	if err != nil {
+		return config, err
 	}
 
 	// Apply defaults for empty fields
 	config = ApplyDefaults(config)
 
 	// Get current working directory
-	cwd, cwdErr := os.Getwd()
-	if cwdErr != nil {
-		return fmt.Errorf("error getting current working directory: %w", cwdErr)
+	cwd, err := os.Getwd()
+	if err != nil {
+		return config, fmt.Errorf("error getting current working directory: %w", err)
 	}
 
 	// Make output file path relative to current working directory if not absolute
-	if !filepath.IsAbs(config.OutputFile) {
-		config.OutputFile = filepath.Join(cwd, config.OutputFile)
+	if !isObjectURL(config.OutputFile) {
+		config.OutputFile = resolvePath(cwd, config.OutputFile)
 	}
 
 	// Validate input directory first
 	if err := validateConfig(&config); err != nil {
-		return err
+		return config, err
 	}
+	return config, nil
+}
 
-	// Create output directory if needed
-	outputDir := filepath.Dir(config.OutputFile)
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		// If it's a read-only filesystem error, wrap it as an output file error
-		if strings.Contains(err.Error(), "read-only file system") {
-			return fmt.Errorf("error creating output file: %w", err)
+// packDirectory packs a validated config into one corpus, a corpus per
+// workspace member or directory group, or a link farm, and then runs the
+// post-pack hook, upload and webhook
+func packDirectory(config Config) error {
+	// Each workspace member is packed by its own run
+	if config.PerWorkspace {
+		return processWorkspaces(config)
+	}
+
+	// Each directory group is packed into its own corpus
+	if config.SplitByDir > 0 {
+		return processSplitByDir(config)
+	}
+
+	// Link farms write a directory tree instead of a corpus file
+	if config.OutputFormat == FormatLinkFarm {
+		if err := processLinkFarm(config); err != nil {
+			return err
+		}
+		return runPostPack(&config, config.OutputFile)
+	}
+
+	if err := packCorpus(config, nil); err != nil {
+		return err
+	}
+	if err := runPostPack(&config, config.OutputFile); err != nil {
+		return err
+	}
+	if config.Upload != "" {
+		id, err := UploadCorpus(UploadOptions{Provider: config.Upload, Path: config.OutputFile})
+		if err != nil {
+			return err
 		}
-		return fmt.Errorf("error creating output directory: %w", err)
+		// Only the id goes to stdout, so scripts can capture it
+		fmt.Println(id)
+	}
+	if config.PostURL != "" {
+		return PostCorpus(PostOptions{
+			URL:         config.PostURL,
+			Path:        config.OutputFile,
+			Token:       config.PostToken,
+			ContentType: postContentType(&config),
+		})
+	}
+	return nil
+}
+
+// packCorpus writes the corpus for a resolved config. With a group, only
+// the files of that --split-by-dir group are packed.
+func packCorpus(config Config, group *dirGroup) error {
+	if _, ok := corpusRenderers[config.OutputFormat]; ok {
+		return packRendered(config, group)
 	}
 
 	var (
-		outputFile   *os.File
-		gzipWriter   *gzip.Writer
-		base64Writer io.WriteCloser
+		err          error
+		outputFile   io.WriteCloser
+		gzipWriter   gzipStream
+		encodeWriter io.WriteCloser
 		writer       io.Writer
 	)
 
-	outputFile, err := os.Create(config.OutputFile)
+	// Read the corpus being appended to, which the new one replaces
+	var existing *Corpus
+	if config.Append {
+		if existing, err = loadExistingCorpus(config.OutputFile); err != nil {
+			return err
+		}
+	}
+
+	processor := newFileProcessor(&config)
+	processor.group = group
+	// Never pack the corpus at the output path
+	processor.skipPath(config.OutputFile)
+	for _, output := range config.runOutputs {
+		processor.skipPath(output)
+	}
+	if config.AnonymizeMapFile != "" {
+		// Never pack the mapping that reverses the anonymization
+		processor.skipPath(config.AnonymizeMapFile)
+	}
+	for _, sidecar := range []string{config.ReportFile, config.ManifestFile} {
+		if sidecar != "" {
+			processor.skipPath(sidecar)
+		}
+	}
+	if config.AnnotationsFile != "" {
+		processor.skipPath(config.AnnotationsFile)
+	}
+	if config.Pprof != "" {
+		processor.skipPath(profilePath(&config))
+	}
+	if config.MetricsFile != "" {
+		processor.skipPath(config.MetricsFile)
+	}
+	if err := processor.loadAnnotations(); err != nil {
+		return err
+	}
+	if err := processor.loadPlugins(); err != nil {
+		return err
+	}
+
+	walkStart := time.Now()
+	if err := processor.collectFiles(); err != nil {
+		return err
+	}
+	processor.orderFiles()
+	processor.limitFilesPerDir()
+	config.phases.add(phaseWalk, walkStart)
+
+	tokenizeStart := time.Now()
+	processor.precountTokens()
+	processor.applyBudget()
+	config.phases.add(phaseTokenize, tokenizeStart)
+	// Limits, secrets and disk space are checked before the output is
+	// opened, so a failed check leaves any earlier corpus in place
+	if err := processor.applyLimits(); err != nil {
+		return err
+	}
+	if err := processor.scanSecrets(); err != nil {
+		return err
+	}
+	if err := processor.checkDiskSpace(); err != nil {
+		return err
+	}
+
+	outputFile, err = createOutput(config.OutputFile)
 	if err != nil {
-		return fmt.Errorf("error creating output file: %w", err)
+		return err
 	}
 	defer outputFile.Close()
 
 	writer = outputFile
 
 	// Create writer chain in correct order
-	if config.Base64 {
-		if !config.Gzip {
-			return fmt.Errorf("--base64 requires --gzip")
-		}
-		base64Writer = base64.NewEncoder(base64.StdEncoding, outputFile)
-		writer = base64Writer
+	encoding := outputEncoding(&config)
+	if encoding != "" {
+		encodeWriter = newTextEncoder(outputFile, encoding, config.Wrap)
+		writer = encodeWriter
 	}
 
 	if config.Gzip {
-		gzipWriter = gzip.NewWriter(writer)
+		gzipWriter = newGzipStream(writer, &config)
 		writer = gzipWriter
 	}
 
-	// If verbose, write to buffer first
-	var contentBuffer *bytes.Buffer
-	if config.Verbose {
-		contentBuffer = &bytes.Buffer{}
+	// The frontmatter counts files and tokens, so the corpus after it is
+	// held back until every file is packed
+	var frontmatterBody *spillBuffer
+	var corpusWriter io.Writer
+	if config.Frontmatter {
+		maxMemory, _ := parseSize(config.MaxMemory)
+		frontmatterBody = newSpillBuffer(maxMemory)
+		defer frontmatterBody.Close()
+		corpusWriter = writer
+		writer = frontmatterBody
 	}
 
-	processor := &fileProcessor{
-		config:         &config,
-		outputFile:     writer,
-		contentBuffer:  contentBuffer,
-		processedFiles: make(map[string]bool),
-		summary: &Summary{
-			StartTime: time.Now(),
-		},
+	// If verbose, write to buffer first (low-memory mode streams instead).
+	// Past --max-memory the buffer spills to a temporary file.
+	var contentBuffer *spillBuffer
+	if config.Verbose && !config.LowMemory {
+		// Sizes were checked by validateConfig
+		maxMemory, _ := parseSize(config.MaxMemory)
+		contentBuffer = newSpillBuffer(maxMemory)
+		defer contentBuffer.Close()
 	}
 
-	err = filepath.Walk(config.InputDir, processor.processPath)
-	if err != nil {
-		return err
+	processor.outputFile = writer
+	processor.contentBuffer = contentBuffer
+
+	// A corpus without files stays empty
+	stamped := stampsCorpus(&config) && len(processor.files) > 0
+	if stamped {
+		if err := writeString(writer, formatVersionLine()); err != nil {
+			return fmt.Errorf("error writing version line: %w", err)
+		}
 	}
 
-	processor.summary.EndTime = time.Now()
+	if config.Instructions != "" {
+		if err := writeString(writer, formatInstructions(config.Instructions)); err != nil {
+			return fmt.Errorf("error writing instructions: %w", err)
+		}
+	}
 
-	if config.Verbose {
+	// The git header names the repository and its remote, so anonymized
+	// corpora leave it out
+	if !config.NoGitHeader && !anonymizesPaths(&config) {
+		if info, ok := ReadGitInfo(config.InputDir); ok {
+			if err := writeString(writer, formatGitInfo(info)); err != nil {
+				return fmt.Errorf("error writing git header: %w", err)
+			}
+		}
+	}
+
+	// The import graph maps the Go packages before any of their files
+	if config.ImportGraph || config.ImportGraphMermaid {
+		if graph := processor.formatImportGraph(config.ImportGraphMermaid); graph != "" {
+			if err := writeString(writer, graph); err != nil {
+				return fmt.Errorf("error writing import graph: %w", err)
+			}
+		}
+	}
+
+	// In low-memory mode the summary is built from file metadata up front,
+	// so file content never has to be held back behind it. Packing counts
+	// the files again, so it starts from the summary as it was before.
+	if config.Verbose && config.LowMemory {
+		packed := *processor.summary
+		processor.summarizeFromStat()
 		if err := processor.writeSummary(); err != nil {
 			return err
 		}
+		*processor.summary = packed
+	}
+
+	stopPrefetch := processor.prefetch()
+	defer stopPrefetch()
+	for _, entry := range processor.files {
+		if err := processor.processFile(entry.relPath, entry.absPath); err != nil {
+			return err
+		}
+		if entry.omitted > 0 {
+			if err := processor.writeOmittedNote(entry); err != nil {
+				return fmt.Errorf("error writing omitted files note: %w", err)
+			}
+		}
+		// A reader of the output sees every file complete as soon as it is packed
+		if config.FlushPerFile && gzipWriter != nil {
+			if err := gzipWriter.Flush(); err != nil {
+				return fmt.Errorf("error flushing gzip writer: %w", err)
+			}
+		}
+	}
 
-		if _, err := writer.Write(contentBuffer.Bytes()); err != nil {
+	processor.summary.EndTime = time.Now()
+
+	if contentBuffer != nil {
+		if config.CostModel != "" {
+			tokenizeStart := time.Now()
+			err := contentBuffer.chunks(func(chunk []byte) {
+				processor.summary.TotalTokens += processor.countTokens(chunk)
+			})
+			if err != nil {
+				return err
+			}
+			config.phases.add(phaseTokenize, tokenizeStart)
+		}
+
+		writeStart := time.Now()
+		if err := processor.writeSummary(); err != nil {
+			return err
+		}
+		if _, err := contentBuffer.WriteTo(writer); err != nil {
 			return fmt.Errorf("error writing file content: %w", err)
 		}
+		config.phases.add(phaseWrite, writeStart)
+	}
+
+	// The index follows the last file, so cpack validate can check every one
+	writeStart := time.Now()
+	if stamped {
+		if err := writeString(writer, formatIndex(processor.index)); err != nil {
+			return fmt.Errorf("error writing index: %w", err)
+		}
+	}
+
+	if len(processor.symbols) > 0 {
+		if err := writeString(writer, formatSymbols(processor.symbols)); err != nil {
+			return fmt.Errorf("error writing symbols: %w", err)
+		}
+	}
+
+	if err := processor.writeGitLog(writer); err != nil {
+		return err
+	}
+
+	if config.AnonymizeMapFile != "" && processor.paths != nil {
+		if err := processor.paths.writeMapping(config.AnonymizeMapFile); err != nil {
+			return err
+		}
+	}
+
+	if err := processor.writeReports(); err != nil {
+		return err
+	}
+
+	// A corpus without files stays empty
+	if frontmatterBody != nil {
+		if len(processor.files) > 0 {
+			frontmatter, err := processor.frontmatter(frontmatterBody)
+			if err != nil {
+				return err
+			}
+			if err := writeString(corpusWriter, frontmatter.format()); err != nil {
+				return fmt.Errorf("error writing frontmatter: %w", err)
+			}
+		}
+		if _, err := frontmatterBody.WriteTo(corpusWriter); err != nil {
+			return fmt.Errorf("error writing corpus after frontmatter: %w", err)
+		}
 	}
 
 	// Close in reverse order
@@ -137,19 +431,50 @@ func ProcessDirectory(config Config) error {
 		}
 	}
 
-	if config.Base64 {
-		if err := base64Writer.Close(); err != nil {
-			return fmt.Errorf("error closing base64 encoder: %w", err)
+	if encodeWriter != nil {
+		if err := encodeWriter.Close(); err != nil {
+			return fmt.Errorf("error closing %s encoder: %w", encoding, err)
+		}
+	}
+
+	// The merged corpus replaces the existing one only once it is complete
+	if existing != nil {
+		if err := appendToCorpus(outputFile.(*replaceFile).Name(), existing, config.Gzip, encoding, config.Wrap); err != nil {
+			return err
+		}
+	}
+
+	if err := finishOutput(outputFile); err != nil {
+		return err
+	}
+	config.phases.add(phaseWrite, writeStart)
+
+	if config.Durable {
+		if err := syncOutput(config.OutputFile); err != nil {
+			return err
+		}
+	}
+	if config.Verify {
+		if err := processor.verifyOutput(); err != nil {
+			return err
 		}
 	}
 
+	// The cost estimate belongs to the summary, or to stderr without one
+	if config.CostModel != "" && !config.Verbose {
+		fmt.Fprint(os.Stderr, processor.costSummary())
+	}
+
+	metrics.recordCorpus(processor.summary)
 	return nil
 }
 
 // ProcessDirectoryWithConfigFile processes files using configuration from a file
+//
+// Deprecated: library code should use cpack.New with cpack.WithConfigFile.
 func ProcessDirectoryWithConfigFile(configPath string, overrideConfig Config) error {
 	// Load config from file
-	fileConfig, err := LoadConfigFromFile(configPath)
+	fileConfig, err := LoadConfigProfile(configPath, overrideConfig.Profile)
 	if err != nil {
 		return fmt.Errorf("error loading config file: %w", err)
 	}
@@ -163,61 +488,102 @@ func ProcessDirectoryWithConfigFile(configPath string, overrideConfig Config) er
 	// Create a new config that will hold the merged values
 	mergedConfig := *fileConfig
 
-	// Handle input directory
-	if overrideConfig.InputDir != "" {
-		mergedConfig.InputDir = overrideConfig.InputDir
-	} else if !filepath.IsAbs(mergedConfig.InputDir) {
-		// Make input directory relative to current working directory
-		mergedConfig.InputDir = filepath.Join(cwd, mergedConfig.InputDir)
+	// Paths from the file are relative to the current working directory
+	if overrideConfig.InputDir == "" {
+		mergedConfig.InputDir = resolvePath(cwd, stripLongPathPrefix(mergedConfig.InputDir))
 	}
-
-	// Handle output file path
-	if overrideConfig.OutputFile != "" {
-		mergedConfig.OutputFile = overrideConfig.OutputFile
-	} else if !filepath.IsAbs(mergedConfig.OutputFile) {
-		// Make output file relative to current working directory
-		mergedConfig.OutputFile = filepath.Join(cwd, mergedConfig.OutputFile)
+	if overrideConfig.OutputFile == "" && !isObjectURL(mergedConfig.OutputFile) {
+		mergedConfig.OutputFile = resolvePath(cwd, stripLongPathPrefix(mergedConfig.OutputFile))
 	}
 
+	// Every setting the caller gives takes precedence over the file
+	overrideFields(&mergedConfig, overrideConfig)
+
 	// Create output directory if needed
-	outputDir := filepath.Dir(mergedConfig.OutputFile)
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		// If it's a read-only filesystem error, wrap it as an output file error
-		if strings.Contains(err.Error(), "read-only file system") {
-			return fmt.Errorf("error creating output file: %w", err)
+	if !isObjectURL(mergedConfig.OutputFile) {
+		outputDir := filepath.Dir(mergedConfig.OutputFile)
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			// If it's a read-only filesystem error, wrap it as an output file error
+			if strings.Contains(err.Error(), "read-only file system") {
+				return fmt.Errorf("error creating output file: %w", err)
+			}
+			return fmt.Errorf("error creating output directory: %w", err)
 		}
-		return fmt.Errorf("error creating output directory: %w", err)
 	}
 
-	// Handle include patterns - override takes precedence over file config
-	if len(overrideConfig.IncludeGlobs) > 0 {
-		mergedConfig.IncludeGlobs = overrideConfig.IncludeGlobs
-	}
+	// Process with merged config
+	return ProcessDirectory(mergedConfig)
+}
 
-	// Handle exclude patterns - override takes precedence over file config
-	if len(overrideConfig.ExcludeGlobs) > 0 {
-		mergedConfig.ExcludeGlobs = overrideConfig.ExcludeGlobs
+// overrideFields copies every field set in override onto config, so new
+// settings reach it without being listed here. Booleans can only be turned
+// on, and empty lists and maps leave the file's value.
+func overrideFields(config *Config, override Config) {
+	dst := reflect.ValueOf(config).Elem()
+	src := reflect.ValueOf(override)
+	for i := 0; i < src.NumField(); i++ {
+		field := src.Field(i)
+		if !dst.Field(i).CanSet() || field.IsZero() {
+			continue
+		}
+		if (field.Kind() == reflect.Slice || field.Kind() == reflect.Map) && field.Len() == 0 {
+			continue
+		}
+		dst.Field(i).Set(field)
 	}
+}
 
-	// Handle boolean flags - override takes precedence over file config
-	if overrideConfig.Verbose {
-		mergedConfig.Verbose = true
+// newFileProcessor creates a processor for the given config
+func newFileProcessor(config *Config) *fileProcessor {
+	return &fileProcessor{
+		config:         config,
+		processedFiles: make(map[string]bool),
+		skipPaths:      make(map[string]bool),
+		visitedDirs:    make(map[dirID]bool),
+		minified:       make(map[string]bool),
+		paths:          newPathMapper(config),
+		content:        newContentFilter(config),
+		window:         newTimeWindow(config),
+		summary: &Summary{
+			StartTime: time.Now(),
+		},
 	}
-	if overrideConfig.Compress {
-		mergedConfig.Compress = true
+}
+
+// skipPath excludes a file or directory from the walk by path
+func (p *fileProcessor) skipPath(path string) {
+	if absPath, err := filepath.Abs(path); err == nil {
+		p.skipPaths[absPath] = true
 	}
-	if overrideConfig.MaxCompress {
-		mergedConfig.MaxCompress = true
+}
+
+// selectFiles resolves the config the same way ProcessDirectory does and
+// returns a processor holding the selected files, without writing any output
+func selectFiles(config Config) (*fileProcessor, error) {
+	config, err := mergeAutoConfig(config)
+	if err != nil {
+		return nil, err
 	}
-	if overrideConfig.Gzip {
-		mergedConfig.Gzip = true
+	config = ApplyDefaults(config)
+
+	if err := validateConfig(&config); err != nil {
+		return nil, err
 	}
-	if overrideConfig.Base64 {
-		mergedConfig.Base64 = true
+
+	processor := newFileProcessor(&config)
+	if err := processor.collectFiles(); err != nil {
+		return nil, err
 	}
 
-	// Process with merged config
-	return ProcessDirectory(mergedConfig)
+	return processor, nil
+}
+
+// collectFiles walks the input directory and records the files selected for packing
+func (p *fileProcessor) collectFiles() error {
+	if err := filepath.Walk(p.config.InputDir, p.processPath); err != nil {
+		return err
+	}
+	return p.filterReachable()
 }
 
 func (p *fileProcessor) processPath(path string, info os.FileInfo, err error) error {
@@ -247,19 +613,56 @@ func (p *fileProcessor) processPath(path string, info os.FileInfo, err error) er
 		return nil
 	}
 
+	return p.visit(absPath, relPath, info)
+}
+
+// visit handles one walked path. relPath is where the path appears in the
+// corpus, which differs from absPath under a followed directory symlink.
+func (p *fileProcessor) visit(absPath, relPath string, info os.FileInfo) error {
 	if p.processedFiles[relPath] {
 		return nil
 	}
 
+	if p.skipPaths[absPath] {
+		if info.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		return p.visitSymlink(absPath, relPath)
+	}
+
 	if info.IsDir() {
-		return p.processDirectory(relPath)
+		if err := p.processDirectory(relPath); err != nil {
+			return err
+		}
+		if p.skipNestedRepo(absPath, relPath) {
+			return filepath.SkipDir
+		}
+		if p.visitDir(absPath, info) {
+			return filepath.SkipDir
+		}
+		if !p.config.NoGitAttributes {
+			p.loadGitAttributes(absPath, relPath)
+		}
+		return nil
 	}
 
-	return p.processFile(relPath, absPath)
+	return p.selectFile(relPath, absPath)
 }
 
 func (p *fileProcessor) processDirectory(relPath string) error {
-	if p.shouldIgnoreDir(relPath) {
+	if p.belowMaxDepth(relPath) {
+		return filepath.SkipDir
+	}
+
+	if p.group != nil && !p.group.walksDir(relPath) {
+		return filepath.SkipDir
+	}
+
+	if p.shouldIgnoreDir(relPath) || p.hiddenExcluded(relPath, true) {
 		return filepath.SkipDir
 	}
 
@@ -273,8 +676,9 @@ func (p *fileProcessor) processDirectory(relPath string) error {
 // matchGlobPattern checks if a path matches a glob pattern, properly handling ** patterns
 func matchGlobPattern(pattern, path string) (bool, error) {
 	// Convert pattern to regex
-	pattern = filepath.Clean(pattern)
-	path = filepath.Clean(path)
+	// Corpus paths use forward slashes on every OS, and patterns may use either
+	pattern = filepath.ToSlash(filepath.Clean(normalizeGlob(pattern)))
+	path = filepath.ToSlash(filepath.Clean(path))
 
 	// Make file extensions case insensitive by converting both to lowercase
 	// Only do this for the extension part to preserve case sensitivity for directories
@@ -301,6 +705,9 @@ func matchGlobPattern(pattern, path string) (bool, error) {
 
 	// Ensure pattern matches the entire path
 	regexPattern = "^" + regexPattern + "$"
+	if caseInsensitivePaths {
+		regexPattern = "(?i)" + regexPattern
+	}
 
 	// Compile and match
 	regex, err := regexp.Compile(regexPattern)
@@ -374,35 +781,330 @@ func writeString(w io.Writer, s string) error {
 	return err
 }
 
-func (p *fileProcessor) processFile(relPath, path string) error {
+// belowMaxDepth reports whether the files in a directory lie deeper than
+// MaxDepth, where files in the input directory are at depth 1
+func (p *fileProcessor) belowMaxDepth(relPath string) bool {
+	if p.config.MaxDepth <= 0 || relPath == "." {
+		return false
+	}
+	return strings.Count(filepath.ToSlash(relPath), "/")+1 >= p.config.MaxDepth
+}
+
+// defaultOutputNames are the corpora a run writes without --output
+var defaultOutputNames = map[string]bool{"corpus-out.txt": true, "corpus-out.txt.gz": true}
+
+// previousCorpusGlob matches corpora left by earlier runs with the default
+// output name, including renamed copies like corpus-out-old.txt
+const previousCorpusGlob = "corpus-out*"
+
+// stampScanLen is how far into a file the corpus stamp is looked for, past
+// any frontmatter
+const stampScanLen = 4096
+
+// isPreviousCorpus reports whether a file is an earlier run's output: one
+// with a default output name, or a corpus-out* file carrying the corpus
+// stamp. Sources that merely share the prefix, like corpus-outline.md, are
+// not.
+func isPreviousCorpus(path string) bool {
+	name := filepath.Base(path)
+	if defaultOutputNames[name] {
+		return true
+	}
+	if matched, _ := filepath.Match(previousCorpusGlob, name); !matched {
+		return false
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	head := make([]byte, stampScanLen)
+	n, _ := io.ReadFull(f, head)
+	return bytes.Contains(head[:n], []byte(versionMarker))
+}
+
+// selectFile records a file for packing if it passes the include and exclude rules
+func (p *fileProcessor) selectFile(relPath, path string) error {
+	// Outputs of the other groups are neither packed nor listed as skipped
+	if p.group != nil && (!p.group.holdsFile(relPath) || p.group.isOutput(path)) {
+		return nil
+	}
+	if isPreviousCorpus(path) {
+		p.summary.SkippedFiles = append(p.summary.SkippedFiles, SkippedFile{Path: p.displayPath(relPath), Reason: "previous corpus"})
+		return nil
+	}
 	if !p.isValidFile(relPath, path) {
-		p.summary.SkippedFiles = append(p.summary.SkippedFiles, relPath)
+		p.summary.SkippedFiles = append(p.summary.SkippedFiles, SkippedFile{Path: p.displayPath(relPath)})
+		return nil
+	}
+	if reason := p.testReason(relPath); reason != "" {
+		p.summary.SkippedFiles = append(p.summary.SkippedFiles, SkippedFile{Path: p.displayPath(relPath), Reason: reason})
+		return nil
+	}
+	if reason := p.sizeReason(path); reason != "" {
+		p.summary.SkippedFiles = append(p.summary.SkippedFiles, SkippedFile{Path: p.displayPath(relPath), Reason: reason})
+		return nil
+	}
+	if reason := p.documentReason(relPath); reason != "" {
+		p.summary.SkippedFiles = append(p.summary.SkippedFiles, SkippedFile{Path: p.displayPath(relPath), Reason: reason})
 		return nil
 	}
+	if reason := p.linguistReason(relPath); reason != "" {
+		p.summary.SkippedFiles = append(p.summary.SkippedFiles, SkippedFile{Path: p.displayPath(relPath), Reason: reason})
+		return nil
+	}
+	if reason := p.ageReason(relPath, path); reason != "" {
+		p.summary.SkippedFiles = append(p.summary.SkippedFiles, SkippedFile{Path: p.displayPath(relPath), Reason: reason})
+		return nil
+	}
+	if p.config.SkipGenerated && isGeneratedFile(path) {
+		p.summary.SkippedFiles = append(p.summary.SkippedFiles, SkippedFile{Path: p.displayPath(relPath), Reason: "generated"})
+		return nil
+	}
+	if p.config.Minified != MinifiedKeep && isMinifiedFile(relPath, path) {
+		if p.config.Minified != MinifiedStub {
+			p.summary.SkippedFiles = append(p.summary.SkippedFiles, SkippedFile{Path: p.displayPath(relPath), Reason: "minified"})
+			return nil
+		}
+		p.minified[relPath] = true
+	}
+	if reason := p.content.reason(relPath, path); reason != "" {
+		p.summary.SkippedFiles = append(p.summary.SkippedFiles, SkippedFile{Path: p.displayPath(relPath), Reason: reason})
+		return nil
+	}
+
+	p.files = append(p.files, fileEntry{relPath: relPath, absPath: path})
+	// Each path is visited once, so low-memory mode skips the dedup table
+	if !p.config.LowMemory {
+		p.processedFiles[relPath] = true
+	}
+	return nil
+}
+
+// summarizeFromStat fills the summary from file sizes without reading content
+func (p *fileProcessor) summarizeFromStat() {
+	for _, entry := range p.files {
+		info, err := os.Stat(entry.absPath)
+		if err != nil {
+			p.summary.SkippedFiles = append(p.summary.SkippedFiles, SkippedFile{Path: p.displayPath(entry.relPath), Reason: "read error"})
+			continue
+		}
+		p.summary.ProcessedFiles = append(p.summary.ProcessedFiles, p.displayPath(entry.relPath))
+		p.summary.TotalBytes += info.Size()
+		tokens, _ := p.fileTokens(entry.absPath)
+		if p.config.CostModel != "" {
+			p.summary.TotalTokens += tokens
+		}
+		p.countForSummary(p.displayPath(entry.relPath), DetectLanguage(entry.relPath, nil), info.Size(), tokens)
+	}
+	p.summary.EndTime = time.Now()
+}
+
+// streamFile copies a file to the output without loading it into memory
+func (p *fileProcessor) streamFile(relPath, path string) error {
+	name := p.displayPath(relPath)
 
-	content, err := os.ReadFile(path)
+	f, err := os.Open(path)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", path, err)
-		p.summary.SkippedFiles = append(p.summary.SkippedFiles, relPath+" (read error)")
+		p.summary.SkippedFiles = append(p.summary.SkippedFiles, SkippedFile{Path: name, Reason: "read error"})
 		return nil
 	}
+	defer f.Close()
+
+	// Files too large for --max-memory stream into the verbose buffer
+	var out io.Writer = p.outputFile
+	if p.contentBuffer != nil {
+		out = p.contentBuffer
+	}
+
+	reader := bufio.NewReaderSize(f, sniffLen)
+	head, _ := reader.Peek(sniffLen)
+	language := DetectLanguage(relPath, head)
+
+	startSeparator := p.startSeparator(relPath, name)
+	fence := ""
+	if p.config.OutputFormat == FormatMarkdown {
+		// The fence has to be known before the content, so the file is read twice
+		if fence, err = fileFence(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", path, err)
+			p.summary.SkippedFiles = append(p.summary.SkippedFiles, SkippedFile{Path: name, Reason: "read error"})
+			return nil
+		}
+		startSeparator = p.markdownStart(relPath, name, fence, p.fenceLanguage(relPath, head))
+	}
+	if err := writeString(out, startSeparator); err != nil {
+		return fmt.Errorf("error writing separator to output file: %w", err)
+	}
+
+	hash := sha256.New()
+	last := &lastByteWriter{}
+	n, err := io.Copy(io.MultiWriter(out, last), io.TeeReader(reader, hash))
+	if err != nil {
+		return fmt.Errorf("error writing content to output file: %w", err)
+	}
+	endSeparator := fmt.Sprintf("\n--- END OF FILE: %s ---\n\n", name)
+	if fence != "" {
+		endSeparator = markdownEnd(fence, last.b == '\n')
+	}
+	if err := writeString(out, endSeparator); err != nil {
+		return fmt.Errorf("error writing separator to output file: %w", err)
+	}
+
+	p.summary.ProcessedFiles = append(p.summary.ProcessedFiles, name)
+	p.summary.TotalBytes += n
+	if p.contentBuffer != nil {
+		tokens, _ := p.fileTokens(path)
+		p.countForSummary(name, language, n, tokens)
+	} else if p.config.CostModel != "" && !p.config.Verbose {
+		tokens, _ := p.fileTokens(path)
+		p.summary.TotalTokens += tokens
+	}
+	sum := hex.EncodeToString(hash.Sum(nil))
+	p.index = append(p.index, indexEntry{path: name, size: int(n), sha256: sum})
+	p.manifest = append(p.manifest, ManifestEntry{
+		Path:     name,
+		Size:     n,
+		SHA256:   sum,
+		Language: language,
+		Note:     p.annotation(relPath),
+
+		LastCommit: p.lastCommit(relPath),
+	})
+	return nil
+}
+
+// streams reports whether a file can be copied to the output as is, which
+// low-memory mode does for every file and --max-memory for files larger
+// than the limit. Rewrites, hooks and symbol extraction need whole files,
+// so only untouched content can stream.
+func (p *fileProcessor) streams(relPath, path string, fileConfig *Config) bool {
+	if !p.config.LowMemory && !p.exceedsMemory(path) {
+		return false
+	}
+	return p.packsVerbatim(relPath, fileConfig) && !p.config.Symbols
+}
+
+// exceedsMemory reports whether a file is larger than --max-memory
+func (p *fileProcessor) exceedsMemory(path string) bool {
+	// Sizes were checked by validateConfig
+	maxMemory, _ := parseSize(p.config.MaxMemory)
+	if maxMemory <= 0 {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.Size() > maxMemory
+}
+
+func (p *fileProcessor) processFile(relPath, path string) error {
+	fileConfig := p.configFor(relPath)
+
+	if p.streams(relPath, path, fileConfig) {
+		defer p.config.phases.add(phaseWrite, time.Now())
+		return p.streamFile(relPath, path)
+	}
 
-	p.summary.ProcessedFiles = append(p.summary.ProcessedFiles, relPath)
+	name := p.displayPath(relPath)
+
+	readStart := time.Now()
+	content := p.takeContent(path)
+	var err error
+	if content == nil {
+		content, err = os.ReadFile(path)
+	}
+	p.config.phases.add(phaseRead, readStart)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", path, err)
+		p.summary.SkippedFiles = append(p.summary.SkippedFiles, SkippedFile{Path: name, Reason: "read error"})
+		return nil
+	}
+
+	// Documents are packed as their extracted text, while the manifest
+	// describes the original file
+	transformStart := time.Now()
+	text := content
+	if isDocument(relPath) {
+		extracted, err := extractDocumentText(relPath, content)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error extracting text from %s: %v\n", path, err)
+			p.summary.SkippedFiles = append(p.summary.SkippedFiles, SkippedFile{Path: name, Reason: "unreadable document"})
+			return nil
+		}
+		if extracted == "" {
+			p.summary.SkippedFiles = append(p.summary.SkippedFiles, SkippedFile{Path: name, Reason: "no extractable text"})
+			return nil
+		}
+		text = []byte(extracted)
+	}
+	if p.minified[relPath] {
+		text = minifiedStub(len(content))
+	} else if text, err = p.customTransforms(relPath, text); err != nil {
+		return err
+	}
+
+	// Symbols are found in the text as written, before cpack's transforms
+	if p.config.Symbols && !p.minified[relPath] {
+		if symbols := extractSymbols(relPath, text); len(symbols) > 0 {
+			p.symbols = append(p.symbols, fileSymbols{path: name, symbols: symbols})
+		}
+	}
+
+	if p.config.DocsOnly {
+		if text = docsOnly(relPath, text); len(bytes.TrimSpace(text)) == 0 {
+			p.summary.SkippedFiles = append(p.summary.SkippedFiles, SkippedFile{Path: name, Reason: "no documentation"})
+			return nil
+		}
+	}
+	p.config.phases.add(phaseTransform, transformStart)
+
+	p.summary.ProcessedFiles = append(p.summary.ProcessedFiles, name)
 	p.summary.TotalBytes += int64(len(content))
+	// Only a summary written after the files shows the breakdowns
+	if p.contentBuffer != nil {
+		tokenizeStart := time.Now()
+		tokens := p.sourceTokens(path, content)
+		p.config.phases.add(phaseTokenize, tokenizeStart)
+		p.countForSummary(name, DetectLanguage(relPath, content), int64(len(content)), tokens)
+	}
+	sourceSHA256 := p.sourceSHA256(path, content)
+	p.manifest = append(p.manifest, ManifestEntry{
+		Path:     name,
+		Size:     int64(len(content)),
+		SHA256:   sourceSHA256,
+		Language: DetectLanguage(relPath, content),
+		Note:     p.annotation(relPath),
 
-	// Create separators
-	startSeparator := fmt.Sprintf("--- START OF FILE: %s ---\n", relPath)
-	endSeparator := fmt.Sprintf("\n--- END OF FILE: %s ---\n\n", relPath)
+		LastCommit: p.lastCommit(relPath),
+	})
 
-	// Apply compression if enabled
-	if p.config.Compress {
-		content = compressContent(content, p.config)
-		// Also compress separators
-		startSeparator = strings.TrimSpace(startSeparator) + " "
+	// Create separators
+	startSeparator := p.startSeparator(relPath, name)
+	endSeparator := fmt.Sprintf("\n--- END OF FILE: %s ---\n\n", name)
+	transformStart = time.Now()
+	content = p.packContent(relPath, text, fileConfig)
+	p.config.phases.add(phaseTransform, transformStart)
+
+	// Compressed content gets compressed separators
+	if fileConfig.Compress {
+		startSeparator = strings.ReplaceAll(strings.TrimSpace(startSeparator), "\n", " ") + " "
 		endSeparator = " " + strings.TrimSpace(endSeparator) + " "
 	}
 
-	if p.config.Verbose {
+	if p.packsVerbatim(relPath, fileConfig) {
+		p.index = append(p.index, indexEntry{path: name, size: len(content), sha256: sourceSHA256})
+	} else {
+		p.index = append(p.index, newIndexEntry(name, content))
+	}
+
+	// Fences need lines of their own, so markdown separators are never compressed
+	if p.config.OutputFormat == FormatMarkdown {
+		fence := markdownFence(content)
+		startSeparator = p.markdownStart(relPath, name, fence, p.fenceLanguage(relPath, text))
+		endSeparator = markdownEnd(fence, bytes.HasSuffix(content, []byte("\n")))
+	}
+
+	defer p.config.phases.add(phaseWrite, time.Now())
+	if p.contentBuffer != nil {
 		if _, err = p.contentBuffer.WriteString(startSeparator); err != nil {
 			return fmt.Errorf("error writing separator to buffer: %w", err)
 		}
@@ -422,13 +1124,41 @@ func (p *fileProcessor) processFile(relPath, path string) error {
 		if err = writeString(p.outputFile, endSeparator); err != nil {
 			return fmt.Errorf("error writing separator to output file: %w", err)
 		}
+		// Without a summary, the cost estimate is printed at the end
+		if p.config.CostModel != "" && !p.config.Verbose {
+			p.summary.TotalTokens += p.countTokens([]byte(startSeparator)) + p.countTokens(content) + p.countTokens([]byte(endSeparator))
+		}
 	}
 
-	p.processedFiles[relPath] = true
 	return nil
 }
 
+// packContent applies a file's transforms, path mapping, truncation and
+// compression to the text packed for it
+func (p *fileProcessor) packContent(relPath string, text []byte, fileConfig *Config) []byte {
+	content := transformContent(text, relPath, fileConfig)
+	if !p.minified[relPath] {
+		content = p.paths.mapContent(content)
+	}
+
+	if fileConfig.HeadLines > 0 {
+		content = truncateLines(content, fileConfig.HeadLines)
+	}
+	if fileConfig.MaxFileTokens > 0 {
+		content = p.truncateTokens(content, fileConfig.MaxFileTokens)
+	}
+
+	if fileConfig.Compress {
+		content = compressContent(content, fileConfig)
+	}
+	return content
+}
+
 func (p *fileProcessor) isValidFile(relPath, path string) bool {
+	if p.hiddenExcluded(relPath, false) {
+		return false
+	}
+
 	// First check if it matches any ignore patterns
 	for _, pattern := range p.config.ExcludeGlobs {
 		// For patterns without /, match against base name
@@ -460,6 +1190,18 @@ func (p *fileProcessor) isValidFile(relPath, path string) bool {
 		return true // If no include patterns specified, accept all files
 	}
 
+	// Extension-less project files are matched by name wherever they appear
+	for _, name := range p.config.IncludeNames {
+		matched, err := matchGlobPattern(name, filepath.Base(relPath))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error matching include name %s: %v\n", name, err)
+			continue
+		}
+		if matched {
+			return true
+		}
+	}
+
 	for _, pattern := range p.config.IncludeGlobs {
 		// For patterns without /, match against base name
 		if !strings.Contains(pattern, "/") {
@@ -485,23 +1227,44 @@ func (p *fileProcessor) isValidFile(relPath, path string) bool {
 		}
 	}
 
+	// Files no pattern matched may still be wanted for their language,
+	// such as scripts identified only by their shebang
+	if len(p.config.IncludeLangs) > 0 {
+		lang := detectFileLanguage(path)
+		for _, want := range p.config.IncludeLangs {
+			if lang != "" && strings.EqualFold(lang, want) {
+				return true
+			}
+		}
+	}
+
 	return false
 }
 
+// formatInstructions wraps task instructions in the block that opens a corpus
+func formatInstructions(instructions string) string {
+	return instructionsMarker + strings.TrimSpace(instructions) + endInstructionsMarker + "\n\n"
+}
+
 func (p *fileProcessor) writeSummary() error {
 	duration := p.summary.EndTime.Sub(p.summary.StartTime)
 
-	// Sort files for consistent output
-	sort.Strings(p.summary.ProcessedFiles)
-	sort.Strings(p.summary.SkippedFiles)
+	// Sort files for consistent output across machines
+	sortPaths(p.summary.ProcessedFiles)
+	skipped := skippedStrings(p.summary.SkippedFiles)
+
+	// Deterministic output leaves out the wall-clock time
+	timing := fmt.Sprintf("Processing Time: %v\n", duration)
+	if p.config.Deterministic {
+		timing = ""
+	}
 
 	summary := fmt.Sprintf(`--- CORPUS PACKER SUMMARY ---
-Processing Time: %v
-Total Files: %d
+%sTotal Files: %d
 Total Files Processed: %d
 Total Files Skipped: %d
 Total Bytes Processed: %d
-
+%s%s
 Processed Files:
 %s
 
@@ -511,13 +1274,15 @@ Skipped Files:
 --- END OF SUMMARY ---
 
 `,
-		duration,
+		timing,
 		len(p.summary.ProcessedFiles)+len(p.summary.SkippedFiles),
 		len(p.summary.ProcessedFiles),
 		len(p.summary.SkippedFiles),
 		p.summary.TotalBytes,
+		p.costSummary(),
+		p.formatBreakdowns(),
 		strings.Join(p.summary.ProcessedFiles, "\n"),
-		strings.Join(p.summary.SkippedFiles, "\n"),
+		strings.Join(skipped, "\n"),
 	)
 
 	// Apply compression if enabled
@@ -544,7 +1309,7 @@ func validateConfig(config *Config) error {
 	}
 
 	// Clean output file path
-	if !filepath.IsAbs(config.OutputFile) {
+	if !filepath.IsAbs(config.OutputFile) && !isObjectURL(config.OutputFile) {
 		// Get absolute path relative to current working directory
 		absPath, err := filepath.Abs(config.OutputFile)
 		if err != nil {
@@ -553,6 +1318,168 @@ func validateConfig(config *Config) error {
 		config.OutputFile = absPath
 	}
 
+	switch config.OutputFormat {
+	case "", FormatText, FormatLinkFarm, FormatMarkdown, FormatHTML, FormatYAML, FormatPB,
+		FormatParquet, FormatRAGJSONL, FormatLangChain, FormatLlamaIndex:
+	default:
+		return fmt.Errorf("unsupported output format: %s", config.OutputFormat)
+	}
+	_, rendered := corpusRenderers[config.OutputFormat]
+	if config.Append && (config.OutputFormat == FormatMarkdown || rendered) {
+		// Only text corpora are merged into
+		return fmt.Errorf("--append requires the text output format")
+	}
+	if rendered && config.Gzip {
+		return fmt.Errorf("--format %s cannot be combined with --gzip", config.OutputFormat)
+	}
+	if (rendered || config.OutputFormat == FormatLinkFarm) && outputEncoding(config) != "" {
+		return fmt.Errorf("--format %s cannot be combined with --%s", config.OutputFormat, encodingFlag(config))
+	}
+	if config.OutputFormat == FormatHTML && config.Compress {
+		// A page is for reading in a browser, as laid out in the files
+		return fmt.Errorf("--format html cannot be combined with --compress")
+	}
+
+	if config.SplitByDir < 0 {
+		return fmt.Errorf("--split-by-dir must be a positive depth, got %d", config.SplitByDir)
+	}
+	if config.SplitByDir > 0 && config.OutputFormat == FormatLinkFarm {
+		return fmt.Errorf("--split-by-dir requires the text output format")
+	}
+
+	switch config.SortOrder {
+	case "", SortWalk, SortDeps, SortSmart:
+	default:
+		return fmt.Errorf("unsupported sort order: %s", config.SortOrder)
+	}
+
+	if _, err := GetTokenizer(config.Tokenizer); err != nil {
+		return err
+	}
+
+	if err := validateCostModel(config); err != nil {
+		return err
+	}
+
+	if _, err := parseSize(config.MaxTotalSize); err != nil {
+		return fmt.Errorf("error parsing --max-total-size: %w", err)
+	}
+	minSize, err := parseSize(config.MinSize)
+	if err != nil {
+		return fmt.Errorf("error parsing --min-size: %w", err)
+	}
+	maxSize, err := parseSize(config.MaxSize)
+	if err != nil {
+		return fmt.Errorf("error parsing --max-size: %w", err)
+	}
+	if maxSize > 0 && minSize > maxSize {
+		return fmt.Errorf("--min-size %s is larger than --max-size %s", config.MinSize, config.MaxSize)
+	}
+	if _, err := parseSize(config.MaxMemory); err != nil {
+		return fmt.Errorf("error parsing --max-memory: %w", err)
+	}
+
+	switch config.SymlinkPolicy {
+	case "", SymlinkFollow, SymlinkSkip, SymlinkError:
+	default:
+		return fmt.Errorf("unsupported symlink policy: %s (expected follow, skip or error)", config.SymlinkPolicy)
+	}
+
+	if err := validateWhitespace(config); err != nil {
+		return err
+	}
+	if err := validateContentFilter(config); err != nil {
+		return err
+	}
+	if err := validateTimeWindow(config); err != nil {
+		return err
+	}
+	if err := validateChunking(config); err != nil {
+		return err
+	}
+	if err := validateUpload(config); err != nil {
+		return err
+	}
+	if err := validateObjectOutput(config); err != nil {
+		return err
+	}
+	if err := validatePost(config); err != nil {
+		return err
+	}
+	if err := validatePipeOutput(config); err != nil {
+		return err
+	}
+	if err := validateVerify(config); err != nil {
+		return err
+	}
+	if err := validateFrontmatter(config); err != nil {
+		return err
+	}
+	if err := validateWorkers(config); err != nil {
+		return err
+	}
+	if err := validateProfile(config); err != nil {
+		return err
+	}
+	if err := validateGzipJobs(config); err != nil {
+		return err
+	}
+	if err := validateEncoding(config); err != nil {
+		return err
+	}
+	if err := validateHooks(config); err != nil {
+		return err
+	}
+	if err := validatePlugins(config); err != nil {
+		return err
+	}
+	if err := validateTests(config); err != nil {
+		return err
+	}
+	if err := validateBudget(config); err != nil {
+		return err
+	}
+	if err := validateSecrets(config); err != nil {
+		return err
+	}
+
+	switch config.Minified {
+	case "", MinifiedSkip, MinifiedStub, MinifiedKeep:
+	default:
+		return fmt.Errorf("unsupported minified file policy: %s (expected skip, stub or keep)", config.Minified)
+	}
+
+	switch config.Hidden {
+	case "", HiddenInclude, HiddenExclude:
+	default:
+		return fmt.Errorf("unsupported hidden file policy: %s (expected include or exclude)", config.Hidden)
+	}
+
+	if _, ok := presets[config.Preset]; config.Preset != "" && !ok {
+		return fmt.Errorf("unknown preset: %s (available: %s)", config.Preset, strings.Join(PresetNames(), ", "))
+	}
+
+	if len(config.AnonymizeDirs) > 0 || config.Anonymize {
+		if _, ok := pathAnonymizers[config.AnonymizeMode]; !ok && config.AnonymizeMode != "" {
+			return fmt.Errorf("unsupported anonymize mode: %s", config.AnonymizeMode)
+		}
+	}
+	if config.Anonymize && (config.GitMeta || config.GitLog > 0) {
+		// Commits name their authors, which --anonymize would leave exposed
+		return fmt.Errorf("--anonymize cannot be combined with --git-meta or --git-log")
+	}
+	if config.Anonymize && config.OutputFormat == FormatLinkFarm {
+		// A link farm mirrors the original files, emails and hosts included
+		return fmt.Errorf("--anonymize requires the text output format")
+	}
+
+	for i, rule := range config.Rules {
+		if rule.Glob == "" {
+			return fmt.Errorf("rule %d has no glob", i+1)
+		}
+		config.Rules[i].Glob = filepath.Clean(rule.Glob)
+	}
+
 	// Clean glob patterns
 	for i, pattern := range config.IncludeGlobs {
 		config.IncludeGlobs[i] = filepath.Clean(pattern)
@@ -560,6 +1487,9 @@ func validateConfig(config *Config) error {
 	for i, pattern := range config.ExcludeGlobs {
 		config.ExcludeGlobs[i] = filepath.Clean(pattern)
 	}
+	for i, pattern := range config.PriorityGlobs {
+		config.PriorityGlobs[i] = filepath.Clean(pattern)
+	}
 
 	return nil
 }
@@ -610,25 +1540,82 @@ func removeComments(str string) string {
 	return str
 }
 
-// tryLoadDefaultConfig attempts to load a config file from the default locations
-func tryLoadDefaultConfig(dir string) (*Config, error) {
-	// Check for cpack.yml first
-	ymlPath := filepath.Join(dir, "cpack.yml")
-	if _, err := os.Stat(ymlPath); err == nil {
-		return LoadConfigFromFile(ymlPath)
+// mergeAutoConfig fills config from its config file, if it has one
+func mergeAutoConfig(config Config) (Config, error) {
+	fileConfig, err := loadConfigFor(config)
+	if err != nil {
+		return config, err
+	}
+	return MergeConfig(config, fileConfig), nil
+}
+
+// loadConfigFor loads the config file for config: ConfigFile if set,
+// otherwise the first file FindConfigFile finds. It returns nil if there is
+// none. An explicit config file or a selected profile makes the file
+// mandatory; otherwise a file that fails to load is ignored.
+func loadConfigFor(config Config) (*Config, error) {
+	if config.ConfigFile != "" {
+		fileConfig, err := LoadConfigProfile(config.ConfigFile, config.Profile)
+		if err != nil {
+			return nil, fmt.Errorf("error loading config file: %w", err)
+		}
+		return fileConfig, nil
+	}
+
+	fileConfig, err := tryLoadDefaultConfig(config.InputDir, config.Profile)
+	if err != nil {
+		if config.Profile != "" {
+			return nil, fmt.Errorf("error loading profile %s: %w", config.Profile, err)
+		}
+		return nil, nil
+	}
+	return fileConfig, nil
+}
+
+// configFileNames are the config file names looked for in the input and
+// current directories, in order
+var configFileNames = []string{"cpack.yml", "cpack.yaml", "cpack.json"}
+
+// userConfigFileNames are the config file names looked for in the user
+// config directory, in order
+var userConfigFileNames = []string{"config.yaml", "config.yml", "config.json"}
+
+// FindConfigFile returns the config file used for input directory dir when
+// none is given: the first of cpack.yml, cpack.yaml and cpack.json in dir,
+// then in the current directory, then config.yaml, config.yml or
+// config.json in $XDG_CONFIG_HOME/cpack. It returns "" if there is none.
+func FindConfigFile(dir string) string {
+	if dir == "" {
+		dir = "."
 	}
 
-	// Then check for cpack.yaml
-	yamlPath := filepath.Join(dir, "cpack.yaml")
-	if _, err := os.Stat(yamlPath); err == nil {
-		return LoadConfigFromFile(yamlPath)
+	var candidates []string
+	for _, name := range configFileNames {
+		candidates = append(candidates, filepath.Join(dir, name))
+	}
+	for _, name := range configFileNames {
+		candidates = append(candidates, name)
+	}
+	if userDir, err := os.UserConfigDir(); err == nil {
+		for _, name := range userConfigFileNames {
+			candidates = append(candidates, filepath.Join(userDir, "cpack", name))
+		}
 	}
 
-	// Finally check for cpack.json
-	jsonPath := filepath.Join(dir, "cpack.json")
-	if _, err := os.Stat(jsonPath); err == nil {
-		return LoadConfigFromFile(jsonPath)
+	for _, path := range candidates {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
 	}
+	return ""
+}
 
-	return nil, fmt.Errorf("no default config file found")
+// tryLoadDefaultConfig attempts to load the config file FindConfigFile
+// finds, with the named profile applied if profile is set
+func tryLoadDefaultConfig(dir, profile string) (*Config, error) {
+	path := FindConfigFile(dir)
+	if path == "" {
+		return nil, fmt.Errorf("no default config file found")
+	}
+	return LoadConfigProfile(path, profile)
 }
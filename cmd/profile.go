@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"sync"
+	"time"
+)
+
+// Profiles --pprof writes
+const (
+	ProfileCPU   = "cpu"
+	ProfileMem   = "mem"
+	ProfileTrace = "trace"
+)
+
+// Phases of a pack timed by --pprof
+const (
+	phaseWalk = iota
+	phaseRead
+	phaseTransform
+	phaseTokenize
+	phaseWrite
+	numPhases
+)
+
+var phaseNames = [numPhases]string{"walk", "read", "transform", "tokenize", "write"}
+
+// phaseTimes adds up the time a pack spends in each phase. It is shared by
+// the corpora of --per-workspace and --split-by-dir, and is nil unless
+// profiling, which makes add a no-op.
+type phaseTimes struct {
+	mu        sync.Mutex
+	durations [numPhases]time.Duration
+}
+
+// add counts the time since start towards phase
+func (t *phaseTimes) add(phase int, start time.Time) {
+	if t == nil {
+		return
+	}
+	elapsed := time.Since(start)
+	t.mu.Lock()
+	t.durations[phase] += elapsed
+	t.mu.Unlock()
+}
+
+// write prints each phase's share of total, and the time no phase covers
+func (t *phaseTimes) write(w io.Writer, total time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	fmt.Fprintln(w, "Phase Timings:")
+	other := total
+	row := func(name string, d time.Duration) {
+		share := 0.0
+		if total > 0 {
+			share = float64(d) * 100 / float64(total)
+		}
+		fmt.Fprintf(w, "  %-10s %10s %6.1f%%\n", name, d.Round(time.Microsecond), share)
+	}
+	for phase, d := range t.durations {
+		row(phaseNames[phase], d)
+		other -= d
+	}
+	if other < 0 {
+		other = 0
+	}
+	row("other", other)
+	fmt.Fprintf(w, "  %-10s %10s\n", "total", total.Round(time.Microsecond))
+}
+
+// profilePath returns where a profile is written: next to the corpus, or
+// in the working directory when the output is a pipe or an object store
+func profilePath(config *Config) string {
+	suffix := "." + config.Pprof + ".pprof"
+	if config.Pprof == ProfileTrace {
+		suffix = ".trace.out"
+	}
+	if isObjectURL(config.OutputFile) || isPipeOutput(config.OutputFile) {
+		return "cpack" + suffix
+	}
+	return config.OutputFile + suffix
+}
+
+// startProfile starts the --pprof profile and phase timings. The
+// returned function stops them, writes the profile and prints the timings
+// to stderr.
+func startProfile(config *Config) (func(), error) {
+	path := profilePath(config)
+	// The profile starts before the corpus creates its directory
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("error creating profile directory: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("error creating profile: %w", err)
+	}
+
+	switch config.Pprof {
+	case ProfileCPU:
+		err = pprof.StartCPUProfile(f)
+	case ProfileTrace:
+		err = trace.Start(f)
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("error starting %s profile: %w", config.Pprof, err)
+	}
+
+	config.phases = &phaseTimes{}
+	start := time.Now()
+	return func() {
+		total := time.Since(start)
+		switch config.Pprof {
+		case ProfileCPU:
+			pprof.StopCPUProfile()
+		case ProfileTrace:
+			trace.Stop()
+		case ProfileMem:
+			// Collect first, so the profile shows what is still live
+			runtime.GC()
+			err = pprof.WriteHeapProfile(f)
+		}
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing profile %s: %v\n", path, err)
+		}
+
+		config.phases.write(os.Stderr, total)
+		fmt.Fprintf(os.Stderr, "Wrote %s profile to %s\n", config.Pprof, path)
+	}, nil
+}
+
+// validateProfile checks the --pprof profile kind
+func validateProfile(config *Config) error {
+	switch config.Pprof {
+	case "", ProfileCPU, ProfileMem, ProfileTrace:
+		return nil
+	}
+	return fmt.Errorf("unsupported --pprof profile: %s (expected cpu, mem or trace)", config.Pprof)
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// defaultChunkTokens is the chunk size of --format rag-jsonl when
+// --chunk-tokens is not set
+const defaultChunkTokens = 512
+
+// ragRecord is one line of a rag-jsonl export: a chunk of a file with the
+// lines it spans
+type ragRecord struct {
+	ID        string `json:"id"`
+	Path      string `json:"path"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Content   string `json:"content"`
+}
+
+// textChunk is a run of whole lines of a file, numbered from 1
+type textChunk struct {
+	startLine int
+	endLine   int
+	content   []byte
+}
+
+// renderRAGJSONL writes a corpus as JSON lines, one per chunk of each file,
+// ready to be embedded. Ids are stable across runs for unchanged files.
+func renderRAGJSONL(w io.Writer, corpus *Corpus, config *Config, title string) error {
+	counter := newFileProcessor(config)
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	for _, f := range corpus.Files {
+		for _, chunk := range chunkLines(corpus.Content(f), chunkTokens(config), config.ChunkOverlap, counter.countTokens) {
+			record := ragRecord{
+				ID:        fmt.Sprintf("%s#L%d-L%d", f.Path, chunk.startLine, chunk.endLine),
+				Path:      f.Path,
+				StartLine: chunk.startLine,
+				EndLine:   chunk.endLine,
+				Content:   string(chunk.content),
+			}
+			if err := encoder.Encode(record); err != nil {
+				return fmt.Errorf("error writing output file: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// chunkTokens returns the configured chunk size, or the default
+func chunkTokens(config *Config) int {
+	if config.ChunkTokens > 0 {
+		return config.ChunkTokens
+	}
+	return defaultChunkTokens
+}
+
+// chunkLines splits content into chunks of whole lines holding at most
+// maxTokens tokens, each starting with the last lines of the one before
+// that fit in overlap tokens. A line longer than maxTokens is a chunk of
+// its own.
+func chunkLines(content []byte, maxTokens, overlap int, count func([]byte) int) []textChunk {
+	lines := bytes.SplitAfter(content, []byte("\n"))
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	tokens := make([]int, len(lines))
+	for i, line := range lines {
+		tokens[i] = count(line)
+	}
+
+	var chunks []textChunk
+	for start := 0; start < len(lines); {
+		end, total := start, 0
+		for end < len(lines) && (end == start || total+tokens[end] <= maxTokens) {
+			total += tokens[end]
+			end++
+		}
+		chunks = append(chunks, textChunk{
+			startLine: start + 1,
+			endLine:   end,
+			content:   bytes.Join(lines[start:end], nil),
+		})
+		if end == len(lines) {
+			break
+		}
+
+		// Step back over the lines to repeat, always moving forward by at least one
+		next, repeated := end, 0
+		for next > start+1 && repeated+tokens[next-1] <= overlap {
+			repeated += tokens[next-1]
+			next--
+		}
+		start = next
+	}
+	return chunks
+}
+
+// validateChunking checks the --chunk-tokens and --chunk-overlap sizes
+func validateChunking(config *Config) error {
+	if config.ChunkTokens < 0 {
+		return fmt.Errorf("--chunk-tokens must not be negative, got %d", config.ChunkTokens)
+	}
+	if config.ChunkOverlap < 0 {
+		return fmt.Errorf("--chunk-overlap must not be negative, got %d", config.ChunkOverlap)
+	}
+	if size := chunkTokens(config); config.ChunkOverlap >= size {
+		return fmt.Errorf("--chunk-overlap %d must be smaller than --chunk-tokens %d", config.ChunkOverlap, size)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// corpusRenderer writes a parsed corpus in an output format. Title names
+// the packed directory.
+type corpusRenderer func(w io.Writer, corpus *Corpus, config *Config, title string) error
+
+// corpusRenderers write the output formats that are rendered from a packed
+// text corpus, keyed by format
+var corpusRenderers = map[string]corpusRenderer{
+	FormatHTML:       renderHTML,
+	FormatYAML:       renderYAML,
+	FormatPB:         renderPB,
+	FormatParquet:    renderParquet,
+	FormatRAGJSONL:   renderRAGJSONL,
+	FormatLangChain:  renderLangChain,
+	FormatLlamaIndex: renderLlamaIndex,
+}
+
+// packRendered packs the corpus as text, then rewrites the output in the
+// config's format, rendered from the parsed corpus. For an object-store
+// or pipe output, which cannot be read back, the text is packed into a
+// temporary file instead.
+func packRendered(config Config, group *dirGroup) error {
+	text := config
+	text.OutputFormat = FormatText
+	// Only the rendered output needs syncing
+	text.Durable = false
+	if isObjectURL(config.OutputFile) || isPipeOutput(config.OutputFile) {
+		tmp, err := os.CreateTemp("", "cpack-*.txt")
+		if err != nil {
+			return fmt.Errorf("error creating temporary corpus: %w", err)
+		}
+		tmp.Close()
+		defer os.Remove(tmp.Name())
+		text.OutputFile = tmp.Name()
+	}
+	if err := packCorpus(text, group); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(text.OutputFile)
+	if err != nil {
+		return fmt.Errorf("error reading corpus: %w", err)
+	}
+
+	var out bytes.Buffer
+	title := filepath.Base(config.InputDir)
+	if group != nil {
+		title += "/" + groupName(group.dir)
+	}
+	if err := corpusRenderers[config.OutputFormat](&out, ParseCorpus(data), &config, title); err != nil {
+		return err
+	}
+
+	output, err := createOutput(config.OutputFile)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+	if _, err := output.Write(out.Bytes()); err != nil {
+		return fmt.Errorf("error writing output file: %w", err)
+	}
+	if err := finishOutput(output); err != nil {
+		return err
+	}
+	if config.Durable {
+		return syncOutput(config.OutputFile)
+	}
+	return nil
+}
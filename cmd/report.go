@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// SchemaVersion is the version of the report and manifest JSON formats.
+// It changes only when a field is removed or its meaning changes.
+const SchemaVersion = "1"
+
+//go:embed schemas/*.json schemas/*.proto
+var schemaFS embed.FS
+
+// PackReport is the machine-readable counterpart of the verbose summary
+type PackReport struct {
+	SchemaVersion  string    `json:"schemaVersion"`
+	InputDir       string    `json:"inputDir"`
+	OutputFile     string    `json:"outputFile"`
+	StartTime      time.Time `json:"startTime"`
+	EndTime        time.Time `json:"endTime"`
+	DurationMs     int64     `json:"durationMs"`
+	TotalFiles     int       `json:"totalFiles"`
+	TotalBytes     int64     `json:"totalBytes"`
+	ProcessedFiles []string  `json:"processedFiles"`
+	SkippedFiles   []string  `json:"skippedFiles"`
+}
+
+// Manifest lists the files packed into a corpus in emission order
+type Manifest struct {
+	SchemaVersion string          `json:"schemaVersion"`
+	OutputFile    string          `json:"outputFile"`
+	Files         []ManifestEntry `json:"files"`
+}
+
+// ManifestEntry describes one packed file by its source content
+type ManifestEntry struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+
+	Language string `json:"language,omitempty"`
+	Note     string `json:"note,omitempty"`
+
+	LastCommit *CommitInfo `json:"lastCommit,omitempty"`
+}
+
+var schemaCmd = &cobra.Command{
+	Use:       "schema report|manifest|corpus",
+	Short:     "Print the JSON Schema for the report or manifest format, or the protobuf schema of pb corpora",
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"report", "manifest", "corpus"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		schema, err := Schema(args[0])
+		if err != nil {
+			return err
+		}
+		_, err = cmd.OutOrStdout().Write(schema)
+		return err
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+}
+
+// Schema returns the JSON Schema document for the named format, or the
+// .proto file of the pb corpus format for "corpus"
+func Schema(name string) ([]byte, error) {
+	switch name {
+	case "report", "manifest":
+		return schemaFS.ReadFile(fmt.Sprintf("schemas/%s.v%s.json", name, SchemaVersion))
+	case "corpus":
+		return schemaFS.ReadFile("schemas/corpus.v1.proto")
+	default:
+		return nil, fmt.Errorf("unknown schema: %s (expected report, manifest or corpus)", name)
+	}
+}
+
+// buildReport converts the processing summary into a report document
+func (p *fileProcessor) buildReport() PackReport {
+	processed := append([]string{}, p.summary.ProcessedFiles...)
+	sortPaths(processed)
+	skipped := skippedStrings(p.summary.SkippedFiles)
+
+	return PackReport{
+		SchemaVersion:  SchemaVersion,
+		InputDir:       p.config.InputDir,
+		OutputFile:     p.config.OutputFile,
+		StartTime:      p.summary.StartTime,
+		EndTime:        p.summary.EndTime,
+		DurationMs:     p.summary.EndTime.Sub(p.summary.StartTime).Milliseconds(),
+		TotalFiles:     len(processed) + len(skipped),
+		TotalBytes:     p.summary.TotalBytes,
+		ProcessedFiles: processed,
+		SkippedFiles:   skipped,
+	}
+}
+
+// writeReports writes the report and manifest files if they are configured
+func (p *fileProcessor) writeReports() error {
+	if p.config.ReportFile != "" {
+		if err := writeJSONFile(p.config.ReportFile, p.buildReport()); err != nil {
+			return fmt.Errorf("error writing report: %w", err)
+		}
+	}
+
+	if p.config.ManifestFile != "" {
+		manifest := Manifest{
+			SchemaVersion: SchemaVersion,
+			OutputFile:    p.config.OutputFile,
+			Files:         p.manifest,
+		}
+		if manifest.Files == nil {
+			manifest.Files = []ManifestEntry{}
+		}
+		if err := writeJSONFile(p.config.ManifestFile, manifest); err != nil {
+			return fmt.Errorf("error writing manifest: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// writeJSONFile writes v as indented JSON
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -1,9 +1,12 @@
 package cmd
 
 import (
+	"fmt"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
@@ -15,10 +18,24 @@ var (
 It can process multiple file types and directories while respecting ignore patterns.`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ApplyEnvFlags(cmd); err != nil {
+				return err
+			}
+			clearUnsetFlags(cmd)
 			// If directory argument is provided, use it
 			if len(args) > 0 {
 				config.InputDir = args[0]
 			}
+			applySelectionFlags(cmd, &config)
+			ApplyDeprecatedFlags(cmd, &config)
+			if err := applySmartOrder(cmd, &config); err != nil {
+				return err
+			}
+
+			// Errors loading the file are reported by ProcessDirectory
+			if fileConfig, _ := loadConfigFor(config); fileConfig != nil {
+				applyConfigSwitches(cmd, &config, fileConfig)
+			}
 			return ProcessDirectory(config)
 		},
 	}
@@ -31,33 +48,357 @@ func Execute() error {
 
 func init() {
 	defaults := DefaultConfig()
+	rootCmd.Version = Version
 
-	// Input/Output flags
-	rootCmd.Flags().StringVarP(&config.InputDir, "dir", "d", defaults.InputDir,
-		"Input directory to process")
+	// Input and file pattern flags
+	addSelectionFlags(rootCmd, &config)
+
+	rootCmd.Flags().StringVar(&config.ConfigFile, "config", defaults.ConfigFile,
+		"Config file to use instead of searching the input directory, the current directory and $XDG_CONFIG_HOME/cpack")
+
+	// Output flags
 	rootCmd.Flags().StringVarP(&config.OutputFile, "output", "o", defaults.OutputFile,
 		"Output file path (default: corpus-out.txt or corpus-out.txt.gz with --gzip)")
 	rootCmd.Flags().BoolVarP(&config.Verbose, "verbose", "v", defaults.Verbose,
 		"Include summary at the start of output file")
+	rootCmd.Flags().StringVar(&config.ReportFile, "report", defaults.ReportFile,
+		"Write a JSON pack report to this file (see 'cpack schema report')")
+	rootCmd.Flags().StringVar(&config.ManifestFile, "manifest", defaults.ManifestFile,
+		"Write a JSON manifest of packed files to this file (see 'cpack schema manifest')")
+	rootCmd.Flags().StringVar(&config.AnnotationsFile, "annotations", defaults.AnnotationsFile,
+		"JSON file mapping paths or globs to notes shown in each file's header and the manifest")
+	rootCmd.Flags().IntVar(&config.MaxTokens, "max-tokens", defaults.MaxTokens,
+		"Stop adding files once the estimated token count would exceed this budget (0 for no limit)")
+	rootCmd.Flags().IntVar(&config.MaxFileTokens, "max-file-tokens", defaults.MaxFileTokens,
+		"Truncate each file after this many tokens, keeping its beginning (0 for no limit)")
+	rootCmd.Flags().StringToStringVar(&config.Budget, "budget", defaults.Budget,
+		"Shares of --max-tokens reserved by file glob, each filled on its own (e.g., 'src/core/**=60%')")
+	rootCmd.Flags().StringVar(&config.ScanSecrets, "scan-secrets", defaults.ScanSecrets,
+		"List lines that look like secrets without changing them, then fail before packing, or with --scan-secrets=warn pack anyway")
+	rootCmd.Flags().Lookup("scan-secrets").NoOptDefVal = SecretsFail
+	rootCmd.Flags().StringVar(&config.Tokenizer, "tokenizer", defaults.Tokenizer,
+		"Tokenizer for token counts: estimate, cl100k_base or o200k_base (see 'cpack tokenizer list')")
+	rootCmd.Flags().StringVar(&config.CostModel, "cost-model", defaults.CostModel,
+		"Estimate the input cost for this model, in the summary or on stderr (e.g., 'gpt-4o', 'claude-sonnet')")
+	rootCmd.Flags().StringVar(&config.Instructions, "instructions", defaults.Instructions,
+		"Text written in an instructions block at the top of the corpus")
 	rootCmd.Flags().BoolVarP(&config.Compress, "compress", "c", defaults.Compress,
 		"Compress output by removing extra whitespace")
 	rootCmd.Flags().BoolVarP(&config.MaxCompress, "max-compress", "m", defaults.MaxCompress,
 		"Maximum compression: remove comments and all unnecessary whitespace")
+	rootCmd.Flags().StringVar(&config.NormalizeEOL, "normalize-eol", defaults.NormalizeEOL,
+		"Convert every line ending to lf or crlf")
+	rootCmd.Flags().BoolVar(&config.StripTrailingSpace, "strip-trailing-space", defaults.StripTrailingSpace,
+		"Remove spaces and tabs at the end of each line")
+	rootCmd.Flags().IntVar(&config.TabsToSpaces, "tabs-to-spaces", defaults.TabsToSpaces,
+		"Expand tabs to the next multiple of N columns, except in Makefiles and TSV files (0 keeps tabs)")
+	rootCmd.Flags().IntVar(&config.CollapseBlankLines, "collapse-blank-lines", defaults.CollapseBlankLines,
+		"Keep at most N consecutive blank lines (0 keeps them all)")
+	rootCmd.Flags().BoolVar(&config.NormalizeUnicode, "normalize-unicode", defaults.NormalizeUnicode,
+		"Normalize text to Unicode NFC, so visually identical strings are byte-identical")
+	rootCmd.Flags().BoolVar(&config.StripInvisible, "strip-invisible", defaults.StripInvisible,
+		"Remove zero-width, bidirectional control and tag characters")
 	rootCmd.Flags().BoolVarP(&config.Gzip, "gzip", "z", defaults.Gzip,
 		"Compress output file using gzip")
+	rootCmd.Flags().IntVar(&config.GzipJobs, "gzip-jobs", defaults.GzipJobs,
+		"Compress with N parallel gzip workers (0 uses every CPU, 1 compresses on one thread)")
+	rootCmd.Flags().BoolVar(&config.Deterministic, "deterministic", defaults.Deterministic,
+		"Make repeated packs of the same tree byte-identical: path order, no timings, fixed gzip blocks")
+	rootCmd.Flags().BoolVar(&config.Symbols, "symbols", defaults.Symbols,
+		"Append an index of the functions, types and classes defined in each file")
+	rootCmd.Flags().BoolVar(&config.ExportedOnly, "exported-only", defaults.ExportedOnly,
+		"Keep only the exported declarations of Go files, with their doc comments")
+	rootCmd.Flags().BoolVar(&config.DocsOnly, "docs-only", defaults.DocsOnly,
+		"Pack documentation only: prose files, and doc comments and docstrings with their signatures")
+	rootCmd.Flags().BoolVar(&config.ImportGraph, "import-graph", defaults.ImportGraph,
+		"Add the import graph of the Go packages in the module before the files")
+	rootCmd.Flags().BoolVar(&config.ImportGraphMermaid, "import-graph-mermaid", defaults.ImportGraphMermaid,
+		"Add the import graph with a mermaid diagram of it (implies --import-graph)")
+	rootCmd.Flags().BoolVar(&config.AllowHooks, "allow-hooks", defaults.AllowHooks,
+		"Run the hook commands and load the transformer plugins declared in the config file")
 	rootCmd.Flags().BoolVarP(&config.Base64, "base64", "b", defaults.Base64,
-		"Base64 encode the output (use with --gzip)")
+		"Base64 encode the output, gzipped or not")
+	rootCmd.Flags().StringVar(&config.Encoding, "encoding", defaults.Encoding,
+		"Encode the output as text: base64, base64url or base85")
+	rootCmd.Flags().BoolVar(&config.Wrap, "wrap", defaults.Wrap,
+		"Break encoded output into 76-column lines, as MIME does")
+	rootCmd.Flags().StringVar(&config.SortOrder, "sort", defaults.SortOrder,
+		"File order: walk, deps (Go packages after the packages they import) or smart (READMEs, manifests and entrypoints, then the most imported files)")
+	rootCmd.Flags().Bool("smart-order", false,
+		"Rank files by signal: READMEs, manifests and entrypoints first, then by how often they are imported (same as --sort smart)")
+	rootCmd.Flags().StringSliceVarP(&config.PriorityGlobs, "priority", "p", defaults.PriorityGlobs,
+		"Glob patterns emitted first, in order (e.g., 'README.md', 'go.mod')")
+	rootCmd.Flags().StringVarP(&config.OutputFormat, "format", "f", defaults.OutputFormat,
+		"Output format: text, markdown (a heading and code fence per file), html (a page with a file tree and highlighted code), yaml (a files: list), pb (a binary protobuf message; see 'cpack schema corpus'), parquet (a table with a row per file), rag-jsonl (chunks for embedding), langchain or llamaindex (a document per file) or linkfarm (mirror selected files into the --output directory)")
+	rootCmd.Flags().StringToStringVar(&config.FenceLanguages, "fence-lang", defaults.FenceLanguages,
+		"Code fence tags for the markdown format by file glob, overriding the detected language (e.g., '*.tpl=gotemplate')")
+	rootCmd.Flags().IntVar(&config.ChunkTokens, "chunk-tokens", defaults.ChunkTokens,
+		"Maximum tokens per chunk in the rag-jsonl format (0 = 512)")
+	rootCmd.Flags().IntVar(&config.ChunkOverlap, "chunk-overlap", defaults.ChunkOverlap,
+		"Tokens of trailing lines each rag-jsonl chunk repeats from the one before")
+	rootCmd.Flags().BoolVar(&config.Anonymize, "anonymize", defaults.Anonymize,
+		"Replace every directory name, email and hostname with a stable alias")
+	rootCmd.Flags().StringSliceVar(&config.AnonymizeDirs, "anonymize-dirs", defaults.AnonymizeDirs,
+		"Glob patterns of directory names to replace with stable aliases")
+	rootCmd.Flags().StringVar(&config.AnonymizeMode, "anonymize-mode", defaults.AnonymizeMode,
+		"How anonymized directories are renamed: hash or alias")
+	rootCmd.Flags().StringVar(&config.AnonymizeSalt, "anonymize-salt", defaults.AnonymizeSalt,
+		"Secret salt mixed into hashed directory names")
+	rootCmd.Flags().StringVar(&config.AnonymizeMapFile, "anonymize-map", defaults.AnonymizeMapFile,
+		"Write the alias to original name mapping to this JSON file")
+	rootCmd.Flags().IntVar(&config.HeadLines, "head-lines", defaults.HeadLines,
+		"Keep only the first N lines of each file (0 keeps everything)")
+	rootCmd.Flags().IntVar(&config.MaxFilesPerDir, "max-files-per-dir", defaults.MaxFilesPerDir,
+		"Pack only the first and last files of directories with more than N matches (0 for no limit)")
+	rootCmd.Flags().IntVar(&config.MaxFiles, "max-files", defaults.MaxFiles,
+		"Stop packing after N files (0 for no limit)")
+	rootCmd.Flags().StringVar(&config.MaxTotalSize, "max-total-size", defaults.MaxTotalSize,
+		"Stop packing before the files would exceed this size (e.g., '50MB', '1G')")
+	rootCmd.Flags().BoolVar(&config.Strict, "strict", defaults.Strict,
+		"Fail instead of packing a partial corpus when --max-files or --max-total-size is exceeded")
+	rootCmd.Flags().BoolVar(&config.Append, "append", defaults.Append,
+		"Merge into the existing output corpus instead of replacing it; re-packed paths replace their old copies")
+	rootCmd.Flags().BoolVar(&config.NoGitHeader, "no-git-header", defaults.NoGitHeader,
+		"Omit the repository, branch and commit block written when the input is in a git repository")
+	rootCmd.Flags().BoolVar(&config.GitMeta, "git-meta", defaults.GitMeta,
+		"Add each file's last commit hash, date and author to its header (from git log -1)")
+	rootCmd.Flags().IntVar(&config.GitLog, "git-log", defaults.GitLog,
+		"Append the last N commit messages to the corpus (0 for none)")
+	rootCmd.Flags().BoolVar(&config.GitLogScoped, "git-log-scoped", defaults.GitLogScoped,
+		"Limit --git-log to commits that touched the packed files")
+	rootCmd.Flags().StringVar(&config.Upload, "upload", defaults.Upload,
+		"After packing, upload the corpus to openai, anthropic or gemini and print the file ID (key from OPENAI_API_KEY, ANTHROPIC_API_KEY or GEMINI_API_KEY)")
+	rootCmd.Flags().StringVar(&config.PostURL, "post-url", defaults.PostURL,
+		"After packing, send the corpus as the body of an HTTP POST to this URL")
+	rootCmd.Flags().StringVar(&config.PostToken, "post-token", defaults.PostToken,
+		"Bearer token for --post-url; prefer setting CPACK_POST_TOKEN")
+	rootCmd.Flags().StringVar(&config.PostContentType, "post-content-type", defaults.PostContentType,
+		"Content-Type for --post-url (default: from the output format, or application/gzip with --gzip)")
+	rootCmd.Flags().BoolVar(&config.PerWorkspace, "per-workspace", defaults.PerWorkspace,
+		"Write one corpus per go.work, package.json or Cargo.toml workspace member, named after its path")
+	rootCmd.Flags().IntVar(&config.SplitByDir, "split-by-dir", defaults.SplitByDir,
+		"Write one corpus per directory at this depth, named after it; files above it go to a -root corpus")
+	rootCmd.Flags().BoolVar(&config.LowMemory, "low-memory", defaults.LowMemory,
+		"Stream file content instead of buffering it, for memory-constrained environments")
+	rootCmd.Flags().StringVar(&config.MaxMemory, "max-memory", defaults.MaxMemory,
+		"Spill the verbose buffer to a temporary file past this size, and stream larger files when untransformed (e.g., '256MB')")
+	rootCmd.Flags().IntVar(&config.IOWorkers, "io-workers", defaults.IOWorkers,
+		"Read files on this many goroutines ahead of packing (0 reads each as it is packed)")
+	rootCmd.Flags().IntVar(&config.HashWorkers, "hash-workers", defaults.HashWorkers,
+		"Hash files on this many goroutines ahead of packing (0 hashes each as it is packed)")
+	rootCmd.Flags().IntVar(&config.TokenWorkers, "token-workers", defaults.TokenWorkers,
+		"Count tokens on this many goroutines ahead of packing (0 counts each as it is packed)")
+	rootCmd.Flags().StringVar(&config.Pprof, "pprof", defaults.Pprof,
+		"Write a cpu, mem or trace profile next to the output, and print how long each phase of the pack took")
+	rootCmd.Flags().StringVar(&config.MetricsFile, "metrics-file", defaults.MetricsFile,
+		"Write Prometheus metrics of the pack to this file, for a textfile collector such as node_exporter's")
+	rootCmd.Flags().BoolVar(&config.FlushPerFile, "flush-per-file", defaults.FlushPerFile,
+		"Flush the output after every file, so a reader of a pipe sees each file complete while packing continues")
+	rootCmd.Flags().BoolVar(&config.Durable, "durable", defaults.Durable,
+		"Fsync the output once it is written, for pipelines that read it straight away")
+	rootCmd.Flags().BoolVar(&config.Verify, "verify", defaults.Verify,
+		"Re-read the written corpus, decoding it, and check it against its index and sources")
+	rootCmd.Flags().BoolVar(&config.Frontmatter, "frontmatter", defaults.Frontmatter,
+		"Open the corpus with a YAML frontmatter block: repo, commit, generation time, config hash, file and token counts")
+	rootCmd.Flags().StringVar(&config.LinkMode, "link-mode", defaults.LinkMode,
+		"How linkfarm mirrors files: symlink or copy")
 
-	// File pattern flags
-	rootCmd.Flags().StringSliceVarP(&config.IncludeGlobs, "include", "i", defaults.IncludeGlobs,
-		"Glob patterns to include (e.g., '**/*.go', 'src/**/*.py')")
-	rootCmd.Flags().StringSliceVarP(&config.ExcludeGlobs, "exclude", "x", defaults.ExcludeGlobs,
-		"Glob patterns to exclude (e.g., '**/vendor/**', '**/*_test.go')")
+	AddDeprecatedFlags(rootCmd)
 
 	// Ensure paths are cleaned
 	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
 		config.InputDir = filepath.Clean(config.InputDir)
-		config.OutputFile = filepath.Clean(config.OutputFile)
+		if !isObjectURL(config.OutputFile) {
+			config.OutputFile = filepath.Clean(config.OutputFile)
+		}
 		return nil
 	}
 }
+
+// addSelectionFlags registers the flags that control which files are selected,
+// so subcommands share the same selection engine as the root command
+func addSelectionFlags(cmd *cobra.Command, c *Config) {
+	defaults := DefaultConfig()
+
+	cmd.Flags().StringVarP(&c.InputDir, "dir", "d", defaults.InputDir,
+		"Input directory to process")
+	cmd.Flags().StringSliceVarP(&c.IncludeGlobs, "include", "i", defaults.IncludeGlobs,
+		"Glob patterns to include (e.g., '**/*.go', 'src/**/*.py')")
+	cmd.Flags().StringSliceVarP(&c.ExcludeGlobs, "exclude", "x", defaults.ExcludeGlobs,
+		"Glob patterns to exclude (e.g., '**/vendor/**', '**/*_test.go')")
+	cmd.Flags().StringSlice("ext", nil,
+		"File extensions to include, expanded to '**/*.ext' globs (e.g., 'go,py,md')")
+	cmd.Flags().StringSliceVar(&c.IncludeNames, "include-name", defaults.IncludeNames,
+		"File names to include wherever they appear (e.g., 'Makefile', 'Dockerfile')")
+	cmd.Flags().StringSliceVar(&c.IncludeLangs, "include-lang", defaults.IncludeLangs,
+		"Also include files detected as these languages, e.g. extension-less scripts (e.g., 'bash', 'python')")
+	cmd.Flags().BoolVar(&c.SkipGenerated, "skip-generated", defaults.SkipGenerated,
+		"Skip files marked as generated ('Code generated ... DO NOT EDIT', '@generated', source maps)")
+	cmd.Flags().BoolVar(&c.NoTests, "no-tests", defaults.NoTests,
+		"Skip test files by language convention ('*_test.go', 'test_*.py', '*.spec.ts', '__tests__/', ...)")
+	cmd.Flags().BoolVar(&c.TestsOnly, "tests-only", defaults.TestsOnly,
+		"Select only test files, by the same conventions as --no-tests")
+	cmd.Flags().StringVar(&c.Hidden, "hidden", defaults.Hidden,
+		"Dotfiles and dot-directories: include, or exclude unless an include pattern names them")
+	cmd.Flags().IntVar(&c.MaxDepth, "max-depth", defaults.MaxDepth,
+		"Stop descending below this directory depth; 1 packs only top-level files (0 = no limit)")
+	cmd.Flags().StringVar(&c.SymlinkPolicy, "symlinks", defaults.SymlinkPolicy,
+		"Symbolic links: follow (each directory walked once), skip, or error")
+	cmd.Flags().BoolVar(&c.IncludeSubmodules, "include-submodules", defaults.IncludeSubmodules,
+		"Walk git submodules and nested repositories instead of skipping them")
+	cmd.Flags().BoolVar(&c.ExtractDocs, "extract-docs", defaults.ExtractDocs,
+		"Pack the text of PDF, DOCX, PPTX and XLSX files instead of skipping them")
+	cmd.Flags().StringVar(&c.Minified, "minified", defaults.Minified,
+		"JavaScript and CSS detected as minified by content: skip, stub (pack a placeholder) or keep")
+	cmd.Flags().StringVar(&c.Grep, "grep", defaults.Grep,
+		"Only include files whose content matches this regular expression")
+	cmd.Flags().StringVar(&c.GrepV, "grep-v", defaults.GrepV,
+		"Exclude files whose content matches this regular expression")
+	cmd.Flags().StringVar(&c.MinSize, "min-size", defaults.MinSize,
+		"Skip files smaller than this size; 1 skips empty files (e.g., '100B', '1KB')")
+	cmd.Flags().StringVar(&c.MaxSize, "max-size", defaults.MaxSize,
+		"Skip files larger than this size (e.g., '200KB', '1MB')")
+	cmd.Flags().StringVar(&c.NewerThan, "newer-than", defaults.NewerThan,
+		"Only include files changed within this age (e.g., '30d', '2w', '12h') or since this date (e.g., '2024-01-31')")
+	cmd.Flags().StringVar(&c.OlderThan, "older-than", defaults.OlderThan,
+		"Only include files last changed at least this long ago or before this date")
+	cmd.Flags().BoolVar(&c.GitDates, "git-dates", defaults.GitDates,
+		"Date files by their last commit instead of their modification time")
+	cmd.Flags().BoolVar(&c.NoLockfiles, "no-lockfiles", defaults.NoLockfiles,
+		"Exclude dependency lockfiles, images, fonts and binaries (see lockfileGlobs in the config)")
+	cmd.Flags().BoolVar(&c.NoGitAttributes, "no-gitattributes", defaults.NoGitAttributes,
+		"Keep files marked linguist-generated or linguist-vendored in .gitattributes")
+	cmd.Flags().StringSliceVar(&c.EntryFiles, "entry", defaults.EntryFiles,
+		"Only select files reachable from these HTML/JS entrypoints")
+	cmd.Flags().StringVar(&c.Profile, "profile", defaults.Profile,
+		"Named profile from the config file's profiles section")
+	cmd.Flags().StringVar(&c.Preset, "preset", defaults.Preset,
+		"Curated include/exclude globs: "+strings.Join(PresetNames(), ", "))
+}
+
+// applySelectionFlags adds the --ext globs to the include globs, drops the
+// default include names when --include or --ext narrowed the selection
+// without also setting --include-name, and drops every default selection
+// flag that a --preset should replace
+func applySelectionFlags(cmd *cobra.Command, c *Config) {
+	flags := cmd.Flags()
+	if flags.Changed("ext") {
+		if !flags.Changed("include") {
+			c.IncludeGlobs = nil
+		}
+		exts, _ := flags.GetStringSlice("ext")
+		c.IncludeGlobs = append(c.IncludeGlobs, extensionGlobs(exts)...)
+	}
+	if (flags.Changed("include") || flags.Changed("ext")) && !flags.Changed("include-name") {
+		c.IncludeNames = nil
+	}
+	if flags.Changed("preset") {
+		if !flags.Changed("include") && !flags.Changed("ext") {
+			c.IncludeGlobs = nil
+		}
+		if !flags.Changed("include-name") {
+			c.IncludeNames = nil
+		}
+		if !flags.Changed("exclude") {
+			c.ExcludeGlobs = nil
+		}
+	}
+}
+
+// applySmartOrder turns --smart-order into --sort smart
+func applySmartOrder(cmd *cobra.Command, c *Config) error {
+	if smart, _ := cmd.Flags().GetBool("smart-order"); !smart {
+		return nil
+	}
+	if c.SortOrder != "" && c.SortOrder != SortSmart {
+		return fmt.Errorf("--smart-order and --sort %s cannot be combined", c.SortOrder)
+	}
+	c.SortOrder = SortSmart
+	return nil
+}
+
+// clearUnsetFlags zeroes the fields behind flags that were not given on the
+// command line or in the environment, so the config file can fill them.
+// ProcessDirectory applies the defaults to whatever is still unset.
+func clearUnsetFlags(cmd *cobra.Command) {
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if flag.Changed {
+			return
+		}
+		if slice, ok := flag.Value.(pflag.SliceValue); ok {
+			slice.Replace(nil)
+			return
+		}
+		switch flag.Value.Type() {
+		case "string":
+			flag.Value.Set("")
+		case "int":
+			flag.Value.Set("0")
+		case "bool":
+			flag.Value.Set("false")
+		}
+	})
+}
+
+// configSwitches returns the boolean fields of c by flag name
+func configSwitches(c *Config) map[string]*bool {
+	return map[string]*bool{
+		"verbose":              &c.Verbose,
+		"compress":             &c.Compress,
+		"max-compress":         &c.MaxCompress,
+		"strip-trailing-space": &c.StripTrailingSpace,
+		"normalize-unicode":    &c.NormalizeUnicode,
+		"strip-invisible":      &c.StripInvisible,
+		"anonymize":            &c.Anonymize,
+		"git-dates":            &c.GitDates,
+		"gzip":                 &c.Gzip,
+		"base64":               &c.Base64,
+		"wrap":                 &c.Wrap,
+		"deterministic":        &c.Deterministic,
+		"symbols":              &c.Symbols,
+		"exported-only":        &c.ExportedOnly,
+		"docs-only":            &c.DocsOnly,
+		"import-graph":         &c.ImportGraph,
+		"import-graph-mermaid": &c.ImportGraphMermaid,
+		"low-memory":           &c.LowMemory,
+		"flush-per-file":       &c.FlushPerFile,
+		"durable":              &c.Durable,
+		"verify":               &c.Verify,
+		"frontmatter":          &c.Frontmatter,
+		"skip-generated":       &c.SkipGenerated,
+		"no-tests":             &c.NoTests,
+		"tests-only":           &c.TestsOnly,
+		"include-submodules":   &c.IncludeSubmodules,
+		"extract-docs":         &c.ExtractDocs,
+		"no-gitattributes":     &c.NoGitAttributes,
+		"no-lockfiles":         &c.NoLockfiles,
+		"strict":               &c.Strict,
+		"append":               &c.Append,
+		"no-git-header":        &c.NoGitHeader,
+		"git-meta":             &c.GitMeta,
+		"git-log-scoped":       &c.GitLogScoped,
+		"per-workspace":        &c.PerWorkspace,
+	}
+}
+
+// applyConfigSwitches turns on the switches fileConfig sets for flags that
+// were not given. MergeConfig leaves switches alone, since a library
+// caller's false is deliberate, while an unset flag's false is only a default.
+func applyConfigSwitches(cmd *cobra.Command, c *Config, fileConfig *Config) {
+	switches := configSwitches(c)
+	for name, on := range configSwitches(fileConfig) {
+		if *on && !cmd.Flags().Changed(name) {
+			*switches[name] = true
+		}
+	}
+}
+
+// extensionGlobs expands file extensions, with or without a leading dot,
+// to globs matching them at any depth
+func extensionGlobs(exts []string) []string {
+	globs := make([]string, 0, len(exts))
+	for _, ext := range exts {
+		globs = append(globs, "**/*."+strings.TrimPrefix(ext, "."))
+	}
+	return globs
+}
@@ -0,0 +1,437 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// JSON-RPC 2.0 error codes
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcPackFailed     = -32000
+)
+
+// rpcRequest is a JSON-RPC request, or a notification when ID is absent
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// RPCParams are the parameters of every method: a config as in a JSON
+// config file, plus the editor's selection. Paths are files or directories,
+// absolute or relative to inputDir, and replace includeGlobs when given.
+type RPCParams struct {
+	Config
+	Paths []string `json:"paths"`
+	// Path is the file explain reports on
+	Path string `json:"path"`
+}
+
+// RPCPackResult is the result of pack
+type RPCPackResult struct {
+	OutputFile string `json:"outputFile"`
+	DurationMS int64  `json:"durationMs"`
+}
+
+// RPCFile is a file listFiles selects, in corpus order
+type RPCFile struct {
+	Path     string `json:"path"`
+	Language string `json:"language"`
+	Size     int64  `json:"size"`
+}
+
+// RPCSkippedFile is a file listFiles leaves out. Reason is empty for files
+// excluded by the selection rules.
+type RPCSkippedFile struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// RPCListResult is the result of listFiles
+type RPCListResult struct {
+	Files   []RPCFile        `json:"files"`
+	Skipped []RPCSkippedFile `json:"skipped"`
+}
+
+// RPCExplainResult is the result of explain
+type RPCExplainResult struct {
+	Path     string `json:"path"`
+	Selected bool   `json:"selected"`
+	Reason   string `json:"reason"`
+}
+
+// rpcServer answers editor requests, keeping file hashes and token counts
+// warm between packs like cpack daemon
+type rpcServer struct {
+	cache *digestCache
+}
+
+// ServeRPC answers JSON-RPC 2.0 requests read from r and writes responses
+// to w, both framed with Content-Length headers as in the Language Server
+// Protocol, until r is exhausted
+func ServeRPC(r io.Reader, w io.Writer) error {
+	server := &rpcServer{cache: newDigestCache()}
+	reader := bufio.NewReader(r)
+	for {
+		body, err := readRPCMessage(reader)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		response := server.handle(body)
+		if response == nil {
+			continue
+		}
+		if err := writeRPCMessage(w, response); err != nil {
+			return err
+		}
+	}
+}
+
+// readRPCMessage reads one message body after its headers
+func readRPCMessage(reader *bufio.Reader) ([]byte, error) {
+	header, err := textproto.NewReader(reader).ReadMIMEHeader()
+	if err != nil {
+		if errors.Is(err, io.EOF) && len(header) == 0 {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("error reading message header: %w", err)
+	}
+
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length header: %q", header.Get("Content-Length"))
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return nil, fmt.Errorf("error reading message body: %w", err)
+	}
+	return body, nil
+}
+
+// writeRPCMessage writes a message with its Content-Length header
+func writeRPCMessage(w io.Writer, message interface{}) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("error encoding response: %w", err)
+	}
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n%s", len(body), body); err != nil {
+		return fmt.Errorf("error writing response: %w", err)
+	}
+	return nil
+}
+
+// handle answers one message, returning nil for a notification
+func (s *rpcServer) handle(body []byte) *rpcResponse {
+	var request rpcRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		return &rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"),
+			Error: &rpcError{Code: rpcParseError, Message: fmt.Sprintf("error parsing request: %v", err)}}
+	}
+
+	response := &rpcResponse{JSONRPC: "2.0", ID: request.ID}
+	if request.JSONRPC != "2.0" || request.Method == "" {
+		if len(response.ID) == 0 {
+			response.ID = json.RawMessage("null")
+		}
+		response.Error = &rpcError{Code: rpcInvalidRequest, Message: "invalid JSON-RPC 2.0 request"}
+		return response
+	}
+
+	result, err := s.call(request.Method, request.Params)
+	if len(request.ID) == 0 {
+		return nil
+	}
+	if err != nil {
+		var rpcErr *rpcError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &rpcError{Code: rpcPackFailed, Message: err.Error()}
+		}
+		response.Error = rpcErr
+	} else {
+		response.Result = result
+	}
+	return response
+}
+
+// call runs a method
+func (s *rpcServer) call(method string, raw json.RawMessage) (interface{}, error) {
+	switch method {
+	case "pack", "listFiles", "explain", "stats":
+	default:
+		return nil, &rpcError{Code: rpcMethodNotFound, Message: fmt.Sprintf("unknown method: %s", method)}
+	}
+
+	params, err := parseRPCParams(raw)
+	if err != nil {
+		return nil, err
+	}
+	config := params.Config
+	if len(params.Paths) > 0 {
+		config.IncludeGlobs = selectionGlobs(config.InputDir, params.Paths)
+	}
+	// Hooks and plugins run code, which a request may not enable
+	config.AllowHooks = false
+
+	switch method {
+	case "pack":
+		return s.pack(config)
+	case "listFiles":
+		return listFiles(config)
+	case "explain":
+		if params.Path == "" {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: "explain needs a path"}
+		}
+		return explainFile(config, params.Path)
+	default:
+		return DirectoryStats(config)
+	}
+}
+
+// parseRPCParams decodes method parameters, rejecting unknown fields
+func parseRPCParams(raw json.RawMessage) (RPCParams, error) {
+	var params RPCParams
+	if len(raw) == 0 {
+		return params, nil
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(raw)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&params); err != nil {
+		return params, &rpcError{Code: rpcInvalidParams, Message: fmt.Sprintf("error parsing params: %v", err)}
+	}
+	return params, nil
+}
+
+func (s *rpcServer) pack(config Config) (*RPCPackResult, error) {
+	// The output would interleave with responses on stdout
+	if config.OutputFile == "-" || isPipeOutput(config.OutputFile) {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: "pack needs an output file, not a pipe"}
+	}
+	config.cache = s.cache
+
+	start := time.Now()
+	if err := ProcessDirectory(config); err != nil {
+		return nil, err
+	}
+	return &RPCPackResult{OutputFile: config.OutputFile, DurationMS: time.Since(start).Milliseconds()}, nil
+}
+
+// selectionGlobs turns the files and directories selected in an editor into
+// include globs relative to inputDir
+func selectionGlobs(inputDir string, paths []string) []string {
+	if inputDir == "" {
+		inputDir = "."
+	}
+	globs := make([]string, 0, len(paths))
+	for _, path := range paths {
+		full := path
+		if !filepath.IsAbs(path) {
+			full = filepath.Join(inputDir, path)
+		}
+		relPath := path
+		if rel, err := relativeTo(inputDir, full); err == nil {
+			relPath = rel
+		}
+		glob := filepath.ToSlash(relPath)
+		if info, err := os.Stat(full); err == nil && info.IsDir() {
+			glob = strings.TrimSuffix(glob, "/") + "/**"
+		}
+		globs = append(globs, glob)
+	}
+	return globs
+}
+
+// relativeTo returns path relative to dir, resolving both first
+func relativeTo(dir, path string) (string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Rel(absDir, absPath)
+}
+
+// selectPacked selects files the way a pack does, up to the budget and
+// limits, without reading them
+func selectPacked(config Config) (*fileProcessor, error) {
+	processor, err := selectFiles(config)
+	if err != nil {
+		return nil, err
+	}
+	processor.orderFiles()
+	processor.limitFilesPerDir()
+	processor.applyBudget()
+	if err := processor.applyLimits(); err != nil {
+		return nil, err
+	}
+	return processor, nil
+}
+
+// listFiles returns the files a pack of config would hold, in corpus
+// order, and the files it would leave out
+func listFiles(config Config) (*RPCListResult, error) {
+	processor, err := selectPacked(config)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RPCListResult{Files: []RPCFile{}, Skipped: []RPCSkippedFile{}}
+	for _, entry := range processor.files {
+		file := RPCFile{Path: processor.displayPath(entry.relPath), Language: detectFileLanguage(entry.absPath)}
+		if info, err := os.Stat(entry.absPath); err == nil {
+			file.Size = info.Size()
+		}
+		result.Files = append(result.Files, file)
+	}
+	sortSkipped(processor.summary.SkippedFiles)
+	for _, skipped := range processor.summary.SkippedFiles {
+		result.Skipped = append(result.Skipped, RPCSkippedFile{Path: skipped.Path, Reason: skipped.Reason})
+	}
+	return result, nil
+}
+
+// explainFile reports whether a pack of config would hold path, and why
+func explainFile(config Config, path string) (*RPCExplainResult, error) {
+	processor, err := selectPacked(config)
+	if err != nil {
+		return nil, err
+	}
+
+	full := path
+	if !filepath.IsAbs(path) {
+		full = filepath.Join(processor.config.InputDir, path)
+	}
+	relPath, err := relativeTo(processor.config.InputDir, full)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: fmt.Sprintf("%s is outside the input directory", path)}
+	}
+	result := &RPCExplainResult{Path: processor.displayPath(relPath)}
+
+	for _, entry := range processor.files {
+		if entry.relPath == relPath {
+			result.Selected = true
+			result.Reason = "selected"
+			return result, nil
+		}
+	}
+	for _, skipped := range processor.summary.SkippedFiles {
+		if skipped.Path == result.Path && skipped.Reason != "" {
+			result.Reason = skipped.Reason
+			return result, nil
+		}
+	}
+
+	if _, err := os.Stat(full); err != nil {
+		result.Reason = "not found"
+		return result, nil
+	}
+	result.Reason = processor.selectionReason(relPath)
+	return result, nil
+}
+
+// selectionReason explains why the selection rules leave out a file
+func (p *fileProcessor) selectionReason(relPath string) string {
+	if p.belowMaxDepth(filepath.Dir(relPath)) {
+		return "below --max-depth"
+	}
+	// Directories are checked from the top, as the walk visits them
+	var dirs []string
+	for dir := filepath.Dir(relPath); dir != "." && dir != string(filepath.Separator); dir = filepath.Dir(dir) {
+		dirs = append([]string{dir}, dirs...)
+	}
+	for _, dir := range dirs {
+		if p.hiddenExcluded(dir, true) {
+			return fmt.Sprintf("in hidden directory %s", p.displayPath(dir))
+		}
+		for _, pattern := range p.config.ExcludeGlobs {
+			if matched, _ := matchGlobPattern(pattern, dir); matched {
+				return fmt.Sprintf("in directory %s, excluded by %s", p.displayPath(dir), pattern)
+			}
+		}
+	}
+	if p.hiddenExcluded(relPath, false) {
+		return "hidden"
+	}
+	for _, pattern := range p.config.ExcludeGlobs {
+		target := relPath
+		if !strings.Contains(pattern, "/") {
+			target = filepath.Base(relPath)
+		}
+		if matched, _ := matchGlobPattern(pattern, target); matched {
+			return fmt.Sprintf("excluded by %s", pattern)
+		}
+	}
+	if len(p.config.IncludeGlobs) > 0 {
+		return "matches no include pattern"
+	}
+	return "not selected"
+}
+
+var rpcCmd = &cobra.Command{
+	Use:   "rpc",
+	Short: "Answer JSON-RPC requests from an editor on stdin and stdout",
+	Long: `Serve JSON-RPC 2.0 on stdin and stdout for editor plugins, with messages
+framed by Content-Length headers as in the Language Server Protocol.
+Methods:
+
+  pack       pack the selection and return the output file
+  listFiles  the files a pack would hold, in corpus order, and those it skips
+  explain    whether a pack would hold "path", and why
+  stats      per-language counts of the files a pack would select
+
+Every method takes a config as in a JSON config file, plus "paths": files
+and directories selected in the editor, which replace includeGlobs.
+File hashes and token counts stay cached between requests, as in
+'cpack daemon'. Hooks and plugins never run for a request.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Anything printed while packing goes to stderr, not into responses
+		stdout := os.Stdout
+		os.Stdout = os.Stderr
+		defer func() { os.Stdout = stdout }()
+
+		return ServeRPC(cmd.InOrStdin(), stdout)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rpcCmd)
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// configFor returns the effective config for a file after applying every
+// matching rule in order
+func (p *fileProcessor) configFor(relPath string) *Config {
+	if len(p.config.Rules) == 0 {
+		return p.config
+	}
+
+	fileConfig := *p.config
+	for _, rule := range p.config.Rules {
+		matched, err := matchPathPattern(rule.Glob, relPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error matching rule pattern %s: %v\n", rule.Glob, err)
+			continue
+		}
+		if !matched {
+			continue
+		}
+
+		if rule.Compress != nil {
+			fileConfig.Compress = *rule.Compress
+		}
+		if rule.MaxCompress != nil {
+			fileConfig.MaxCompress = *rule.MaxCompress
+		}
+		if rule.HeadLines != nil {
+			fileConfig.HeadLines = *rule.HeadLines
+		}
+		if rule.MaxFilesPerDir != nil {
+			fileConfig.MaxFilesPerDir = *rule.MaxFilesPerDir
+		}
+	}
+
+	return &fileConfig
+}
+
+// matchPathPattern matches patterns without a / against the base name and
+// all other patterns against the full relative path. Patterns may separate
+// directories with \ as well.
+func matchPathPattern(pattern, relPath string) (bool, error) {
+	pattern = normalizeGlob(pattern)
+	if !strings.Contains(pattern, "/") {
+		return matchGlobPattern(pattern, filepath.Base(relPath))
+	}
+	return matchGlobPattern(pattern, relPath)
+}
+
+// truncateLines keeps the first n lines of content and notes how many were dropped
+func truncateLines(content []byte, n int) []byte {
+	lines := bytes.SplitAfter(content, []byte("\n"))
+	if len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) <= n {
+		return content
+	}
+
+	truncated := bytes.Join(lines[:n], nil)
+	if !bytes.HasSuffix(truncated, []byte("\n")) {
+		truncated = append(truncated, '\n')
+	}
+	note := fmt.Sprintf("... [truncated: %d of %d lines omitted]", len(lines)-n, len(lines))
+	return append(truncated, note...)
+}
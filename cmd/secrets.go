@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// Secret scan modes
+const (
+	SecretsFail = "fail" // Report probable secrets and fail without packing
+	SecretsWarn = "warn" // Report probable secrets and pack anyway
+)
+
+// secretPatterns match lines that probably hold a credential, by kind
+var secretPatterns = []struct {
+	kind    string
+	pattern *regexp.Regexp
+}{
+	{"private key", regexp.MustCompile(`-----BEGIN (?:RSA |EC |DSA |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY( BLOCK)?-----`)},
+	{"AWS access key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"GitHub token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{40,})\b`)},
+	{"GitLab token", regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)},
+	{"Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"Stripe key", regexp.MustCompile(`\b[rs]k_live_[0-9A-Za-z]{24,}\b`)},
+	{"API key", regexp.MustCompile(`\bsk-(?:ant-|proj-)?[A-Za-z0-9_-]{32,}\b`)},
+	{"JWT", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	{"password or key assignment", regexp.MustCompile(`(?i)\b(?:password|passwd|pwd|secret|api[_-]?key|access[_-]?key|auth[_-]?token|client[_-]?secret)\w*["']?\s*[:=]\s*["'][^"'\s]{8,}["']`)},
+}
+
+// secretFinding is a line that probably holds a secret
+type secretFinding struct {
+	path string
+	line int
+	kind string
+}
+
+// scanSecrets reports, on stderr, the lines of the selected files that match
+// a secret pattern, without changing what is packed. The secrets themselves
+// are never printed. In fail mode any finding is an error.
+func (p *fileProcessor) scanSecrets() error {
+	if p.config.ScanSecrets == "" {
+		return nil
+	}
+
+	var findings []secretFinding
+	files := 0
+	for _, entry := range p.files {
+		if isDocument(entry.relPath) {
+			continue
+		}
+		content, err := os.ReadFile(entry.absPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", entry.absPath, err)
+			continue
+		}
+		found := findSecrets(p.displayPath(entry.relPath), content)
+		if len(found) > 0 {
+			files++
+			findings = append(findings, found...)
+		}
+	}
+	if len(findings) == 0 {
+		return nil
+	}
+
+	for _, f := range findings {
+		fmt.Fprintf(os.Stderr, "%s:%d: probable %s\n", f.path, f.line, f.kind)
+	}
+	if p.config.ScanSecrets == SecretsFail {
+		return fmt.Errorf("found %d probable secrets in %d files; remove them, exclude the files or pass --scan-secrets=warn to pack anyway",
+			len(findings), files)
+	}
+	fmt.Fprintf(os.Stderr, "Warning: found %d probable secrets in %d files\n", len(findings), files)
+	return nil
+}
+
+// findSecrets returns a finding per line of content matching a secret
+// pattern, naming the first pattern that matches
+func findSecrets(path string, content []byte) []secretFinding {
+	var findings []secretFinding
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(content)+1)
+	for line := 1; scanner.Scan(); line++ {
+		for _, secret := range secretPatterns {
+			if secret.pattern.Match(scanner.Bytes()) {
+				findings = append(findings, secretFinding{path: path, line: line, kind: secret.kind})
+				break
+			}
+		}
+	}
+	return findings
+}
+
+// validateSecrets checks the secret scan mode
+func validateSecrets(config *Config) error {
+	switch config.ScanSecrets {
+	case "", SecretsFail, SecretsWarn:
+		return nil
+	}
+	return fmt.Errorf("unsupported secret scan mode: %s (expected fail or warn)", config.ScanSecrets)
+}
@@ -0,0 +1,313 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Version is the cpack release, set at build time with
+// -ldflags "-X github.com/oreofeolurin/corpus-packer/cpack/cmd.Version=v1.2.0"
+var Version = "dev"
+
+// latestReleaseURL is the GitHub API endpoint for the latest cpack release
+const latestReleaseURL = "https://api.github.com/repos/oreofeolurin/corpus-packer/releases/latest"
+
+// checksumsAsset is the release asset listing the SHA-256 of every binary,
+// in sha256sum format
+const checksumsAsset = "checksums.txt"
+
+// updateTimeout bounds each request made by self-update
+const updateTimeout = 2 * time.Minute
+
+// Release is the part of a GitHub release self-update reads
+type Release struct {
+	TagName string         `json:"tag_name"`
+	Assets  []ReleaseAsset `json:"assets"`
+}
+
+// ReleaseAsset is a file attached to a GitHub release
+type ReleaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// UpdateOptions controls SelfUpdate
+type UpdateOptions struct {
+	ReleaseURL string // Release API endpoint, the latest GitHub release if empty
+	Current    string // Installed version, Version if empty
+	Executable string // Binary to replace, the running binary if empty
+	CheckOnly  bool   // Report whether an update exists without installing it
+	Force      bool   // Install even when the versions match or cannot be compared
+}
+
+// UpdateResult reports what SelfUpdate found and did
+type UpdateResult struct {
+	Current string
+	Latest  string
+	Newer   bool // Latest is newer than Current
+	Updated bool // The binary was replaced
+}
+
+var (
+	updateCheckOnly bool
+	updateForce     bool
+
+	selfUpdateCmd = &cobra.Command{
+		Use:   "self-update",
+		Short: "Update cpack to the latest GitHub release",
+		Long: `Download the latest cpack release for this platform from GitHub, verify it
+against the release's SHA-256 checksums and replace the running binary.
+
+Development builds have no version to compare, so they only update with --force.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			result, err := SelfUpdate(UpdateOptions{CheckOnly: updateCheckOnly, Force: updateForce})
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			switch {
+			case result.Updated:
+				fmt.Fprintf(out, "Updated cpack %s -> %s\n", result.Current, result.Latest)
+			case result.Newer:
+				fmt.Fprintf(out, "cpack %s is available (installed: %s); run 'cpack self-update' to install it\n",
+					result.Latest, result.Current)
+			case !isRelease(result.Current):
+				fmt.Fprintf(out, "cpack %s is a development build; run 'cpack self-update --force' to install %s\n",
+					result.Current, result.Latest)
+			default:
+				fmt.Fprintf(out, "cpack %s is up to date\n", result.Current)
+			}
+			return nil
+		},
+	}
+)
+
+func init() {
+	selfUpdateCmd.Flags().BoolVar(&updateCheckOnly, "check", false,
+		"Only report whether a newer release exists")
+	selfUpdateCmd.Flags().BoolVar(&updateForce, "force", false,
+		"Install the latest release even if it is not newer than this binary")
+
+	rootCmd.AddCommand(selfUpdateCmd)
+}
+
+// SelfUpdate replaces the cpack binary with the latest release built for
+// this platform. The download must match its entry in the release's
+// checksums file; a release without one is refused.
+func SelfUpdate(opts UpdateOptions) (UpdateResult, error) {
+	if opts.ReleaseURL == "" {
+		opts.ReleaseURL = latestReleaseURL
+	}
+	if opts.Current == "" {
+		opts.Current = Version
+	}
+
+	client := &http.Client{Timeout: updateTimeout}
+	data, err := fetchURL(client, opts.ReleaseURL)
+	if err != nil {
+		return UpdateResult{}, fmt.Errorf("error checking for releases: %w", err)
+	}
+
+	var release Release
+	if err := json.Unmarshal(data, &release); err != nil {
+		return UpdateResult{}, fmt.Errorf("error parsing release: %w", err)
+	}
+
+	result := UpdateResult{
+		Current: opts.Current,
+		Latest:  release.TagName,
+		Newer:   isRelease(opts.Current) && compareVersions(release.TagName, opts.Current) > 0,
+	}
+	if opts.CheckOnly || !(result.Newer || opts.Force) {
+		return result, nil
+	}
+
+	name := binaryAssetName(runtime.GOOS, runtime.GOARCH)
+	binaryURL, checksumsURL := "", ""
+	for _, asset := range release.Assets {
+		switch asset.Name {
+		case name:
+			binaryURL = asset.URL
+		case checksumsAsset:
+			checksumsURL = asset.URL
+		}
+	}
+	if binaryURL == "" {
+		return result, fmt.Errorf("release %s has no binary for %s/%s (expected %s)",
+			release.TagName, runtime.GOOS, runtime.GOARCH, name)
+	}
+	if checksumsURL == "" {
+		return result, fmt.Errorf("release %s has no %s; refusing to install an unverified binary",
+			release.TagName, checksumsAsset)
+	}
+
+	checksums, err := fetchURL(client, checksumsURL)
+	if err != nil {
+		return result, fmt.Errorf("error downloading %s: %w", checksumsAsset, err)
+	}
+	want, err := expectedChecksum(checksums, name)
+	if err != nil {
+		return result, err
+	}
+
+	binary, err := fetchURL(client, binaryURL)
+	if err != nil {
+		return result, fmt.Errorf("error downloading %s: %w", name, err)
+	}
+	sum := sha256.Sum256(binary)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return result, fmt.Errorf("checksum mismatch for %s: got %s, want %s", name, got, want)
+	}
+
+	executable := opts.Executable
+	if executable == "" {
+		if executable, err = os.Executable(); err != nil {
+			return result, fmt.Errorf("error locating the cpack binary: %w", err)
+		}
+	}
+	if err := replaceExecutable(executable, binary); err != nil {
+		return result, err
+	}
+
+	result.Updated = true
+	return result, nil
+}
+
+// binaryAssetName returns the release asset holding the binary for a platform
+func binaryAssetName(goos, goarch string) string {
+	name := fmt.Sprintf("cpack_%s_%s", goos, goarch)
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// fetchURL returns the body of a successful GET request
+func fetchURL(client *http.Client, url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s from %s", resp.Status, url)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// expectedChecksum returns the SHA-256 listed for name in a sha256sum file
+func expectedChecksum(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("%s has no checksum for %s", checksumsAsset, name)
+}
+
+// replaceExecutable swaps the binary at path for data. The new binary is
+// written next to the old one and renamed over it, so an interrupted update
+// leaves the old binary in place. The old binary is moved aside first,
+// since Windows cannot overwrite a running executable.
+func replaceExecutable(path string, data []byte) error {
+	path, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fmt.Errorf("error locating the cpack binary: %w", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("error reading the cpack binary: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".cpack-update-*")
+	if err != nil {
+		return fmt.Errorf("error writing the new binary: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing the new binary: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing the new binary: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()|0111); err != nil {
+		return fmt.Errorf("error writing the new binary: %w", err)
+	}
+
+	old := path + ".old"
+	if err := os.Rename(path, old); err != nil {
+		return fmt.Errorf("error replacing the cpack binary: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Rename(old, path)
+		return fmt.Errorf("error replacing the cpack binary: %w", err)
+	}
+	os.Remove(old)
+	return nil
+}
+
+// isRelease reports whether version is a release tag such as v1.2.0
+func isRelease(version string) bool {
+	_, ok := parseVersion(version)
+	return ok
+}
+
+// compareVersions compares two release tags, returning -1, 0 or 1
+func compareVersions(a, b string) int {
+	va, _ := parseVersion(a)
+	vb, _ := parseVersion(b)
+	for i := range va {
+		if va[i] != vb[i] {
+			if va[i] < vb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// parseVersion parses a vMAJOR.MINOR.PATCH tag, ignoring any pre-release
+// or build suffix
+func parseVersion(version string) ([3]int, bool) {
+	var parts [3]int
+	version = strings.TrimPrefix(version, "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+
+	fields := strings.Split(version, ".")
+	if len(fields) != 3 {
+		return parts, false
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
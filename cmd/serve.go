@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveAddr string
+
+	serveCmd = &cobra.Command{
+		Use:   "serve <corpus>",
+		Short: "Serve a corpus over HTTP with per-file and byte-range access",
+		Long: `Serve a generated corpus over HTTP. Endpoints:
+
+  GET /corpus           the whole decoded corpus
+  GET /files            JSON index of embedded files (id, path, offset, length)
+  GET /files/{id}       one embedded file by corpus ID
+  GET /file?path=<p>    one embedded file by path
+  GET /metrics          request counters in the Prometheus text format
+
+Every content endpoint honours HTTP Range requests.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			corpus, err := LoadCorpus(args[0])
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Serving %d files from %s on %s\n", len(corpus.Files), args[0], serveAddr)
+			return http.ListenAndServe(serveAddr, NewCorpusHandler(corpus))
+		},
+	}
+)
+
+func init() {
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "127.0.0.1:8080", "Address to listen on")
+	rootCmd.AddCommand(serveCmd)
+}
+
+// NewCorpusHandler returns an HTTP handler exposing a corpus and its embedded files
+func NewCorpusHandler(corpus *Corpus) http.Handler {
+	loadedAt := time.Now()
+	mux := http.NewServeMux()
+	handle := func(pattern string, handler http.HandlerFunc) {
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			metrics.recordRequest(pattern)
+			handler(w, r)
+		})
+	}
+
+	serveBytes := func(w http.ResponseWriter, r *http.Request, name string, data []byte) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		http.ServeContent(w, r, name, loadedAt, bytes.NewReader(data))
+	}
+
+	handle("GET /corpus", func(w http.ResponseWriter, r *http.Request) {
+		serveBytes(w, r, "corpus", corpus.Data)
+	})
+
+	handle("GET /files", func(w http.ResponseWriter, r *http.Request) {
+		files := corpus.Files
+		if files == nil {
+			files = []CorpusFile{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(files)
+	})
+
+	handle("GET /files/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil || id < 1 || id > len(corpus.Files) {
+			http.Error(w, "file not found", http.StatusNotFound)
+			return
+		}
+		f := corpus.Files[id-1]
+		serveBytes(w, r, f.Path, corpus.Content(f))
+	})
+
+	handle("GET /file", func(w http.ResponseWriter, r *http.Request) {
+		f, ok := corpus.Find(r.URL.Query().Get("path"))
+		if !ok {
+			http.Error(w, "file not found", http.StatusNotFound)
+			return
+		}
+		serveBytes(w, r, f.Path, corpus.Content(f))
+	})
+
+	mux.Handle("GET /metrics", MetricsHandler())
+
+	return mux
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// manifestNames are project manifests, which --smart-order packs right
+// after the READMEs
+var manifestNames = map[string]bool{
+	"go.mod": true, "package.json": true, "Cargo.toml": true, "pyproject.toml": true,
+	"setup.py": true, "pom.xml": true, "build.gradle": true, "build.gradle.kts": true,
+	"Gemfile": true, "composer.json": true, "mix.exs": true,
+}
+
+// entrypointNames are file names that usually start a program
+var entrypointNames = map[string]bool{
+	"main.go": true, "main.py": true, "__main__.py": true, "app.py": true, "manage.py": true,
+	"index.js": true, "index.ts": true, "main.js": true, "main.ts": true, "server.js": true,
+	"server.ts": true, "main.rs": true, "lib.rs": true, "Main.java": true, "Program.cs": true,
+}
+
+// pythonImportRegex matches "from module import" and "import module"
+var pythonImportRegex = regexp.MustCompile(`(?m)^[ \t]*(?:from[ \t]+(\.*[\w.]*)[ \t]+import\b|import[ \t]+([\w.]+))`)
+
+// Ranks of --smart-order, highest signal first
+const (
+	rankReadme = iota
+	rankManifest
+	rankEntrypoint
+	rankImported
+	rankRest
+)
+
+// smartOrder ranks files by how much they tell a reader early on: READMEs,
+// then manifests, then entrypoints, each shallowest first; then files in
+// order of how many other files import them; then the rest in their
+// current order.
+func (p *fileProcessor) smartOrder() {
+	fanIn := p.importFanIn()
+
+	rank := make(map[string]int, len(p.files))
+	for _, entry := range p.files {
+		rank[entry.relPath] = smartRank(entry.relPath, fanIn[filepath.ToSlash(entry.relPath)])
+	}
+	depth := func(relPath string) int {
+		return strings.Count(filepath.ToSlash(relPath), "/")
+	}
+
+	sort.SliceStable(p.files, func(i, j int) bool {
+		a, b := p.files[i].relPath, p.files[j].relPath
+		if rank[a] != rank[b] {
+			return rank[a] < rank[b]
+		}
+		switch rank[a] {
+		case rankImported:
+			return fanIn[filepath.ToSlash(a)] > fanIn[filepath.ToSlash(b)]
+		case rankRest:
+			return false
+		}
+		return depth(a) < depth(b)
+	})
+}
+
+// smartRank returns the --smart-order rank of a file
+func smartRank(relPath string, fanIn int) int {
+	base := filepath.Base(relPath)
+	switch {
+	case strings.HasPrefix(strings.ToUpper(base), "README"):
+		return rankReadme
+	case manifestNames[base]:
+		return rankManifest
+	case entrypointNames[base]:
+		return rankEntrypoint
+	case fanIn > 0:
+		return rankImported
+	}
+	return rankRest
+}
+
+// importFanIn counts, for each selected file, the other files that import
+// it. Go files count the packages importing their package; JavaScript,
+// TypeScript, CSS, HTML and Python files count the files referencing them.
+func (p *fileProcessor) importFanIn() map[string]int {
+	fanIn := make(map[string]int)
+
+	pkgs := p.readGoPackages()
+	importers := make(map[string]int)
+	for _, deps := range pkgs.imports {
+		for dep := range deps {
+			importers[dep]++
+		}
+	}
+	for dir, files := range pkgs.files {
+		for _, entry := range files {
+			fanIn[filepath.ToSlash(entry.relPath)] = importers[dir]
+		}
+	}
+
+	absInputDir, err := filepath.Abs(p.config.InputDir)
+	if err != nil {
+		return fanIn
+	}
+	for _, entry := range pkgs.other {
+		relPath := filepath.ToSlash(entry.relPath)
+		isPython := filepath.Ext(relPath) == ".py"
+		if !isPython && !referencesOthers(relPath) {
+			continue
+		}
+		content, err := os.ReadFile(entry.absPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", entry.absPath, err)
+			continue
+		}
+
+		targets := make(map[string]bool)
+		if isPython {
+			for _, target := range pythonReferences(absInputDir, relPath, string(content)) {
+				targets[target] = true
+			}
+		} else {
+			for _, ref := range extractReferences(relPath, string(content)) {
+				if target := resolveReference(absInputDir, relPath, ref); target != "" {
+					targets[target] = true
+				}
+			}
+		}
+		for target := range targets {
+			if target != relPath {
+				fanIn[target]++
+			}
+		}
+	}
+	return fanIn
+}
+
+// referencesOthers reports whether extractReferences reads a file's type
+func referencesOthers(relPath string) bool {
+	switch strings.ToLower(filepath.Ext(relPath)) {
+	case ".html", ".htm", ".js", ".mjs", ".cjs", ".jsx", ".ts", ".tsx", ".vue", ".svelte", ".css", ".scss", ".less":
+		return true
+	}
+	return false
+}
+
+// pythonReferences resolves the modules a Python file imports to files
+// in the input directory. Absolute imports are resolved from its root.
+func pythonReferences(absInputDir, from, content string) []string {
+	var targets []string
+	for _, m := range pythonImportRegex.FindAllStringSubmatch(content, -1) {
+		module := m[1]
+		if module == "" {
+			module = m[2]
+		}
+
+		base := ""
+		if dots := len(module) - len(strings.TrimLeft(module, ".")); dots > 0 {
+			base = path.Dir(from)
+			for i := 1; i < dots; i++ {
+				base = path.Dir(base)
+			}
+			module = module[dots:]
+		}
+		if module == "" {
+			continue
+		}
+
+		candidate := path.Join(base, strings.ReplaceAll(module, ".", "/"))
+		for _, suffix := range []string{".py", "/__init__.py"} {
+			target := candidate + suffix
+			if info, err := os.Stat(filepath.Join(absInputDir, filepath.FromSlash(target))); err == nil && !info.IsDir() {
+				targets = append(targets, target)
+				break
+			}
+		}
+	}
+	return targets
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// spillChunkSize is the smallest read when a spilled buffer is scanned
+const spillChunkSize = 64 << 10
+
+// spillBuffer holds written bytes in memory up to a limit, then moves them
+// to a temporary file and writes the rest there. A limit of 0 never spills.
+type spillBuffer struct {
+	limit int64
+	mem   bytes.Buffer
+	file  *os.File
+}
+
+func newSpillBuffer(limit int64) *spillBuffer {
+	return &spillBuffer{limit: limit}
+}
+
+func (b *spillBuffer) Write(data []byte) (int, error) {
+	if b.file == nil && b.limit > 0 && int64(b.mem.Len()+len(data)) > b.limit {
+		if err := b.spill(); err != nil {
+			return 0, err
+		}
+	}
+	if b.file != nil {
+		return b.file.Write(data)
+	}
+	return b.mem.Write(data)
+}
+
+func (b *spillBuffer) WriteString(s string) (int, error) {
+	return b.Write([]byte(s))
+}
+
+// spill moves the buffered bytes to a temporary file
+func (b *spillBuffer) spill() error {
+	file, err := os.CreateTemp("", "cpack-buffer-*")
+	if err != nil {
+		return fmt.Errorf("error creating spill file: %w", err)
+	}
+	b.file = file
+	if _, err := b.mem.WriteTo(file); err != nil {
+		return fmt.Errorf("error writing spill file: %w", err)
+	}
+	b.mem = bytes.Buffer{}
+	return nil
+}
+
+// WriteTo copies everything written so far to w, leaving the buffer intact
+func (b *spillBuffer) WriteTo(w io.Writer) (int64, error) {
+	if b.file == nil {
+		return bytes.NewReader(b.mem.Bytes()).WriteTo(w)
+	}
+	if _, err := b.file.Seek(0, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("error reading spill file: %w", err)
+	}
+	defer b.file.Seek(0, io.SeekEnd)
+	return io.Copy(w, b.file)
+}
+
+// chunks calls fn with the buffered bytes, in pieces of about the limit
+// that end at a line break where one is found
+func (b *spillBuffer) chunks(fn func([]byte)) error {
+	if b.file == nil {
+		fn(b.mem.Bytes())
+		return nil
+	}
+	if _, err := b.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("error reading spill file: %w", err)
+	}
+	defer b.file.Seek(0, io.SeekEnd)
+
+	chunk := make([]byte, max(b.limit, spillChunkSize))
+	var carry []byte
+	for {
+		n, err := io.ReadFull(b.file, chunk[len(carry):])
+		data := chunk[:len(carry)+n]
+		if err != nil {
+			if len(data) > 0 {
+				fn(data)
+			}
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil
+			}
+			return fmt.Errorf("error reading spill file: %w", err)
+		}
+		cut := bytes.LastIndexByte(data, '\n') + 1
+		if cut == 0 {
+			cut = len(data)
+		}
+		fn(data[:cut])
+		carry = append(carry[:0], data[cut:]...)
+		copy(chunk, carry)
+	}
+}
+
+// Close removes the spill file, if any
+func (b *spillBuffer) Close() error {
+	if b.file == nil {
+		return nil
+	}
+	b.file.Close()
+	return os.Remove(b.file.Name())
+}
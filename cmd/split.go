@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// dirGroup is the share of the input directory packed into one
+// --split-by-dir corpus
+type dirGroup struct {
+	depth   int
+	dir     string   // Slash-separated directory at depth, "" for the files above that depth
+	outputs []string // Globs matching the corpora and sidecar files of every group
+}
+
+// newDirGroup returns the group of dir for config's split
+func newDirGroup(config Config, dir string) *dirGroup {
+	g := &dirGroup{depth: config.SplitByDir, dir: dir}
+	for _, path := range []string{config.OutputFile, config.ReportFile, config.ManifestFile, config.AnonymizeMapFile} {
+		if path != "" {
+			if abs, err := filepath.Abs(outputVariant(path, "*")); err == nil {
+				g.outputs = append(g.outputs, abs)
+			}
+		}
+	}
+	return g
+}
+
+// isOutput reports whether absPath is written by this or an earlier split
+// with the same output names
+func (g *dirGroup) isOutput(absPath string) bool {
+	for _, glob := range g.outputs {
+		if matched, _ := filepath.Match(glob, absPath); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// groupDir returns the directory at depth that the file relPath lies
+// under, or "" when the file lies above that depth
+func groupDir(relPath string, depth int) string {
+	dir := filepath.ToSlash(filepath.Dir(relPath))
+	if dir == "." {
+		return ""
+	}
+	parts := strings.Split(dir, "/")
+	if len(parts) < depth {
+		return ""
+	}
+	return strings.Join(parts[:depth], "/")
+}
+
+// holdsFile reports whether the file relPath belongs to the group
+func (g *dirGroup) holdsFile(relPath string) bool {
+	return groupDir(relPath, g.depth) == g.dir
+}
+
+// walksDir reports whether the directory relPath may hold files of the
+// group, so the walk can skip every other group's directories
+func (g *dirGroup) walksDir(relPath string) bool {
+	dir := filepath.ToSlash(relPath)
+	if dir == "." {
+		return true
+	}
+	if g.dir == "" {
+		return strings.Count(dir, "/")+1 < g.depth
+	}
+	return dir == g.dir || strings.HasPrefix(dir, g.dir+"/") || strings.HasPrefix(g.dir, dir+"/")
+}
+
+// groupName names a group's outputs: its directory with slashes as dashes,
+// or "root" for the files above the split depth
+func groupName(dir string) string {
+	if dir == "" {
+		return "root"
+	}
+	return strings.ReplaceAll(dir, "/", "-")
+}
+
+// processSplitByDir packs the files under each directory at depth
+// SplitByDir into its own corpus, named after the directory. Files above
+// that depth share a root corpus. Only groups with selected files are
+// written.
+func processSplitByDir(config Config) error {
+	processor := newFileProcessor(&config)
+	if err := processor.collectFiles(); err != nil {
+		return err
+	}
+
+	// Outputs left by an earlier split of this directory are not a group's files
+	outputs := newDirGroup(config, "")
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, entry := range processor.files {
+		if outputs.isOutput(entry.absPath) {
+			continue
+		}
+		dir := groupDir(entry.relPath, config.SplitByDir)
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Strings(dirs)
+
+	for _, dir := range dirs {
+		name := groupName(dir)
+		group := config
+		group.SplitByDir = 0
+		group.OutputFile = outputVariant(config.OutputFile, name)
+		group.ReportFile = outputVariant(config.ReportFile, name)
+		group.ManifestFile = outputVariant(config.ManifestFile, name)
+		group.AnonymizeMapFile = outputVariant(config.AnonymizeMapFile, name)
+
+		if err := packCorpus(group, newDirGroup(config, dir)); err != nil {
+			return fmt.Errorf("error packing %s: %w", name, err)
+		}
+		if err := runPostPack(&group, group.OutputFile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
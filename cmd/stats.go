@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+// LanguageStats totals the files of one language
+type LanguageStats struct {
+	Language string `json:"language"`
+	Files    int    `json:"files"`
+	Lines    int    `json:"lines"`
+	Bytes    int64  `json:"bytes"`
+	Tokens   int    `json:"tokens"`
+}
+
+// StatsReport breaks a directory's selected files or a corpus's embedded
+// files down by language
+type StatsReport struct {
+	Source    string          `json:"source"`
+	Tokenizer string          `json:"tokenizer"`
+	Languages []LanguageStats `json:"languages"`
+	Total     LanguageStats   `json:"total"`
+	Histogram []SizeBucket    `json:"histogram"`
+	Largest   []FileStats     `json:"largest"`
+}
+
+var (
+	statsConfig Config
+	statsFormat string
+
+	statsCmd = &cobra.Command{
+		Use:   "stats [directory|corpus]",
+		Short: "Count files, lines, bytes and tokens per language",
+		Long: `Count files, lines, bytes and tokens per language, either for the files a
+pack of a directory would select, or for the files embedded in an existing
+corpus (plain, gzipped, zstd-compressed or base64- or base85-encoded). Languages are
+detected the same way as for --include-lang; undetected files count as
+"other".`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				statsConfig.InputDir = args[0]
+			}
+			applySelectionFlags(cmd, &statsConfig)
+
+			var (
+				report *StatsReport
+				err    error
+			)
+			if info, statErr := os.Stat(statsConfig.InputDir); statErr == nil && !info.IsDir() {
+				report, err = CorpusStats(statsConfig.InputDir, statsConfig.Tokenizer)
+			} else {
+				report, err = DirectoryStats(statsConfig)
+			}
+			if err != nil {
+				return err
+			}
+
+			return writeStatsReport(cmd.OutOrStdout(), report, statsFormat)
+		},
+	}
+)
+
+func init() {
+	addSelectionFlags(statsCmd, &statsConfig)
+	statsCmd.Flags().StringVar(&statsConfig.Tokenizer, "tokenizer", TokenizerEstimate,
+		"Tokenizer for token counts (see 'cpack tokenizer list')")
+	statsCmd.Flags().StringVarP(&statsFormat, "format", "f", "table",
+		"Report format: table or json")
+
+	rootCmd.AddCommand(statsCmd)
+}
+
+// DirectoryStats counts the files config selects, before packing
+func DirectoryStats(config Config) (*StatsReport, error) {
+	processor, err := selectFiles(config)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := newStatsBuilder(processor)
+	for _, entry := range processor.files {
+		content, err := os.ReadFile(entry.absPath)
+		if err != nil {
+			continue
+		}
+		stats.add(entry.relPath, content)
+	}
+	return stats.report(config.InputDir), nil
+}
+
+// CorpusStats counts the files embedded in the corpus at path
+func CorpusStats(path, tokenizer string) (*StatsReport, error) {
+	if _, err := GetTokenizer(tokenizer); err != nil {
+		return nil, err
+	}
+	corpus, err := LoadCorpus(path)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := newStatsBuilder(newFileProcessor(&Config{Tokenizer: tokenizer}))
+	for _, f := range corpus.Files {
+		stats.add(f.Path, corpus.Content(f))
+	}
+	return stats.report(path), nil
+}
+
+// statsBuilder accumulates per-language totals
+type statsBuilder struct {
+	processor *fileProcessor
+	languages map[string]*LanguageStats
+	files     []FileStats
+}
+
+func newStatsBuilder(processor *fileProcessor) *statsBuilder {
+	return &statsBuilder{processor: processor, languages: make(map[string]*LanguageStats)}
+}
+
+func (b *statsBuilder) add(path string, content []byte) {
+	head := content
+	if len(head) > sniffLen {
+		head = head[:sniffLen]
+	}
+	language := DetectLanguage(path, head)
+	if language == "" {
+		language = "other"
+	}
+
+	stats, ok := b.languages[language]
+	if !ok {
+		stats = &LanguageStats{Language: language}
+		b.languages[language] = stats
+	}
+	tokens := b.processor.countTokens(content)
+	stats.Files++
+	stats.Lines += countLines(content)
+	stats.Bytes += int64(len(content))
+	stats.Tokens += tokens
+	b.files = append(b.files, FileStats{Path: filepath.ToSlash(path), Language: language, Bytes: int64(len(content)), Tokens: tokens})
+}
+
+// report lists languages by token count, largest first, then buckets files
+// by size and picks the largest
+func (b *statsBuilder) report(source string) *StatsReport {
+	tokenizer := b.processor.config.Tokenizer
+	if tokenizer == "" {
+		tokenizer = TokenizerEstimate
+	}
+	report := &StatsReport{Source: source, Tokenizer: tokenizer, Languages: []LanguageStats{}, Total: LanguageStats{Language: "total"}}
+
+	for _, stats := range b.languages {
+		report.Languages = append(report.Languages, *stats)
+		report.Total.Files += stats.Files
+		report.Total.Lines += stats.Lines
+		report.Total.Bytes += stats.Bytes
+		report.Total.Tokens += stats.Tokens
+	}
+	sort.Slice(report.Languages, func(i, j int) bool {
+		if report.Languages[i].Tokens != report.Languages[j].Tokens {
+			return report.Languages[i].Tokens > report.Languages[j].Tokens
+		}
+		return report.Languages[i].Language < report.Languages[j].Language
+	})
+	report.Histogram = sizeHistogram(b.files)
+	report.Largest = largestFiles(b.files, largestFilesCount)
+	return report
+}
+
+// countLines counts newline-terminated lines plus a final unterminated one
+func countLines(content []byte) int {
+	lines := bytes.Count(content, []byte("\n"))
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		lines++
+	}
+	return lines
+}
+
+// writeStatsReport writes the report as aligned tables or JSON
+func writeStatsReport(w io.Writer, report *StatsReport, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case "table", "":
+		fmt.Fprintf(w, "%-16s %7s %9s %12s %10s\n", "LANGUAGE", "FILES", "LINES", "BYTES", "TOKENS")
+		for _, stats := range append(report.Languages, report.Total) {
+			fmt.Fprintf(w, "%-16s %7d %9d %12d %10d\n", stats.Language, stats.Files, stats.Lines, stats.Bytes, stats.Tokens)
+		}
+		if len(report.Largest) > 0 {
+			fmt.Fprintln(w)
+			writeHistogram(w, report.Histogram)
+			fmt.Fprintln(w)
+			writeLargest(w, report.Largest)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported stats format: %s", format)
+	}
+}
@@ -0,0 +1,359 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Suggestion is a proposed exclude glob and the evidence behind it
+type Suggestion struct {
+	Glob   string `json:"glob"`
+	Reason string `json:"reason"`
+	Files  int    `json:"files"`
+	Tokens int    `json:"tokens"`
+}
+
+// SuggestOptions tunes which directories are worth excluding
+type SuggestOptions struct {
+	MinShare   float64 // Minimum share of total tokens for a directory suggestion
+	MinLowRate float64 // Minimum share of a directory's tokens that is low value
+}
+
+// fixtureDirs are directory names that usually hold test data rather than code
+var fixtureDirs = map[string]bool{
+	"fixtures": true, "__fixtures__": true, "testdata": true, "__snapshots__": true,
+	"snapshots": true, "mocks": true, "__mocks__": true, "golden": true,
+}
+
+// dataExtensions are formats of exported data, which are low value once
+// larger than dataDumpSize, unlike a small seed file or migration
+var dataExtensions = map[string]bool{
+	".csv": true, ".tsv": true, ".jsonl": true, ".ndjson": true, ".sql": true,
+	".log": true, ".dump": true,
+}
+
+// dataDumpSize is the size above which a data file counts as a dump
+const dataDumpSize = 16 << 10
+
+var (
+	suggestConfig  Config
+	suggestOptions = SuggestOptions{MinShare: 0.05, MinLowRate: 0.5}
+	suggestWrite   string
+	suggestYes     bool
+
+	suggestCmd = &cobra.Command{
+		Use:     "suggest [directory]",
+		Aliases: []string{"suggest-excludes"},
+		Short:   "Propose exclude globs for low-value files in the selection",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				suggestConfig.InputDir = args[0]
+			}
+			applySelectionFlags(cmd, &suggestConfig)
+
+			suggestions, err := SuggestExcludes(suggestConfig, suggestOptions)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			if len(suggestions) == 0 {
+				fmt.Fprintln(out, "No exclude suggestions; the selection looks clean.")
+				return nil
+			}
+			printSuggestions(out, suggestions)
+
+			if suggestWrite == "" {
+				fmt.Fprintln(out)
+				return printExcludeBlock(out, suggestions)
+			}
+			if !suggestYes && !confirm(cmd.InOrStdin(), out,
+				fmt.Sprintf("Add %d exclude globs to %s? [y/N] ", len(suggestions), suggestWrite)) {
+				fmt.Fprintln(out, "Nothing written.")
+				return nil
+			}
+
+			globs := make([]string, len(suggestions))
+			for i, s := range suggestions {
+				globs[i] = s.Glob
+			}
+			if err := appendExcludeGlobs(suggestWrite, globs); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Wrote %d exclude globs to %s\n", len(globs), suggestWrite)
+			return nil
+		},
+	}
+)
+
+func init() {
+	addSelectionFlags(suggestCmd, &suggestConfig)
+	suggestCmd.Flags().StringVar(&suggestConfig.Tokenizer, "tokenizer", TokenizerEstimate,
+		"Tokenizer for token counts (see 'cpack tokenizer list')")
+	suggestCmd.Flags().Float64Var(&suggestOptions.MinShare, "min-share", suggestOptions.MinShare,
+		"Minimum share of total tokens for a directory to be suggested")
+	suggestCmd.Flags().Float64Var(&suggestOptions.MinLowRate, "min-low-value", suggestOptions.MinLowRate,
+		"Minimum share of a directory's tokens that must be low value")
+	suggestCmd.Flags().StringVarP(&suggestWrite, "write", "w", "",
+		"Append the suggestions to this config file's excludeGlobs (e.g., cpack.yaml)")
+	suggestCmd.Flags().BoolVarP(&suggestYes, "yes", "y", false,
+		"Write without asking for confirmation")
+
+	rootCmd.AddCommand(suggestCmd)
+}
+
+// SuggestExcludes analyzes the files selected by config and proposes exclude
+// globs for directories dominated by low-value content and for individual
+// lockfiles, data dumps and generated or minified files, largest first
+func SuggestExcludes(config Config, opts SuggestOptions) ([]Suggestion, error) {
+	// Minified files are selected so they can be named in the suggestions
+	config.Minified = MinifiedKeep
+	processor, err := selectFiles(config)
+	if err != nil {
+		return nil, err
+	}
+
+	type fileStats struct {
+		relPath string
+		tokens  int
+		reason  string
+	}
+	var files []fileStats
+	dirs := make(map[string]bool)
+	total := 0
+
+	for _, entry := range processor.files {
+		content, err := os.ReadFile(entry.absPath)
+		if err != nil {
+			continue
+		}
+		relPath := filepath.ToSlash(entry.relPath)
+		tokens := processor.countTokens(content)
+		total += tokens
+		files = append(files, fileStats{relPath: relPath, tokens: tokens, reason: lowValueReason(relPath, content)})
+
+		for dir := path.Dir(relPath); dir != "."; dir = path.Dir(dir) {
+			dirs[dir] = true
+		}
+	}
+
+	if total == 0 {
+		return nil, nil
+	}
+
+	// Deepest directories first, so a parent is only suggested for what its
+	// already-suggested children do not explain
+	dirNames := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		dirNames = append(dirNames, dir)
+	}
+	sort.Slice(dirNames, func(i, j int) bool {
+		di, dj := strings.Count(dirNames[i], "/"), strings.Count(dirNames[j], "/")
+		if di != dj {
+			return di > dj
+		}
+		return dirNames[i] < dirNames[j]
+	})
+
+	var suggestions []Suggestion
+	var covered []string
+	isCovered := func(p string) bool {
+		for _, dir := range covered {
+			if strings.HasPrefix(p, dir+"/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, dir := range dirNames {
+		var fileCount, lowCount, tokens, lowTokens int
+		for _, f := range files {
+			if !strings.HasPrefix(f.relPath, dir+"/") || isCovered(f.relPath) {
+				continue
+			}
+			fileCount++
+			tokens += f.tokens
+			if f.reason != "" {
+				lowCount++
+				lowTokens += f.tokens
+			}
+		}
+		if fileCount == 0 {
+			continue
+		}
+
+		share := float64(tokens) / float64(total)
+		fixture := fixtureDirs[path.Base(dir)]
+		// Both most of the tokens and most of the files must be low value, so
+		// one large generated file does not condemn its whole directory
+		lowValue := float64(lowTokens)/float64(tokens) >= opts.MinLowRate &&
+			float64(lowCount)/float64(fileCount) >= opts.MinLowRate
+
+		if share >= opts.MinShare && (lowValue || fixture) {
+			reason := fmt.Sprintf("%.0f%% of tokens, %.0f%% low value", share*100, float64(lowTokens)/float64(tokens)*100)
+			if fixture {
+				reason = fmt.Sprintf("%.0f%% of tokens in a fixture directory", share*100)
+			}
+			suggestions = append(suggestions, Suggestion{
+				Glob:   dir + "/**",
+				Reason: reason,
+				Files:  fileCount,
+				Tokens: tokens,
+			})
+			covered = append(covered, dir)
+		}
+	}
+
+	// Individual low-value files outside suggested directories
+	for _, f := range files {
+		if f.reason == "" || isCovered(f.relPath) {
+			continue
+		}
+		suggestions = append(suggestions, Suggestion{
+			Glob:   f.relPath,
+			Reason: f.reason,
+			Files:  1,
+			Tokens: f.tokens,
+		})
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].Tokens != suggestions[j].Tokens {
+			return suggestions[i].Tokens > suggestions[j].Tokens
+		}
+		return suggestions[i].Glob < suggestions[j].Glob
+	})
+
+	return suggestions, nil
+}
+
+// lowValueReason explains why a file is unlikely to help a model, or returns ""
+func lowValueReason(relPath string, content []byte) string {
+	for _, dir := range strings.Split(path.Dir(relPath), "/") {
+		if fixtureDirs[dir] {
+			return "fixture data"
+		}
+	}
+
+	for _, pattern := range lockfileGlobs {
+		if matched, err := matchPathPattern(pattern, relPath); err == nil && matched {
+			return "lockfile"
+		}
+	}
+	if dataExtensions[strings.ToLower(path.Ext(relPath))] && len(content) > dataDumpSize {
+		return "data dump"
+	}
+
+	head, tail := content, content
+	if len(content) > generatedSniffLen {
+		head, tail = content[:generatedSniffLen], content[len(content)-generatedSniffLen:]
+	}
+	if generatedMarker(head, tail) != "" {
+		return "generated file"
+	}
+
+	// Minified or machine-written content has very long lines
+	lines := bytes.Count(content, []byte("\n")) + 1
+	if len(content) > 2048 && len(content)/lines > 300 {
+		return "minified or single-line content"
+	}
+
+	return ""
+}
+
+// printSuggestions writes the suggestions as an aligned table
+func printSuggestions(w io.Writer, suggestions []Suggestion) {
+	fmt.Fprintf(w, "%-40s %8s %6s  %s\n", "GLOB", "TOKENS", "FILES", "REASON")
+	for _, s := range suggestions {
+		fmt.Fprintf(w, "%-40s %8d %6d  %s\n", s.Glob, s.Tokens, s.Files, s.Reason)
+	}
+}
+
+// printExcludeBlock writes the suggested globs as an excludeGlobs block,
+// ready to paste into a YAML config
+func printExcludeBlock(w io.Writer, suggestions []Suggestion) error {
+	globs := make([]string, len(suggestions))
+	for i, s := range suggestions {
+		globs[i] = s.Glob
+	}
+
+	fmt.Fprintln(w, "# Add to cpack.yaml, or rerun with --write cpack.yaml")
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(map[string][]string{"excludeGlobs": globs}); err != nil {
+		return fmt.Errorf("error encoding exclude globs: %w", err)
+	}
+	return encoder.Close()
+}
+
+// confirm asks a yes/no question and reports whether the answer was yes
+func confirm(in io.Reader, out io.Writer, question string) bool {
+	fmt.Fprint(out, question)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// appendExcludeGlobs adds globs to a YAML config's excludeGlobs, creating the
+// file if needed and keeping its existing content and comments
+func appendExcludeGlobs(configPath string, globs []string) error {
+	var doc yaml.Node
+	data, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("error parsing YAML config: %w", err)
+		}
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("config file %s is not a YAML mapping", configPath)
+	}
+
+	var excludes *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "excludeGlobs" {
+			excludes = root.Content[i+1]
+		}
+	}
+	if excludes == nil {
+		excludes = &yaml.Node{Kind: yaml.SequenceNode}
+		root.Content = append(root.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: "excludeGlobs"}, excludes)
+	}
+
+	existing := make(map[string]bool)
+	for _, item := range excludes.Content {
+		existing[item.Value] = true
+	}
+	for _, glob := range globs {
+		if !existing[glob] {
+			excludes.Content = append(excludes.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Style: yaml.DoubleQuotedStyle, Value: glob})
+		}
+	}
+
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return fmt.Errorf("error encoding YAML config: %w", err)
+	}
+	return os.WriteFile(configPath, out, 0644)
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Markers of the symbol index written by --symbols
+const (
+	symbolsMarker    = "--- SYMBOLS ---\n"
+	endSymbolsMarker = "--- END OF SYMBOLS ---\n"
+)
+
+// symbol is a definition found in a file
+type symbol struct {
+	line int
+	kind string // function, method, type, class, interface, ...
+	name string
+}
+
+// fileSymbols are the definitions of one packed file
+type fileSymbols struct {
+	path    string
+	symbols []symbol
+}
+
+// symbolPattern finds definitions of one kind. Without a kind, the first
+// group of the expression is the kind and the second the name.
+type symbolPattern struct {
+	kind string
+	re   *regexp.Regexp
+}
+
+var (
+	jsSymbols = []symbolPattern{
+		{"function", regexp.MustCompile(`(?m)^[ \t]*(?:export[ \t]+)?(?:default[ \t]+)?(?:async[ \t]+)?function\*?[ \t]*([A-Za-z_$][\w$]*)`)},
+		{"class", regexp.MustCompile(`(?m)^[ \t]*(?:export[ \t]+)?(?:default[ \t]+)?(?:abstract[ \t]+)?class[ \t]+([A-Za-z_$][\w$]*)`)},
+	}
+	tsSymbols = append([]symbolPattern{
+		{"interface", regexp.MustCompile(`(?m)^[ \t]*(?:export[ \t]+)?interface[ \t]+([A-Za-z_$][\w$]*)`)},
+		{"type", regexp.MustCompile(`(?m)^[ \t]*(?:export[ \t]+)?type[ \t]+([A-Za-z_$][\w$]*)[ \t]*(?:<[^=\n]*>)?[ \t]*=`)},
+	}, jsSymbols...)
+	classSymbols = []symbolPattern{
+		{"", regexp.MustCompile(`(?m)^[ \t]*(?:(?:public|private|protected|internal|abstract|final|static|sealed|data|open|partial)[ \t]+)*(class|interface|enum|record|object)[ \t]+([A-Za-z_]\w*)`)},
+	}
+)
+
+// symbolPatterns find definitions by language, for languages without a
+// parser in the standard library
+var symbolPatterns = map[string][]symbolPattern{
+	"python": {
+		{"class", regexp.MustCompile(`(?m)^[ \t]*class[ \t]+([A-Za-z_]\w*)`)},
+		{"function", regexp.MustCompile(`(?m)^[ \t]*(?:async[ \t]+)?def[ \t]+([A-Za-z_]\w*)`)},
+	},
+	"javascript": jsSymbols,
+	"jsx":        jsSymbols,
+	"typescript": tsSymbols,
+	"tsx":        tsSymbols,
+	"java":       classSymbols,
+	"kotlin":     append([]symbolPattern{{"function", regexp.MustCompile(`(?m)^[ \t]*(?:(?:public|private|protected|internal|override|suspend|inline)[ \t]+)*fun[ \t]+(?:<[^>]*>[ \t]*)?(?:[\w.]+\.)?([A-Za-z_]\w*)`)}}, classSymbols...),
+	"csharp":     classSymbols,
+	"rust": {
+		{"function", regexp.MustCompile(`(?m)^[ \t]*(?:pub(?:\([^)]*\))?[ \t]+)?(?:const[ \t]+)?(?:async[ \t]+)?(?:unsafe[ \t]+)?fn[ \t]+([A-Za-z_]\w*)`)},
+		{"", regexp.MustCompile(`(?m)^[ \t]*(?:pub(?:\([^)]*\))?[ \t]+)?(struct|enum|trait)[ \t]+([A-Za-z_]\w*)`)},
+	},
+	"ruby": {
+		{"", regexp.MustCompile(`(?m)^[ \t]*(class|module)[ \t]+([A-Z][\w:]*)`)},
+		{"method", regexp.MustCompile(`(?m)^[ \t]*def[ \t]+(?:self\.)?([A-Za-z_]\w*[?!=]?)`)},
+	},
+}
+
+// extractSymbols returns the definitions in a file's text, in line order.
+// Go is parsed; other languages are matched line by line, so definitions
+// inside strings or comments may be reported.
+func extractSymbols(relPath string, text []byte) []symbol {
+	language := DetectLanguage(relPath, text)
+	if language == "go" {
+		return goSymbols(text)
+	}
+
+	var symbols []symbol
+	for _, pattern := range symbolPatterns[language] {
+		for _, m := range pattern.re.FindAllSubmatchIndex(text, -1) {
+			kind, name := pattern.kind, string(text[m[2]:m[3]])
+			if kind == "" {
+				kind, name = name, string(text[m[4]:m[5]])
+			}
+			line := bytes.Count(text[:m[0]], []byte("\n")) + 1
+			symbols = append(symbols, symbol{line: line, kind: kind, name: name})
+		}
+	}
+	sort.SliceStable(symbols, func(i, j int) bool { return symbols[i].line < symbols[j].line })
+	return symbols
+}
+
+// goSymbols returns the functions, methods and types declared in Go
+// source. A file that does not parse yields what was declared before the
+// error.
+func goSymbols(text []byte) []symbol {
+	fset := token.NewFileSet()
+	file, _ := parser.ParseFile(fset, "", text, parser.SkipObjectResolution)
+	if file == nil {
+		return nil
+	}
+
+	var symbols []symbol
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			s := symbol{line: fset.Position(d.Pos()).Line, kind: "function", name: d.Name.Name}
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				s.kind = "method"
+				s.name = receiverName(d.Recv.List[0].Type) + "." + d.Name.Name
+			}
+			symbols = append(symbols, s)
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				ts, ok := spec.(*ast.TypeSpec)
+				if !ok {
+					continue
+				}
+				kind := "type"
+				if _, ok := ts.Type.(*ast.InterfaceType); ok {
+					kind = "interface"
+				}
+				symbols = append(symbols, symbol{line: fset.Position(ts.Pos()).Line, kind: kind, name: ts.Name.Name})
+			}
+		}
+	}
+	return symbols
+}
+
+// receiverName returns the type name of a method receiver, without a
+// pointer or type parameters
+func receiverName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(e.X)
+	case *ast.IndexExpr:
+		return receiverName(e.X)
+	case *ast.IndexListExpr:
+		return receiverName(e.X)
+	case *ast.Ident:
+		return e.Name
+	}
+	return "?"
+}
+
+// formatSymbols returns the symbol index, one definition per line as
+// "path:line kind name", files in corpus order
+func formatSymbols(files []fileSymbols) string {
+	var b strings.Builder
+	b.WriteString(symbolsMarker)
+	for _, f := range files {
+		for _, s := range f.symbols {
+			fmt.Fprintf(&b, "%s:%d %s %s\n", f.path, s.line, s.kind, s.name)
+		}
+	}
+	b.WriteString(endSymbolsMarker)
+	return b.String()
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Symlink policies
+const (
+	SymlinkFollow = "follow" // Links are packed under their own path; each directory is walked once
+	SymlinkSkip   = "skip"   // Links are listed as skipped
+	SymlinkError  = "error"  // A link anywhere in the input directory stops the run
+)
+
+// dirID identifies a directory independently of the path it was reached by.
+// Platforms without inode numbers fall back to the resolved path.
+type dirID struct {
+	dev  uint64
+	ino  uint64
+	path string
+}
+
+// visitDir records a directory as walked and reports whether it already was,
+// which is how followed links that loop back into the tree are caught
+func (p *fileProcessor) visitDir(path string, info os.FileInfo) bool {
+	id, ok := fileID(info)
+	if !ok {
+		real, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			real = path
+		}
+		id = dirID{path: real}
+	}
+
+	if p.visitedDirs[id] {
+		return true
+	}
+	p.visitedDirs[id] = true
+	return false
+}
+
+// visitSymlink applies the symlink policy to the link at path. The input
+// directory itself is always followed, whatever the policy.
+func (p *fileProcessor) visitSymlink(path, relPath string) error {
+	if relPath != "." {
+		switch p.config.SymlinkPolicy {
+		case SymlinkSkip:
+			p.summary.SkippedFiles = append(p.summary.SkippedFiles, SkippedFile{Path: p.displayPath(relPath), Reason: "symlink"})
+			return nil
+		case SymlinkError:
+			return fmt.Errorf("error walking %s: symlink found (symlink policy is %s)", relPath, SymlinkError)
+		}
+	}
+
+	target, err := os.Stat(path)
+	if err != nil {
+		p.summary.SkippedFiles = append(p.summary.SkippedFiles, SkippedFile{Path: p.displayPath(relPath), Reason: "broken symlink"})
+		return nil
+	}
+	if !target.IsDir() {
+		return p.selectFile(relPath, path)
+	}
+
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		p.summary.SkippedFiles = append(p.summary.SkippedFiles, SkippedFile{Path: p.displayPath(relPath), Reason: "broken symlink"})
+		return nil
+	}
+
+	// A link to an excluded directory is excluded too. SkipDir is not passed
+	// on, since for the walk the link is a file and SkipDir would end its
+	// parent directory early.
+	if err := p.processDirectory(relPath); err != nil {
+		if err == filepath.SkipDir {
+			return nil
+		}
+		return err
+	}
+
+	// Directories inside the input directory are packed under their own
+	// path, and each directory outside it only under the first link to it
+	root := p.resolvedRoot()
+	switch {
+	case relPath == ".":
+	case root == real || strings.HasPrefix(root, real+string(filepath.Separator)):
+		p.summary.SkippedFiles = append(p.summary.SkippedFiles, SkippedFile{Path: p.displayPath(relPath), Reason: "symlink cycle"})
+		return nil
+	case strings.HasPrefix(real, root+string(filepath.Separator)) || p.visitDir(real, target):
+		p.summary.SkippedFiles = append(p.summary.SkippedFiles, SkippedFile{Path: p.displayPath(relPath), Reason: "duplicate symlink"})
+		return nil
+	}
+
+	if p.skipNestedRepo(real, relPath) {
+		return nil
+	}
+	if !p.config.NoGitAttributes {
+		p.loadGitAttributes(real, relPath)
+	}
+
+	return filepath.Walk(real, func(sub string, info os.FileInfo, err error) error {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error accessing %s: %v\n", sub, err)
+			return nil
+		}
+		if sub == real {
+			return nil
+		}
+
+		rel, err := filepath.Rel(real, sub)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error getting relative path for %s: %v\n", sub, err)
+			return nil
+		}
+		return p.visit(sub, filepath.Join(relPath, rel), info)
+	})
+}
+
+// resolvedRoot returns the input directory as an absolute path with
+// symlinks resolved, so it compares with resolved link targets
+func (p *fileProcessor) resolvedRoot() string {
+	root, err := filepath.Abs(p.config.InputDir)
+	if err != nil {
+		return p.config.InputDir
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	return root
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// taskTemplate bundles the settings that suit one kind of LLM task
+type taskTemplate struct {
+	short         string
+	instructions  string // %s is replaced by the focus path
+	sortOrder     string
+	maxTokens     int
+	extraExcludes []string // Added to the selected exclude globs
+	priorityGlobs []string // Emitted after the focus path
+}
+
+var taskTemplates = map[string]taskTemplate{
+	"review": {
+		short: "Review changes in a focus path with its tests and surrounding code",
+		instructions: `Review the code in %s. Point out bugs, missing error handling,
+untested behavior and places that do not follow the conventions of the rest
+of the codebase. Cite files by the paths in the START OF FILE markers.`,
+		sortOrder:     SortDeps,
+		maxTokens:     100000,
+		priorityGlobs: []string{"go.mod", "package.json", "pyproject.toml"},
+	},
+	"explain": {
+		short: "Explain how a focus path works, with the project docs for context",
+		instructions: `Explain how the code in %s works: its responsibilities, the main
+types and functions, and how it fits into the rest of the project. Assume the
+reader is new to the codebase.`,
+		sortOrder:     SortDeps,
+		maxTokens:     60000,
+		extraExcludes: []string{"**/*_test.go", "**/*.test.*", "**/*.spec.*", "**/testdata/**", "**/fixtures/**"},
+		priorityGlobs: []string{"README*", "docs/**"},
+	},
+	"migrate": {
+		short: "Plan a migration of a focus path, with everything that depends on it",
+		instructions: `Plan a migration of the code in %s. List every file that has to
+change, the order to change them in, and the risks at each step. Files are
+ordered so that packages come after the packages they import.`,
+		sortOrder:     SortDeps,
+		maxTokens:     150000,
+		priorityGlobs: []string{"go.mod", "go.work", "package.json", "pyproject.toml"},
+	},
+}
+
+var (
+	taskConfig Config
+	taskFocus  string
+
+	taskCmd = &cobra.Command{
+		Use:   "task <" + strings.Join(taskNames(), "|") + "> [directory]",
+		Short: "Pack a corpus tuned for a common LLM task",
+		Long: `Pack a corpus with the selection, ordering, instructions and token
+budget that suit a common LLM task, so a good corpus needs no further flags.
+Files under --focus are emitted first.
+
+Tasks:
+` + taskSummaries(),
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ApplyEnvFlags(cmd); err != nil {
+				return err
+			}
+			if len(args) > 1 {
+				taskConfig.InputDir = args[1]
+			}
+			applySelectionFlags(cmd, &taskConfig)
+
+			config, err := applyTask(taskConfig, args[0], taskFocus)
+			if err != nil {
+				return err
+			}
+			if cmd.Flags().Changed("max-tokens") {
+				config.MaxTokens = taskConfig.MaxTokens
+			}
+			return ProcessDirectory(config)
+		},
+	}
+)
+
+func init() {
+	addSelectionFlags(taskCmd, &taskConfig)
+	taskCmd.Flags().StringVar(&taskFocus, "focus", "",
+		"Path the task is about, relative to the input directory (e.g., 'internal/auth')")
+	taskCmd.Flags().StringVarP(&taskConfig.OutputFile, "output", "o", DefaultConfig().OutputFile,
+		"Output file path")
+	taskCmd.Flags().IntVar(&taskConfig.MaxTokens, "max-tokens", 0,
+		"Override the task's token budget")
+	taskCmd.MarkFlagRequired("focus")
+
+	rootCmd.AddCommand(taskCmd)
+}
+
+// applyTask fills config with the named task template, focused on focus
+func applyTask(config Config, name, focus string) (Config, error) {
+	task, ok := taskTemplates[name]
+	if !ok {
+		return config, fmt.Errorf("unknown task: %s (available: %s)", name, strings.Join(taskNames(), ", "))
+	}
+
+	focus = filepath.ToSlash(filepath.Clean(focus))
+	if focus == "." || focus == "" {
+		return config, fmt.Errorf("task %s needs a --focus path inside the input directory", name)
+	}
+
+	config.Instructions = fmt.Sprintf(task.instructions, focus)
+	config.SortOrder = task.sortOrder
+	config.MaxTokens = task.maxTokens
+	config.SkipGenerated = true
+	config.PriorityGlobs = append([]string{focus, focus + "/**"}, task.priorityGlobs...)
+
+	if len(task.extraExcludes) > 0 {
+		if config.ExcludeGlobs == nil {
+			config.ExcludeGlobs = DefaultConfig().ExcludeGlobs
+		}
+		config.ExcludeGlobs = append(append([]string{}, config.ExcludeGlobs...), task.extraExcludes...)
+	}
+
+	return config, nil
+}
+
+// taskNames returns the available task names in sorted order
+func taskNames() []string {
+	names := make([]string, 0, len(taskTemplates))
+	for name := range taskTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// taskSummaries lists each task with its description for help output
+func taskSummaries() string {
+	var b strings.Builder
+	for _, name := range taskNames() {
+		fmt.Fprintf(&b, "  %-9s %s\n", name, taskTemplates[name].short)
+	}
+	return b.String()
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// testNamePatterns match the base names of test files by language convention
+var testNamePatterns = []string{
+	"*_test.go",
+	"test_*.py", "*_test.py", "conftest.py",
+	"*.test.[jt]s", "*.test.[jt]sx", "*.test.[mc]js", "*.spec.[jt]s", "*.spec.[jt]sx", "*.spec.[mc]js",
+	"*_spec.rb", "*_test.rb",
+	"*Test.java", "*Tests.java", "*IT.java", "*Test.kt", "*Tests.kt",
+	"*Test.cs", "*Tests.cs", "*Test.php", "*Tests.swift", "*_test.exs",
+}
+
+// isTestFile reports whether a file is a test, by its name or by a
+// directory on its path. Go's testdata directories hold test fixtures.
+func isTestFile(relPath string) bool {
+	dirs := strings.Split(filepath.ToSlash(filepath.Dir(relPath)), "/")
+	for _, dir := range dirs {
+		if testDirs[dir] || dir == "testdata" {
+			return true
+		}
+	}
+
+	base := filepath.Base(relPath)
+	for _, pattern := range testNamePatterns {
+		matched, err := filepath.Match(pattern, base)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error matching test pattern %s: %v\n", pattern, err)
+			continue
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// testReason returns why a file is skipped under --no-tests or
+// --tests-only, or "" if it is packed
+func (p *fileProcessor) testReason(relPath string) string {
+	switch {
+	case p.config.NoTests && isTestFile(relPath):
+		return "test"
+	case p.config.TestsOnly && !isTestFile(relPath):
+		return "not a test"
+	}
+	return ""
+}
+
+// validateTests checks that at most one test selection is given
+func validateTests(config *Config) error {
+	if config.NoTests && config.TestsOnly {
+		return fmt.Errorf("--no-tests and --tests-only cannot be combined")
+	}
+	return nil
+}
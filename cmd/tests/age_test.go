@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestAgeFilters(t *testing.T) {
+	now := time.Now()
+	ages := map[string]time.Duration{
+		"fresh.go": time.Hour,
+		"month.go": 20 * 24 * time.Hour,
+		"stale.go": 400 * 24 * time.Hour,
+	}
+	cutoff := now.Add(-200 * 24 * time.Hour).Format("2006-01-02")
+
+	tests := []struct {
+		name    string
+		config  cmd.Config
+		want    []string
+		skipped map[string]string
+	}{
+		{
+			name:   "no filter",
+			config: cmd.Config{},
+			want:   []string{"fresh.go", "month.go", "stale.go"},
+		},
+		{
+			name:    "newer than days",
+			config:  cmd.Config{NewerThan: "30d"},
+			want:    []string{"fresh.go", "month.go"},
+			skipped: map[string]string{"stale.go": "too old"},
+		},
+		{
+			name:    "newer than hours",
+			config:  cmd.Config{NewerThan: "12h"},
+			want:    []string{"fresh.go"},
+			skipped: map[string]string{"month.go": "too old"},
+		},
+		{
+			name:    "older than weeks",
+			config:  cmd.Config{OlderThan: "2w"},
+			want:    []string{"month.go", "stale.go"},
+			skipped: map[string]string{"fresh.go": "too new"},
+		},
+		{
+			name:   "between an age and a date",
+			config: cmd.Config{NewerThan: cutoff, OlderThan: "1d"},
+			want:   []string{"month.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			for name, age := range ages {
+				path := filepath.Join(tempDir, name)
+				if err := os.WriteFile(path, []byte("package main\n"), 0644); err != nil {
+					t.Fatalf("Failed to write %s: %v", name, err)
+				}
+				modified := now.Add(-age)
+				if err := os.Chtimes(path, modified, modified); err != nil {
+					t.Fatalf("Failed to set times of %s: %v", name, err)
+				}
+			}
+			outputPath := filepath.Join(t.TempDir(), "out.txt")
+
+			config := tt.config
+			config.InputDir = tempDir
+			config.OutputFile = outputPath
+			config.IncludeGlobs = []string{"**/*.go"}
+			config.Verbose = true
+			if err := cmd.ProcessDirectory(config); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			data, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			if got := corpusPaths(cmd.ParseCorpus(data)); !sliceEqual(got, tt.want) {
+				t.Errorf("Packed %v, want %v", got, tt.want)
+			}
+			for path, reason := range tt.skipped {
+				if !strings.Contains(string(data), path+" ("+reason+")") {
+					t.Errorf("Expected %s to be listed as skipped with reason %q", path, reason)
+				}
+			}
+		})
+	}
+}
+
+func TestAgeFiltersGitDates(t *testing.T) {
+	// Committed on 2024-03-01, while the files on disk were just written
+	repo := initGitRepo(t, "service", map[string]string{
+		"main.go":    "package main\n",
+		"api/api.go": "package api\n",
+	})
+	if err := os.WriteFile(filepath.Join(repo, "draft.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatalf("Failed to write draft.go: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		config cmd.Config
+		want   []string
+	}{
+		{name: "modification times", config: cmd.Config{NewerThan: "2024-06-01"}, want: []string{"api/api.go", "draft.go", "main.go"}},
+		{name: "commit dates", config: cmd.Config{NewerThan: "2024-06-01", GitDates: true}, want: []string{"draft.go"}},
+		{name: "commit dates older than", config: cmd.Config{OlderThan: "2024-06-01", GitDates: true}, want: []string{"api/api.go", "main.go"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "out.txt")
+			config := tt.config
+			config.InputDir = repo
+			config.OutputFile = outputPath
+			config.IncludeGlobs = []string{"**/*.go"}
+			config.NoGitHeader = true
+			if err := cmd.ProcessDirectory(config); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			data, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			if got := corpusPaths(cmd.ParseCorpus(data)); !sliceEqual(got, tt.want) {
+				t.Errorf("Packed %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAgeFilterValidation(t *testing.T) {
+	tempDir, cleanup := createTestFiles(t)
+	defer cleanup()
+
+	for _, config := range []cmd.Config{{NewerThan: "yesterday"}, {OlderThan: "-3d"}} {
+		config.InputDir = tempDir
+		config.OutputFile = filepath.Join(t.TempDir(), "out.txt")
+		if err := cmd.ProcessDirectory(config); err == nil || !strings.Contains(err.Error(), "than") {
+			t.Errorf("Expected an invalid time error, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestAnnotations(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"billing/invoice.go": "package billing\n",
+		"legacy/old.go":      "package legacy\n",
+		"main.go":            "package main\n",
+		"annotations.json": `{
+  "billing/invoice.go": "core business logic",
+  "legacy/**": "deprecated,\n do not extend"
+}`,
+	}
+	for path, content := range files {
+		fullPath := filepath.Join(tempDir, path)
+		os.MkdirAll(filepath.Dir(fullPath), 0755)
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	outDir := t.TempDir()
+	config := cmd.Config{
+		InputDir:        tempDir,
+		OutputFile:      filepath.Join(outDir, "out.txt"),
+		IncludeGlobs:    []string{"**/*.go", "**/*.json"},
+		AnnotationsFile: filepath.Join(tempDir, "annotations.json"),
+		ManifestFile:    filepath.Join(outDir, "manifest.json"),
+	}
+	if err := cmd.ProcessDirectory(config); err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+
+	assertFileContains(t, config.OutputFile, "--- START OF FILE: billing/invoice.go ---\n--- NOTE: core business logic ---\npackage billing\n")
+	assertFileContains(t, config.OutputFile, "--- START OF FILE: legacy/old.go ---\n--- NOTE: deprecated, do not extend ---\n")
+	assertFileContains(t, config.OutputFile, "--- START OF FILE: main.go ---\npackage main\n")
+	assertFileNotContains(t, config.OutputFile, "--- START OF FILE: annotations.json ---")
+
+	data, _ := os.ReadFile(config.ManifestFile)
+	var manifest cmd.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("Failed to parse manifest: %v", err)
+	}
+	notes := make(map[string]string)
+	for _, entry := range manifest.Files {
+		notes[entry.Path] = entry.Note
+	}
+	if notes["billing/invoice.go"] != "core business logic" || notes["main.go"] != "" {
+		t.Errorf("Unexpected manifest notes %v", notes)
+	}
+
+	corpus, err := cmd.LoadCorpus(config.OutputFile)
+	if err != nil {
+		t.Fatalf("LoadCorpus failed: %v", err)
+	}
+	f, ok := corpus.Find("billing/invoice.go")
+	if !ok || f.Note != "core business logic" || string(corpus.Content(f)) != "package billing\n" {
+		t.Errorf("Unexpected parsed file %+v with content %q", f, corpus.Content(f))
+	}
+}
@@ -0,0 +1,193 @@
+package tests
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestAnonymizeDirs(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     string
+		validate func(t *testing.T, content string, mapping map[string]string)
+	}{
+		{
+			name: "hash mode",
+			mode: "hash",
+			validate: func(t *testing.T, content string, mapping map[string]string) {
+				if len(mapping) != 2 {
+					t.Fatalf("Expected 2 mapped directories, got %v", mapping)
+				}
+				for alias, original := range mapping {
+					if !strings.HasPrefix(alias, "dir-") {
+						t.Errorf("Unexpected alias %s", alias)
+					}
+					if !strings.Contains(content, "src/"+alias+"/") {
+						t.Errorf("Expected alias %s for %s in corpus", alias, original)
+					}
+				}
+			},
+		},
+		{
+			name: "alias mode",
+			mode: "alias",
+			validate: func(t *testing.T, content string, mapping map[string]string) {
+				if mapping["dir-1"] != "pkg1" || mapping["dir-2"] != "pkg2" {
+					t.Errorf("Expected sequential aliases, got %v", mapping)
+				}
+				if !strings.Contains(content, "--- START OF FILE: src/dir-1/file1.go ---") {
+					t.Error("Expected aliased path in separator")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir, cleanup := createTestFiles(t)
+			defer cleanup()
+
+			outputPath := filepath.Join(tempDir, "out.txt")
+			mapPath := filepath.Join(tempDir, "mapping.json")
+			config := cmd.Config{
+				InputDir:         tempDir,
+				OutputFile:       outputPath,
+				IncludeGlobs:     []string{"**/*.go", "**/*.json"},
+				ExcludeGlobs:     []string{"**/vendor/**"},
+				Verbose:          true,
+				AnonymizeDirs:    []string{"pkg*"},
+				AnonymizeMode:    tt.mode,
+				AnonymizeMapFile: mapPath,
+			}
+
+			// Run twice so the mapping file from the first run is present in the tree
+			for i := 0; i < 2; i++ {
+				if err := cmd.ProcessDirectory(config); err != nil {
+					t.Fatalf("ProcessDirectory failed: %v", err)
+				}
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+			if strings.Contains(string(content), "pkg1/") || strings.Contains(string(content), "pkg2/") {
+				t.Error("Original directory names should not appear in the corpus")
+			}
+			if strings.Contains(string(content), "mapping.json") {
+				t.Error("Mapping file should never be packed")
+			}
+
+			data, err := os.ReadFile(mapPath)
+			if err != nil {
+				t.Fatalf("Failed to read mapping file: %v", err)
+			}
+			var mapping map[string]string
+			if err := json.Unmarshal(data, &mapping); err != nil {
+				t.Fatalf("Mapping file is not valid JSON: %v", err)
+			}
+
+			tt.validate(t, string(content), mapping)
+		})
+	}
+}
+
+func TestAnonymizeDirsGitHeader(t *testing.T) {
+	repo := initGitRepo(t, "customer-acme", map[string]string{"customer-acme/main.go": "package main\n"})
+	runGit(t, repo, "remote", "add", "origin", "https://git.example.com/customer-acme.git")
+	output := filepath.Join(t.TempDir(), "corpus.txt")
+
+	config := cmd.Config{
+		InputDir:      repo,
+		OutputFile:    output,
+		IncludeGlobs:  []string{"**/*.go"},
+		AnonymizeDirs: []string{"customer-*"},
+	}
+	if err := cmd.ProcessDirectory(config); err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+
+	assertFileNotContains(t, output, "--- GIT ---")
+	assertFileNotContains(t, output, "customer-acme")
+}
+
+func TestAnonymize(t *testing.T) {
+	tempDir := t.TempDir()
+	writeWorkspaceFiles(t, tempDir, map[string]string{
+		"billing/api/client.go": "// Maintainer: Alice <alice@acme-corp.com>\n" +
+			"import \"github.com/acme/billing\"\n" +
+			"const api = \"https://api.acme-corp.com/v1\"\n" +
+			"const db = \"db.acme.internal:5432\"\n" +
+			"var u = java.net.URL\n" +
+			"x := config.dev()\n",
+		"docs/notes.md": "Contact alice@acme-corp.com or visit acme-corp.com.\n",
+	})
+	outputPath := filepath.Join(t.TempDir(), "out.txt")
+	mapPath := filepath.Join(t.TempDir(), "mapping.json")
+
+	config := cmd.Config{
+		InputDir:         tempDir,
+		OutputFile:       outputPath,
+		IncludeGlobs:     []string{"**/*.go", "**/*.md"},
+		Anonymize:        true,
+		AnonymizeMode:    "alias",
+		AnonymizeMapFile: mapPath,
+	}
+	if err := cmd.ProcessDirectory(config); err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	content := string(data)
+	for _, leaked := range []string{"alice", "acme-corp.com", "acme.internal", "billing/api/", "--- GIT ---"} {
+		if strings.Contains(content, leaked) {
+			t.Errorf("Corpus should not contain %q", leaked)
+		}
+	}
+	for _, want := range []string{
+		"--- START OF FILE: dir-1/dir-2/client.go ---",
+		"<user-1@host-1.example>",
+		"\"https://host-2.example/v1\"",
+		"\"host-3.example:5432\"",
+		"Contact user-1@host-1.example or visit host-1.example.",
+		"github.com/acme/billing",
+		"java.net.URL",
+		"config.dev()",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Corpus should contain %q", want)
+		}
+	}
+
+	data, err = os.ReadFile(mapPath)
+	if err != nil {
+		t.Fatalf("Failed to read mapping file: %v", err)
+	}
+	var mapping map[string]string
+	if err := json.Unmarshal(data, &mapping); err != nil {
+		t.Fatalf("Mapping file is not valid JSON: %v", err)
+	}
+	want := map[string]string{
+		"dir-1": "billing", "dir-2": "api", "dir-3": "docs",
+		"user-1": "alice", "host-1.example": "acme-corp.com",
+		"host-2.example": "api.acme-corp.com", "host-3.example": "db.acme.internal",
+	}
+	for alias, original := range want {
+		if mapping[alias] != original {
+			t.Errorf("mapping[%s] = %q, want %q", alias, mapping[alias], original)
+		}
+	}
+
+	config.GitLog = 5
+	if err := cmd.ProcessDirectory(config); err == nil || !strings.Contains(err.Error(), "--git-log") {
+		t.Errorf("Expected --anonymize with --git-log to fail, got %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestBatch(t *testing.T) {
+	tempDir := t.TempDir()
+	writeWorkspaceFiles(t, tempDir, map[string]string{
+		"services/api/main.go":      "package main\n",
+		"services/api/main_test.go": "package main\n",
+		"services/api/README.md":    "# API\n",
+		"services/web/app.js":       "console.log('web')\n",
+		"services/web/app.test.js":  "test('web')\n",
+	})
+
+	tests := []struct {
+		name      string
+		file      string
+		content   string
+		parallel  int
+		wantError string
+		validate  func(t *testing.T, batch *cmd.Batch, results []cmd.BatchResult)
+	}{
+		{
+			name: "yaml jobs over defaults",
+			file: "batch.yaml",
+			content: `parallel: 2
+defaults:
+  excludeGlobs: ["**/*_test.go", "**/*.test.js"]
+  noGitHeader: true
+jobs:
+  - name: api
+    inputDir: services/api
+    outputFile: out/api.txt
+    includeGlobs: ["**/*.go"]
+  - inputDir: services/web
+    includeGlobs: ["**/*.js"]
+`,
+			validate: func(t *testing.T, batch *cmd.Batch, results []cmd.BatchResult) {
+				if batch.Parallel != 2 {
+					t.Errorf("Expected parallel 2, got %d", batch.Parallel)
+				}
+				if results[1].Name != "web" {
+					t.Errorf("Expected an unnamed job to be named after its directory, got %q", results[1].Name)
+				}
+
+				apiOut := filepath.Join(tempDir, "out", "api.txt")
+				assertFileContains(t, apiOut, "main.go")
+				assertFileNotContains(t, apiOut, "main_test.go")
+				assertFileNotContains(t, apiOut, "README.md")
+
+				webOut := filepath.Join(tempDir, "web.txt")
+				assertFileContains(t, webOut, "app.js")
+				assertFileNotContains(t, webOut, "app.test.js")
+			},
+		},
+		{
+			name:     "json jobs",
+			file:     "batch.json",
+			parallel: 1,
+			content: `{"jobs": [
+  {"name": "docs", "inputDir": "services/api", "outputFile": "docs.txt", "includeGlobs": ["**/*.md"], "noGitHeader": true}
+]}`,
+			validate: func(t *testing.T, batch *cmd.Batch, results []cmd.BatchResult) {
+				assertFileContains(t, filepath.Join(tempDir, "docs.txt"), "README.md")
+			},
+		},
+		{
+			name: "failed jobs do not stop the batch",
+			file: "failing.yaml",
+			content: `jobs:
+  - name: missing
+    inputDir: services/none
+  - name: ok
+    inputDir: services/api
+    outputFile: ok.txt
+    noGitHeader: true
+`,
+			validate: func(t *testing.T, batch *cmd.Batch, results []cmd.BatchResult) {
+				if results[0].Err == nil {
+					t.Error("Expected the missing directory to fail")
+				}
+				if results[1].Err != nil {
+					t.Errorf("Expected the second job to succeed, got %v", results[1].Err)
+				}
+				assertFileContains(t, filepath.Join(tempDir, "ok.txt"), "main.go")
+			},
+		},
+		{
+			name: "duplicate outputs",
+			file: "duplicate.yaml",
+			content: `jobs:
+  - inputDir: services/api
+    outputFile: same.txt
+  - inputDir: services/web
+    outputFile: same.txt
+`,
+			wantError: "both write",
+		},
+		{
+			name: "cpu profiles of parallel jobs",
+			file: "pprof.yaml",
+			content: `parallel: 2
+jobs:
+  - inputDir: services/api
+    pprof: cpu
+`,
+			wantError: "needs parallel 1",
+		},
+		{
+			name:      "no jobs",
+			file:      "empty.yaml",
+			content:   "parallel: 2\n",
+			wantError: "has no jobs",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tempDir, tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write batch file: %v", err)
+			}
+
+			batch, err := cmd.LoadBatch(path)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadBatch failed: %v", err)
+			}
+			if tt.parallel > 0 {
+				batch.Parallel = tt.parallel
+			}
+
+			tt.validate(t, batch, cmd.RunBatch(batch))
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestSummaryBreakdowns(t *testing.T) {
+	files := map[string]string{
+		"main.go":       strings.Repeat("a", 400),
+		"src/app.py":    strings.Repeat("b", 400),
+		"src/util.py":   strings.Repeat("c", 800),
+		"docs/guide.md": strings.Repeat("d", 400),
+	}
+
+	tests := []struct {
+		name      string
+		lowMemory bool
+		expected  []string
+	}{
+		{
+			name: "buffered summary",
+			expected: []string{
+				"By Directory:\nsrc/   2 files (50.0%), 1200 bytes (60.0%), 300 tokens (60.0%)\n" +
+					".      1 files (25.0%), 400 bytes (20.0%), 100 tokens (20.0%)\n" +
+					"docs/  1 files (25.0%), 400 bytes (20.0%), 100 tokens (20.0%)\n",
+				"By Language:\npython    2 files (50.0%), 1200 bytes (60.0%), 300 tokens (60.0%)\n" +
+					"go        1 files (25.0%), 400 bytes (20.0%), 100 tokens (20.0%)\n" +
+					"markdown  1 files (25.0%), 400 bytes (20.0%), 100 tokens (20.0%)\n",
+				"Size Histogram:\nTOKENS PER FILE    FILES        BYTES     TOKENS\n" +
+					"0-99                   0            0          0\n" +
+					"100-999                4         2000        500  ##############################\n",
+				"Largest Files:\n    TOKENS        BYTES  PATH\n" +
+					"       200          800  src/util.py\n" +
+					"       100          400  docs/guide.md\n",
+			},
+		},
+		{
+			name:      "low-memory summary",
+			lowMemory: true,
+			expected:  []string{"By Directory:\nsrc/   2 files", "By Language:\npython    2 files"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputDir := t.TempDir()
+			writeWorkspaceFiles(t, inputDir, files)
+			outputPath := filepath.Join(t.TempDir(), "out.txt")
+
+			config := cmd.Config{
+				InputDir:      inputDir,
+				OutputFile:    outputPath,
+				IncludeGlobs:  []string{"**/*"},
+				Verbose:       true,
+				LowMemory:     tt.lowMemory,
+				Deterministic: true,
+			}
+			if err := cmd.ProcessDirectory(config); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+			for _, want := range tt.expected {
+				if !strings.Contains(string(content), want) {
+					t.Errorf("Expected summary to contain %q, got:\n%s", want, content)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package tests
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+// numberedLines returns n lines of eight bytes each, two estimated tokens apiece
+func numberedLines(n int) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&b, "line%03d\n", i)
+	}
+	return b.String()
+}
+
+func TestMaxFileTokens(t *testing.T) {
+	tests := []struct {
+		name       string
+		files      map[string]string
+		maxTokens  int
+		fileTokens int
+		contains   []string
+		absent     []string
+		order      []string
+	}{
+		{
+			name:       "long file keeps its beginning",
+			files:      map[string]string{"big.txt": numberedLines(100), "small.txt": "short\n"},
+			fileTokens: 20,
+			contains:   []string{"line010\n... [truncated: 180 of 200 tokens omitted]", "short\n"},
+			absent:     []string{"line011"},
+			order:      []string{"big.txt", "small.txt"},
+		},
+		{
+			name:       "first line longer than the cap",
+			files:      map[string]string{"wide.txt": strings.Repeat("é", 40) + "\nrest\n"},
+			fileTokens: 5,
+			// Nine characters and the line break are the five tokens
+			contains: []string{"\n" + strings.Repeat("é", 9) + "\n... [truncated:"},
+			absent:   []string{"rest"},
+			order:    []string{"wide.txt"},
+		},
+		{
+			name:       "single line longer than the cap",
+			files:      map[string]string{"line.txt": strings.Repeat("x", 5000)},
+			fileTokens: 50,
+			contains:   []string{"\n" + strings.Repeat("x", 199) + "\n... [truncated: 1200 of 1250 tokens omitted]"},
+			absent:     []string{strings.Repeat("x", 200)},
+			order:      []string{"line.txt"},
+		},
+		{
+			name:       "budget counts capped files",
+			files:      map[string]string{"a.txt": numberedLines(100), "b.txt": numberedLines(100), "c.txt": numberedLines(100)},
+			maxTokens:  60,
+			fileTokens: 20,
+			order:      []string{"a.txt", "b.txt", "c.txt"},
+		},
+		{
+			name:      "budget without a cap",
+			files:     map[string]string{"a.txt": numberedLines(100), "b.txt": numberedLines(100), "c.txt": numberedLines(100)},
+			maxTokens: 60,
+			order:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputDir := t.TempDir()
+			writeWorkspaceFiles(t, inputDir, tt.files)
+			outputPath := filepath.Join(t.TempDir(), "out.txt")
+
+			config := cmd.Config{
+				InputDir:      inputDir,
+				OutputFile:    outputPath,
+				IncludeGlobs:  []string{"**/*.txt"},
+				Deterministic: true,
+				MaxTokens:     tt.maxTokens,
+				MaxFileTokens: tt.fileTokens,
+			}
+			if err := cmd.ProcessDirectory(config); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(string(content), want) {
+					t.Errorf("Expected output to contain %q, got:\n%s", want, content)
+				}
+			}
+			for _, unwanted := range tt.absent {
+				if strings.Contains(string(content), unwanted) {
+					t.Errorf("Expected output not to contain %q", unwanted)
+				}
+			}
+			if got := fileOrder(t, outputPath); !reflect.DeepEqual(got, tt.order) {
+				t.Errorf("Expected files %v, got %v", tt.order, got)
+			}
+		})
+	}
+}
+
+func TestBudgetShares(t *testing.T) {
+	files := map[string]string{
+		"a/x1.txt":    numberedLines(100),
+		"a/x2.txt":    numberedLines(100),
+		"a/x3.txt":    numberedLines(100),
+		"a/x4.txt":    numberedLines(100),
+		"core/c1.txt": numberedLines(100),
+		"core/c2.txt": numberedLines(100),
+		"docs/d.txt":  numberedLines(100),
+	}
+
+	tests := []struct {
+		name      string
+		maxTokens int
+		budget    map[string]string
+		order     []string
+		wantErr   string
+	}{
+		{
+			name:      "one budget for all files",
+			maxTokens: 1000,
+			order:     []string{"a/x1.txt", "a/x2.txt", "a/x3.txt", "a/x4.txt", "core/c1.txt"},
+		},
+		{
+			name:      "shares are filled on their own",
+			maxTokens: 1000,
+			budget:    map[string]string{"core/**": "60%", "docs/**": "10%"},
+			order:     []string{"a/x1.txt", "core/c1.txt", "core/c2.txt"},
+		},
+		{
+			name:      "overlapping shares go to the most specific glob",
+			maxTokens: 1000,
+			budget:    map[string]string{"core/**": "60%", "**": "20%"},
+			order:     []string{"a/x1.txt", "core/c1.txt", "core/c2.txt"},
+		},
+		{
+			name:    "shares without a token budget",
+			budget:  map[string]string{"core/**": "60%"},
+			wantErr: "set --max-tokens",
+		},
+		{
+			name:      "shares over 100%",
+			maxTokens: 1000,
+			budget:    map[string]string{"core/**": "60%", "docs/**": "50%"},
+			wantErr:   "add up to 110%",
+		},
+		{
+			name:      "invalid share",
+			maxTokens: 1000,
+			budget:    map[string]string{"core/**": "most"},
+			wantErr:   `invalid budget share "most"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputDir := t.TempDir()
+			writeWorkspaceFiles(t, inputDir, files)
+			outputPath := filepath.Join(t.TempDir(), "out.txt")
+
+			config := cmd.Config{
+				InputDir:      inputDir,
+				OutputFile:    outputPath,
+				IncludeGlobs:  []string{"**/*.txt"},
+				Deterministic: true,
+				MaxTokens:     tt.maxTokens,
+				Budget:        tt.budget,
+			}
+			err := cmd.ProcessDirectory(config)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			if got := fileOrder(t, outputPath); !reflect.DeepEqual(got, tt.order) {
+				t.Errorf("Expected files %v, got %v", tt.order, got)
+			}
+		})
+	}
+}
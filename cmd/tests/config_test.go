@@ -2,6 +2,8 @@ package tests
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
@@ -463,6 +465,22 @@ func TestMergeConfig(t *testing.T) {
 				IncludeGlobs: []string{"**/*.py"},
 				Verbose:      false,
 			},
+		}, {
+			name: "flag with file head lines",
+			config: cmd.Config{
+				InputDir: ".",
+				Verbose:  true,
+			},
+			autoConfig: &cmd.Config{
+				OutputFile: "auto.txt",
+				HeadLines:  20,
+			},
+			want: cmd.Config{
+				InputDir:   ".",
+				OutputFile: "auto.txt",
+				Verbose:    true,
+				HeadLines:  20,
+			},
 		},
 	}
 
@@ -486,6 +504,9 @@ func TestMergeConfig(t *testing.T) {
 			if got.Verbose != tt.want.Verbose {
 				t.Errorf("Verbose = %v, want %v", got.Verbose, tt.want.Verbose)
 			}
+			if got.HeadLines != tt.want.HeadLines {
+				t.Errorf("HeadLines = %v, want %v", got.HeadLines, tt.want.HeadLines)
+			}
 		})
 	}
 }
@@ -717,3 +738,458 @@ verbose: true
 		})
 	}
 }
+
+func TestConfigEnvExpansion(t *testing.T) {
+	t.Setenv("CPACK_TEST_ROOT", "/srv/app")
+	t.Setenv("CPACK_TEST_SALT", "s3cret")
+
+	tests := []struct {
+		name     string
+		content  string
+		wantErr  string
+		validate func(t *testing.T, config *cmd.Config)
+	}{
+		{
+			name: "paths, globs and salt",
+			content: `
+inputDir: ${CPACK_TEST_ROOT}/src
+outputFile: ${CPACK_TEST_OUT:-build}/corpus.txt
+includeGlobs:
+  - "${CPACK_TEST_ROOT}/**/*.go"
+anonymizeSalt: ${CPACK_TEST_SALT}
+rules:
+  - glob: "${CPACK_TEST_ROOT}/*.csv"
+    headLines: 5
+instructions: Costs $${HOME} per run
+`,
+			validate: func(t *testing.T, config *cmd.Config) {
+				checks := map[string][2]string{
+					"InputDir":      {config.InputDir, "/srv/app/src"},
+					"OutputFile":    {config.OutputFile, "build/corpus.txt"},
+					"IncludeGlobs":  {config.IncludeGlobs[0], "/srv/app/**/*.go"},
+					"AnonymizeSalt": {config.AnonymizeSalt, "s3cret"},
+					"Rules[0].Glob": {config.Rules[0].Glob, "/srv/app/*.csv"},
+					// Only paths, globs and the salt are expanded
+					"Instructions": {config.Instructions, "Costs $${HOME} per run"},
+				}
+				for field, check := range checks {
+					if check[0] != check[1] {
+						t.Errorf("%s = %q, want %q", field, check[0], check[1])
+					}
+				}
+			},
+		},
+		{
+			name:    "unset variable",
+			content: "outputFile: ${CPACK_TEST_UNSET}/corpus.txt\n",
+			wantErr: "environment variable CPACK_TEST_UNSET is not set",
+		},
+		{
+			name:    "escaped reference",
+			content: "outputFile: \"$${CPACK_TEST_UNSET}.txt\"\n",
+			validate: func(t *testing.T, config *cmd.Config) {
+				if config.OutputFile != "${CPACK_TEST_UNSET}.txt" {
+					t.Errorf("OutputFile = %q, want the literal reference", config.OutputFile)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "cpack.yaml")
+			if err := os.WriteFile(configPath, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+
+			config, err := cmd.LoadConfigFromFile(configPath)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfigFromFile failed: %v", err)
+			}
+			tt.validate(t, config)
+		})
+	}
+}
+
+func TestConfigProfiles(t *testing.T) {
+	yamlConfig := `
+excludeGlobs:
+  - "**/vendor/**"
+includeGlobs:
+  - "**/*.go"
+profiles:
+  docs:
+    includeGlobs:
+      - "**/*.md"
+    instructions: Summarize the docs.
+  review:
+    sort: deps
+`
+	jsonConfig := `{
+  "excludeGlobs": ["**/vendor/**"],
+  "includeGlobs": ["**/*.go"],
+  "profiles": {
+    "docs": {"includeGlobs": ["**/*.md"], "instructions": "Summarize the docs."},
+    "review": {"sort": "deps"}
+  }
+}`
+
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		profile  string
+		wantErr  string
+		validate func(t *testing.T, config *cmd.Config)
+	}{
+		{
+			name: "base section without a profile", file: "cpack.yaml", content: yamlConfig,
+			validate: func(t *testing.T, config *cmd.Config) {
+				if !sliceEqual(config.IncludeGlobs, []string{"**/*.go"}) || config.Instructions != "" {
+					t.Errorf("Expected the base section only, got %+v", config)
+				}
+			},
+		},
+		{
+			name: "yaml profile overrides and inherits", file: "cpack.yaml", content: yamlConfig, profile: "docs",
+			validate: func(t *testing.T, config *cmd.Config) {
+				if !sliceEqual(config.IncludeGlobs, []string{"**/*.md"}) {
+					t.Errorf("IncludeGlobs = %v, want the profile's", config.IncludeGlobs)
+				}
+				if !sliceEqual(config.ExcludeGlobs, []string{"**/vendor/**"}) {
+					t.Errorf("ExcludeGlobs = %v, want the inherited base globs", config.ExcludeGlobs)
+				}
+				if config.Instructions != "Summarize the docs." {
+					t.Errorf("Instructions = %q", config.Instructions)
+				}
+			},
+		},
+		{
+			name: "json profile", file: "cpack.json", content: jsonConfig, profile: "review",
+			validate: func(t *testing.T, config *cmd.Config) {
+				if config.SortOrder != cmd.SortDeps || !sliceEqual(config.IncludeGlobs, []string{"**/*.go"}) {
+					t.Errorf("Expected deps order with the base globs, got %+v", config)
+				}
+			},
+		},
+		{
+			name: "unknown profile", file: "cpack.yaml", content: yamlConfig, profile: "nope",
+			wantErr: "unknown profile: nope (available: docs, review)",
+		},
+		{
+			name: "no profiles section", file: "cpack.yaml", content: "verbose: true\n", profile: "docs",
+			wantErr: "defines no profiles",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(configPath, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+
+			config, err := cmd.LoadConfigProfile(configPath, tt.profile)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfigProfile failed: %v", err)
+			}
+			tt.validate(t, config)
+		})
+	}
+
+	t.Run("pack with a profile", func(t *testing.T) {
+		tempDir := t.TempDir()
+		os.WriteFile(filepath.Join(tempDir, "cpack.yaml"), []byte(yamlConfig), 0644)
+		os.WriteFile(filepath.Join(tempDir, "main.go"), []byte("package main\n"), 0644)
+		os.WriteFile(filepath.Join(tempDir, "README.md"), []byte("# readme\n"), 0644)
+
+		outputPath := filepath.Join(t.TempDir(), "out.txt")
+		config := cmd.Config{InputDir: tempDir, OutputFile: outputPath, Profile: "docs"}
+		if err := cmd.ProcessDirectory(config); err != nil {
+			t.Fatalf("ProcessDirectory failed: %v", err)
+		}
+		assertFileContains(t, outputPath, "--- START OF FILE: README.md ---")
+		assertFileNotContains(t, outputPath, "--- START OF FILE: main.go ---")
+
+		config.InputDir = t.TempDir()
+		if err := cmd.ProcessDirectory(config); err == nil || !strings.Contains(err.Error(), "error loading profile docs") {
+			t.Errorf("Expected a missing config file to fail with a profile, got %v", err)
+		}
+	})
+}
+
+func TestConfigExtends(t *testing.T) {
+	baseConfig := `
+excludeGlobs:
+  - "**/vendor/**"
+  - "**/node_modules/**"
+includeGlobs:
+  - "**/*.go"
+maxTokens: 50000
+prices:
+  team-model: 1.5
+  other-model: 2
+`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/shared/cpack.yaml":
+			fmt.Fprint(w, baseConfig)
+		case "/shared/loop.yaml":
+			fmt.Fprint(w, "extends: loop.yaml\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		files    map[string]string
+		config   string
+		wantErr  string
+		validate func(t *testing.T, config *cmd.Config)
+	}{
+		{
+			name: "relative path with deep merge",
+			files: map[string]string{
+				"base-cpack.yaml": baseConfig,
+				"svc/cpack.yaml": `
+extends: ../base-cpack.yaml
+includeGlobs:
+  - "**/*.ts"
+prices:
+  team-model: 3
+`,
+			},
+			config: "svc/cpack.yaml",
+			validate: func(t *testing.T, config *cmd.Config) {
+				if !sliceEqual(config.IncludeGlobs, []string{"**/*.ts"}) {
+					t.Errorf("IncludeGlobs = %v, want the extending file's list", config.IncludeGlobs)
+				}
+				if !sliceEqual(config.ExcludeGlobs, []string{"**/vendor/**", "**/node_modules/**"}) {
+					t.Errorf("ExcludeGlobs = %v, want the base list", config.ExcludeGlobs)
+				}
+				if config.MaxTokens != 50000 {
+					t.Errorf("MaxTokens = %d, want 50000", config.MaxTokens)
+				}
+				if config.Prices["team-model"] != 3 || config.Prices["other-model"] != 2 {
+					t.Errorf("Prices = %v, want the maps merged", config.Prices)
+				}
+			},
+		},
+		{
+			name: "chain with a json file",
+			files: map[string]string{
+				"base-cpack.yaml": baseConfig,
+				"team.yaml":       "extends: base-cpack.yaml\nsort: deps\n",
+				"app/cpack.json":  `{"extends": "../team.yaml", "maxTokens": 80000}`,
+			},
+			config: "app/cpack.json",
+			validate: func(t *testing.T, config *cmd.Config) {
+				if config.SortOrder != cmd.SortDeps || config.MaxTokens != 80000 {
+					t.Errorf("Expected deps order and 80000 tokens, got %q and %d", config.SortOrder, config.MaxTokens)
+				}
+				if !sliceEqual(config.IncludeGlobs, []string{"**/*.go"}) {
+					t.Errorf("IncludeGlobs = %v, want the base list", config.IncludeGlobs)
+				}
+			},
+		},
+		{
+			name: "url",
+			files: map[string]string{
+				"cpack.yaml": fmt.Sprintf("extends: %s/shared/cpack.yaml\nverbose: true\n", server.URL),
+			},
+			config: "cpack.yaml",
+			validate: func(t *testing.T, config *cmd.Config) {
+				if !config.Verbose || config.MaxTokens != 50000 {
+					t.Errorf("Expected the fetched base under the local file, got %+v", config)
+				}
+			},
+		},
+		{
+			name: "missing base",
+			files: map[string]string{
+				"cpack.yaml": "extends: missing.yaml\n",
+			},
+			config:  "cpack.yaml",
+			wantErr: "error reading base config",
+		},
+		{
+			name: "cycle",
+			files: map[string]string{
+				"a.yaml": "extends: b.yaml\n",
+				"b.yaml": "extends: a.yaml\n",
+			},
+			config:  "a.yaml",
+			wantErr: "extends cycle",
+		},
+		{
+			name: "cycle through a url",
+			files: map[string]string{
+				"cpack.yaml": fmt.Sprintf("extends: %s/shared/loop.yaml\n", server.URL),
+			},
+			config:  "cpack.yaml",
+			wantErr: "extends cycle",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				path := filepath.Join(dir, name)
+				os.MkdirAll(filepath.Dir(path), 0755)
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatalf("Failed to write %s: %v", name, err)
+				}
+			}
+
+			config, err := cmd.LoadConfigFromFile(filepath.Join(dir, tt.config))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfigFromFile failed: %v", err)
+			}
+			tt.validate(t, config)
+		})
+	}
+}
+
+func TestFindConfigFile(t *testing.T) {
+	inputDir := t.TempDir()
+	workDir := t.TempDir()
+	userDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", userDir)
+
+	originalDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(workDir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	defer os.Chdir(originalDir)
+
+	write := func(path string) {
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte("verbose: true\n"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	if got := cmd.FindConfigFile(inputDir); got != "" {
+		t.Errorf("Expected no config file, got %s", got)
+	}
+
+	userConfig := filepath.Join(userDir, "cpack", "config.yaml")
+	write(userConfig)
+	if got := cmd.FindConfigFile(inputDir); got != userConfig {
+		t.Errorf("FindConfigFile = %s, want the user config %s", got, userConfig)
+	}
+
+	write(filepath.Join(workDir, "cpack.json"))
+	if got := cmd.FindConfigFile(inputDir); got != "cpack.json" {
+		t.Errorf("FindConfigFile = %s, want cpack.json in the current directory", got)
+	}
+
+	inputConfig := filepath.Join(inputDir, "cpack.yaml")
+	write(inputConfig)
+	if got := cmd.FindConfigFile(inputDir); got != inputConfig {
+		t.Errorf("FindConfigFile = %s, want the input directory's %s", got, inputConfig)
+	}
+}
+
+func TestExplicitConfigFile(t *testing.T) {
+	inputDir := t.TempDir()
+	os.WriteFile(filepath.Join(inputDir, "main.go"), []byte("package main\n"), 0644)
+	os.WriteFile(filepath.Join(inputDir, "notes.md"), []byte("# notes\n"), 0644)
+	os.WriteFile(filepath.Join(inputDir, "cpack.yaml"), []byte("includeGlobs:\n  - \"**/*.go\"\n"), 0644)
+
+	configPath := filepath.Join(t.TempDir(), "docs.yaml")
+	os.WriteFile(configPath, []byte("includeGlobs:\n  - \"**/*.md\"\n"), 0644)
+
+	outputPath := filepath.Join(t.TempDir(), "out.txt")
+	config := cmd.Config{InputDir: inputDir, OutputFile: outputPath, ConfigFile: configPath}
+	if err := cmd.ProcessDirectory(config); err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+	assertFileContains(t, outputPath, "--- START OF FILE: notes.md ---")
+	assertFileNotContains(t, outputPath, "--- START OF FILE: main.go ---")
+
+	config.ConfigFile = filepath.Join(t.TempDir(), "missing.yaml")
+	if err := cmd.ProcessDirectory(config); err == nil || !strings.Contains(err.Error(), "error loading config file") {
+		t.Errorf("Expected a missing --config file to fail, got %v", err)
+	}
+}
+
+func TestConfigFileOverrides(t *testing.T) {
+	inputDir := t.TempDir()
+	writeWorkspaceFiles(t, inputDir, map[string]string{
+		"main.go":         "package main\n\nfunc main() {}\n",
+		"util.go":         "package main\n\nfunc util() {}\n",
+		"deep/nested.go":  "package deep\n",
+		"deep/er/more.go": "package er\n",
+	})
+	configPath := filepath.Join(t.TempDir(), "cpack.yaml")
+	if err := os.WriteFile(configPath, []byte("includeGlobs: [\"**/*.go\"]\nnoGitHeader: true\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		override cmd.Config
+		wantErr  string
+		contains []string
+		absent   []string
+	}{
+		{name: "max files with strict", override: cmd.Config{MaxFiles: 1, Strict: true}, wantErr: "exceed --max-files"},
+		{name: "max total size with strict", override: cmd.Config{MaxTotalSize: "10", Strict: true}, wantErr: "exceed --max-total-size"},
+		{name: "max depth", override: cmd.Config{MaxDepth: 1}, contains: []string{"main.go"}, absent: []string{"nested.go", "more.go"}},
+		{name: "grep", override: cmd.Config{Grep: "func util"}, contains: []string{"util.go"}, absent: []string{"main.go"}},
+		{name: "frontmatter", override: cmd.Config{Frontmatter: true}, contains: []string{"---\ncpack: "}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			override := tt.override
+			override.InputDir = inputDir
+			override.OutputFile = filepath.Join(t.TempDir(), "corpus.txt")
+
+			var err error
+			captureStderr(t, func() {
+				err = cmd.ProcessDirectoryWithConfigFile(configPath, override)
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ProcessDirectoryWithConfigFile failed: %v", err)
+			}
+			for _, want := range tt.contains {
+				assertFileContains(t, override.OutputFile, want)
+			}
+			for _, unwanted := range tt.absent {
+				assertFileNotContains(t, override.OutputFile, unwanted)
+			}
+		})
+	}
+}
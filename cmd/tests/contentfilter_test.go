@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestContentFilter(t *testing.T) {
+	files := map[string]string{
+		"payment/service.go": "type PaymentService struct{}\n",
+		"payment/mock.go":    "// Mock PaymentService for tests\ntype mockPayments struct{}\n",
+		"user/user.go":       "type User struct{}\n",
+	}
+
+	tests := []struct {
+		name    string
+		config  cmd.Config
+		want    []string
+		skipped map[string]string
+	}{
+		{
+			name:   "no filter",
+			config: cmd.Config{},
+			want:   []string{"payment/mock.go", "payment/service.go", "user/user.go"},
+		},
+		{
+			name:    "grep",
+			config:  cmd.Config{Grep: "PaymentService"},
+			want:    []string{"payment/mock.go", "payment/service.go"},
+			skipped: map[string]string{"user/user.go": "no --grep match"},
+		},
+		{
+			name:    "grep-v",
+			config:  cmd.Config{GrepV: `(?i)\bmock`},
+			want:    []string{"payment/service.go", "user/user.go"},
+			skipped: map[string]string{"payment/mock.go": "--grep-v match"},
+		},
+		{
+			name:   "grep and grep-v",
+			config: cmd.Config{Grep: "PaymentService", GrepV: "(?i)mock"},
+			want:   []string{"payment/service.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			writeWorkspaceFiles(t, tempDir, files)
+			outputPath := filepath.Join(t.TempDir(), "out.txt")
+
+			config := tt.config
+			config.InputDir = tempDir
+			config.OutputFile = outputPath
+			config.IncludeGlobs = []string{"**/*.go"}
+			config.Verbose = true
+			if err := cmd.ProcessDirectory(config); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			data, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			if got := corpusPaths(cmd.ParseCorpus(data)); !sliceEqual(got, tt.want) {
+				t.Errorf("Packed %v, want %v", got, tt.want)
+			}
+			for path, reason := range tt.skipped {
+				if !strings.Contains(string(data), path+" ("+reason+")") {
+					t.Errorf("Expected %s to be listed as skipped with reason %q", path, reason)
+				}
+			}
+		})
+	}
+}
+
+func TestContentFilterValidation(t *testing.T) {
+	tempDir, cleanup := createTestFiles(t)
+	defer cleanup()
+
+	for _, config := range []cmd.Config{{Grep: "("}, {GrepV: "[a-"}} {
+		config.InputDir = tempDir
+		config.OutputFile = filepath.Join(t.TempDir(), "out.txt")
+		if err := cmd.ProcessDirectory(config); err == nil || !strings.Contains(err.Error(), "invalid --grep") {
+			t.Errorf("Expected an invalid pattern error, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestCostModel(t *testing.T) {
+	tempDir := t.TempDir()
+	// 4000 bytes is 1000 estimated tokens
+	if err := os.WriteFile(filepath.Join(tempDir, "data.txt"), []byte(strings.Repeat("abcd", 1000)), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		config     cmd.Config
+		quiet      bool
+		contains   []string
+		wantStderr []string
+		wantErr    string
+	}{
+		{
+			name:     "no cost model",
+			config:   cmd.Config{},
+			contains: []string{"Total Bytes Processed: 4000\n\nBy Directory:"},
+		},
+		{
+			name:   "built-in price",
+			config: cmd.Config{CostModel: "claude-sonnet"},
+			// The buffered summary counts the separators as well
+			contains: []string{"Estimated Tokens: 10", "Estimated Input Cost (claude-sonnet at $3.00/M tokens): $0.003"},
+		},
+		{
+			name:     "configured price",
+			config:   cmd.Config{CostModel: "local-llm", Prices: map[string]float64{"local-llm": 1000}},
+			contains: []string{"Estimated Input Cost (local-llm at $1000.00/M tokens): $1.0"},
+		},
+		{
+			name:     "low memory",
+			config:   cmd.Config{CostModel: "gpt-4o", LowMemory: true},
+			contains: []string{"Estimated Tokens: 1000\n", "Estimated Input Cost (gpt-4o at $2.50/M tokens): $0.0025\n"},
+		},
+		{
+			name:       "without verbose",
+			config:     cmd.Config{CostModel: "claude-sonnet"},
+			quiet:      true,
+			wantStderr: []string{"Estimated Tokens: 10", "Estimated Input Cost (claude-sonnet at $3.00/M tokens): $0.003"},
+		},
+		{
+			name:    "unknown model",
+			config:  cmd.Config{CostModel: "gpt-99"},
+			wantErr: "unknown cost model: gpt-99",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			config.InputDir = tempDir
+			config.OutputFile = filepath.Join(t.TempDir(), "out.txt")
+			config.IncludeGlobs = []string{"**/*.txt"}
+			config.Verbose = !tt.quiet
+
+			var err error
+			stderr := captureStderr(t, func() {
+				err = cmd.ProcessDirectory(config)
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+			for _, s := range tt.contains {
+				assertFileContains(t, config.OutputFile, s)
+			}
+			for _, s := range tt.wantStderr {
+				if !strings.Contains(string(stderr), s) {
+					t.Errorf("stderr missing %q:\n%s", s, stderr)
+				}
+			}
+			if tt.quiet {
+				assertFileNotContains(t, config.OutputFile, "Estimated")
+			}
+		})
+	}
+}
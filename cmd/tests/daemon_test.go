@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+// postPack sends a pack request to a daemon and decodes its response
+func postPack(t *testing.T, url, body string) (int, cmd.DaemonPackResponse) {
+	t.Helper()
+
+	resp, err := http.Post(url+"/pack", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to send pack request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var response cmd.DaemonPackResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode pack response: %v", err)
+	}
+	return resp.StatusCode, response
+}
+
+func TestDaemon(t *testing.T) {
+	server := httptest.NewServer(cmd.NewDaemonHandler())
+	defer server.Close()
+
+	t.Run("repeat packs see changed files", func(t *testing.T) {
+		inputDir := t.TempDir()
+		writeWorkspaceFiles(t, inputDir, map[string]string{
+			"main.go": "package main\n\nfunc main() {}\n",
+			"util.go": "package main\n\nfunc util() int { return 1 }\n",
+		})
+		outputFile := filepath.Join(t.TempDir(), "corpus.txt")
+		request, _ := json.Marshal(map[string]interface{}{
+			"inputDir":   inputDir,
+			"outputFile": outputFile,
+			"tokenizer":  "cl100k_base",
+			"maxTokens":  1000,
+		})
+
+		for _, want := range []string{"return 1", "return 12345"} {
+			if want != "return 1" {
+				writeWorkspaceFiles(t, inputDir, map[string]string{"util.go": "package main\n\nfunc util() int { return 12345 }\n"})
+			}
+			status, response := postPack(t, server.URL, string(request))
+			if status != http.StatusOK || !response.OK {
+				t.Fatalf("Pack failed with status %d: %s", status, response.Error)
+			}
+			if response.OutputFile != outputFile {
+				t.Errorf("Expected output file %s, got %s", outputFile, response.OutputFile)
+			}
+			assertFileContains(t, outputFile, want)
+		}
+	})
+
+	t.Run("failed pack", func(t *testing.T) {
+		status, response := postPack(t, server.URL, `{"inputDir": "`+filepath.Join(t.TempDir(), "missing")+`"}`)
+		if status != http.StatusUnprocessableEntity || response.OK || response.Error == "" {
+			t.Errorf("Expected a failed pack, got status %d and %+v", status, response)
+		}
+	})
+
+	badRequests := []struct {
+		name string
+		body string
+	}{
+		{name: "malformed json", body: `{"inputDir": `},
+		{name: "unknown field", body: `{"inputDirectory": "."}`},
+	}
+	for _, tt := range badRequests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, response := postPack(t, server.URL, tt.body)
+			if status != http.StatusBadRequest || !strings.Contains(response.Error, "error parsing pack request") {
+				t.Errorf("Expected a bad request, got status %d and %+v", status, response)
+			}
+		})
+	}
+
+	t.Run("pprof", func(t *testing.T) {
+		status, response := postPack(t, server.URL, `{"inputDir": ".", "pprof": "cpu"}`)
+		if status != http.StatusBadRequest || !strings.Contains(response.Error, "pprof is not supported") {
+			t.Errorf("Expected pprof to be rejected, got status %d and %+v", status, response)
+		}
+	})
+
+	t.Run("health", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/health")
+		if err != nil {
+			t.Fatalf("Failed to get health: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", resp.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+	"github.com/spf13/cobra"
+)
+
+func TestDeprecatedFlags(t *testing.T) {
+	defaultExcludes := cmd.DefaultConfig().ExcludeGlobs
+
+	tests := []struct {
+		name         string
+		args         []string
+		config       cmd.Config
+		wantIncludes []string
+		wantExcludes []string
+		wantMax      bool
+	}{
+		{
+			name:         "extensions with and without a dot",
+			args:         []string{"--valid-extensions", ".go,py"},
+			wantIncludes: []string{"**/*.go", "**/*.py"},
+		},
+		{
+			name:         "directories combined with extensions",
+			args:         []string{"--valid-dirs", "src/,./cmd", "--valid-extensions", "go"},
+			wantIncludes: []string{"src/**/*.go", "cmd/**/*.go"},
+		},
+		{
+			name:         "directories alone",
+			args:         []string{"--valid-dirs", "docs"},
+			wantIncludes: []string{"docs/**/*"},
+		},
+		{
+			name:         "ignored directories and patterns extend the default excludes",
+			args:         []string{"--ignore-dirs", "testdata", "--ignore-patterns", "**/*.pb.go"},
+			wantExcludes: append(append([]string{}, defaultExcludes...), "**/testdata/**", "**/*.pb.go"),
+		},
+		{
+			name:         "ignored directories extend explicit excludes",
+			args:         []string{"--ignore-dirs", "gen"},
+			config:       cmd.Config{ExcludeGlobs: []string{"**/vendor/**"}},
+			wantExcludes: []string{"**/vendor/**", "**/gen/**"},
+		},
+		{
+			name:         "extensions add to explicit includes",
+			args:         []string{"--valid-extensions", "md"},
+			config:       cmd.Config{IncludeGlobs: []string{"**/*.go"}},
+			wantIncludes: []string{"**/*.go", "**/*.md"},
+		},
+		{
+			name:    "aggressive compression",
+			args:    []string{"--aggressive-compress"},
+			wantMax: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &cobra.Command{Use: "test"}
+			cmd.AddDeprecatedFlags(c)
+			if err := c.ParseFlags(tt.args); err != nil {
+				t.Fatalf("ParseFlags failed: %v", err)
+			}
+
+			config := tt.config
+			cmd.ApplyDeprecatedFlags(c, &config)
+
+			if !sliceEqual(config.IncludeGlobs, tt.wantIncludes) {
+				t.Errorf("IncludeGlobs = %v, want %v", config.IncludeGlobs, tt.wantIncludes)
+			}
+			if !sliceEqual(config.ExcludeGlobs, tt.wantExcludes) {
+				t.Errorf("ExcludeGlobs = %v, want %v", config.ExcludeGlobs, tt.wantExcludes)
+			}
+			if config.MaxCompress != tt.wantMax {
+				t.Errorf("MaxCompress = %v, want %v", config.MaxCompress, tt.wantMax)
+			}
+			if flag := c.Flags().Lookup("valid-extensions"); flag == nil || !flag.Hidden {
+				t.Error("Expected the deprecated flags to be hidden from help")
+			}
+		})
+	}
+}
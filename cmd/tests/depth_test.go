@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestMaxDepth(t *testing.T) {
+	tempDir := t.TempDir()
+	files := []string{"main.go", "pkg/a.go", "pkg/sub/b.go", "pkg/sub/deep/c.go"}
+	for _, path := range files {
+		fullPath := filepath.Join(tempDir, path)
+		os.MkdirAll(filepath.Dir(fullPath), 0755)
+		if err := os.WriteFile(fullPath, []byte("package x\n"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		maxDepth int
+		included []string
+	}{
+		{name: "no limit", maxDepth: 0, included: files},
+		{name: "top level only", maxDepth: 1, included: files[:1]},
+		{name: "two levels", maxDepth: 2, included: files[:2]},
+		{name: "limit deeper than tree", maxDepth: 10, included: files},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := cmd.Config{
+				InputDir:     tempDir,
+				OutputFile:   filepath.Join(t.TempDir(), "out.txt"),
+				IncludeGlobs: []string{"**/*.go"},
+				MaxDepth:     tt.maxDepth,
+			}
+			if err := cmd.ProcessDirectory(config); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			for i, path := range files {
+				marker := "--- START OF FILE: " + path + " ---"
+				if i < len(tt.included) {
+					assertFileContains(t, config.OutputFile, marker)
+				} else {
+					assertFileNotContains(t, config.OutputFile, marker)
+				}
+			}
+		})
+	}
+}
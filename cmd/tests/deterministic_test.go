@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestDeterministic(t *testing.T) {
+	tempDir := t.TempDir()
+	writeWorkspaceFiles(t, tempDir, map[string]string{
+		"b.go":        "package b\n",
+		"a/z.go":      "package a\n",
+		"a.go":        "package main\n",
+		"c/d/e.go":    "package e\n",
+		"notes.tmp":   "skipped\n",
+		"README.md":   "# Readme\n",
+		"a/y_test.go": "package a\n",
+	})
+
+	pack := func(t *testing.T, config cmd.Config) []byte {
+		t.Helper()
+		config.InputDir = tempDir
+		config.OutputFile = filepath.Join(t.TempDir(), "corpus.txt")
+		if config.Gzip {
+			config.OutputFile += ".gz"
+		}
+		config.IncludeGlobs = []string{"**/*.go", "**/*.md"}
+		config.Verbose = true
+		config.Deterministic = true
+		if err := cmd.ProcessDirectory(config); err != nil {
+			t.Fatalf("ProcessDirectory failed: %v", err)
+		}
+		data, err := os.ReadFile(config.OutputFile)
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+		return data
+	}
+
+	t.Run("text", func(t *testing.T) {
+		first := pack(t, cmd.Config{})
+		// Files touched between packs must not change the corpus
+		later := time.Now().Add(time.Hour)
+		os.Chtimes(filepath.Join(tempDir, "b.go"), later, later)
+		second := pack(t, cmd.Config{})
+		if !bytes.Equal(first, second) {
+			t.Errorf("Expected repeated packs to be byte-identical")
+		}
+		if strings.Contains(string(first), "Processing Time") {
+			t.Error("Expected the summary to leave out the processing time")
+		}
+
+		var order []string
+		for _, line := range strings.Split(string(first), "\n") {
+			if strings.HasPrefix(line, "--- START OF FILE: ") {
+				order = append(order, strings.TrimSuffix(strings.TrimPrefix(line, "--- START OF FILE: "), " ---"))
+			}
+		}
+		want := []string{"README.md", "a.go", "a/y_test.go", "a/z.go", "b.go", "c/d/e.go"}
+		if strings.Join(order, ",") != strings.Join(want, ",") {
+			t.Errorf("Expected files in path order %v, got %v", want, order)
+		}
+	})
+
+	t.Run("gzip across worker counts", func(t *testing.T) {
+		serial := pack(t, cmd.Config{Gzip: true, GzipJobs: 1})
+		parallel := pack(t, cmd.Config{Gzip: true, GzipJobs: 8})
+		if !bytes.Equal(serial, parallel) {
+			t.Error("Expected the same gzip bytes whatever the number of workers")
+		}
+	})
+}
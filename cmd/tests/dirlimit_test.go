@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestMaxFilesPerDir(t *testing.T) {
+	tempDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tempDir, "migrations"), 0755)
+	for i := 1; i <= 10; i++ {
+		path := filepath.Join(tempDir, "migrations", fmt.Sprintf("%03d.sql", i))
+		if err := os.WriteFile(path, []byte("SELECT 1;\n"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+	os.WriteFile(filepath.Join(tempDir, "migrations", "README.md"), []byte("# Migrations\n"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "main.go"), []byte("package main\n"), 0644)
+
+	two := 2
+	tests := []struct {
+		name     string
+		config   cmd.Config
+		want     []string
+		wantNote string
+	}{
+		{
+			name:     "global limit",
+			config:   cmd.Config{MaxFilesPerDir: 4},
+			want:     []string{"main.go", "migrations/001.sql", "migrations/002.sql", "migrations/010.sql", "migrations/README.md"},
+			wantNote: "--- 7 MORE FILES IN migrations OMITTED ---",
+		},
+		{
+			name:     "per-glob limit",
+			config:   cmd.Config{Rules: []cmd.Rule{{Glob: "migrations/*.sql", MaxFilesPerDir: &two}}},
+			want:     []string{"main.go", "migrations/001.sql", "migrations/010.sql", "migrations/README.md"},
+			wantNote: "--- 8 MORE FILES IN migrations OMITTED ---",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			config.InputDir = tempDir
+			config.OutputFile = filepath.Join(t.TempDir(), "out.txt")
+			config.IncludeGlobs = []string{"**/*.go", "**/*.sql", "**/*.md"}
+			if err := cmd.ProcessDirectory(config); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			if got := fileOrder(t, config.OutputFile); !sliceEqual(got, tt.want) {
+				t.Errorf("Packed files = %v, want %v", got, tt.want)
+			}
+			assertFileContains(t, config.OutputFile, tt.wantNote)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestDocsOnly(t *testing.T) {
+	tempDir := t.TempDir()
+	writeWorkspaceFiles(t, tempDir, map[string]string{
+		"README.md": "# App\n\nUsage notes.\n",
+		"api/api.go": `// Package api is the public client.
+package api
+
+// Client talks to the service.
+type Client struct {
+	addr string
+}
+
+// New returns a client for addr.
+func New(addr string) *Client {
+	return &Client{addr: addr}
+}
+
+func helper() int { return 42 }
+
+const (
+	// Version of the API.
+	Version = "1"
+	timeout = 30
+)
+`,
+		"tool.py": `"""Command line tool."""
+
+import sys
+
+def run(args,
+        verbose=False):
+    """Run the tool.
+
+    Returns an exit code.
+    """
+    return 0
+
+def undocumented():
+    return 1
+
+class Runner:
+    '''Runs things.'''
+`,
+		"web/app.ts": `/**
+ * Renders the app.
+ */
+export function render(): void {
+  console.log("hi");
+}
+
+// Not a doc comment
+function internal() {}
+`,
+		"plain.go": "package plain\n\nfunc f() {}\n",
+	})
+
+	output := filepath.Join(t.TempDir(), "corpus.txt")
+	err := cmd.ProcessDirectory(cmd.Config{
+		InputDir:     tempDir,
+		OutputFile:   output,
+		IncludeGlobs: []string{"**/*.go", "**/*.py", "**/*.ts", "**/*.md"},
+		DocsOnly:     true,
+		Verbose:      true,
+	})
+	if err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	corpus := cmd.ParseCorpus(data)
+	content := func(path string) string {
+		f, ok := corpus.Find(path)
+		if !ok {
+			t.Fatalf("Expected %s in the corpus", path)
+		}
+		return string(corpus.Content(f))
+	}
+
+	tests := []struct {
+		path   string
+		want   string
+		absent []string
+	}{
+		{
+			path: "README.md",
+			want: "# App\n\nUsage notes.\n",
+		},
+		{
+			path: "api/api.go",
+			want: "// Package api is the public client.\npackage api\n\n" +
+				"// Client talks to the service.\ntype Client struct\n\n" +
+				"// New returns a client for addr.\nfunc New(addr string) *Client\n\n" +
+				"// Version of the API.\nVersion = \"1\"\n",
+			absent: []string{"addr string\n", "return &Client", "helper", "timeout"},
+		},
+		{
+			path: "tool.py",
+			want: "\"\"\"Command line tool.\"\"\"\n\n" +
+				"def run(args,\n        verbose=False):\n    \"\"\"Run the tool.\n\n    Returns an exit code.\n    \"\"\"\n\n" +
+				"class Runner:\n    '''Runs things.'''\n",
+			absent: []string{"import sys", "undocumented", "return 0"},
+		},
+		{
+			path:   "web/app.ts",
+			want:   "/**\n* Renders the app.\n*/\nexport function render(): void\n",
+			absent: []string{"console.log", "internal"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got := content(tt.path)
+			if got != tt.want {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.want, got)
+			}
+			for _, s := range tt.absent {
+				if strings.Contains(got, s) {
+					t.Errorf("Expected %q to be elided", s)
+				}
+			}
+		})
+	}
+
+	if _, ok := corpus.Find("plain.go"); ok {
+		t.Error("Expected code without docs to be left out")
+	}
+	if !strings.Contains(string(data), "plain.go") || !strings.Contains(string(data), "no documentation") {
+		t.Error("Expected the summary to list plain.go as having no documentation")
+	}
+}
@@ -0,0 +1,159 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+// officeZip builds an Office Open XML package from part names and contents
+func officeZip(t *testing.T, parts map[string]string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range parts {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+		f.Write([]byte(content))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to write package: %v", err)
+	}
+	return buf.String()
+}
+
+// minimalPDF builds a one-page PDF whose content stream is Flate-compressed
+func minimalPDF(t *testing.T, content string) string {
+	t.Helper()
+	var stream bytes.Buffer
+	zw := zlib.NewWriter(&stream)
+	zw.Write([]byte(content))
+	zw.Close()
+
+	return fmt.Sprintf("%%PDF-1.4\n"+
+		"1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n"+
+		"2 0 obj\n<< /Type /Pages /Kids [3 0 R] /Count 1 >>\nendobj\n"+
+		"3 0 obj\n<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>\nendobj\n"+
+		"4 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream\nendobj\n"+
+		"trailer\n<< /Root 1 0 R >>\n%%%%EOF\n", stream.Len(), stream.String())
+}
+
+func TestExtractDocs(t *testing.T) {
+	const wordNS = `xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"`
+	const drawingNS = `xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main"`
+
+	files := map[string]string{
+		"main.go": "package main\n",
+		"docs/spec.docx": officeZip(t, map[string]string{
+			"word/document.xml": `<w:document ` + wordNS + `><w:body>` +
+				`<w:p><w:r><w:t>Design</w:t></w:r><w:r><w:t xml:space="preserve"> overview</w:t></w:r></w:p>` +
+				`<w:p><w:r><w:t>Second</w:t><w:tab/><w:t>paragraph</w:t></w:r></w:p>` +
+				`</w:body></w:document>`,
+		}),
+		"docs/deck.pptx": officeZip(t, map[string]string{
+			"ppt/slides/slide1.xml":  `<p:sld ` + drawingNS + `><a:p><a:r><a:t>Intro slide</a:t></a:r></a:p></p:sld>`,
+			"ppt/slides/slide2.xml":  `<p:sld ` + drawingNS + `><a:p><a:r><a:t>Second slide</a:t></a:r></a:p></p:sld>`,
+			"ppt/slides/slide10.xml": `<p:sld ` + drawingNS + `><a:p><a:r><a:t>Tenth slide</a:t></a:r></a:p></p:sld>`,
+		}),
+		"docs/budget.xlsx": officeZip(t, map[string]string{
+			"xl/workbook.xml": `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+				`<sheets><sheet name="Costs" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+			"xl/_rels/workbook.xml.rels": `<Relationships><Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`,
+			"xl/sharedStrings.xml":       `<sst><si><t>Item</t></si><si><t>Cost</t></si><si><r><t>Ser</t></r><r><t>vers</t></r></si></sst>`,
+			"xl/worksheets/sheet1.xml": `<worksheet><sheetData>` +
+				`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>` +
+				`<row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2"><v>1200</v></c></row>` +
+				`<row r="3"><c r="A3" t="inlineStr"><is><t>Total</t></is></c><c r="B3"><v>1200</v></c></row>` +
+				`</sheetData></worksheet>`,
+		}),
+		"docs/report.pdf": minimalPDF(t, "BT /F1 12 Tf 72 720 Td (Quarterly report) Tj 0 -14 Td [(Reven) 20 (ue) -300 (grew)] TJ ET"),
+		"docs/scan.pdf":   minimalPDF(t, "q 100 0 0 100 0 0 cm /Im1 Do Q"),
+		"docs/old.doc":    "\xd0\xcf\x11\xe0 legacy binary",
+	}
+
+	tests := []struct {
+		name        string
+		extractDocs bool
+		contains    []string
+		notContains []string
+	}{
+		{
+			name:        "skipped by default",
+			contains:    []string{"main.go", "docs/spec.docx (document, use --extract-docs)", "docs/old.doc (binary document)"},
+			notContains: []string{"Design overview", "--- START OF FILE: docs/report.pdf"},
+		},
+		{
+			name:        "extracted",
+			extractDocs: true,
+			contains: []string{
+				"Design overview\nSecond\tparagraph",
+				"# Slide 1\n\nIntro slide\n# Slide 2\n\nSecond slide\n# Slide 3\n\nTenth slide",
+				"# Sheet Costs\n\nItem\tCost\nServers\t1200\nTotal\t1200",
+				"Quarterly report\nRevenue grew",
+				"docs/scan.pdf (no extractable text)",
+				"docs/old.doc (binary document)",
+			},
+			notContains: []string{"legacy binary"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			writeWorkspaceFiles(t, tempDir, files)
+			outputPath := filepath.Join(t.TempDir(), "out.txt")
+
+			config := cmd.Config{
+				InputDir:     tempDir,
+				OutputFile:   outputPath,
+				IncludeGlobs: []string{"**/*.go", "**/*.pdf", "**/*.docx", "**/*.pptx", "**/*.xlsx", "**/*.doc"},
+				ExtractDocs:  tt.extractDocs,
+				Verbose:      true,
+				NoGitHeader:  true,
+			}
+			if err := cmd.ProcessDirectory(config); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			for _, want := range tt.contains {
+				assertFileContains(t, outputPath, want)
+			}
+			for _, unwanted := range tt.notContains {
+				assertFileNotContains(t, outputPath, unwanted)
+			}
+		})
+	}
+}
+
+func TestExtractDocsLowMemory(t *testing.T) {
+	tempDir := t.TempDir()
+	writeWorkspaceFiles(t, tempDir, map[string]string{
+		"report.pdf": minimalPDF(t, "BT (Streamed text) Tj ET"),
+	})
+	outputPath := filepath.Join(t.TempDir(), "out.txt")
+
+	config := cmd.Config{
+		InputDir:     tempDir,
+		OutputFile:   outputPath,
+		IncludeGlobs: []string{"**/*.pdf"},
+		ExtractDocs:  true,
+		LowMemory:    true,
+		NoGitHeader:  true,
+	}
+	if err := cmd.ProcessDirectory(config); err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+
+	assertFileContains(t, outputPath, "Streamed text")
+	if data, _ := os.ReadFile(outputPath); bytes.Contains(data, []byte("FlateDecode")) {
+		t.Error("Expected the raw PDF not to be streamed")
+	}
+}
@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestOutputEncoding(t *testing.T) {
+	tempDir := t.TempDir()
+	files := make(map[string]string)
+	for i := 0; i < 20; i++ {
+		files[fmt.Sprintf("f%d.go", i)] = fmt.Sprintf("package f%d\n\nconst Name = %q\n", i, strings.Repeat("x", i*37))
+	}
+	writeWorkspaceFiles(t, tempDir, files)
+
+	tests := []struct {
+		name     string
+		config   cmd.Config
+		plain    bool
+		alphabet string
+	}{
+		{name: "base64", config: cmd.Config{Base64: true}, alphabet: "+/="},
+		{name: "base64 wrapped", config: cmd.Config{Base64: true, Wrap: true}, alphabet: "+/="},
+		{name: "base64url", config: cmd.Config{Encoding: cmd.EncodingBase64URL}, alphabet: "-_="},
+		{name: "base64url wrapped", config: cmd.Config{Encoding: cmd.EncodingBase64URL, Wrap: true}, alphabet: "-_="},
+		{name: "base85", config: cmd.Config{Encoding: cmd.EncodingBase85}},
+		{name: "base85 wrapped", config: cmd.Config{Encoding: cmd.EncodingBase85, Wrap: true}},
+		{name: "plain base64", config: cmd.Config{Base64: true}, plain: true, alphabet: "+/="},
+		{name: "plain base64 wrapped", config: cmd.Config{Base64: true, Wrap: true}, plain: true, alphabet: "+/="},
+		{name: "plain base85", config: cmd.Config{Encoding: cmd.EncodingBase85}, plain: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			config.InputDir = tempDir
+			config.OutputFile = filepath.Join(t.TempDir(), "corpus.txt.gz")
+			config.IncludeGlobs = []string{"**/*.go"}
+			config.Gzip = !tt.plain
+			if err := cmd.ProcessDirectory(config); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			data, err := os.ReadFile(config.OutputFile)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+			if config.Wrap {
+				if len(lines) < 2 {
+					t.Errorf("Expected wrapped output over several lines, got %d", len(lines))
+				}
+				for i, line := range lines {
+					if len(line) > 76 || (i < len(lines)-1 && len(line) != 76) {
+						t.Fatalf("Line %d is %d columns, expected 76", i+1, len(line))
+					}
+				}
+			} else if len(lines) != 1 {
+				t.Errorf("Expected unwrapped output on one line, got %d", len(lines))
+			}
+			for _, c := range data {
+				if c >= 0x80 || (c < ' ' && c != '\n') {
+					t.Fatalf("Expected printable ASCII output, found byte %#x", c)
+				}
+				if tt.alphabet != "" && !isAlphanumeric(c) && c != '\n' && !strings.ContainsRune(tt.alphabet, rune(c)) {
+					t.Fatalf("Unexpected %q in %s output", c, tt.name)
+				}
+			}
+
+			corpus, err := cmd.LoadCorpus(config.OutputFile)
+			if err != nil {
+				t.Fatalf("Failed to load encoded corpus: %v", err)
+			}
+			if len(corpus.Files) != len(files) {
+				t.Errorf("Expected %d files after decoding, got %d", len(files), len(corpus.Files))
+			}
+		})
+	}
+
+	rejected := []struct {
+		name   string
+		config cmd.Config
+	}{
+		{name: "unknown encoding", config: cmd.Config{Gzip: true, Encoding: "base32"}},
+		{name: "rendered format", config: cmd.Config{Base64: true, OutputFormat: cmd.FormatYAML}},
+		{name: "wrap without encoding", config: cmd.Config{Gzip: true, Wrap: true}},
+		{name: "base64 with another encoding", config: cmd.Config{Gzip: true, Base64: true, Encoding: cmd.EncodingBase85}},
+	}
+	for _, tt := range rejected {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			config.InputDir = tempDir
+			config.OutputFile = filepath.Join(t.TempDir(), "corpus.txt.gz")
+			if err := cmd.ProcessDirectory(config); err == nil {
+				t.Error("Expected the options to be rejected")
+			}
+		})
+	}
+}
+
+func isAlphanumeric(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func TestLoadPlainCorpusAllocations(t *testing.T) {
+	// A plain corpus written without a version line or git header
+	var corpus strings.Builder
+	for i := 0; corpus.Len() < 4<<20; i++ {
+		fmt.Fprintf(&corpus, "--- START OF FILE: f%d.go ---\npackage f\n\nconst Name = %q\n\n--- END OF FILE: f%d.go ---\n\n", i, strings.Repeat("x", 200), i)
+	}
+	path := filepath.Join(t.TempDir(), "corpus.txt")
+	if err := os.WriteFile(path, []byte(corpus.String()), 0644); err != nil {
+		t.Fatalf("Failed to write corpus: %v", err)
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	loaded, err := cmd.LoadCorpus(path)
+	runtime.ReadMemStats(&after)
+	if err != nil {
+		t.Fatalf("LoadCorpus failed: %v", err)
+	}
+	if len(loaded.Files) == 0 {
+		t.Fatal("Expected the corpus's files")
+	}
+
+	// Reading and parsing take a few times the corpus, but trying it as
+	// base64 and Ascii85 would take several more
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 4*uint64(corpus.Len()) {
+		t.Errorf("Loading a %d-byte plain corpus allocated %d bytes", corpus.Len(), allocated)
+	}
+}
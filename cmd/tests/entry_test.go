@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestEntrypointSelection(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "entry-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{
+		"index.html":          `<html><link rel="stylesheet" href="styles/main.css"><script type="module" src="/src/app.js"></script><script src="https://cdn.example.com/lib.js"></script></html>`,
+		"styles/main.css":     `@import "./reset.css"; body { background: url(../img/bg.png); }`,
+		"styles/reset.css":    `* { margin: 0; }`,
+		"styles/unused.css":   `.dead { }`,
+		"src/app.js":          "import { render } from './view'\nimport React from 'react'\nconst util = require(\"./util.js\")\n",
+		"src/view/index.ts":   "export * from '../shared/types'\nexport function render() {}\n",
+		"src/shared/types.ts": "export type Props = {}\n",
+		"src/util.js":         "module.exports = {}\n",
+		"src/dead.js":         "export const dead = true\n",
+		"img/bg.png":          "png",
+	}
+	for path, content := range files {
+		fullPath := filepath.Join(tempDir, path)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	outputPath := filepath.Join(tempDir, "out.txt")
+	config := cmd.Config{
+		InputDir:     tempDir,
+		OutputFile:   outputPath,
+		IncludeGlobs: []string{"**/*.js", "**/*.ts", "**/*.css"},
+		EntryFiles:   []string{"index.html"},
+	}
+	if err := cmd.ProcessDirectory(config); err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+
+	order := fileOrder(t, outputPath)
+	expected := []string{
+		"index.html",
+		"src/app.js",
+		"src/shared/types.ts",
+		"src/util.js",
+		"src/view/index.ts",
+		"styles/main.css",
+		"styles/reset.css",
+	}
+	if !sliceEqual(order, expected) {
+		t.Errorf("Expected reachable files %v, got %v", expected, order)
+	}
+
+	content, _ := os.ReadFile(outputPath)
+	if strings.Contains(string(content), "dead") {
+		t.Error("Unreachable files should not be packed")
+	}
+
+	config.EntryFiles = []string{"missing.html"}
+	if err := cmd.ProcessDirectory(config); err == nil || !strings.Contains(err.Error(), "entrypoint does not exist") {
+		t.Errorf("Expected missing entrypoint error, got %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+	"github.com/spf13/cobra"
+)
+
+func TestApplyEnvFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		args     []string
+		wantErr  string
+		validate func(t *testing.T, c *cobra.Command)
+	}{
+		{
+			name: "environment fills unset flags",
+			env: map[string]string{
+				"CPACK_OUTPUT":        "ci/corpus.txt",
+				"CPACK_GZIP":          "true",
+				"CPACK_INCLUDE_GLOBS": "**/*.go,**/*.md",
+				"CPACK_MAX_TOKENS":    "5000",
+			},
+			validate: func(t *testing.T, c *cobra.Command) {
+				flags := c.Flags()
+				if output, _ := flags.GetString("output"); output != "ci/corpus.txt" {
+					t.Errorf("output = %q, want ci/corpus.txt", output)
+				}
+				if gzip, _ := flags.GetBool("gzip"); !gzip {
+					t.Error("Expected gzip from CPACK_GZIP")
+				}
+				if include, _ := flags.GetStringSlice("include"); !sliceEqual(include, []string{"**/*.go", "**/*.md"}) {
+					t.Errorf("include = %v, want the CPACK_INCLUDE_GLOBS list", include)
+				}
+				if maxTokens, _ := flags.GetInt("max-tokens"); maxTokens != 5000 {
+					t.Errorf("max-tokens = %d, want 5000", maxTokens)
+				}
+				if !flags.Changed("include") {
+					t.Error("Expected a flag set from the environment to count as changed")
+				}
+			},
+		},
+		{
+			name: "flags win over the environment",
+			env:  map[string]string{"CPACK_OUTPUT": "env.txt", "CPACK_INCLUDE": "**/*.py"},
+			args: []string{"-o", "flag.txt"},
+			validate: func(t *testing.T, c *cobra.Command) {
+				if output, _ := c.Flags().GetString("output"); output != "flag.txt" {
+					t.Errorf("output = %q, want flag.txt", output)
+				}
+				if include, _ := c.Flags().GetStringSlice("include"); !sliceEqual(include, []string{"**/*.py"}) {
+					t.Errorf("include = %v, want CPACK_INCLUDE", include)
+				}
+			},
+		},
+		{
+			name: "unset variables keep defaults",
+			validate: func(t *testing.T, c *cobra.Command) {
+				if output, _ := c.Flags().GetString("output"); output != "corpus-out.txt" {
+					t.Errorf("output = %q, want the default", output)
+				}
+				if c.Flags().Changed("gzip") {
+					t.Error("Expected gzip to stay unchanged")
+				}
+			},
+		},
+		{
+			name: "cobra flags are not read",
+			env:  map[string]string{"CPACK_VERSION": "1.2.3", "CPACK_HELP": "yes"},
+			validate: func(t *testing.T, c *cobra.Command) {
+				if c.Flags().Changed("version") || c.Flags().Changed("help") {
+					t.Error("Expected --version and --help to stay unset")
+				}
+			},
+		},
+		{
+			name:    "invalid value",
+			env:     map[string]string{"CPACK_MAX_TOKENS": "lots"},
+			wantErr: "error parsing CPACK_MAX_TOKENS",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			c := &cobra.Command{Use: "test", RunE: func(*cobra.Command, []string) error { return nil }}
+			c.Flags().StringP("output", "o", "corpus-out.txt", "")
+			c.Flags().Bool("gzip", false, "")
+			c.Flags().StringSlice("include", []string{"**/*.txt"}, "")
+			c.Flags().Int("max-tokens", 0, "")
+			c.Flags().Bool("version", false, "")
+			c.Flags().BoolP("help", "h", false, "")
+			if err := c.ParseFlags(tt.args); err != nil {
+				t.Fatalf("ParseFlags failed: %v", err)
+			}
+
+			err := cmd.ApplyEnvFlags(c)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplyEnvFlags failed: %v", err)
+			}
+			tt.validate(t, c)
+		})
+	}
+}
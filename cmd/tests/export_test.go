@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestDocumentExport(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"main.go":          "package main\n\nfunc main() {}\n",
+		"docs/guide.md":    "# Guide\n",
+		"annotations.json": `{"main.go": "Entry point"}`,
+	}
+	writeWorkspaceFiles(t, tempDir, files)
+
+	tests := []struct {
+		format     string
+		contentKey string
+		pathKey    string
+		extra      map[string]interface{}
+	}{
+		{format: cmd.FormatLangChain, contentKey: "page_content", pathKey: "source", extra: map[string]interface{}{"type": "Document"}},
+		{format: cmd.FormatLlamaIndex, contentKey: "text", pathKey: "file_path"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "docs.jsonl")
+			config := cmd.Config{
+				InputDir:        tempDir,
+				OutputFile:      outputPath,
+				OutputFormat:    tt.format,
+				IncludeGlobs:    []string{"**/*.go", "**/*.md"},
+				AnnotationsFile: filepath.Join(tempDir, "annotations.json"),
+				NoGitHeader:     true,
+			}
+			if err := cmd.ProcessDirectory(config); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			data, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+			if len(lines) != 2 {
+				t.Fatalf("Expected 2 documents, got %d", len(lines))
+			}
+
+			for _, line := range lines {
+				var doc map[string]interface{}
+				if err := json.Unmarshal(line, &doc); err != nil {
+					t.Fatalf("Invalid document %q: %v", line, err)
+				}
+				metadata, ok := doc["metadata"].(map[string]interface{})
+				if !ok {
+					t.Fatalf("Expected metadata in %s", line)
+				}
+				path, _ := metadata[tt.pathKey].(string)
+				if doc[tt.contentKey] != files[path] {
+					t.Errorf("%s: expected %s %q, got %v", path, tt.contentKey, files[path], doc[tt.contentKey])
+				}
+				for key, value := range tt.extra {
+					if doc[key] != value {
+						t.Errorf("Expected %s to be %v, got %v", key, value, doc[key])
+					}
+				}
+				if path == "main.go" {
+					if metadata["language"] != "go" {
+						t.Errorf("Expected language go, got %v", metadata["language"])
+					}
+					if metadata["note"] != "Entry point" {
+						t.Errorf("Expected the annotation as note, got %v", metadata["note"])
+					}
+				}
+			}
+		})
+	}
+}
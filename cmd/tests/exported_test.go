@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestExportedOnly(t *testing.T) {
+	source := `// Copyright notice
+
+//go:build linux && !appengine
+// +build linux,!appengine
+
+// Package api is the public client.
+package api
+
+import "fmt"
+
+// Client talks to the service.
+type Client struct {
+	addr string
+}
+
+type conn struct{}
+
+// New returns a client for addr.
+func New(addr string) *Client {
+	return &Client{addr: addr}
+}
+
+func helper() {}
+
+// Do sends a request.
+func (c *Client) Do() error { return fmt.Errorf("unimplemented") }
+
+func (c *Client) retry() {}
+
+// Read is exported, but its receiver is not.
+func (c *conn) Read() {}
+
+const (
+	// Version of the API.
+	Version = "1"
+	timeout = 30 // seconds
+	// Retries before giving up.
+	Retries = 3 // attempts
+)
+
+const (
+	KindA = iota
+	kindB
+	KindC
+)
+
+var debug = false
+`
+
+	tempDir := t.TempDir()
+	writeWorkspaceFiles(t, tempDir, map[string]string{
+		"api/api.go":    source,
+		"api/broken.go": "package api\n\nfunc broken( {\n",
+		"notes.txt":     "func helper() {}\n",
+	})
+	output := filepath.Join(t.TempDir(), "corpus.txt")
+	err := cmd.ProcessDirectory(cmd.Config{
+		InputDir:     tempDir,
+		OutputFile:   output,
+		IncludeGlobs: []string{"**/*.go", "**/*.txt"},
+		ExportedOnly: true,
+	})
+	if err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	corpus := cmd.ParseCorpus(data)
+	content := func(path string) string {
+		f, ok := corpus.Find(path)
+		if !ok {
+			t.Fatalf("Expected %s in the corpus", path)
+		}
+		return string(corpus.Content(f))
+	}
+
+	api := content("api/api.go")
+	for _, want := range []string{
+		"//go:build linux && !appengine\n// +build linux,!appengine\n\n// Package api is the public client.\npackage api\n",
+		"import \"fmt\"",
+		"// Client talks to the service.\ntype Client struct {\n\taddr string\n}",
+		"// New returns a client for addr.\nfunc New(addr string) *Client {",
+		"// Do sends a request.\nfunc (c *Client) Do() error",
+		"const (\n\t// Version of the API.\n\tVersion = \"1\"\n\t// Retries before giving up.\n\tRetries = 3 // attempts\n)",
+		"const (\n\tKindA = iota\n\tkindB\n\tKindC\n)",
+	} {
+		if !strings.Contains(api, want) {
+			t.Errorf("Expected %q in:\n%s", want, api)
+		}
+	}
+	for _, unwanted := range []string{"Copyright", "type conn", "func helper", "retry", "Read", "timeout", "debug"} {
+		if strings.Contains(api, unwanted) {
+			t.Errorf("Expected %q to be dropped from:\n%s", unwanted, api)
+		}
+	}
+
+	if broken := content("api/broken.go"); !strings.Contains(broken, "func broken(") {
+		t.Errorf("Expected source that does not parse to be kept, got:\n%s", broken)
+	}
+	if notes := content("notes.txt"); !strings.Contains(notes, "func helper() {}") {
+		t.Errorf("Expected non-Go files to be kept, got:\n%s", notes)
+	}
+}
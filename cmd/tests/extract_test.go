@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestExtractCommand(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	tempDir, cleanup := createTestFiles(t)
+	defer cleanup()
+
+	outDir := t.TempDir()
+	corpusPath := filepath.Join(outDir, "corpus.txt.gz")
+	config := cmd.Config{
+		InputDir:     tempDir,
+		OutputFile:   corpusPath,
+		IncludeGlobs: []string{"**/*.go"},
+		Gzip:         true,
+	}
+	if err := cmd.ProcessDirectory(config); err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+
+	want, err := os.ReadFile(filepath.Join(tempDir, "src/pkg1/file1.go"))
+	if err != nil {
+		t.Fatalf("Failed to read source: %v", err)
+	}
+	corpus, err := cmd.LoadCorpus(corpusPath)
+	if err != nil {
+		t.Fatalf("Failed to load corpus: %v", err)
+	}
+	file, _ := corpus.Find("src/pkg1/file1.go")
+
+	tests := []struct {
+		name    string
+		ref     string
+		wantErr string
+	}{
+		{name: "by path", ref: "src/pkg1/file1.go"},
+		{name: "by id", ref: strconv.Itoa(file.ID)},
+		{name: "missing path", ref: "src/nope.go", wantErr: "no file src/nope.go"},
+		{name: "id out of range", ref: "999", wantErr: "no file 999"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outPath := filepath.Join(outDir, "extracted.go")
+			os.Remove(outPath)
+
+			os.Args = []string{"cpack", "extract", corpusPath, tt.ref, "-o", outPath}
+			err := cmd.Execute()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("extract failed: %v", err)
+			}
+
+			got, err := os.ReadFile(outPath)
+			if err != nil {
+				t.Fatalf("Failed to read extracted file: %v", err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("Extracted %q, want %q", got, want)
+			}
+		})
+	}
+}
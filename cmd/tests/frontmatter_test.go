@@ -0,0 +1,210 @@
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+// frontmatterBlock matches the frontmatter at the top of a corpus
+var frontmatterBlock = regexp.MustCompile(`(?s)\A---\n(.*?)\n---\n\n--- CPACK CORPUS: `)
+
+// readFrontmatter returns the frontmatter fields of the corpus at path
+func readFrontmatter(t *testing.T, path string) map[string]string {
+	t.Helper()
+
+	corpus, err := cmd.LoadCorpus(path)
+	if err != nil {
+		t.Fatalf("Failed to load corpus: %v", err)
+	}
+	match := frontmatterBlock.FindSubmatch(corpus.Data)
+	if match == nil {
+		t.Fatalf("Expected the corpus to open with frontmatter, got:\n%s", corpus.Data)
+	}
+	if problems := cmd.ValidateCorpus(corpus.Data); len(problems) > 0 {
+		t.Errorf("Expected a valid corpus, got %v", problems)
+	}
+
+	fields := make(map[string]string)
+	for _, line := range strings.Split(string(match[1]), "\n") {
+		key, value, _ := strings.Cut(line, ": ")
+		fields[key] = strings.Trim(value, `"`)
+	}
+	return fields
+}
+
+func TestFrontmatter(t *testing.T) {
+	files := map[string]string{
+		"main.go":     "package main\n\nfunc main() {}\n",
+		"lib/util.go": "package lib\n\nfunc Util() {}\n",
+	}
+
+	tests := []struct {
+		name    string
+		config  cmd.Config
+		want    map[string]string
+		absent  []string
+		wantErr string
+	}{
+		{
+			name:   "plain",
+			config: cmd.Config{},
+			want:   map[string]string{"files": "2", "tokenizer": "estimate"},
+			absent: []string{"repo", "commit"},
+		},
+		{
+			name:   "deterministic",
+			config: cmd.Config{Deterministic: true},
+			want:   map[string]string{"files": "2"},
+			absent: []string{"generated"},
+		},
+		{
+			name:   "gzip and base64",
+			config: cmd.Config{Gzip: true, Base64: true, Verbose: true},
+			want:   map[string]string{"files": "2"},
+		},
+		{
+			name:   "tokenizer",
+			config: cmd.Config{Tokenizer: "cl100k_base", Compress: true},
+			want:   map[string]string{"files": "2", "tokenizer": "cl100k_base"},
+		},
+		{
+			name:    "other format",
+			config:  cmd.Config{OutputFormat: cmd.FormatMarkdown},
+			wantErr: "--frontmatter opens text corpora",
+		},
+		{
+			name:    "append",
+			config:  cmd.Config{Append: true},
+			wantErr: "--frontmatter cannot describe a corpus that --append adds to",
+		},
+		{
+			name:    "flush per file",
+			config:  cmd.Config{FlushPerFile: true},
+			wantErr: "cannot be combined with --flush-per-file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputDir := t.TempDir()
+			writeWorkspaceFiles(t, inputDir, files)
+
+			config := tt.config
+			config.InputDir = inputDir
+			config.OutputFile = filepath.Join(t.TempDir(), "corpus.txt")
+			if config.Gzip {
+				config.OutputFile += ".gz"
+			}
+			config.Frontmatter = true
+			err := cmd.ProcessDirectory(config)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ProcessDirectory() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ProcessDirectory() error = %v", err)
+			}
+
+			fields := readFrontmatter(t, config.OutputFile)
+			for key, value := range tt.want {
+				if fields[key] != value {
+					t.Errorf("Expected %s: %s, got %q", key, value, fields[key])
+				}
+			}
+			for _, key := range tt.absent {
+				if _, ok := fields[key]; ok {
+					t.Errorf("Expected no %s field, got %q", key, fields[key])
+				}
+			}
+			if !strings.HasPrefix(fields["configHash"], "sha256:") {
+				t.Errorf("Expected a sha256 config hash, got %q", fields["configHash"])
+			}
+			if fields["tokens"] == "" || fields["tokens"] == "0" {
+				t.Errorf("Expected a token count, got %q", fields["tokens"])
+			}
+		})
+	}
+
+	t.Run("git repository", func(t *testing.T) {
+		repo := initGitRepo(t, "service", files)
+		runGit(t, repo, "remote", "add", "origin", "https://github.com/example/service.git")
+		outputFile := filepath.Join(t.TempDir(), "corpus.txt")
+		if err := cmd.ProcessDirectory(cmd.Config{InputDir: repo, OutputFile: outputFile, Frontmatter: true}); err != nil {
+			t.Fatalf("ProcessDirectory() error = %v", err)
+		}
+
+		fields := readFrontmatter(t, outputFile)
+		if fields["repo"] != "https://github.com/example/service.git" {
+			t.Errorf("Expected the remote as repo, got %q", fields["repo"])
+		}
+		if want := runGit(t, repo, "rev-parse", "HEAD"); fields["commit"] != want {
+			t.Errorf("Expected commit %s, got %q", want, fields["commit"])
+		}
+	})
+
+	t.Run("anonymized directories", func(t *testing.T) {
+		repo := initGitRepo(t, "customer-acme", files)
+		runGit(t, repo, "remote", "add", "origin", "https://git.example.com/customer-acme.git")
+		outputFile := filepath.Join(t.TempDir(), "corpus.txt")
+		config := cmd.Config{InputDir: repo, OutputFile: outputFile, Frontmatter: true, AnonymizeDirs: []string{"lib"}}
+		if err := cmd.ProcessDirectory(config); err != nil {
+			t.Fatalf("ProcessDirectory() error = %v", err)
+		}
+
+		fields := readFrontmatter(t, outputFile)
+		if _, ok := fields["repo"]; ok {
+			t.Errorf("Expected no repo field, got %q", fields["repo"])
+		}
+		assertFileNotContains(t, outputFile, "customer-acme")
+	})
+
+	t.Run("same settings hash the same", func(t *testing.T) {
+		var corpora [][]byte
+		for i := 0; i < 2; i++ {
+			inputDir := t.TempDir()
+			writeWorkspaceFiles(t, inputDir, files)
+			outputDir := t.TempDir()
+			outputFile := filepath.Join(outputDir, "corpus.txt")
+			// Sidecar paths and the salt differ between machines and runs
+			config := cmd.Config{
+				InputDir:      inputDir,
+				OutputFile:    outputFile,
+				ReportFile:    filepath.Join(outputDir, "report.json"),
+				ManifestFile:  filepath.Join(outputDir, "manifest.json"),
+				AnonymizeSalt: fmt.Sprintf("salt-%d", i),
+				Frontmatter:   true,
+				Deterministic: true,
+			}
+			if err := cmd.ProcessDirectory(config); err != nil {
+				t.Fatalf("ProcessDirectory() error = %v", err)
+			}
+			data, err := os.ReadFile(outputFile)
+			if err != nil {
+				t.Fatalf("Failed to read corpus: %v", err)
+			}
+			corpora = append(corpora, data)
+		}
+		if !bytes.Equal(corpora[0], corpora[1]) {
+			t.Errorf("Expected identical corpora from different directories:\n%s\n---\n%s", corpora[0], corpora[1])
+		}
+	})
+
+	t.Run("no files", func(t *testing.T) {
+		outputFile := filepath.Join(t.TempDir(), "corpus.txt")
+		if err := cmd.ProcessDirectory(cmd.Config{InputDir: t.TempDir(), OutputFile: outputFile, Frontmatter: true}); err != nil {
+			t.Fatalf("ProcessDirectory() error = %v", err)
+		}
+		if data, _ := os.ReadFile(outputFile); len(data) != 0 {
+			t.Errorf("Expected an empty corpus, got:\n%s", data)
+		}
+	})
+}
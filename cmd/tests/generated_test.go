@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestSkipGenerated(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"main.go":        "package main\n\n// Mentions Code generated but is not marked\n",
+		"api.pb.go":      "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage main\n",
+		"mock_store.go":  "// @generated by mockery\npackage main\n",
+		"dist-bundle.js": strings.Repeat("var a = 1;\n", 200) + "//# sourceMappingURL=bundle.js.map\n",
+		"app.js":         "console.log('app')\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, path), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		skipGenerated bool
+		wantSkipped   []string
+	}{
+		{
+			name:        "disabled",
+			wantSkipped: []string{},
+		},
+		{
+			name:          "enabled",
+			skipGenerated: true,
+			wantSkipped:   []string{"api.pb.go (generated)", "dist-bundle.js (generated)", "mock_store.go (generated)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outDir := t.TempDir()
+			config := cmd.Config{
+				InputDir:      tempDir,
+				OutputFile:    filepath.Join(outDir, "out.txt"),
+				IncludeGlobs:  []string{"**/*.go", "**/*.js"},
+				SkipGenerated: tt.skipGenerated,
+				ReportFile:    filepath.Join(outDir, "report.json"),
+			}
+			if err := cmd.ProcessDirectory(config); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			data, _ := os.ReadFile(config.ReportFile)
+			var report cmd.PackReport
+			if err := json.Unmarshal(data, &report); err != nil {
+				t.Fatalf("Failed to parse report: %v", err)
+			}
+			if !sliceEqual(report.SkippedFiles, tt.wantSkipped) {
+				t.Errorf("SkippedFiles = %v, want %v", report.SkippedFiles, tt.wantSkipped)
+			}
+			assertFileContains(t, config.OutputFile, "--- START OF FILE: main.go ---")
+			assertFileContains(t, config.OutputFile, "--- START OF FILE: app.js ---")
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestGitAttributes(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		".gitattributes": "# GitHub linguist overrides\n" +
+			"*.pb.go linguist-generated=true\n" +
+			"third_party/** linguist-vendored\n" +
+			"third_party/ours/** -linguist-vendored\n" +
+			"*.md text eol=lf\n",
+		"api/.gitattributes":       "schema.go linguist-generated\n",
+		"main.go":                  "package main\n",
+		"api/api.pb.go":            "package api\n",
+		"api/schema.go":            "package api\n",
+		"schema.go":                "package main\n",
+		"third_party/lib/lib.go":   "package lib\n",
+		"third_party/ours/ours.go": "package ours\n",
+		"README.md":                "# Readme\n",
+	}
+	for path, content := range files {
+		fullPath := filepath.Join(tempDir, path)
+		os.MkdirAll(filepath.Dir(fullPath), 0755)
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		disabled    bool
+		included    []string
+		notIncluded []string
+	}{
+		{
+			name:        "linguist paths excluded by default",
+			included:    []string{"main.go", "schema.go", "third_party/ours/ours.go", "README.md"},
+			notIncluded: []string{"api/api.pb.go", "api/schema.go", "third_party/lib/lib.go"},
+		},
+		{
+			name:     "disabled",
+			disabled: true,
+			included: []string{"api/api.pb.go", "api/schema.go", "third_party/lib/lib.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := cmd.Config{
+				InputDir:        tempDir,
+				OutputFile:      filepath.Join(t.TempDir(), "out.txt"),
+				IncludeGlobs:    []string{"**/*.go", "**/*.md"},
+				NoGitAttributes: tt.disabled,
+			}
+			if err := cmd.ProcessDirectory(config); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			for _, path := range tt.included {
+				assertFileContains(t, config.OutputFile, "--- START OF FILE: "+path+" ---")
+			}
+			for _, path := range tt.notIncluded {
+				assertFileNotContains(t, config.OutputFile, "--- START OF FILE: "+path+" ---")
+			}
+		})
+	}
+}
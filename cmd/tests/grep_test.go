@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestGrepCommand(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	corpus := "--- START OF FILE: a.go ---\npackage a\n\nfunc Hello() {}\n--- END OF FILE: a.go ---\n\n" +
+		"--- START OF FILE: b/b.go ---\npackage b\n// hello.world\nvar x = 1\n--- END OF FILE: b/b.go ---\n\n"
+
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("Failed to create zstd encoder: %v", err)
+	}
+	compressed := encoder.EncodeAll([]byte(corpus), nil)
+	encoder.Close()
+
+	dir := t.TempDir()
+	variants := map[string][]byte{
+		"plain":         []byte(corpus),
+		"zstd":          compressed,
+		"base64 + zstd": []byte(base64.StdEncoding.EncodeToString(compressed)),
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "regexp",
+			args: []string{"^package"},
+			want: "a.go:1:package a\nb/b.go:1:package b\n",
+		},
+		{
+			name: "ignore case",
+			args: []string{"hello", "-i"},
+			want: "a.go:3:func Hello() {}\nb/b.go:2:// hello.world\n",
+		},
+		{
+			name: "fixed strings",
+			args: []string{"hello.world", "-F", "-i=false"},
+			want: "b/b.go:2:// hello.world\n",
+		},
+		{
+			name: "files with matches",
+			args: []string{"a|b", "-l", "-F=false"},
+			want: "a.go\nb/b.go\n",
+		},
+		{
+			name: "no matches",
+			args: []string{"nothing", "-l=false"},
+			want: "",
+		},
+	}
+
+	for variant, data := range variants {
+		path := filepath.Join(dir, strings.ReplaceAll(variant, " ", "")+".corpus")
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("Failed to write corpus: %v", err)
+		}
+
+		for _, tt := range tests {
+			t.Run(variant+"/"+tt.name, func(t *testing.T) {
+				os.Args = append([]string{"cpack", "grep", tt.args[0], path}, tt.args[1:]...)
+				output := captureStdout(t, func() {
+					if err := cmd.Execute(); err != nil {
+						t.Fatalf("grep command failed: %v", err)
+					}
+				})
+				if string(output) != tt.want {
+					t.Errorf("grep output = %q, want %q", output, tt.want)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestGzipJobs(t *testing.T) {
+	// Several megabytes, so the parallel writer compresses many blocks that
+	// refer back across block boundaries
+	tempDir := t.TempDir()
+	files := make(map[string]string)
+	for i := 0; i < 8; i++ {
+		var b strings.Builder
+		for line := 0; line < 20000; line++ {
+			fmt.Fprintf(&b, "func f%d_%d() int { return %d }\n", i, line%97, line*i)
+		}
+		files[fmt.Sprintf("f%d.go", i)] = b.String()
+	}
+	writeWorkspaceFiles(t, tempDir, files)
+
+	unpack := func(t *testing.T, config cmd.Config) []byte {
+		t.Helper()
+		config.InputDir = tempDir
+		config.OutputFile = filepath.Join(t.TempDir(), "corpus.txt.gz")
+		config.IncludeGlobs = []string{"**/*.go"}
+		config.NoGitHeader = true
+		config.Gzip = true
+		if err := cmd.ProcessDirectory(config); err != nil {
+			t.Fatalf("ProcessDirectory failed: %v", err)
+		}
+		data, err := os.ReadFile(config.OutputFile)
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("Invalid gzip stream: %v", err)
+		}
+		// A second member would mean the blocks were not joined into one
+		reader.Multistream(false)
+		text, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("Invalid gzip stream: %v", err)
+		}
+		return text
+	}
+
+	serial := unpack(t, cmd.Config{GzipJobs: 1})
+	if len(serial) < 4<<20 {
+		t.Fatalf("Expected a corpus of several megabytes, got %d bytes", len(serial))
+	}
+
+	tests := []struct {
+		name   string
+		config cmd.Config
+	}{
+		{name: "parallel", config: cmd.Config{GzipJobs: 4}},
+		{name: "every CPU", config: cmd.Config{}},
+		{name: "flushed per file", config: cmd.Config{GzipJobs: 3, FlushPerFile: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unpack(t, tt.config); !bytes.Equal(got, serial) {
+				t.Errorf("Expected the same corpus as serial gzip, got %d bytes instead of %d", len(got), len(serial))
+			}
+		})
+	}
+
+	t.Run("negative", func(t *testing.T) {
+		config := cmd.Config{InputDir: tempDir, OutputFile: filepath.Join(t.TempDir(), "out.txt"), Gzip: true, GzipJobs: -1}
+		if err := cmd.ProcessDirectory(config); err == nil {
+			t.Error("Expected a negative --gzip-jobs to be rejected")
+		}
+	})
+}
@@ -1,8 +1,14 @@
 package tests
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"testing"
 )
@@ -176,3 +182,139 @@ func sliceEqual(a, b []string) bool {
 	}
 	return true
 }
+
+// captureStdout returns everything written to os.Stdout while fn runs
+func captureStdout(t *testing.T, fn func()) []byte {
+	t.Helper()
+	return captureFile(t, &os.Stdout, fn)
+}
+
+// captureStderr returns everything written to os.Stderr while fn runs
+func captureStderr(t *testing.T, fn func()) []byte {
+	t.Helper()
+	return captureFile(t, &os.Stderr, fn)
+}
+
+// captureFile replaces *file with a pipe while fn runs and returns what was
+// written to it
+func captureFile(t *testing.T, file **os.File, fn func()) []byte {
+	t.Helper()
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+
+	original := *file
+	*file = writer
+	done := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(reader)
+		done <- data
+	}()
+
+	defer func() {
+		*file = original
+	}()
+	fn()
+	writer.Close()
+	return <-done
+}
+
+// validateJSONSchema checks a JSON document against the subset of JSON Schema
+// used by cpack's published schemas: type, required, properties,
+// additionalProperties, items, enum, minimum and pattern
+func validateJSONSchema(t *testing.T, schemaData, documentData []byte) {
+	t.Helper()
+
+	var schema, document interface{}
+	if err := json.Unmarshal(schemaData, &schema); err != nil {
+		t.Fatalf("Schema is not valid JSON: %v", err)
+	}
+	if err := json.Unmarshal(documentData, &document); err != nil {
+		t.Fatalf("Document is not valid JSON: %v", err)
+	}
+
+	for _, problem := range schemaProblems(schema.(map[string]interface{}), document, "$") {
+		t.Errorf("Schema violation: %s", problem)
+	}
+}
+
+func schemaProblems(schema map[string]interface{}, value interface{}, path string) []string {
+	var problems []string
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, allowed := range enum {
+			if allowed == value {
+				found = true
+			}
+		}
+		if !found {
+			problems = append(problems, fmt.Sprintf("%s: %v is not one of %v", path, value, enum))
+		}
+	}
+
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return append(problems, fmt.Sprintf("%s: expected object", path))
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, name := range required {
+				if _, ok := object[name.(string)]; !ok {
+					problems = append(problems, fmt.Sprintf("%s: missing required property %s", path, name))
+				}
+			}
+		}
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			propertySchema, ok := properties[key].(map[string]interface{})
+			if !ok {
+				if schema["additionalProperties"] == false {
+					problems = append(problems, fmt.Sprintf("%s: unexpected property %s", path, key))
+				}
+				continue
+			}
+			problems = append(problems, schemaProblems(propertySchema, object[key], path+"."+key)...)
+		}
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return append(problems, fmt.Sprintf("%s: expected array", path))
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range array {
+				problems = append(problems, schemaProblems(items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return append(problems, fmt.Sprintf("%s: expected string", path))
+		}
+		if pattern, ok := schema["pattern"].(string); ok && !regexp.MustCompile(pattern).MatchString(str) {
+			problems = append(problems, fmt.Sprintf("%s: %q does not match %s", path, str, pattern))
+		}
+	case "integer", "number":
+		number, ok := value.(float64)
+		if !ok || (schema["type"] == "integer" && number != math.Trunc(number)) {
+			return append(problems, fmt.Sprintf("%s: expected %s", path, schema["type"]))
+		}
+		if minimum, ok := schema["minimum"].(float64); ok && number < minimum {
+			problems = append(problems, fmt.Sprintf("%s: %v is below minimum %v", path, number, minimum))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			problems = append(problems, fmt.Sprintf("%s: expected boolean", path))
+		}
+	}
+
+	return problems
+}
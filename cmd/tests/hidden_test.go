@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestHiddenPolicy(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"main.go":                    "package main\n",
+		".golangci.yml":              "linters: {}\n",
+		".env.example":               "TOKEN=\n",
+		".github/workflows/ci.yml":   "on: push\n",
+		".cache/state.yml":           "stale: true\n",
+		"config/.local/override.yml": "debug: true\n",
+	}
+	for path, content := range files {
+		fullPath := filepath.Join(tempDir, path)
+		os.MkdirAll(filepath.Dir(fullPath), 0755)
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		config      cmd.Config
+		included    []string
+		notIncluded []string
+		wantErr     string
+	}{
+		{
+			name:     "include traverses dotfiles",
+			config:   cmd.Config{Hidden: cmd.HiddenInclude},
+			included: []string{".golangci.yml", ".github/workflows/ci.yml", ".cache/state.yml", "config/.local/override.yml"},
+		},
+		{
+			name:        "exclude skips dotfiles",
+			config:      cmd.Config{Hidden: cmd.HiddenExclude},
+			included:    []string{"main.go"},
+			notIncluded: []string{".golangci.yml", ".github/workflows/ci.yml", ".cache/state.yml", "config/.local/override.yml"},
+		},
+		{
+			name: "exclude keeps explicitly named hidden paths",
+			config: cmd.Config{
+				Hidden:       cmd.HiddenExclude,
+				IncludeGlobs: []string{"**/*.go", ".github/workflows/*.yml"},
+				IncludeNames: []string{".env.example"},
+			},
+			included:    []string{"main.go", ".github/workflows/ci.yml", ".env.example"},
+			notIncluded: []string{".golangci.yml", ".cache/state.yml"},
+		},
+		{
+			name:    "unknown policy",
+			config:  cmd.Config{Hidden: "sometimes"},
+			wantErr: "unsupported hidden file policy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			config.InputDir = tempDir
+			config.OutputFile = filepath.Join(t.TempDir(), "out.txt")
+			config.ExcludeGlobs = []string{}
+			if config.IncludeGlobs == nil {
+				config.IncludeGlobs = []string{"**/*.go", "**/*.yml"}
+			}
+
+			err := cmd.ProcessDirectory(config)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			for _, path := range tt.included {
+				assertFileContains(t, config.OutputFile, "--- START OF FILE: "+path+" ---")
+			}
+			for _, path := range tt.notIncluded {
+				assertFileNotContains(t, config.OutputFile, "--- START OF FILE: "+path+" ---")
+			}
+		})
+	}
+}
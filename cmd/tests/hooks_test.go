@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook scripts in this test use sh")
+	}
+
+	tempDir := t.TempDir()
+	writeWorkspaceFiles(t, tempDir, map[string]string{
+		"main.go":    "package main\n",
+		"lib/lib.go": "package lib\n",
+	})
+
+	tests := []struct {
+		name     string
+		hooks    cmd.Hooks
+		allow    bool
+		wantErr  string
+		contains []string
+		absent   []string
+	}{
+		{
+			name:     "preFile replaces content",
+			hooks:    cmd.Hooks{PreFile: "tr a-z A-Z"},
+			allow:    true,
+			contains: []string{"PACKAGE MAIN", "PACKAGE LIB", "--- START OF FILE: lib/lib.go ---"},
+			absent:   []string{"package main"},
+		},
+		{
+			name:     "preFile receives the path",
+			hooks:    cmd.Hooks{PreFile: `cat; echo "// $1 $CPACK_FILE"`},
+			allow:    true,
+			contains: []string{"package lib\n// lib/lib.go lib/lib.go"},
+		},
+		{
+			name:    "failing preFile fails the pack",
+			hooks:   cmd.Hooks{PreFile: `echo "secret found" >&2; exit 3`},
+			allow:   true,
+			wantErr: "secret found",
+		},
+		{
+			name:    "hooks need to be allowed",
+			hooks:   cmd.Hooks{PreFile: "cat"},
+			wantErr: "--allow-hooks",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "corpus.txt")
+			err := cmd.ProcessDirectory(cmd.Config{
+				InputDir:     tempDir,
+				OutputFile:   output,
+				IncludeGlobs: []string{"**/*.go"},
+				Hooks:        tt.hooks,
+				AllowHooks:   tt.allow,
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+			for _, s := range tt.contains {
+				assertFileContains(t, output, s)
+			}
+			data, _ := os.ReadFile(output)
+			for _, s := range tt.absent {
+				if strings.Contains(string(data), s) {
+					t.Errorf("Expected output not to contain %q", s)
+				}
+			}
+			if problems := cmd.ValidateCorpus(data); len(problems) > 0 {
+				t.Errorf("Expected a valid corpus, got %v", problems)
+			}
+		})
+	}
+
+	t.Run("postPack runs on the corpus", func(t *testing.T) {
+		outDir := t.TempDir()
+		output := filepath.Join(outDir, "corpus.txt")
+		err := cmd.ProcessDirectory(cmd.Config{
+			InputDir:     tempDir,
+			OutputFile:   output,
+			IncludeGlobs: []string{"**/*.go"},
+			Hooks:        cmd.Hooks{PostPack: `cp "$1" "$CPACK_OUTPUT.copy"`},
+			AllowHooks:   true,
+		})
+		if err != nil {
+			t.Fatalf("ProcessDirectory failed: %v", err)
+		}
+		assertFileContains(t, output+".copy", "--- START OF FILE: main.go ---")
+	})
+
+	t.Run("config file cannot allow its own hooks", func(t *testing.T) {
+		dir := t.TempDir()
+		writeWorkspaceFiles(t, dir, map[string]string{
+			"main.go":    "package main\n",
+			"cpack.yaml": "includeGlobs: [\"**/*.go\"]\nhooks:\n  preFile: \"tr a-z A-Z\"\n",
+		})
+		output := filepath.Join(t.TempDir(), "corpus.txt")
+		err := cmd.ProcessDirectory(cmd.Config{InputDir: dir, OutputFile: output})
+		if err == nil || !strings.Contains(err.Error(), "--allow-hooks") {
+			t.Fatalf("Expected hooks from the config file to need --allow-hooks, got %v", err)
+		}
+
+		if err := cmd.ProcessDirectory(cmd.Config{InputDir: dir, OutputFile: output, AllowHooks: true}); err != nil {
+			t.Fatalf("ProcessDirectory failed: %v", err)
+		}
+		assertFileContains(t, output, "PACKAGE MAIN")
+	})
+}
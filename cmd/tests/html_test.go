@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestHTMLFormat(t *testing.T) {
+	tempDir := t.TempDir()
+	writeWorkspaceFiles(t, tempDir, map[string]string{
+		"main.go":         "package main\n\nfunc main() {}\n",
+		"web/index.html":  "<script>alert(1)</script>\n",
+		"web/lib/util.js": "export const x = 1;\n",
+	})
+	outputPath := filepath.Join(t.TempDir(), "corpus.html")
+
+	config := cmd.Config{
+		InputDir:     tempDir,
+		OutputFile:   outputPath,
+		OutputFormat: cmd.FormatHTML,
+		IncludeGlobs: []string{"**/*"},
+		Instructions: "Review <carefully>",
+		NoGitHeader:  true,
+	}
+	if err := cmd.ProcessDirectory(config); err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	page := string(data)
+
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"<p>3 files</p>",
+		"<li><details open><summary>web/</summary>",
+		"<li><details open><summary>lib/</summary>",
+		`<a href="#file-1">main.go</a>`,
+		`<section id="file-1">`,
+		`<h2><a href="#file-1">main.go</a></h2>`,
+		`class="chroma"`,
+		`id="file-1-L1"`,
+		"Review &lt;carefully&gt;",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("Expected page to contain %q", want)
+		}
+	}
+	if strings.Contains(page, "<script>alert(1)</script>") {
+		t.Error("File content must be escaped")
+	}
+	if strings.Contains(page, "--- START OF FILE") {
+		t.Error("Page should not contain text format markers")
+	}
+}
+
+func TestHTMLFormatValidation(t *testing.T) {
+	tempDir, cleanup := createTestFiles(t)
+	defer cleanup()
+
+	for _, config := range []cmd.Config{{Gzip: true}, {Append: true}} {
+		config.InputDir = tempDir
+		config.OutputFile = filepath.Join(t.TempDir(), "out.html")
+		config.OutputFormat = cmd.FormatHTML
+		if err := cmd.ProcessDirectory(config); err == nil {
+			t.Errorf("Expected %+v to be rejected with the html format", config)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestImportGraph(t *testing.T) {
+	tempDir := t.TempDir()
+	writeWorkspaceFiles(t, tempDir, map[string]string{
+		"go.mod":                  "module example.com/app\n\ngo 1.23\n",
+		"main.go":                 "package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/app/internal/api\"\n)\n\nfunc main() { fmt.Println(api.X) }\n",
+		"internal/api/api.go":     "package api\n\nimport \"example.com/app/internal/store\"\n\nvar X = store.Y\n",
+		"internal/store/store.go": "package store\n\nvar Y = 1\n",
+		"README.md":               "# App\n",
+	})
+
+	pack := func(t *testing.T, config cmd.Config) string {
+		t.Helper()
+		config.InputDir = tempDir
+		config.OutputFile = filepath.Join(t.TempDir(), "corpus.txt")
+		if config.IncludeGlobs == nil {
+			config.IncludeGlobs = []string{"**/*.go", "**/*.md"}
+		}
+		if err := cmd.ProcessDirectory(config); err != nil {
+			t.Fatalf("ProcessDirectory failed: %v", err)
+		}
+		data, err := os.ReadFile(config.OutputFile)
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+		return string(data)
+	}
+
+	t.Run("adjacency list", func(t *testing.T) {
+		output := pack(t, cmd.Config{ImportGraph: true})
+		want := "--- IMPORT GRAPH ---\n" +
+			". (main) -> internal/api\n" +
+			"internal/api -> internal/store\n" +
+			"internal/store\n" +
+			"--- END OF IMPORT GRAPH ---\n"
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected import graph:\n%s\ngot:\n%s", want, output)
+		}
+		if strings.Index(output, want) > strings.Index(output, "--- START OF FILE: ") {
+			t.Error("Expected the import graph before the files")
+		}
+		if strings.Contains(output, "mermaid") {
+			t.Error("Expected no mermaid diagram by default")
+		}
+		if problems := cmd.ValidateCorpus([]byte(output)); len(problems) > 0 {
+			t.Errorf("Expected a valid corpus, got %v", problems)
+		}
+	})
+
+	t.Run("mermaid", func(t *testing.T) {
+		output := pack(t, cmd.Config{ImportGraphMermaid: true})
+		for _, want := range []string{
+			"```mermaid\ngraph TD\n",
+			"  p0[\".\"]\n  p1[\"internal/api\"]\n  p0 --> p1\n",
+			"  p2[\"internal/store\"]\n  p1 --> p2\n",
+		} {
+			if !strings.Contains(output, want) {
+				t.Errorf("Expected %q in:\n%s", want, output)
+			}
+		}
+	})
+
+	t.Run("no Go files", func(t *testing.T) {
+		output := pack(t, cmd.Config{ImportGraph: true, IncludeGlobs: []string{"**/*.md"}})
+		if strings.Contains(output, "--- IMPORT GRAPH ---") {
+			t.Error("Expected no import graph without Go files")
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestInitCommand(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"main.go":                  "package main\n",
+		"internal/app/app.go":      "package app\n",
+		"internal/app/app_test.go": "package app\n",
+		"README.md":                "# app\n",
+		"Makefile":                 "all:\n",
+		"scripts/release":          "#!/bin/bash\necho release\n",
+		"vendor/dep/dep.go":        "package dep\n",
+		"web/node_modules/x/x.js":  "module.exports = 1\n",
+		"web/src/index.test.ts":    "test()\n",
+		"corpus-out.txt":           "--- START OF FILE: old.txt ---\n",
+	}
+	for path, content := range files {
+		fullPath := filepath.Join(tempDir, path)
+		os.MkdirAll(filepath.Dir(fullPath), 0755)
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	os.Args = []string{"cpack", "init", tempDir}
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("init command failed: %v", err)
+	}
+
+	configPath := filepath.Join(tempDir, "cpack.yaml")
+	config, err := cmd.LoadConfigFromFile(configPath)
+	if err != nil {
+		t.Fatalf("Generated config does not load: %v", err)
+	}
+
+	wantIncludes := []string{"**/*.go", "**/*.md", "**/*.ts"}
+	if !sliceEqual(config.IncludeGlobs, wantIncludes) {
+		t.Errorf("IncludeGlobs = %v, want %v", config.IncludeGlobs, wantIncludes)
+	}
+	if !sliceEqual(config.IncludeNames, []string{"Makefile"}) {
+		t.Errorf("IncludeNames = %v, want [Makefile]", config.IncludeNames)
+	}
+	for _, glob := range []string{"**/vendor/**", "**/node_modules/**"} {
+		if !containsString(config.ExcludeGlobs, glob) {
+			t.Errorf("ExcludeGlobs %v should contain %s", config.ExcludeGlobs, glob)
+		}
+	}
+	if containsString(config.ExcludeGlobs, "**/*_test.go") {
+		t.Error("Test globs should be suggested in comments, not excluded")
+	}
+	assertFileContains(t, configPath, `# - "**/*_test.go"`)
+	assertFileContains(t, configPath, `# - "**/*.test.*"`)
+	assertFileContains(t, configPath, "# preset: go")
+
+	// An existing config is kept unless --force is given
+	os.Args = []string{"cpack", "init", tempDir}
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Expected init to refuse to overwrite, got %v", err)
+	}
+	os.Args = []string{"cpack", "init", tempDir, "--force"}
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("init --force failed: %v", err)
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    string
+	}{
+		{name: "extension", file: "main.go", want: "go"},
+		{name: "known name", file: "build/Makefile", want: "makefile"},
+		{name: "dockerfile variant", file: "Dockerfile.prod", want: "dockerfile"},
+		{name: "shebang", file: "scripts/deploy", content: "#!/bin/bash\nset -e\n", want: "bash"},
+		{name: "env shebang with version", file: "tool", content: "#!/usr/bin/env python3.11\nprint()\n", want: "python"},
+		{name: "env shebang with options", file: "tool", content: "#!/usr/bin/env -S node --no-warnings\n", want: "javascript"},
+		{name: "php without extension", file: "index", content: "<?php echo 1;", want: "php"},
+		{name: "c header", file: "util.h", content: "int add(int a, int b);\n", want: "c"},
+		{name: "c++ header", file: "util.h", content: "namespace util { class A {}; }\n", want: "cpp"},
+		{name: "objective-c", file: "View.m", content: "#import <UIKit/UIKit.h>\n@implementation View\n@end\n", want: "objectivec"},
+		{name: "unknown", file: "notes", content: "just some text\n", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cmd.DetectLanguage(tt.file, []byte(tt.content)); got != tt.want {
+				t.Errorf("DetectLanguage(%q) = %q, want %q", tt.file, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIncludeLanguages(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"main.go":        "package main\n",
+		"scripts/deploy": "#!/usr/bin/env bash\necho deploy\n",
+		"scripts/tool":   "#!/usr/bin/env python3\nprint('tool')\n",
+		"notes":          "plain text\n",
+	}
+	for path, content := range files {
+		fullPath := filepath.Join(tempDir, path)
+		os.MkdirAll(filepath.Dir(fullPath), 0755)
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	outDir := t.TempDir()
+	config := cmd.Config{
+		InputDir:     tempDir,
+		OutputFile:   filepath.Join(outDir, "out.txt"),
+		IncludeGlobs: []string{"**/*.go"},
+		IncludeLangs: []string{"bash"},
+		ManifestFile: filepath.Join(outDir, "manifest.json"),
+	}
+	if err := cmd.ProcessDirectory(config); err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+
+	assertFileContains(t, config.OutputFile, "--- START OF FILE: scripts/deploy ---")
+	assertFileNotContains(t, config.OutputFile, "--- START OF FILE: scripts/tool ---")
+	assertFileNotContains(t, config.OutputFile, "--- START OF FILE: notes ---")
+
+	data, _ := os.ReadFile(config.ManifestFile)
+	var manifest cmd.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("Failed to parse manifest: %v", err)
+	}
+	languages := make(map[string]string)
+	for _, entry := range manifest.Files {
+		languages[entry.Path] = entry.Language
+	}
+	if languages["scripts/deploy"] != "bash" || languages["main.go"] != "go" {
+		t.Errorf("Unexpected manifest languages %v", languages)
+	}
+}
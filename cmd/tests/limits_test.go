@@ -0,0 +1,220 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestPackLimits(t *testing.T) {
+	tempDir := t.TempDir()
+	for i := 1; i <= 5; i++ {
+		path := filepath.Join(tempDir, fmt.Sprintf("f%d.txt", i))
+		if err := os.WriteFile(path, []byte(strings.Repeat("x", 1000)), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		config      cmd.Config
+		wantPacked  []string
+		wantSkipped []string
+		wantErr     string
+	}{
+		{
+			name:       "no limits",
+			config:     cmd.Config{},
+			wantPacked: []string{"f1.txt", "f2.txt", "f3.txt", "f4.txt", "f5.txt"},
+		},
+		{
+			name:        "max files",
+			config:      cmd.Config{MaxFiles: 2},
+			wantPacked:  []string{"f1.txt", "f2.txt"},
+			wantSkipped: []string{"f3.txt (file limit)", "f4.txt (file limit)", "f5.txt (file limit)"},
+		},
+		{
+			name:        "max total size",
+			config:      cmd.Config{MaxTotalSize: "3.5KB"},
+			wantPacked:  []string{"f1.txt", "f2.txt", "f3.txt"},
+			wantSkipped: []string{"f4.txt (size limit)", "f5.txt (size limit)"},
+		},
+		{
+			name:       "limits not reached",
+			config:     cmd.Config{MaxFiles: 5, MaxTotalSize: "1MB", Strict: true},
+			wantPacked: []string{"f1.txt", "f2.txt", "f3.txt", "f4.txt", "f5.txt"},
+		},
+		{
+			name:    "strict max files",
+			config:  cmd.Config{MaxFiles: 2, Strict: true},
+			wantErr: "5 selected files exceed --max-files 2",
+		},
+		{
+			name:    "strict max total size",
+			config:  cmd.Config{MaxTotalSize: "2000", Strict: true},
+			wantErr: "exceed --max-total-size 2.0KB",
+		},
+		{
+			name:    "invalid size",
+			config:  cmd.Config{MaxTotalSize: "lots"},
+			wantErr: "error parsing --max-total-size",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outDir := t.TempDir()
+			config := tt.config
+			config.InputDir = tempDir
+			config.OutputFile = filepath.Join(outDir, "out.txt")
+			config.ReportFile = filepath.Join(outDir, "report.json")
+			config.IncludeGlobs = []string{"**/*.txt"}
+
+			err := cmd.ProcessDirectory(config)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				if _, err := os.Stat(config.OutputFile); !os.IsNotExist(err) {
+					t.Errorf("Expected no output file after a failed run, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			data, _ := os.ReadFile(config.ReportFile)
+			var report cmd.PackReport
+			if err := json.Unmarshal(data, &report); err != nil {
+				t.Fatalf("Failed to parse report: %v", err)
+			}
+			if !sliceEqual(report.ProcessedFiles, tt.wantPacked) {
+				t.Errorf("ProcessedFiles = %v, want %v", report.ProcessedFiles, tt.wantPacked)
+			}
+			if len(tt.wantSkipped) > 0 && !sliceEqual(report.SkippedFiles, tt.wantSkipped) {
+				t.Errorf("SkippedFiles = %v, want %v", report.SkippedFiles, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestStrictKeepsExistingCorpus(t *testing.T) {
+	tempDir := t.TempDir()
+	writeWorkspaceFiles(t, tempDir, map[string]string{"a.txt": "a\n", "b.txt": "b\n"})
+
+	for _, appendCorpus := range []bool{false, true} {
+		t.Run(fmt.Sprintf("append=%v", appendCorpus), func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "out.txt")
+			if err := os.WriteFile(output, []byte("earlier corpus\n"), 0644); err != nil {
+				t.Fatalf("Failed to write corpus: %v", err)
+			}
+
+			config := cmd.Config{
+				InputDir:     tempDir,
+				OutputFile:   output,
+				IncludeGlobs: []string{"**/*.txt"},
+				MaxFiles:     1,
+				Strict:       true,
+				Append:       appendCorpus,
+			}
+			if err := cmd.ProcessDirectory(config); err == nil {
+				t.Fatal("Expected the strict limit to fail the run")
+			}
+			if data, _ := os.ReadFile(output); string(data) != "earlier corpus\n" {
+				t.Errorf("Expected the earlier corpus to be kept, got %q", data)
+			}
+		})
+	}
+}
+
+func TestSizeRange(t *testing.T) {
+	tempDir := t.TempDir()
+	sizes := map[string]int{"empty.txt": 0, "small.txt": 10, "medium.txt": 2000, "large.txt": 300 * 1024}
+	for name, size := range sizes {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(strings.Repeat("x", size)), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		config      cmd.Config
+		wantPacked  []string
+		wantSkipped []string
+		wantErr     string
+	}{
+		{
+			name:       "no range",
+			config:     cmd.Config{},
+			wantPacked: []string{"empty.txt", "large.txt", "medium.txt", "small.txt"},
+		},
+		{
+			name:        "skip empty files",
+			config:      cmd.Config{MinSize: "1"},
+			wantPacked:  []string{"large.txt", "medium.txt", "small.txt"},
+			wantSkipped: []string{"empty.txt (too small)"},
+		},
+		{
+			name:        "max size",
+			config:      cmd.Config{MaxSize: "200KB"},
+			wantPacked:  []string{"empty.txt", "medium.txt", "small.txt"},
+			wantSkipped: []string{"large.txt (too large)"},
+		},
+		{
+			name:        "range",
+			config:      cmd.Config{MinSize: "1KB", MaxSize: "2000"},
+			wantPacked:  []string{"medium.txt"},
+			wantSkipped: []string{"large.txt (too large)", "empty.txt (too small)", "small.txt (too small)"},
+		},
+		{
+			name:    "invalid size",
+			config:  cmd.Config{MaxSize: "huge"},
+			wantErr: "error parsing --max-size",
+		},
+		{
+			name:    "empty range",
+			config:  cmd.Config{MinSize: "1MB", MaxSize: "1KB"},
+			wantErr: "--min-size 1MB is larger than --max-size 1KB",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outDir := t.TempDir()
+			config := tt.config
+			config.InputDir = tempDir
+			config.OutputFile = filepath.Join(outDir, "out.txt")
+			config.ReportFile = filepath.Join(outDir, "report.json")
+			config.IncludeGlobs = []string{"**/*.txt"}
+
+			err := cmd.ProcessDirectory(config)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			data, _ := os.ReadFile(config.ReportFile)
+			var report cmd.PackReport
+			if err := json.Unmarshal(data, &report); err != nil {
+				t.Fatalf("Failed to parse report: %v", err)
+			}
+			if !sliceEqual(report.ProcessedFiles, tt.wantPacked) {
+				t.Errorf("ProcessedFiles = %v, want %v", report.ProcessedFiles, tt.wantPacked)
+			}
+			if len(tt.wantSkipped) > 0 && !sliceEqual(report.SkippedFiles, tt.wantSkipped) {
+				t.Errorf("SkippedFiles = %v, want %v", report.SkippedFiles, tt.wantSkipped)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestLinkFarm(t *testing.T) {
+	tests := []struct {
+		name     string
+		linkMode string
+		validate func(t *testing.T, farmDir string)
+	}{
+		{
+			name:     "symlink mode",
+			linkMode: cmd.LinkModeSymlink,
+			validate: func(t *testing.T, farmDir string) {
+				info, err := os.Lstat(filepath.Join(farmDir, "src/pkg1/file1.go"))
+				if err != nil {
+					t.Fatalf("Expected linked file: %v", err)
+				}
+				if info.Mode()&os.ModeSymlink == 0 {
+					t.Error("Expected file to be a symlink")
+				}
+				assertFileContains(t, filepath.Join(farmDir, "src/pkg1/file1.go"), "package pkg1")
+			},
+		},
+		{
+			name:     "copy mode",
+			linkMode: cmd.LinkModeCopy,
+			validate: func(t *testing.T, farmDir string) {
+				info, err := os.Lstat(filepath.Join(farmDir, "src/pkg2/file2.go"))
+				if err != nil {
+					t.Fatalf("Expected copied file: %v", err)
+				}
+				if !info.Mode().IsRegular() {
+					t.Error("Expected file to be a regular copy")
+				}
+				assertFileContains(t, filepath.Join(farmDir, "src/pkg2/file2.go"), "package pkg2")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir, cleanup := createTestFiles(t)
+			defer cleanup()
+
+			farmDir := filepath.Join(tempDir, "farm")
+			config := cmd.Config{
+				InputDir:     tempDir,
+				OutputFile:   farmDir,
+				OutputFormat: cmd.FormatLinkFarm,
+				LinkMode:     tt.linkMode,
+				IncludeGlobs: []string{"**/*.go"},
+				ExcludeGlobs: []string{"**/*_test.go"},
+			}
+
+			if err := cmd.ProcessDirectory(config); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			tt.validate(t, farmDir)
+			assertFileNotExists(t, filepath.Join(farmDir, "src/pkg1/file1_test.go"))
+			assertFileNotExists(t, filepath.Join(farmDir, "src/pkg1/main.py"))
+
+			// A second run must not pick up the farm itself
+			if err := cmd.ProcessDirectory(config); err != nil {
+				t.Fatalf("Second ProcessDirectory failed: %v", err)
+			}
+			assertFileNotExists(t, filepath.Join(farmDir, "farm"))
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestMarkdownFormat(t *testing.T) {
+	files := map[string]string{
+		"main.go":         "package main\n",
+		"Dockerfile":      "FROM golang:1.23\n",
+		"scripts/deploy":  "#!/usr/bin/env python3\nprint('deploy')\n",
+		"docs/README.md":  "# Docs\n\n```go\nfmt.Println()\n```\n",
+		"views/page.tpl":  "{{ .Title }}",
+		"notes/plain.txt": "no newline at end",
+	}
+
+	tests := []struct {
+		name   string
+		config cmd.Config
+		want   []string
+	}{
+		{
+			name:   "fence languages",
+			config: cmd.Config{},
+			want: []string{
+				"## main.go\n\n```go\npackage main\n```\n\n",
+				"## Dockerfile\n\n```dockerfile\nFROM golang:1.23\n```\n\n",
+				"## scripts/deploy\n\n```python\n#!/usr/bin/env python3\n",
+				"## notes/plain.txt\n\n```\nno newline at end\n```\n\n",
+			},
+		},
+		{
+			name:   "fence outlasts backticks in content",
+			config: cmd.Config{},
+			want:   []string{"## docs/README.md\n\n````markdown\n# Docs\n\n```go\nfmt.Println()\n```\n````\n\n"},
+		},
+		{
+			name:   "configured fence languages",
+			config: cmd.Config{FenceLanguages: map[string]string{"*.tpl": "gotemplate", "Dockerfile": "docker"}},
+			want: []string{
+				"## views/page.tpl\n\n```gotemplate\n{{ .Title }}\n```\n\n",
+				"## Dockerfile\n\n```docker\n",
+			},
+		},
+		{
+			name:   "low memory streams the same blocks",
+			config: cmd.Config{LowMemory: true},
+			want: []string{
+				"## main.go\n\n```go\npackage main\n```\n\n",
+				"````markdown\n# Docs\n\n```go\nfmt.Println()\n```\n````\n\n",
+				"## notes/plain.txt\n\n```\nno newline at end\n```\n\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			writeWorkspaceFiles(t, tempDir, files)
+			outputPath := filepath.Join(t.TempDir(), "corpus.md")
+
+			config := tt.config
+			config.InputDir = tempDir
+			config.OutputFile = outputPath
+			config.OutputFormat = cmd.FormatMarkdown
+			config.IncludeGlobs = []string{"**/*"}
+			config.NoGitHeader = true
+			if err := cmd.ProcessDirectory(config); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			data, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(data), want) {
+					t.Errorf("Expected corpus to contain %q, got:\n%s", want, data)
+				}
+			}
+			if strings.Contains(string(data), "--- START OF FILE") {
+				t.Error("Markdown corpus should not contain text format markers")
+			}
+		})
+	}
+}
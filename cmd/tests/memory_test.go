@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestMaxMemory(t *testing.T) {
+	files := map[string]string{
+		"small.go":   "package small\n\nfunc Small() {}\n",
+		"big.txt":    strings.Repeat("a line of text that is long enough\n", 200),
+		"medium.go":  "package medium\n\n" + strings.Repeat("// a comment line\n", 40),
+		"script.txt": strings.Repeat("echo hello\n", 50),
+	}
+
+	tests := []struct {
+		name    string
+		config  cmd.Config
+		wantErr string
+	}{
+		{
+			name:   "verbose buffer spills",
+			config: cmd.Config{Verbose: true, CostModel: "gpt-4o"},
+		},
+		{
+			name:   "transformed files are still read whole",
+			config: cmd.Config{Verbose: true, StripTrailingSpace: true},
+		},
+		{
+			name:   "markdown output",
+			config: cmd.Config{Verbose: true, OutputFormat: cmd.FormatMarkdown},
+		},
+		{
+			name:    "invalid size",
+			config:  cmd.Config{MaxMemory: "lots"},
+			wantErr: "error parsing --max-memory",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputDir := t.TempDir()
+			writeWorkspaceFiles(t, inputDir, files)
+			outputDir := t.TempDir()
+			tempDir := t.TempDir()
+			t.Setenv("TMPDIR", tempDir)
+
+			pack := func(name, maxMemory string) []byte {
+				config := tt.config
+				config.InputDir = inputDir
+				config.OutputFile = filepath.Join(outputDir, name)
+				config.IncludeGlobs = []string{"**/*.go", "**/*.txt"}
+				config.Deterministic = true
+				if maxMemory != "" {
+					config.MaxMemory = maxMemory
+				}
+				err := cmd.ProcessDirectory(config)
+				if tt.wantErr != "" {
+					if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+						t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+					}
+					return nil
+				}
+				if err != nil {
+					t.Fatalf("ProcessDirectory failed: %v", err)
+				}
+				content, err := os.ReadFile(config.OutputFile)
+				if err != nil {
+					t.Fatalf("Failed to read output file: %v", err)
+				}
+				return content
+			}
+
+			unbounded := pack("unbounded.txt", "")
+			if tt.wantErr != "" {
+				return
+			}
+			bounded := pack("bounded.txt", "1KB")
+			if !bytes.Equal(bounded, unbounded) {
+				t.Errorf("Expected the same corpus with --max-memory, got:\n%s\nwant:\n%s", bounded, unbounded)
+			}
+
+			// Spill files are removed once the corpus is written
+			if entries, _ := os.ReadDir(tempDir); len(entries) > 0 {
+				t.Errorf("Expected no temporary files left, got %d", len(entries))
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestMergeCorpora(t *testing.T) {
+	first := cmd.ParseCorpus([]byte("--- INSTRUCTIONS ---\nReview this.\n--- END OF INSTRUCTIONS ---\n\n" +
+		"--- START OF FILE: a.go ---\nold a\n--- END OF FILE: a.go ---\n\n" +
+		"--- START OF FILE: b.go ---\n--- NOTE: core ---\nb\n--- END OF FILE: b.go ---\n\n"))
+	second := cmd.ParseCorpus([]byte("--- START OF FILE: c.go --- c --- END OF FILE: c.go --- " +
+		"--- START OF FILE: a.go --- new a --- END OF FILE: a.go --- "))
+
+	merged := cmd.MergeCorpora(first, second)
+
+	want := []struct{ path, content, note string }{
+		{"a.go", "new a", ""},
+		{"b.go", "b", "core"},
+		{"c.go", "c", ""},
+	}
+	if len(merged.Files) != len(want) {
+		t.Fatalf("Expected %d files, got %+v", len(want), merged.Files)
+	}
+	for i, w := range want {
+		f := merged.Files[i]
+		if f.ID != i+1 || f.Path != w.path || string(merged.Content(f)) != w.content || f.Note != w.note {
+			t.Errorf("File %d = %+v with content %q, want %s %q note %q", i, f, merged.Content(f), w.path, w.content, w.note)
+		}
+	}
+	if got := merged.Instructions(); got != "Review this." {
+		t.Errorf("Instructions() = %q, want the first corpus's instructions", got)
+	}
+
+	// The merged corpus parses back to the same files
+	reparsed := cmd.ParseCorpus(merged.Data)
+	if !sliceEqual(corpusPaths(reparsed), []string{"a.go", "b.go", "c.go"}) {
+		t.Errorf("Reparsed paths = %v", corpusPaths(reparsed))
+	}
+}
+
+func TestMergeCommand(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.txt")
+	b := filepath.Join(dir, "b.txt")
+	os.WriteFile(a, []byte("--- START OF FILE: x.go ---\nx\n--- END OF FILE: x.go ---\n\n"), 0644)
+	os.WriteFile(b, []byte("--- START OF FILE: y.go ---\ny\n--- END OF FILE: y.go ---\n\n"), 0644)
+
+	// The output may be one of the inputs
+	os.Args = []string{"cpack", "merge", a, a, b, "--gzip"}
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("merge command failed: %v", err)
+	}
+
+	corpus, err := cmd.LoadCorpus(a)
+	if err != nil {
+		t.Fatalf("Failed to load merged corpus: %v", err)
+	}
+	if !sliceEqual(corpusPaths(corpus), []string{"x.go", "y.go"}) {
+		t.Errorf("Merged paths = %v", corpusPaths(corpus))
+	}
+}
+
+func TestAppend(t *testing.T) {
+	tempDir := t.TempDir()
+	write := func(path, content string) {
+		if err := os.WriteFile(filepath.Join(tempDir, path), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	config := cmd.Config{
+		InputDir:     tempDir,
+		OutputFile:   filepath.Join(t.TempDir(), "out.txt.gz"),
+		IncludeGlobs: []string{"**/*.go"},
+		Gzip:         true,
+		Append:       true,
+	}
+
+	write("a.go", "package a\n")
+	write("b.go", "package b\n")
+	if err := cmd.ProcessDirectory(config); err != nil {
+		t.Fatalf("First run failed: %v", err)
+	}
+
+	// The second run re-packs a.go and packs c.go; b.go only exists in the
+	// first corpus
+	os.Remove(filepath.Join(tempDir, "b.go"))
+	write("a.go", "package a // v2\n")
+	write("c.go", "package c\n")
+	if err := cmd.ProcessDirectory(config); err != nil {
+		t.Fatalf("Second run failed: %v", err)
+	}
+
+	corpus, err := cmd.LoadCorpus(config.OutputFile)
+	if err != nil {
+		t.Fatalf("Failed to load corpus: %v", err)
+	}
+	if !sliceEqual(corpusPaths(corpus), []string{"a.go", "b.go", "c.go"}) {
+		t.Fatalf("Appended paths = %v", corpusPaths(corpus))
+	}
+	if f, _ := corpus.Find("a.go"); string(corpus.Content(f)) != "package a // v2\n" {
+		t.Errorf("Expected the re-packed a.go to replace the old copy, got %q", corpus.Content(f))
+	}
+}
+
+func TestAppendFailureKeepsCorpus(t *testing.T) {
+	inputDir := t.TempDir()
+	writeWorkspaceFiles(t, inputDir, map[string]string{"a.go": "package a\n"})
+	outputDir := t.TempDir()
+	config := cmd.Config{
+		InputDir:     inputDir,
+		OutputFile:   filepath.Join(outputDir, "out.txt"),
+		IncludeGlobs: []string{"**/*.go"},
+		Append:       true,
+	}
+	if err := cmd.ProcessDirectory(config); err != nil {
+		t.Fatalf("First run failed: %v", err)
+	}
+	before, err := os.ReadFile(config.OutputFile)
+	if err != nil {
+		t.Fatalf("Failed to read corpus: %v", err)
+	}
+
+	// A preFile hook that fails stops the pack after the output is opened
+	writeWorkspaceFiles(t, inputDir, map[string]string{"b.go": "package b\n"})
+	config.AllowHooks = true
+	config.Hooks.PreFile = "exit 1"
+	if err := cmd.ProcessDirectory(config); err == nil {
+		t.Fatal("Expected the failing hook to fail the pack")
+	}
+
+	after, err := os.ReadFile(config.OutputFile)
+	if err != nil {
+		t.Fatalf("Expected the existing corpus to survive: %v", err)
+	}
+	if string(after) != string(before) {
+		t.Errorf("Expected the existing corpus unchanged, got:\n%s", after)
+	}
+	entries, _ := os.ReadDir(outputDir)
+	if len(entries) != 1 {
+		t.Errorf("Expected only the corpus in the output directory, got %d entries", len(entries))
+	}
+}
+
+func corpusPaths(corpus *cmd.Corpus) []string {
+	paths := make([]string, 0, len(corpus.Files))
+	for _, f := range corpus.Files {
+		paths = append(paths, f.Path)
+	}
+	return paths
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestMinifiedDetection(t *testing.T) {
+	files := map[string]string{
+		"src/app.js":           "function add(a, b) {\n  return a + b;\n}\n" + strings.Repeat("// padding line for size\n", 60),
+		"dist-ish/bundle.js":   strings.Repeat("var a=function(b){return b*2};", 200),
+		"static/site.css":      strings.Repeat(".a{color:red}.b{margin:0}", 100) + "\n",
+		"src/data.js":          "export const blob = \"" + strings.Repeat("x", 1500) + "\";\n" + strings.Repeat("export const n = 1;\n", 80),
+		"src/tiny.js":          "var a=1;",
+		"assets/vendor.min.js": "var skipped=1;",
+	}
+
+	tests := []struct {
+		name        string
+		minified    string
+		contains    []string
+		notContains []string
+	}{
+		{
+			name:        "skipped by default",
+			contains:    []string{"--- START OF FILE: src/app.js", "--- START OF FILE: src/data.js", "--- START OF FILE: src/tiny.js", "dist-ish/bundle.js (minified)", "static/site.css (minified)"},
+			notContains: []string{"var a=function", "--- START OF FILE: dist-ish/bundle.js"},
+		},
+		{
+			name:        "stubbed",
+			minified:    cmd.MinifiedStub,
+			contains:    []string{"--- START OF FILE: dist-ish/bundle.js ---\n/* minified file omitted (6000 bytes) */", "--- START OF FILE: static/site.css"},
+			notContains: []string{"var a=function", "color:red"},
+		},
+		{
+			name:     "kept",
+			minified: cmd.MinifiedKeep,
+			contains: []string{"var a=function", "color:red"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			writeWorkspaceFiles(t, tempDir, files)
+			outputPath := filepath.Join(t.TempDir(), "out.txt")
+
+			config := cmd.Config{
+				InputDir:     tempDir,
+				OutputFile:   outputPath,
+				IncludeGlobs: []string{"**/*.js", "**/*.css"},
+				ExcludeGlobs: []string{"**/*.min.*"},
+				Minified:     tt.minified,
+				Verbose:      true,
+				NoGitHeader:  true,
+			}
+			if err := cmd.ProcessDirectory(config); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			for _, want := range tt.contains {
+				assertFileContains(t, outputPath, want)
+			}
+			for _, unwanted := range tt.notContains {
+				assertFileNotContains(t, outputPath, unwanted)
+			}
+		})
+	}
+}
+
+func TestMinifiedPolicyValidation(t *testing.T) {
+	tempDir, cleanup := createTestFiles(t)
+	defer cleanup()
+
+	config := cmd.Config{
+		InputDir:   tempDir,
+		OutputFile: filepath.Join(t.TempDir(), "out.txt"),
+		Minified:   "strip",
+	}
+	if err := cmd.ProcessDirectory(config); err == nil || !strings.Contains(err.Error(), "minified") {
+		t.Errorf("Expected an unsupported policy error, got %v", err)
+	}
+}
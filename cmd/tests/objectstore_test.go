@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+// fakeCLI puts a script named tool on PATH that saves its arguments and
+// stdin under dir, then exits with status
+func fakeCLI(t *testing.T, tool, dir string, status int) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake CLIs are shell scripts")
+	}
+	script := "#!/bin/sh\n" +
+		"echo \"$@\" > '" + filepath.Join(dir, "args") + "'\n" +
+		"cat > '" + filepath.Join(dir, "body") + "'\n"
+	if status != 0 {
+		script += "echo 'AccessDenied' >&2\nexit 1\n"
+	}
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, tool), []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write fake %s: %v", tool, err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestObjectStoreOutput(t *testing.T) {
+	tempDir := t.TempDir()
+	writeWorkspaceFiles(t, tempDir, map[string]string{"main.go": "package main\n"})
+
+	tests := []struct {
+		name     string
+		tool     string
+		output   string
+		format   string
+		wantArgs string
+		wantBody string
+	}{
+		{
+			name:     "s3",
+			tool:     "aws",
+			output:   "s3://bucket/corpora/app.txt",
+			wantArgs: "s3 cp --only-show-errors - s3://bucket/corpora/app.txt",
+			wantBody: "--- START OF FILE: main.go ---\npackage main\n",
+		},
+		{
+			name:     "gcs",
+			tool:     "gcloud",
+			output:   "gs://bucket/app.txt",
+			wantArgs: "storage cp - gs://bucket/app.txt",
+			wantBody: "--- START OF FILE: main.go ---\npackage main\n",
+		},
+		{
+			name:     "azure",
+			tool:     "azcopy",
+			output:   "az://account/container/app.txt",
+			wantArgs: "copy https://account.blob.core.windows.net/container/app.txt --from-to PipeBlob --log-level ERROR",
+			wantBody: "--- START OF FILE: main.go ---\npackage main\n",
+		},
+		{
+			name:     "rendered format",
+			tool:     "aws",
+			output:   "s3://bucket/app.yaml",
+			format:   cmd.FormatYAML,
+			wantArgs: "s3 cp --only-show-errors - s3://bucket/app.yaml",
+			wantBody: "# cpack corpus\nfiles:\n  - path: main.go\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			capture := t.TempDir()
+			fakeCLI(t, tt.tool, capture, 0)
+
+			config := cmd.Config{
+				InputDir:     tempDir,
+				OutputFile:   tt.output,
+				OutputFormat: tt.format,
+				IncludeGlobs: []string{"**/*.go"},
+				NoGitHeader:  true,
+			}
+			if err := cmd.ProcessDirectory(config); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			args, _ := os.ReadFile(filepath.Join(capture, "args"))
+			if got := strings.TrimSpace(string(args)); got != tt.wantArgs {
+				t.Errorf("Expected %s %s, got %q", tt.tool, tt.wantArgs, got)
+			}
+			assertFileContains(t, filepath.Join(capture, "body"), tt.wantBody)
+			scheme, _, _ := strings.Cut(tt.output, "://")
+			if _, err := os.Stat(scheme + ":"); err == nil {
+				t.Error("Object-store output must not create a local directory")
+			}
+		})
+	}
+}
+
+func TestObjectStoreOutputErrors(t *testing.T) {
+	tempDir, cleanup := createTestFiles(t)
+	defer cleanup()
+
+	t.Run("upload fails", func(t *testing.T) {
+		fakeCLI(t, "aws", t.TempDir(), 1)
+		err := cmd.ProcessDirectory(cmd.Config{InputDir: tempDir, OutputFile: "s3://bucket/app.txt"})
+		if err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+			t.Errorf("Expected the CLI's error, got %v", err)
+		}
+	})
+
+	t.Run("missing cli", func(t *testing.T) {
+		t.Setenv("PATH", t.TempDir())
+		err := cmd.ProcessDirectory(cmd.Config{InputDir: tempDir, OutputFile: "gs://bucket/app.txt"})
+		if err == nil || !strings.Contains(err.Error(), "gcloud") {
+			t.Errorf("Expected a missing gcloud error, got %v", err)
+		}
+	})
+
+	fakeCLI(t, "aws", t.TempDir(), 0)
+	for _, config := range []cmd.Config{
+		{Append: true},
+		{SplitByDir: 1},
+		{OutputFormat: cmd.FormatLinkFarm},
+	} {
+		config.InputDir = tempDir
+		config.OutputFile = "s3://bucket/app.txt"
+		if err := cmd.ProcessDirectory(config); err == nil {
+			t.Errorf("Expected %+v to be rejected with object-store output", config)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+// fileOrder returns the file paths of a corpus in the order they were emitted
+func fileOrder(t *testing.T, outputPath string) []string {
+	t.Helper()
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+
+	var order []string
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.HasPrefix(line, "--- START OF FILE: ") {
+			order = append(order, strings.TrimSuffix(strings.TrimPrefix(line, "--- START OF FILE: "), " ---"))
+		}
+	}
+	return order
+}
+
+func TestPriorityGlobs(t *testing.T) {
+	tests := []struct {
+		name     string
+		priority []string
+		expected []string
+	}{
+		{
+			name:     "walk order without priorities",
+			priority: nil,
+			expected: []string{"src/pkg1/file1.go", "src/pkg1/main.py", "src/pkg2/README.md", "src/pkg2/file2.go", "src/pkg2/utils.py"},
+		},
+		{
+			name:     "earlier patterns first",
+			priority: []string{"README.md", "**/pkg2/*.go"},
+			expected: []string{"src/pkg2/README.md", "src/pkg2/file2.go", "src/pkg1/file1.go", "src/pkg1/main.py", "src/pkg2/utils.py"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir, cleanup := createTestFiles(t)
+			defer cleanup()
+
+			config := cmd.Config{
+				InputDir:      tempDir,
+				OutputFile:    filepath.Join(tempDir, "out.txt"),
+				IncludeGlobs:  []string{"**/*.go", "**/*.py", "**/*.md"},
+				ExcludeGlobs:  []string{"**/*_test.go"},
+				PriorityGlobs: tt.priority,
+			}
+			if err := cmd.ProcessDirectory(config); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			order := fileOrder(t, config.OutputFile)
+			if !sliceEqual(order, tt.expected) {
+				t.Errorf("Expected order %v, got %v", tt.expected, order)
+			}
+		})
+	}
+}
+
+func TestSortByDeps(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "deps-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{
+		"go.mod":               "module example.com/app\n\ngo 1.21\n",
+		"main.go":              "package main\n\nimport \"example.com/app/api\"\n\nfunc main() { api.Serve() }\n",
+		"api/api.go":           "package api\n\nimport (\n\t\"fmt\"\n\t\"example.com/app/store\"\n)\n\nfunc Serve() { fmt.Println(store.Get()) }\n",
+		"store/store.go":       "package store\n\nimport \"example.com/app/model\"\n\nfunc Get() model.Item { return model.Item{} }\n",
+		"model/model.go":       "package model\n\ntype Item struct{}\n",
+		"aardvark/aardvark.go": "package aardvark\n\nimport \"example.com/app/model\"\n\nvar _ model.Item\n",
+		"README.md":            "# App\n",
+	}
+	for path, content := range files {
+		fullPath := filepath.Join(tempDir, path)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	config := cmd.Config{
+		InputDir:     tempDir,
+		OutputFile:   filepath.Join(tempDir, "out.txt"),
+		IncludeGlobs: []string{"**/*.go", "**/*.md"},
+		SortOrder:    cmd.SortDeps,
+	}
+	if err := cmd.ProcessDirectory(config); err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+
+	expected := []string{
+		"README.md",
+		"model/model.go",
+		"aardvark/aardvark.go",
+		"store/store.go",
+		"api/api.go",
+		"main.go",
+	}
+	order := fileOrder(t, config.OutputFile)
+	if !sliceEqual(order, expected) {
+		t.Errorf("Expected dependency order %v, got %v", expected, order)
+	}
+
+	config.SortOrder = "random"
+	if err := cmd.ProcessDirectory(config); err == nil {
+		t.Error("Expected error for unsupported sort order")
+	}
+}
@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestParquetFormat(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"main.go":       "package main\n\nfunc main() {}\n",
+		"docs/guide.md": "# Guide\n",
+	}
+	writeWorkspaceFiles(t, tempDir, files)
+	outputPath := filepath.Join(t.TempDir(), "corpus.parquet")
+
+	config := cmd.Config{
+		InputDir:     tempDir,
+		OutputFile:   outputPath,
+		OutputFormat: cmd.FormatParquet,
+		IncludeGlobs: []string{"**/*"},
+		NoGitHeader:  true,
+	}
+	if err := cmd.ProcessDirectory(config); err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if !bytes.HasPrefix(data, []byte("PAR1")) || !bytes.HasSuffix(data, []byte("PAR1")) {
+		t.Fatal("Expected the file to open and close with the Parquet magic")
+	}
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	if footerLen <= 0 || footerLen > len(data)-12 {
+		t.Fatalf("Invalid footer length %d for a %d byte file", footerLen, len(data))
+	}
+	footer := data[len(data)-8-footerLen : len(data)-8]
+	for _, column := range []string{"path", "language", "size", "tokens", "content"} {
+		if !bytes.Contains(footer, []byte(column)) {
+			t.Errorf("Expected column %s in the footer", column)
+		}
+	}
+
+	// Values are PLAIN encoded: a little-endian length, then the bytes
+	for path, content := range files {
+		for _, value := range []string{path, content} {
+			encoded := binary.LittleEndian.AppendUint32(nil, uint32(len(value)))
+			if !bytes.Contains(data, append(encoded, value...)) {
+				t.Errorf("Expected %q to be stored as a column value", value)
+			}
+		}
+		size := binary.LittleEndian.AppendUint64(nil, uint64(len(content)))
+		if !bytes.Contains(data, size) {
+			t.Errorf("Expected the size of %s to be stored", path)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestPBFormat(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"main.go":     "package main\n\nfunc main() {}\n",
+		"notes.txt":   "trailing space   \n",
+		"no-eol.sh":   "echo hi",
+		"empty.txt":   "",
+		"docs/big.md": strings.Repeat("# heading\n\ntext\n", 200),
+		"latin1.txt":  "caf\xe9\n",
+	}
+	writeWorkspaceFiles(t, tempDir, files)
+	outputPath := filepath.Join(t.TempDir(), "corpus.pb")
+
+	config := cmd.Config{
+		InputDir:     tempDir,
+		OutputFile:   outputPath,
+		OutputFormat: cmd.FormatPB,
+		IncludeGlobs: []string{"**/*"},
+		Instructions: "Review carefully",
+		NoGitHeader:  true,
+	}
+	if err := cmd.ProcessDirectory(config); err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if bytes.Contains(data, []byte("--- START OF FILE")) {
+		t.Error("Corpus should not contain text format markers")
+	}
+
+	corpus, err := cmd.ReadPBCorpus(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadPBCorpus failed: %v", err)
+	}
+	loaded, err := cmd.LoadCorpus(outputPath)
+	if err != nil {
+		t.Fatalf("LoadCorpus failed: %v", err)
+	}
+	for _, c := range []*cmd.Corpus{corpus, loaded} {
+		if len(c.Files) != len(files) {
+			t.Fatalf("Expected %d files, got %d", len(files), len(c.Files))
+		}
+		for path, content := range files {
+			f, ok := c.Find(path)
+			if !ok {
+				t.Errorf("Expected %s in the corpus", path)
+				continue
+			}
+			if got := string(c.Content(f)); got != content {
+				t.Errorf("%s: expected content %q, got %q", path, content, got)
+			}
+		}
+		if !bytes.Contains(c.Data, []byte("Review carefully")) {
+			t.Error("Expected the instructions to be kept")
+		}
+	}
+
+	if _, err := cmd.ReadPBCorpus(strings.NewReader("--- START OF FILE: a ---\n")); err == nil {
+		t.Error("Expected a text corpus to be rejected")
+	}
+	if _, err := cmd.ReadPBCorpus(bytes.NewReader(data[:len(data)-3])); err == nil {
+		t.Error("Expected a truncated corpus to be rejected")
+	}
+}
+
+func TestPBSchema(t *testing.T) {
+	schema, err := cmd.Schema("corpus")
+	if err != nil {
+		t.Fatalf("Failed to load corpus schema: %v", err)
+	}
+	for _, want := range []string{`syntax = "proto3";`, "message Corpus", "message File", "bytes content = 5;"} {
+		if !strings.Contains(string(schema), want) {
+			t.Errorf("Expected corpus schema to contain %q", want)
+		}
+	}
+}
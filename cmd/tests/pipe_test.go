@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+// makeFIFO creates a named pipe and starts reading it, returning a function
+// that waits for the writer to close it and returns what was read
+func makeFIFO(t *testing.T) (string, func() []byte) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "corpus.pipe")
+	if err := exec.Command("mkfifo", path).Run(); err != nil {
+		t.Skipf("mkfifo unavailable: %v", err)
+	}
+
+	done := make(chan []byte)
+	go func() {
+		f, err := os.Open(path)
+		if err != nil {
+			done <- nil
+			return
+		}
+		defer f.Close()
+		data, _ := io.ReadAll(f)
+		done <- data
+	}()
+	return path, func() []byte {
+		select {
+		case data := <-done:
+			return data
+		case <-time.After(10 * time.Second):
+			t.Fatal("Timed out waiting for the pipe to be written and closed")
+			return nil
+		}
+	}
+}
+
+func TestPipeOutput(t *testing.T) {
+	tempDir := t.TempDir()
+	writeWorkspaceFiles(t, tempDir, map[string]string{
+		"a.go": "package a\n",
+		"b.go": "package b\n",
+	})
+
+	tests := []struct {
+		name   string
+		config cmd.Config
+		want   string
+	}{
+		{name: "text", want: "--- START OF FILE: b.go ---\npackage b\n"},
+		{name: "gzip flushed", config: cmd.Config{Gzip: true, FlushPerFile: true}, want: "--- START OF FILE: b.go ---\npackage b\n"},
+		{name: "rendered", config: cmd.Config{OutputFormat: cmd.FormatYAML}, want: "  - path: b.go\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fifo, read := makeFIFO(t)
+			config := tt.config
+			config.InputDir = tempDir
+			config.OutputFile = fifo
+			config.IncludeGlobs = []string{"**/*.go"}
+			config.NoGitHeader = true
+			if err := cmd.ProcessDirectory(config); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			data := read()
+			if config.Gzip {
+				reader, err := gzip.NewReader(bytes.NewReader(data))
+				if err != nil {
+					t.Fatalf("Invalid gzip stream: %v", err)
+				}
+				if data, err = io.ReadAll(reader); err != nil {
+					t.Fatalf("Invalid gzip stream: %v", err)
+				}
+			}
+			if !strings.Contains(string(data), tt.want) {
+				t.Errorf("Expected output to contain %q, got %q", tt.want, data)
+			}
+			if info, err := os.Stat(fifo); err != nil || info.Mode()&os.ModeNamedPipe == 0 {
+				t.Error("Expected the pipe to be left in place")
+			}
+		})
+	}
+
+	t.Run("strict failure keeps the pipe", func(t *testing.T) {
+		fifo, read := makeFIFO(t)
+		config := cmd.Config{InputDir: tempDir, OutputFile: fifo, IncludeGlobs: []string{"**/*.go"}, MaxFiles: 1, Strict: true}
+		if err := cmd.ProcessDirectory(config); err == nil {
+			t.Fatal("Expected the strict limit to fail the pack")
+		}
+		// The limit fails the pack before the pipe is opened, so close the
+		// reader's wait from here
+		writer, err := os.OpenFile(fifo, os.O_WRONLY, 0)
+		if err != nil {
+			t.Fatalf("Failed to open the pipe: %v", err)
+		}
+		writer.Close()
+		if data := read(); len(data) != 0 {
+			t.Errorf("Expected nothing written to the pipe, got %q", data)
+		}
+		if _, err := os.Stat(fifo); err != nil {
+			t.Errorf("Expected the pipe to survive a failed pack: %v", err)
+		}
+	})
+}
+
+func TestFlushPerFile(t *testing.T) {
+	tempDir := t.TempDir()
+	writeWorkspaceFiles(t, tempDir, map[string]string{
+		"a.go": "package a\n",
+		"b.go": "package b\n",
+		"c.go": "package c\n",
+	})
+	outputPath := filepath.Join(t.TempDir(), "corpus.txt.gz")
+
+	config := cmd.Config{
+		InputDir:     tempDir,
+		OutputFile:   outputPath,
+		IncludeGlobs: []string{"**/*.go"},
+		Gzip:         true,
+		FlushPerFile: true,
+	}
+	if err := cmd.ProcessDirectory(config); err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	// Each flush ends in an empty stored block
+	if flushes := bytes.Count(data, []byte{0, 0, 0xff, 0xff}); flushes < 3 {
+		t.Errorf("Expected a gzip flush after each of 3 files, found %d", flushes)
+	}
+
+	for _, config := range []cmd.Config{
+		{FlushPerFile: true, Gzip: true, Base64: true},
+		{FlushPerFile: true, Verbose: true},
+	} {
+		config.InputDir = tempDir
+		config.OutputFile = filepath.Join(t.TempDir(), "out.txt")
+		if err := cmd.ProcessDirectory(config); err == nil {
+			t.Errorf("Expected %+v to be rejected", config)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+// buildPlugin builds a transformer plugin from source, skipping the test
+// where Go plugins are not supported. A process loads each plugin module
+// once, so every plugin needs its own name.
+func buildPlugin(t *testing.T, name, source string) string {
+	t.Helper()
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("Go plugins are not supported on " + runtime.GOOS)
+	}
+	dir := t.TempDir()
+	writeWorkspaceFiles(t, dir, map[string]string{
+		"go.mod":    "module " + name + "\n\ngo 1.23\n",
+		"plugin.go": source,
+	})
+	path := filepath.Join(dir, name+".so")
+	build := exec.Command("go", "build", "-buildmode=plugin", "-o", path, ".")
+	build.Dir = dir
+	build.Env = append(os.Environ(), "CGO_ENABLED=1", "GOFLAGS=-mod=mod")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Skipf("Cannot build Go plugins here: %v\n%s", err, out)
+	}
+	return path
+}
+
+func TestPlugins(t *testing.T) {
+	tempDir := t.TempDir()
+	writeWorkspaceFiles(t, tempDir, map[string]string{
+		"main.go":    "package main\n\nconst key = \"SECRET-123\"\n",
+		"lib/lib.go": "package lib\n",
+	})
+	pack := func(t *testing.T, config cmd.Config) (string, error) {
+		t.Helper()
+		config.InputDir = tempDir
+		config.OutputFile = filepath.Join(t.TempDir(), "corpus.txt")
+		config.IncludeGlobs = []string{"**/*.go"}
+		return config.OutputFile, cmd.ProcessDirectory(config)
+	}
+
+	rejected := []struct {
+		name    string
+		config  cmd.Config
+		wantErr string
+	}{
+		{name: "not allowed", config: cmd.Config{Plugins: []string{"redact.so"}}, wantErr: "--allow-hooks"},
+		{name: "wasm", config: cmd.Config{Plugins: []string{"redact.wasm"}, AllowHooks: true}, wantErr: "WASI modules are not supported"},
+		{name: "missing", config: cmd.Config{Plugins: []string{"missing.so"}, AllowHooks: true}, wantErr: "error loading plugin missing.so"},
+	}
+	for _, tt := range rejected {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := pack(t, tt.config)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	t.Run("transform", func(t *testing.T) {
+		plugin := buildPlugin(t, "redact", `package main
+
+import (
+	"bytes"
+	"errors"
+)
+
+func Transform(path string, content []byte) ([]byte, error) {
+	if path == "fail.go" {
+		return nil, errors.New("refused")
+	}
+	content = bytes.ReplaceAll(content, []byte("SECRET-123"), []byte("[REDACTED]"))
+	return append(content, []byte("// redacted "+path+"\n")...), nil
+}
+`)
+		output, err := pack(t, cmd.Config{Plugins: []string{plugin}, AllowHooks: true})
+		if err != nil {
+			t.Fatalf("ProcessDirectory failed: %v", err)
+		}
+		assertFileContains(t, output, "const key = \"[REDACTED]\"")
+		assertFileContains(t, output, "package lib\n// redacted lib/lib.go\n")
+		data, _ := os.ReadFile(output)
+		if strings.Contains(string(data), "SECRET-123") {
+			t.Error("Expected the plugin to redact the secret")
+		}
+		if problems := cmd.ValidateCorpus(data); len(problems) > 0 {
+			t.Errorf("Expected a valid corpus, got %v", problems)
+		}
+
+		failing := t.TempDir()
+		writeWorkspaceFiles(t, failing, map[string]string{"fail.go": "package fail\n"})
+		err = cmd.ProcessDirectory(cmd.Config{
+			InputDir:   failing,
+			OutputFile: filepath.Join(t.TempDir(), "corpus.txt"),
+			Plugins:    []string{plugin},
+			AllowHooks: true,
+		})
+		if err == nil || !strings.Contains(err.Error(), "refused") {
+			t.Errorf("Expected the plugin's error to fail the pack, got %v", err)
+		}
+	})
+
+	t.Run("wrong signature", func(t *testing.T) {
+		plugin := buildPlugin(t, "badsig", "package main\n\nfunc Transform(path string) string { return path }\n")
+		_, err := pack(t, cmd.Config{Plugins: []string{plugin}, AllowHooks: true})
+		if err == nil || !strings.Contains(err.Error(), "not a func(string, []byte) ([]byte, error)") {
+			t.Errorf("Expected a signature error, got %v", err)
+		}
+	})
+}
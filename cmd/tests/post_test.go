@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestPostURL(t *testing.T) {
+	tempDir := t.TempDir()
+	writeWorkspaceFiles(t, tempDir, map[string]string{"main.go": "package main\n"})
+
+	tests := []struct {
+		name            string
+		config          cmd.Config
+		wantContentType string
+		wantAuth        string
+	}{
+		{
+			name:            "text with token",
+			config:          cmd.Config{PostToken: "secret"},
+			wantContentType: "text/plain; charset=utf-8",
+			wantAuth:        "Bearer secret",
+		},
+		{
+			name:            "gzip",
+			config:          cmd.Config{Gzip: true},
+			wantContentType: "application/gzip",
+		},
+		{
+			name:            "format type",
+			config:          cmd.Config{OutputFormat: cmd.FormatRAGJSONL},
+			wantContentType: "application/x-ndjson",
+		},
+		{
+			name:            "explicit type",
+			config:          cmd.Config{PostContentType: "application/vnd.corpus"},
+			wantContentType: "application/vnd.corpus",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body []byte
+			var header http.Header
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost {
+					t.Errorf("Expected POST, got %s", r.Method)
+				}
+				header = r.Header
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(http.StatusAccepted)
+			}))
+			defer server.Close()
+
+			config := tt.config
+			config.InputDir = tempDir
+			config.OutputFile = filepath.Join(t.TempDir(), "corpus.txt")
+			if config.Gzip {
+				config.OutputFile += ".gz"
+			}
+			config.IncludeGlobs = []string{"**/*.go"}
+			config.NoGitHeader = true
+			config.PostURL = server.URL + "/index"
+			if err := cmd.ProcessDirectory(config); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			written, err := os.ReadFile(config.OutputFile)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			if string(body) != string(written) {
+				t.Error("Expected the posted body to be the corpus file")
+			}
+			if got := header.Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("Expected Content-Type %q, got %q", tt.wantContentType, got)
+			}
+			if got := header.Get("Authorization"); got != tt.wantAuth {
+				t.Errorf("Expected Authorization %q, got %q", tt.wantAuth, got)
+			}
+		})
+	}
+}
+
+func TestPostURLErrors(t *testing.T) {
+	tempDir, cleanup := createTestFiles(t)
+	defer cleanup()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "index is read-only", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		config  cmd.Config
+		wantErr string
+	}{
+		{"error status", cmd.Config{PostURL: server.URL}, "index is read-only"},
+		{"not http", cmd.Config{PostURL: "ftp://example.com/corpus"}, "http or https"},
+		{"token without url", cmd.Config{PostToken: "secret"}, "require --post-url"},
+		{"split output", cmd.Config{PostURL: server.URL, SplitByDir: 1}, "single local corpus file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			config.InputDir = tempDir
+			config.OutputFile = filepath.Join(t.TempDir(), "corpus.txt")
+			err := cmd.ProcessDirectory(config)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestPostTokenNotInConfigFile(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "cpack.yaml")
+	if err := os.WriteFile(configPath, []byte("postUrl: https://example.com/corpora\npostToken: secret\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	config, err := cmd.LoadConfigFromFile(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfigFromFile failed: %v", err)
+	}
+	if config.PostToken != "" {
+		t.Errorf("Expected the token in the config file to be ignored, got %q", config.PostToken)
+	}
+}
@@ -0,0 +1,156 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestPresets(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      cmd.Config
+		included    []string
+		notIncluded []string
+		wantErr     string
+	}{
+		{
+			name:        "infra selects terraform, docker and yaml",
+			config:      cmd.Config{Preset: "infra"},
+			included:    []string{"--- START OF FILE: infra/main.tf ---", "--- START OF FILE: Dockerfile ---", "--- START OF FILE: k8s/deploy.yaml ---"},
+			notIncluded: []string{"--- START OF FILE: main.go ---", "--- START OF FILE: infra/.terraform/plugin.tf ---"},
+		},
+		{
+			name:        "go keeps default excludes and drops generated code",
+			config:      cmd.Config{Preset: "go"},
+			included:    []string{"--- START OF FILE: main.go ---", "--- START OF FILE: go.mod ---"},
+			notIncluded: []string{"--- START OF FILE: api.pb.go ---", "--- START OF FILE: vendor/dep/dep.go ---", "--- START OF FILE: infra/main.tf ---"},
+		},
+		{
+			name:        "explicit globs override the preset",
+			config:      cmd.Config{Preset: "infra", IncludeGlobs: []string{"**/*.go"}},
+			included:    []string{"--- START OF FILE: main.go ---"},
+			notIncluded: []string{"--- START OF FILE: infra/main.tf ---", "--- START OF FILE: Dockerfile ---"},
+		},
+		{
+			name:    "unknown preset",
+			config:  cmd.Config{Preset: "cobol"},
+			wantErr: "unknown preset: cobol",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir, err := os.MkdirTemp("", "cpack-preset-*")
+			if err != nil {
+				t.Fatalf("Failed to create temp dir: %v", err)
+			}
+			defer os.RemoveAll(tempDir)
+
+			projectFiles := map[string]string{
+				"main.go":                    "package main\n",
+				"api.pb.go":                  "package main\n",
+				"go.mod":                     "module example.com/test\n",
+				"vendor/dep/dep.go":          "package dep\n",
+				"Dockerfile":                 "FROM scratch\n",
+				"infra/main.tf":              "resource \"null_resource\" \"x\" {}\n",
+				"infra/.terraform/plugin.tf": "# cached\n",
+				"k8s/deploy.yaml":            "kind: Deployment\n",
+			}
+			for path, content := range projectFiles {
+				fullPath := filepath.Join(tempDir, path)
+				os.MkdirAll(filepath.Dir(fullPath), 0755)
+				if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+					t.Fatalf("Failed to write %s: %v", path, err)
+				}
+			}
+
+			config := tt.config
+			config.InputDir = tempDir
+			config.OutputFile = filepath.Join(tempDir, "out.txt")
+			err = cmd.ProcessDirectory(config)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			for _, expected := range tt.included {
+				assertFileContains(t, config.OutputFile, expected)
+			}
+			for _, unexpected := range tt.notIncluded {
+				assertFileNotContains(t, config.OutputFile, unexpected)
+			}
+		})
+	}
+}
+
+func TestNoLockfiles(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"go.mod":                "module example.com/test\n",
+		"go.sum":                "example.com/dep v1.0.0 h1:abc=\n",
+		"web/package.json":      "{}\n",
+		"web/package-lock.json": "{}\n",
+		"web/yarn.lock":         "# yarn\n",
+		"docs/logo.png":         "\x89PNG\r\n",
+	}
+	for path, content := range files {
+		fullPath := filepath.Join(tempDir, path)
+		os.MkdirAll(filepath.Dir(fullPath), 0755)
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		config      cmd.Config
+		included    []string
+		notIncluded []string
+	}{
+		{
+			name:     "disabled",
+			config:   cmd.Config{},
+			included: []string{"go.sum", "web/package-lock.json", "web/yarn.lock", "docs/logo.png"},
+		},
+		{
+			name:        "built-in set",
+			config:      cmd.Config{NoLockfiles: true},
+			included:    []string{"go.mod", "web/package.json"},
+			notIncluded: []string{"go.sum", "web/package-lock.json", "web/yarn.lock", "docs/logo.png"},
+		},
+		{
+			name:        "overridden set",
+			config:      cmd.Config{NoLockfiles: true, LockfileGlobs: []string{"**/yarn.lock"}},
+			included:    []string{"go.sum", "web/package-lock.json"},
+			notIncluded: []string{"web/yarn.lock"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			config.InputDir = tempDir
+			config.OutputFile = filepath.Join(t.TempDir(), "out.txt")
+			config.IncludeGlobs = []string{"**/*.json", "**/*.lock", "**/*.png", "**/*.mod", "**/*.sum"}
+			if err := cmd.ProcessDirectory(config); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			for _, path := range tt.included {
+				assertFileContains(t, config.OutputFile, "--- START OF FILE: "+path+" ---")
+			}
+			for _, path := range tt.notIncluded {
+				assertFileNotContains(t, config.OutputFile, "--- START OF FILE: "+path+" ---")
+			}
+		})
+	}
+}
@@ -942,6 +942,34 @@ func TestProcessDirectory(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "low memory verbose streaming",
+			config: cmd.Config{
+				IncludeGlobs: []string{"**/*.go"},
+				Verbose:      true,
+				LowMemory:    true,
+				OutputFile:   "out.txt",
+			},
+			validate: func(t *testing.T, outputPath string, config cmd.Config) {
+				content, err := os.ReadFile(outputPath)
+				if err != nil {
+					t.Fatalf("Failed to read output file: %v", err)
+				}
+				contentStr := string(content)
+
+				summaryIdx := strings.Index(contentStr, "--- END OF SUMMARY ---")
+				fileIdx := strings.Index(contentStr, "--- START OF FILE: src/pkg1/file1.go ---")
+				if summaryIdx == -1 || fileIdx == -1 || summaryIdx > fileIdx {
+					t.Error("Summary should precede streamed file content")
+				}
+				if !strings.Contains(contentStr, "package pkg1\n\nfunc Test() {}\n") {
+					t.Error("Streamed content should be copied verbatim")
+				}
+				if !strings.Contains(contentStr, "Total Files Processed: 3") {
+					t.Error("Summary should count files from metadata")
+				}
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -1140,3 +1168,66 @@ verbose: true
 	// Clean up any output files created in CWD
 	os.Remove(filepath.Join(originalWd, "output.txt"))
 }
+
+func TestIncludeNames(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      cmd.Config
+		included    []string
+		notIncluded []string
+	}{
+		{
+			name:        "default names accompany default globs",
+			config:      cmd.Config{},
+			included:    []string{"--- START OF FILE: Makefile ---", "--- START OF FILE: deploy/Dockerfile.prod ---", "--- START OF FILE: go.mod ---", "--- START OF FILE: .env.example ---"},
+			notIncluded: []string{"--- START OF FILE: .env ---"},
+		},
+		{
+			name:        "explicit globs drop default names",
+			config:      cmd.Config{IncludeGlobs: []string{"**/*.go"}},
+			notIncluded: []string{"--- START OF FILE: Makefile ---", "--- START OF FILE: go.mod ---"},
+		},
+		{
+			name:        "explicit names with explicit globs",
+			config:      cmd.Config{IncludeGlobs: []string{"**/*.go"}, IncludeNames: []string{"Makefile"}},
+			included:    []string{"--- START OF FILE: Makefile ---"},
+			notIncluded: []string{"--- START OF FILE: go.mod ---"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir, cleanup := createTestFiles(t)
+			defer cleanup()
+
+			projectFiles := map[string]string{
+				"Makefile":               "build:\n\tgo build ./...\n",
+				"deploy/Dockerfile.prod": "FROM scratch\n",
+				"go.mod":                 "module example.com/test\n",
+				".env.example":           "TOKEN=\n",
+				".env":                   "TOKEN=secret\n",
+			}
+			for path, content := range projectFiles {
+				fullPath := filepath.Join(tempDir, path)
+				os.MkdirAll(filepath.Dir(fullPath), 0755)
+				if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+					t.Fatalf("Failed to write %s: %v", path, err)
+				}
+			}
+
+			config := tt.config
+			config.InputDir = tempDir
+			config.OutputFile = filepath.Join(tempDir, "out.txt")
+			if err := cmd.ProcessDirectory(config); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			for _, expected := range tt.included {
+				assertFileContains(t, config.OutputFile, expected)
+			}
+			for _, unexpected := range tt.notIncluded {
+				assertFileNotContains(t, config.OutputFile, unexpected)
+			}
+		})
+	}
+}
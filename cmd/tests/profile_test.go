@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestPprof(t *testing.T) {
+	files := map[string]string{
+		"main.go":     "package main\n\nfunc main() {}\n",
+		"lib/util.go": "package lib\n\nfunc Util() {}\n",
+	}
+
+	tests := []struct {
+		name       string
+		pprof      string
+		wantFile   string
+		wantPrefix []byte
+		wantErr    string
+	}{
+		{name: "cpu", pprof: "cpu", wantFile: "corpus.txt.cpu.pprof", wantPrefix: []byte{0x1f, 0x8b}},
+		{name: "mem", pprof: "mem", wantFile: "corpus.txt.mem.pprof", wantPrefix: []byte{0x1f, 0x8b}},
+		{name: "trace", pprof: "trace", wantFile: "corpus.txt.trace.out", wantPrefix: []byte("go 1.")},
+		{name: "unknown", pprof: "disk", wantErr: "unsupported --pprof profile: disk"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputDir := t.TempDir()
+			writeWorkspaceFiles(t, inputDir, files)
+			outputDir := t.TempDir()
+
+			config := cmd.Config{
+				InputDir:     inputDir,
+				OutputFile:   filepath.Join(outputDir, "corpus.txt"),
+				IncludeGlobs: []string{"**/*.go"},
+				Verbose:      true,
+				Pprof:        tt.pprof,
+			}
+			var err error
+			stderr := captureStderr(t, func() {
+				err = cmd.ProcessDirectory(config)
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ProcessDirectory() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ProcessDirectory() error = %v", err)
+			}
+
+			profile, err := os.ReadFile(filepath.Join(outputDir, tt.wantFile))
+			if err != nil {
+				t.Fatalf("Failed to read profile: %v", err)
+			}
+			if !bytes.HasPrefix(profile, tt.wantPrefix) {
+				t.Errorf("profile starts with %q, want %q", profile[:min(len(profile), 8)], tt.wantPrefix)
+			}
+			for _, want := range []string{"Phase Timings:", "  walk ", "  read ", "  transform ", "  tokenize ", "  write ", "  other ", "  total ", "Wrote " + tt.pprof + " profile to "} {
+				if !strings.Contains(string(stderr), want) {
+					t.Errorf("stderr missing %q:\n%s", want, stderr)
+				}
+			}
+		})
+	}
+}
+
+func TestPprofPerWorkspace(t *testing.T) {
+	inputDir := t.TempDir()
+	writeWorkspaceFiles(t, inputDir, map[string]string{
+		"go.work":     "go 1.22\n\nuse ./api\nuse ./lib\n",
+		"api/go.mod":  "module api\n",
+		"api/main.go": "package main\n\nfunc main() {}\n",
+		"lib/go.mod":  "module lib\n",
+		"lib/lib.go":  "package lib\n",
+	})
+	outputDir := t.TempDir()
+
+	// The members are packed under the one profile of the whole run
+	config := cmd.Config{
+		InputDir:     inputDir,
+		OutputFile:   filepath.Join(outputDir, "corpus.txt"),
+		IncludeGlobs: []string{"**/*.go"},
+		PerWorkspace: true,
+		Pprof:        cmd.ProfileCPU,
+	}
+	var err error
+	stderr := captureStderr(t, func() {
+		err = cmd.ProcessDirectory(config)
+	})
+	if err != nil {
+		t.Fatalf("ProcessDirectory() error = %v", err)
+	}
+	assertFileExists(t, filepath.Join(outputDir, "corpus.txt.cpu.pprof"))
+	assertFileNotExists(t, filepath.Join(outputDir, "corpus-api.txt.cpu.pprof"))
+	if n := strings.Count(string(stderr), "Phase Timings:"); n != 1 {
+		t.Errorf("Expected one set of phase timings, got %d:\n%s", n, stderr)
+	}
+}
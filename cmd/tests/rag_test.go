@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+type ragRecord struct {
+	ID        string `json:"id"`
+	Path      string `json:"path"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Content   string `json:"content"`
+}
+
+func TestRAGJSONLFormat(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 40; i++ {
+		lines = append(lines, fmt.Sprintf("line %02d of the file", i))
+	}
+	// Each line is 5 tokens by the default estimate
+	long := strings.Join(lines, "\n") + "\n"
+
+	tests := []struct {
+		name     string
+		tokens   int
+		overlap  int
+		expected [][2]int
+	}{
+		{
+			name:     "default size holds the file",
+			expected: [][2]int{{1, 40}},
+		},
+		{
+			name:     "chunks without overlap",
+			tokens:   50,
+			expected: [][2]int{{1, 10}, {11, 20}, {21, 30}, {31, 40}},
+		},
+		{
+			name:     "chunks repeat lines",
+			tokens:   50,
+			overlap:  10,
+			expected: [][2]int{{1, 10}, {9, 18}, {17, 26}, {25, 34}, {33, 40}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			writeWorkspaceFiles(t, tempDir, map[string]string{"doc.txt": long})
+			outputPath := filepath.Join(t.TempDir(), "chunks.jsonl")
+
+			config := cmd.Config{
+				InputDir:     tempDir,
+				OutputFile:   outputPath,
+				OutputFormat: cmd.FormatRAGJSONL,
+				IncludeGlobs: []string{"**/*"},
+				NoGitHeader:  true,
+				ChunkTokens:  tt.tokens,
+				ChunkOverlap: tt.overlap,
+			}
+			if err := cmd.ProcessDirectory(config); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			file, err := os.Open(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to open output: %v", err)
+			}
+			defer file.Close()
+
+			var spans [][2]int
+			scanner := bufio.NewScanner(file)
+			scanner.Buffer(nil, 1<<20)
+			for scanner.Scan() {
+				var record ragRecord
+				if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+					t.Fatalf("Invalid record %q: %v", scanner.Text(), err)
+				}
+				if record.Path != "doc.txt" {
+					t.Errorf("Expected path doc.txt, got %q", record.Path)
+				}
+				if want := fmt.Sprintf("doc.txt#L%d-L%d", record.StartLine, record.EndLine); record.ID != want {
+					t.Errorf("Expected id %q, got %q", want, record.ID)
+				}
+				want := strings.Join(lines[record.StartLine-1:record.EndLine], "\n") + "\n"
+				if record.Content != want {
+					t.Errorf("Chunk %s: expected content %q, got %q", record.ID, want, record.Content)
+				}
+				spans = append(spans, [2]int{record.StartLine, record.EndLine})
+			}
+			if fmt.Sprint(spans) != fmt.Sprint(tt.expected) {
+				t.Errorf("Expected chunks %v, got %v", tt.expected, spans)
+			}
+		})
+	}
+}
+
+func TestRAGJSONLValidation(t *testing.T) {
+	tempDir, cleanup := createTestFiles(t)
+	defer cleanup()
+
+	for _, config := range []cmd.Config{
+		{ChunkTokens: -1},
+		{ChunkOverlap: -1},
+		{ChunkTokens: 100, ChunkOverlap: 100},
+		{Append: true},
+	} {
+		config.InputDir = tempDir
+		config.OutputFile = filepath.Join(t.TempDir(), "out.jsonl")
+		config.OutputFormat = cmd.FormatRAGJSONL
+		if err := cmd.ProcessDirectory(config); err == nil {
+			t.Errorf("Expected %+v to be rejected", config)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestReportAndManifest(t *testing.T) {
+	tests := []struct {
+		name   string
+		config cmd.Config
+	}{
+		{
+			name:   "buffered output",
+			config: cmd.Config{Verbose: true},
+		},
+		{
+			name:   "streamed output",
+			config: cmd.Config{LowMemory: true},
+		},
+		{
+			name:   "verbose streamed output",
+			config: cmd.Config{Verbose: true, LowMemory: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir, cleanup := createTestFiles(t)
+			defer cleanup()
+
+			reportPath := filepath.Join(tempDir, "report.json")
+			manifestPath := filepath.Join(tempDir, "manifest.json")
+
+			config := tt.config
+			config.InputDir = tempDir
+			config.OutputFile = filepath.Join(tempDir, "out.txt")
+			config.IncludeGlobs = []string{"**/*.go", "**/*.json"}
+			config.ExcludeGlobs = []string{"**/*_test.go", "**/vendor/**"}
+			config.ReportFile = reportPath
+			config.ManifestFile = manifestPath
+
+			// Run twice so the sidecars from the first run are present in the tree
+			for i := 0; i < 2; i++ {
+				if err := cmd.ProcessDirectory(config); err != nil {
+					t.Fatalf("ProcessDirectory failed: %v", err)
+				}
+			}
+
+			for _, schemaName := range []string{"report", "manifest"} {
+				schema, err := cmd.Schema(schemaName)
+				if err != nil {
+					t.Fatalf("Failed to load %s schema: %v", schemaName, err)
+				}
+				document, err := os.ReadFile(filepath.Join(tempDir, schemaName+".json"))
+				if err != nil {
+					t.Fatalf("Failed to read %s: %v", schemaName, err)
+				}
+				validateJSONSchema(t, schema, document)
+			}
+
+			data, _ := os.ReadFile(reportPath)
+			var report cmd.PackReport
+			if err := json.Unmarshal(data, &report); err != nil {
+				t.Fatalf("Failed to parse report: %v", err)
+			}
+			if len(report.ProcessedFiles) != 2 || report.TotalBytes != 29+13 {
+				t.Errorf("Expected each packed file counted once, got %v (%d bytes)", report.ProcessedFiles, report.TotalBytes)
+			}
+
+			data, _ = os.ReadFile(manifestPath)
+			var manifest cmd.Manifest
+			if err := json.Unmarshal(data, &manifest); err != nil {
+				t.Fatalf("Failed to parse manifest: %v", err)
+			}
+			if len(manifest.Files) != 2 {
+				t.Fatalf("Expected 2 manifest entries, got %+v", manifest.Files)
+			}
+			// sha256("package pkg1\n\nfunc Test() {}\n")
+			if manifest.Files[0].Path != "src/pkg1/file1.go" || manifest.Files[0].Size != 29 ||
+				manifest.Files[0].SHA256 != "76b4b175ded04d5a3f2e1c01b4c7e16c795ed760de1dbf7d17242ee68a8c523f" {
+				t.Errorf("Unexpected manifest entry %+v", manifest.Files[0])
+			}
+		})
+	}
+}
+
+func TestSchemaCommand(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	for _, name := range []string{"report", "manifest"} {
+		expected, err := cmd.Schema(name)
+		if err != nil {
+			t.Fatalf("Failed to load %s schema: %v", name, err)
+		}
+		var schema map[string]interface{}
+		if err := json.Unmarshal(expected, &schema); err != nil {
+			t.Fatalf("%s schema is not valid JSON: %v", name, err)
+		}
+
+		os.Args = []string{"cpack", "schema", name}
+		stdout := captureStdout(t, func() {
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("schema command failed: %v", err)
+			}
+		})
+		if !bytes.Equal(stdout, expected) {
+			t.Errorf("Expected schema command to print the %s schema", name)
+		}
+	}
+
+	if _, err := cmd.Schema("unknown"); err == nil {
+		t.Error("Expected error for unknown schema")
+	}
+}
+
+func TestReportCollation(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "cpack-collate-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{
+		"index.html": `<script src="a/app.js"></script><script src="a-b/lib.js"></script>`,
+		"a/app.js":   "console.log('app')\n",
+		"a-b/lib.js": "console.log('lib')\n",
+		"z/dead.js":  "console.log('dead')\n",
+		"b/dead.js":  "console.log('dead')\n",
+		"skip.txt":   "not selected\n",
+	}
+	for path, content := range files {
+		fullPath := filepath.Join(tempDir, path)
+		os.MkdirAll(filepath.Dir(fullPath), 0755)
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	outDir := t.TempDir()
+	reportPath := filepath.Join(outDir, "report.json")
+	config := cmd.Config{
+		InputDir:     tempDir,
+		OutputFile:   filepath.Join(outDir, "out.txt"),
+		IncludeGlobs: []string{"**/*.js", "**/*.html"},
+		ExcludeGlobs: []string{},
+		EntryFiles:   []string{"index.html"},
+		ReportFile:   reportPath,
+	}
+	if err := cmd.ProcessDirectory(config); err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(reportPath)
+	var report cmd.PackReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("Failed to parse report: %v", err)
+	}
+
+	// Byte-wise on slash paths: '-' sorts before '/'
+	wantProcessed := []string{"a-b/lib.js", "a/app.js", "index.html"}
+	// Filtered files first, then each reason group in path order
+	wantSkipped := []string{"skip.txt", "b/dead.js (unreachable)", "z/dead.js (unreachable)"}
+
+	if !sliceEqual(report.ProcessedFiles, wantProcessed) {
+		t.Errorf("ProcessedFiles = %v, want %v", report.ProcessedFiles, wantProcessed)
+	}
+	if !sliceEqual(report.SkippedFiles, wantSkipped) {
+		t.Errorf("SkippedFiles = %v, want %v", report.SkippedFiles, wantSkipped)
+	}
+}
@@ -0,0 +1,196 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+// rpcReply is a decoded JSON-RPC response
+type rpcReply struct {
+	ID     interface{}     `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// runRPC frames each message, serves them and decodes the responses
+func runRPC(t *testing.T, messages ...string) []rpcReply {
+	t.Helper()
+
+	var input, output bytes.Buffer
+	for _, message := range messages {
+		fmt.Fprintf(&input, "Content-Length: %d\r\n\r\n%s", len(message), message)
+	}
+	if err := cmd.ServeRPC(&input, &output); err != nil {
+		t.Fatalf("ServeRPC failed: %v", err)
+	}
+
+	var replies []rpcReply
+	reader := bufio.NewReader(&output)
+	for {
+		header, err := textproto.NewReader(reader).ReadMIMEHeader()
+		if err == io.EOF && len(header) == 0 {
+			return replies
+		}
+		if err != nil {
+			t.Fatalf("Failed to read response header: %v", err)
+		}
+		length, _ := strconv.Atoi(header.Get("Content-Length"))
+		body := make([]byte, length)
+		if _, err := io.ReadFull(reader, body); err != nil {
+			t.Fatalf("Failed to read response body: %v", err)
+		}
+		var reply rpcReply
+		if err := json.Unmarshal(body, &reply); err != nil {
+			t.Fatalf("Response is not valid JSON: %v\n%s", err, body)
+		}
+		replies = append(replies, reply)
+	}
+}
+
+// rpcCall formats a request for method with params
+func rpcCall(t *testing.T, id int, method string, params map[string]interface{}) string {
+	t.Helper()
+	message, err := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": id, "method": method, "params": params})
+	if err != nil {
+		t.Fatalf("Failed to encode request: %v", err)
+	}
+	return string(message)
+}
+
+func TestRPC(t *testing.T) {
+	inputDir := t.TempDir()
+	writeWorkspaceFiles(t, inputDir, map[string]string{
+		"src/main.go":       "package main\n\nfunc main() {}\n",
+		"src/util.go":       "package main\n\nfunc util() {}\n",
+		"docs/guide.md":     "# Guide\n",
+		"vendor/lib/lib.go": "package lib\n",
+		"src/main_test.go":  "package main\n",
+	})
+
+	t.Run("pack a selection", func(t *testing.T) {
+		outputFile := filepath.Join(t.TempDir(), "corpus.txt")
+		replies := runRPC(t, rpcCall(t, 1, "pack", map[string]interface{}{
+			"inputDir":   inputDir,
+			"outputFile": outputFile,
+			"paths":      []string{"src/main.go", filepath.Join(inputDir, "docs")},
+		}))
+		if len(replies) != 1 || replies[0].Error != nil {
+			t.Fatalf("Expected one successful response, got %+v", replies)
+		}
+		var result cmd.RPCPackResult
+		json.Unmarshal(replies[0].Result, &result)
+		if result.OutputFile != outputFile {
+			t.Errorf("Expected output file %s, got %s", outputFile, result.OutputFile)
+		}
+		assertFileContains(t, outputFile, "func main() {}")
+		assertFileContains(t, outputFile, "# Guide")
+		assertFileNotContains(t, outputFile, "func util() {}")
+	})
+
+	t.Run("list files", func(t *testing.T) {
+		replies := runRPC(t, rpcCall(t, 1, "listFiles", map[string]interface{}{"inputDir": inputDir, "noTests": true}))
+		if len(replies) != 1 || replies[0].Error != nil {
+			t.Fatalf("Expected one successful response, got %+v", replies)
+		}
+		var result cmd.RPCListResult
+		json.Unmarshal(replies[0].Result, &result)
+		var paths []string
+		for _, file := range result.Files {
+			paths = append(paths, file.Path)
+		}
+		for _, want := range []string{"src/main.go", "src/util.go"} {
+			if !contains(paths, want) {
+				t.Errorf("Expected %s in %v", want, paths)
+			}
+		}
+		if contains(paths, "src/main_test.go") || contains(paths, "vendor/lib/lib.go") {
+			t.Errorf("Expected tests and vendored files to be left out, got %v", paths)
+		}
+	})
+
+	explains := []struct {
+		name     string
+		path     string
+		params   map[string]interface{}
+		selected bool
+		reason   string
+	}{
+		{name: "selected file", path: "src/main.go", selected: true, reason: "selected"},
+		{name: "test file", path: "src/main_test.go", params: map[string]interface{}{"noTests": true}, reason: "test"},
+		{name: "excluded directory", path: "vendor/lib/lib.go", reason: "in directory vendor/lib, excluded by **/vendor/**"},
+		{name: "outside selection", path: "src/util.go", params: map[string]interface{}{"paths": []string{"docs"}}, reason: "matches no include pattern"},
+		{name: "missing file", path: "src/missing.go", reason: "not found"},
+	}
+	for _, tt := range explains {
+		t.Run("explain "+tt.name, func(t *testing.T) {
+			params := map[string]interface{}{"inputDir": inputDir, "path": tt.path}
+			for key, value := range tt.params {
+				params[key] = value
+			}
+			replies := runRPC(t, rpcCall(t, 1, "explain", params))
+			if len(replies) != 1 || replies[0].Error != nil {
+				t.Fatalf("Expected one successful response, got %+v", replies)
+			}
+			var result cmd.RPCExplainResult
+			json.Unmarshal(replies[0].Result, &result)
+			if result.Selected != tt.selected || result.Reason != tt.reason {
+				t.Errorf("Expected selected=%v reason %q, got %+v", tt.selected, tt.reason, result)
+			}
+		})
+	}
+
+	t.Run("stats", func(t *testing.T) {
+		replies := runRPC(t, rpcCall(t, 1, "stats", map[string]interface{}{"inputDir": inputDir}))
+		if len(replies) != 1 || replies[0].Error != nil {
+			t.Fatalf("Expected one successful response, got %+v", replies)
+		}
+		var report cmd.StatsReport
+		json.Unmarshal(replies[0].Result, &report)
+		if report.Total.Files == 0 {
+			t.Errorf("Expected files in the stats, got %+v", report)
+		}
+	})
+
+	errorCases := []struct {
+		name    string
+		message string
+		code    int
+	}{
+		{name: "malformed json", message: `{"jsonrpc": "2.0", `, code: -32700},
+		{name: "missing version", message: `{"id": 1, "method": "pack"}`, code: -32600},
+		{name: "unknown method", message: `{"jsonrpc": "2.0", "id": 1, "method": "unpack"}`, code: -32601},
+		{name: "unknown param", message: `{"jsonrpc": "2.0", "id": 1, "method": "stats", "params": {"inputDirectory": "."}}`, code: -32602},
+		{name: "explain without path", message: `{"jsonrpc": "2.0", "id": 1, "method": "explain", "params": {}}`, code: -32602},
+		{name: "pack to stdout", message: `{"jsonrpc": "2.0", "id": 1, "method": "pack", "params": {"outputFile": "-"}}`, code: -32602},
+		{name: "failed pack", message: rpcCall(t, 1, "pack", map[string]interface{}{"inputDir": filepath.Join(inputDir, "missing"), "outputFile": filepath.Join(t.TempDir(), "out.txt")}), code: -32000},
+	}
+	for _, tt := range errorCases {
+		t.Run(tt.name, func(t *testing.T) {
+			replies := runRPC(t, tt.message)
+			if len(replies) != 1 || replies[0].Error == nil || replies[0].Error.Code != tt.code {
+				t.Errorf("Expected error code %d, got %+v", tt.code, replies)
+			}
+		})
+	}
+
+	t.Run("notifications get no response", func(t *testing.T) {
+		replies := runRPC(t,
+			`{"jsonrpc": "2.0", "method": "stats", "params": {"inputDir": "`+filepath.ToSlash(inputDir)+`"}}`,
+			rpcCall(t, 7, "listFiles", map[string]interface{}{"inputDir": inputDir}))
+		if len(replies) != 1 || replies[0].ID != float64(7) {
+			t.Errorf("Expected only the response to request 7, got %+v", replies)
+		}
+	})
+}
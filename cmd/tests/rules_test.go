@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestRules(t *testing.T) {
+	tempDir, cleanup := createTestFiles(t)
+	defer cleanup()
+
+	csvContent := "id,name\n1,a\n2,b\n3,c\n4,d\n"
+	if err := os.WriteFile(filepath.Join(tempDir, "src/data.csv"), []byte(csvContent), 0644); err != nil {
+		t.Fatalf("Failed to write csv file: %v", err)
+	}
+
+	configContent := `
+includeGlobs:
+  - "**/*.go"
+  - "**/*.md"
+  - "**/*.csv"
+excludeGlobs:
+  - "**/*_test.go"
+compress: true
+rules:
+  - glob: "**/*.md"
+    compress: false
+  - glob: "*.csv"
+    compress: false
+    headLines: 2
+`
+	configPath := filepath.Join(tempDir, "rules.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	config, err := cmd.LoadConfigFromFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if len(config.Rules) != 2 || config.Rules[1].HeadLines == nil || *config.Rules[1].HeadLines != 2 {
+		t.Fatalf("Expected rules to be parsed, got %+v", config.Rules)
+	}
+
+	config.InputDir = tempDir
+	config.OutputFile = filepath.Join(tempDir, "out.txt")
+	if err := cmd.ProcessDirectory(*config); err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+
+	content, err := os.ReadFile(config.OutputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	contentStr := string(content)
+
+	if !strings.Contains(contentStr, "package pkg1 func Test(){}") {
+		t.Error("Go files should use the top-level compress setting")
+	}
+	if !strings.Contains(contentStr, "# Package 2\n") {
+		t.Error("Markdown rule should disable compression")
+	}
+	if !strings.Contains(contentStr, "id,name\n1,a\n... [truncated: 3 of 5 lines omitted]") {
+		t.Errorf("CSV rule should truncate to 2 lines, got:\n%s", contentStr)
+	}
+	if strings.Contains(contentStr, "4,d") {
+		t.Error("Truncated lines should not be packed")
+	}
+}
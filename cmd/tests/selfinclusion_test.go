@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestOutputNotPacked(t *testing.T) {
+	tests := []struct {
+		name       string
+		outputFile string
+		stale      []string // Outputs left over from earlier runs
+		sources    []string // Files named like a corpus, which are packed
+	}{
+		{name: "default output name", outputFile: "corpus-out.txt"},
+		{name: "custom output name", outputFile: "context.txt"},
+		{name: "previous corpora", outputFile: "context.txt", stale: []string{"corpus-out.txt", "docs/corpus-out-v1.txt"}},
+		{name: "sources named like corpora", outputFile: "corpus-out.txt", sources: []string{"corpus-outline.txt", "docs/corpus-out-v1.txt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(tempDir, "main.txt"), []byte("hello\n"), 0644); err != nil {
+				t.Fatalf("Failed to write input: %v", err)
+			}
+			for _, path := range tt.stale {
+				writeWorkspaceFiles(t, tempDir, map[string]string{
+					path: "--- CPACK CORPUS: format 1, cpack dev ---\n\n--- START OF FILE: old.txt ---\nold\n--- END OF FILE: old.txt ---\n\n",
+				})
+			}
+			for _, path := range tt.sources {
+				writeWorkspaceFiles(t, tempDir, map[string]string{path: "notes\n"})
+			}
+
+			config := cmd.Config{
+				InputDir:     tempDir,
+				OutputFile:   filepath.Join(tempDir, tt.outputFile),
+				IncludeGlobs: []string{"**/*.txt"},
+			}
+			// The second run finds the first run's corpus in the input directory
+			for run := 0; run < 2; run++ {
+				if err := cmd.ProcessDirectory(config); err != nil {
+					t.Fatalf("ProcessDirectory run %d failed: %v", run+1, err)
+				}
+			}
+
+			data, err := os.ReadFile(config.OutputFile)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			content := string(data)
+			if got := strings.Count(content, "--- START OF FILE: "); got != 1+len(tt.sources) {
+				t.Errorf("Expected main.txt and %d sources to be packed, found %d files:\n%s", len(tt.sources), got, content)
+			}
+			for _, path := range append([]string{"main.txt"}, tt.sources...) {
+				assertFileContains(t, config.OutputFile, "--- START OF FILE: "+path+" ---")
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestSelfUpdate(t *testing.T) {
+	binaryName := fmt.Sprintf("cpack_%s_%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		binaryName += ".exe"
+	}
+	newBinary := []byte("new cpack binary")
+	sum := sha256.Sum256(newBinary)
+
+	tests := []struct {
+		name        string
+		current     string
+		checksums   string // "" omits the checksums asset
+		checkOnly   bool
+		force       bool
+		wantErr     string
+		wantNewer   bool
+		wantUpdated bool
+	}{
+		{
+			name:        "newer release",
+			current:     "v1.1.0",
+			checksums:   hex.EncodeToString(sum[:]) + "  " + binaryName + "\n",
+			wantNewer:   true,
+			wantUpdated: true,
+		},
+		{
+			name:      "check only",
+			current:   "v1.1.0",
+			checksums: hex.EncodeToString(sum[:]) + "  " + binaryName + "\n",
+			checkOnly: true,
+			wantNewer: true,
+		},
+		{
+			name:      "up to date",
+			current:   "v1.2.0",
+			checksums: hex.EncodeToString(sum[:]) + "  " + binaryName + "\n",
+		},
+		{
+			name:        "development build with force",
+			current:     "dev",
+			checksums:   hex.EncodeToString(sum[:]) + " *" + binaryName + "\n",
+			force:       true,
+			wantUpdated: true,
+		},
+		{
+			name:      "checksum mismatch",
+			current:   "v1.1.0",
+			checksums: strings.Repeat("0", 64) + "  " + binaryName + "\n",
+			wantErr:   "checksum mismatch",
+		},
+		{
+			name:    "no checksums",
+			current: "v1.1.0",
+			wantErr: "refusing to install an unverified binary",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var server *httptest.Server
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/release":
+					release := cmd.Release{TagName: "v1.2.0", Assets: []cmd.ReleaseAsset{
+						{Name: binaryName, URL: server.URL + "/binary"},
+					}}
+					if tt.checksums != "" {
+						release.Assets = append(release.Assets, cmd.ReleaseAsset{Name: "checksums.txt", URL: server.URL + "/checksums"})
+					}
+					json.NewEncoder(w).Encode(release)
+				case "/binary":
+					w.Write(newBinary)
+				case "/checksums":
+					fmt.Fprint(w, tt.checksums)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer server.Close()
+
+			executable := filepath.Join(t.TempDir(), "cpack")
+			if err := os.WriteFile(executable, []byte("old cpack binary"), 0755); err != nil {
+				t.Fatalf("Failed to write executable: %v", err)
+			}
+
+			result, err := cmd.SelfUpdate(cmd.UpdateOptions{
+				ReleaseURL: server.URL + "/release",
+				Current:    tt.current,
+				Executable: executable,
+				CheckOnly:  tt.checkOnly,
+				Force:      tt.force,
+			})
+
+			data, readErr := os.ReadFile(executable)
+			if readErr != nil {
+				t.Fatalf("Failed to read executable: %v", readErr)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				if string(data) != "old cpack binary" {
+					t.Error("Expected a failed update to leave the old binary in place")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SelfUpdate failed: %v", err)
+			}
+
+			if result.Latest != "v1.2.0" || result.Newer != tt.wantNewer || result.Updated != tt.wantUpdated {
+				t.Errorf("Result = %+v, want newer %v and updated %v", result, tt.wantNewer, tt.wantUpdated)
+			}
+			if tt.wantUpdated != (string(data) == string(newBinary)) {
+				t.Errorf("Executable contains %q after update %v", data, tt.wantUpdated)
+			}
+			if info, err := os.Stat(executable); err == nil && info.Mode().Perm()&0100 == 0 {
+				t.Error("Expected the new binary to be executable")
+			}
+			if matches, _ := filepath.Glob(filepath.Join(filepath.Dir(executable), "*")); len(matches) != 1 {
+				t.Errorf("Expected no leftover files next to the binary, got %v", matches)
+			}
+		})
+	}
+}
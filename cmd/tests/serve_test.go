@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestCorpusHandler(t *testing.T) {
+	tempDir, cleanup := createTestFiles(t)
+	defer cleanup()
+
+	outputPath := filepath.Join(tempDir, "out.txt.gz")
+	config := cmd.Config{
+		InputDir:     tempDir,
+		OutputFile:   outputPath,
+		IncludeGlobs: []string{"**/*.go"},
+		ExcludeGlobs: []string{"**/*_test.go"},
+		Gzip:         true,
+		Base64:       true,
+		Verbose:      true,
+	}
+	if err := cmd.ProcessDirectory(config); err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+
+	corpus, err := cmd.LoadCorpus(outputPath)
+	if err != nil {
+		t.Fatalf("LoadCorpus failed: %v", err)
+	}
+
+	server := httptest.NewServer(cmd.NewCorpusHandler(corpus))
+	defer server.Close()
+
+	get := func(path string, header http.Header) (*http.Response, string) {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Request %s failed: %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		header     http.Header
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "file by id",
+			path:       "/files/1",
+			wantStatus: http.StatusOK,
+			wantBody:   "package pkg1\n\nfunc Test() {}\n",
+		},
+		{
+			name:       "file by path",
+			path:       "/file?path=" + url.QueryEscape("src/pkg2/file2.go"),
+			wantStatus: http.StatusOK,
+			wantBody:   "package pkg2\n",
+		},
+		{
+			name:       "byte range of a file",
+			path:       "/files/1",
+			header:     http.Header{"Range": []string{"bytes=8-11"}},
+			wantStatus: http.StatusPartialContent,
+			wantBody:   "pkg1",
+		},
+		{
+			name:       "unknown id",
+			path:       "/files/99",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "unknown path",
+			path:       "/file?path=missing.go",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := get(tt.path, tt.header)
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if tt.wantBody != "" && body != tt.wantBody {
+				t.Errorf("Expected body %q, got %q", tt.wantBody, body)
+			}
+		})
+	}
+
+	t.Run("file index", func(t *testing.T) {
+		_, body := get("/files", nil)
+		var files []cmd.CorpusFile
+		if err := json.Unmarshal([]byte(body), &files); err != nil {
+			t.Fatalf("Index is not valid JSON: %v", err)
+		}
+		if len(files) != 2 || files[0].Path != "src/pkg1/file1.go" || files[1].ID != 2 {
+			t.Errorf("Unexpected index %+v", files)
+		}
+	})
+}
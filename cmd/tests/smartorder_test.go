@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestSmartOrder(t *testing.T) {
+	files := map[string]string{
+		"README.md":      "# Project\n",
+		"docs/README.md": "# Docs\n",
+		"go.mod":         "module example.com/m\n\ngo 1.21\n",
+		"main.go":        "package main\n\nimport (\n\t\"example.com/m/lib\"\n\t\"example.com/m/pkg\"\n)\n\nfunc main() { lib.Run(); pkg.Run() }\n",
+		"lib/lib.go":     "package lib\n\nfunc Run() {}\n",
+		"pkg/pkg.go":     "package pkg\n\nimport \"example.com/m/lib\"\n\nfunc Run() { lib.Run() }\n",
+		"py/util.py":     "def f():\n    pass\n",
+		"py/a.py":        "from .util import f\n",
+		"py/b.py":        "import py.util\n",
+		"notes.txt":      "notes\n",
+	}
+
+	tests := []struct {
+		name      string
+		sortOrder string
+		expected  []string
+	}{
+		{
+			name:      "path order",
+			sortOrder: "",
+			expected: []string{"README.md", "docs/README.md", "go.mod", "lib/lib.go", "main.go", "notes.txt",
+				"pkg/pkg.go", "py/a.py", "py/b.py", "py/util.py"},
+		},
+		{
+			name:      "readmes, manifests and entrypoints, then by fan-in",
+			sortOrder: cmd.SortSmart,
+			expected: []string{"README.md", "docs/README.md", "go.mod", "main.go", "lib/lib.go", "py/util.py",
+				"pkg/pkg.go", "notes.txt", "py/a.py", "py/b.py"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputDir := t.TempDir()
+			writeWorkspaceFiles(t, inputDir, files)
+			outputPath := filepath.Join(t.TempDir(), "out.txt")
+
+			config := cmd.Config{
+				InputDir:      inputDir,
+				OutputFile:    outputPath,
+				IncludeGlobs:  []string{"**/*"},
+				Deterministic: true,
+				SortOrder:     tt.sortOrder,
+			}
+			if err := cmd.ProcessDirectory(config); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			if got := fileOrder(t, outputPath); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected order %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
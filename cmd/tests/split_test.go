@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestSplitByDir(t *testing.T) {
+	files := map[string]string{
+		"main.go":              "package main\n",
+		"src/app.go":           "package src\n",
+		"src/api/handler.go":   "package api\n",
+		"src/api/v2/routes.go": "package v2\n",
+		"src/db/store.go":      "package db\n",
+		"docs/guide.md":        "# Guide\n",
+		"empty/notes.bin":      "not selected\n",
+	}
+
+	tests := []struct {
+		name    string
+		depth   int
+		want    map[string][]string
+		missing []string
+	}{
+		{
+			name:  "top-level directories",
+			depth: 1,
+			want: map[string][]string{
+				"corpus-root.txt": {"main.go"},
+				"corpus-src.txt":  {"src/app.go", "src/api/handler.go", "src/api/v2/routes.go", "src/db/store.go"},
+				"corpus-docs.txt": {"docs/guide.md"},
+			},
+			missing: []string{"corpus-empty.txt", "corpus.txt"},
+		},
+		{
+			name:  "second-level directories",
+			depth: 2,
+			want: map[string][]string{
+				"corpus-root.txt":    {"main.go", "src/app.go", "docs/guide.md"},
+				"corpus-src-api.txt": {"src/api/handler.go", "src/api/v2/routes.go"},
+				"corpus-src-db.txt":  {"src/db/store.go"},
+			},
+			missing: []string{"corpus-src.txt", "corpus-src-api-v2.txt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			writeWorkspaceFiles(t, tempDir, files)
+
+			// Outputs go inside the input directory, so a second run
+			// shows earlier splits are not packed
+			config := cmd.Config{
+				InputDir:     tempDir,
+				OutputFile:   filepath.Join(tempDir, "corpus.txt"),
+				IncludeGlobs: []string{"**/*.go", "**/*.md", "**/*.txt"},
+				SplitByDir:   tt.depth,
+				NoGitHeader:  true,
+			}
+			for run := 0; run < 2; run++ {
+				if err := cmd.ProcessDirectory(config); err != nil {
+					t.Fatalf("ProcessDirectory failed: %v", err)
+				}
+			}
+
+			for output, wantFiles := range tt.want {
+				data, err := os.ReadFile(filepath.Join(tempDir, output))
+				if err != nil {
+					t.Fatalf("Failed to read %s: %v", output, err)
+				}
+				got := corpusPaths(cmd.ParseCorpus(data))
+				sort.Strings(got)
+				sort.Strings(wantFiles)
+				if !sliceEqual(got, wantFiles) {
+					t.Errorf("%s packed %v, want %v", output, got, wantFiles)
+				}
+			}
+			for _, output := range tt.missing {
+				assertFileNotExists(t, filepath.Join(tempDir, output))
+			}
+		})
+	}
+}
+
+func TestSplitByDirValidation(t *testing.T) {
+	tempDir, cleanup := createTestFiles(t)
+	defer cleanup()
+
+	tests := []struct {
+		name   string
+		config cmd.Config
+	}{
+		{
+			name:   "negative depth",
+			config: cmd.Config{SplitByDir: -1},
+		},
+		{
+			name:   "linkfarm output",
+			config: cmd.Config{SplitByDir: 1, OutputFormat: cmd.FormatLinkFarm},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.InputDir = tempDir
+			tt.config.OutputFile = filepath.Join(t.TempDir(), "out")
+			if err := cmd.ProcessDirectory(tt.config); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
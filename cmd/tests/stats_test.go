@@ -0,0 +1,145 @@
+package tests
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestStats(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"main.go":        "package main\n\nfunc main() {}\n",
+		"util/util.go":   "package util\n",
+		"scripts/deploy": "#!/usr/bin/env bash\necho deploy",
+		"notes.txt":      "plain notes\n",
+	}
+	for path, content := range files {
+		fullPath := filepath.Join(tempDir, path)
+		os.MkdirAll(filepath.Dir(fullPath), 0755)
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	config := cmd.Config{
+		InputDir:     tempDir,
+		IncludeGlobs: []string{"**/*.go", "**/*.txt", "scripts/*"},
+	}
+	report, err := cmd.DirectoryStats(config)
+	if err != nil {
+		t.Fatalf("DirectoryStats failed: %v", err)
+	}
+
+	want := []cmd.LanguageStats{
+		{Language: "go", Files: 2, Lines: 4, Bytes: 42, Tokens: 12},
+		{Language: "bash", Files: 1, Lines: 2, Bytes: 31, Tokens: 8},
+		{Language: "other", Files: 1, Lines: 1, Bytes: 12, Tokens: 3},
+	}
+	if !reflect.DeepEqual(report.Languages, want) {
+		t.Errorf("Languages = %+v, want %+v", report.Languages, want)
+	}
+	if report.Total.Files != 4 || report.Total.Lines != 7 || report.Total.Bytes != 85 || report.Total.Tokens != 23 {
+		t.Errorf("Total = %+v", report.Total)
+	}
+	wantHistogram := []cmd.SizeBucket{{Min: 0, Max: 100, Files: 4, Bytes: 85, Tokens: 23}}
+	if !reflect.DeepEqual(report.Histogram, wantHistogram) {
+		t.Errorf("Histogram = %+v, want %+v", report.Histogram, wantHistogram)
+	}
+	var largest []string
+	for _, f := range report.Largest {
+		largest = append(largest, f.Path)
+	}
+	if want := []string{"scripts/deploy", "main.go", "util/util.go", "notes.txt"}; !reflect.DeepEqual(largest, want) {
+		t.Errorf("Largest = %v, want %v", largest, want)
+	}
+
+	// A corpus of the same files reports the same numbers
+	config.OutputFile = filepath.Join(t.TempDir(), "corpus.txt.gz")
+	config.Gzip = true
+	if err := cmd.ProcessDirectory(config); err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+	corpusReport, err := cmd.CorpusStats(config.OutputFile, cmd.TokenizerEstimate)
+	if err != nil {
+		t.Fatalf("CorpusStats failed: %v", err)
+	}
+	if !reflect.DeepEqual(corpusReport.Languages, report.Languages) {
+		t.Errorf("Corpus languages = %+v, want %+v", corpusReport.Languages, report.Languages)
+	}
+}
+
+func TestStatsCommand(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	tempDir, cleanup := createTestFiles(t)
+	defer cleanup()
+
+	os.Args = []string{"cpack", "stats", tempDir, "-i", "**/*.go", "--format", "json"}
+	output := captureStdout(t, func() {
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("stats command failed: %v", err)
+		}
+	})
+
+	var report cmd.StatsReport
+	if err := json.Unmarshal(output, &report); err != nil {
+		t.Fatalf("Failed to parse stats JSON: %v\n%s", err, output)
+	}
+	if len(report.Languages) != 1 || report.Languages[0].Language != "go" || report.Total.Files == 0 {
+		t.Errorf("Expected only Go files, got %+v", report)
+	}
+
+	os.Args = []string{"cpack", "stats", tempDir, "-i", "**/*.go", "--format", "table"}
+	output = captureStdout(t, func() {
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("stats command failed: %v", err)
+		}
+	})
+	if !strings.HasPrefix(string(output), "LANGUAGE") || !strings.Contains(string(output), "\ntotal ") {
+		t.Errorf("Expected a table with a total row, got:\n%s", output)
+	}
+}
+
+func TestStatsExtensions(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	tempDir := t.TempDir()
+	for _, name := range []string{"main.go", "app.py", "notes.md", "todo.txt", "Makefile"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("x\n"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	// --ext adds to --include and, like it, drops the default include names
+	os.Args = []string{"cpack", "stats", tempDir, "-i", "**/*.txt", "--ext", ".py,md", "--format", "json"}
+	output := captureStdout(t, func() {
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("stats command failed: %v", err)
+		}
+	})
+
+	var report cmd.StatsReport
+	if err := json.Unmarshal(output, &report); err != nil {
+		t.Fatalf("Failed to parse stats JSON: %v\n%s", err, output)
+	}
+	languages := make(map[string]bool)
+	for _, lang := range report.Languages {
+		languages[lang.Language] = true
+	}
+	for _, want := range []string{"python", "markdown", "other"} {
+		if !languages[want] {
+			t.Errorf("Expected %s files, got %+v", want, report.Languages)
+		}
+	}
+	if languages["makefile"] {
+		t.Errorf("Expected the default include names to be dropped, got %+v", report.Languages)
+	}
+}
@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestNestedRepositories(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		".git/HEAD":                "ref: refs/heads/main\n",
+		"main.go":                  "package main\n",
+		"libs/vendored/.git/HEAD":  "ref: refs/heads/main\n",
+		"libs/vendored/lib.go":     "package vendored\n",
+		"third_party/sub/.git":     "gitdir: ../../.git/modules/sub\n",
+		"third_party/sub/sub.go":   "package sub\n",
+		"third_party/plain/dir.go": "package plain\n",
+	}
+	for path, content := range files {
+		fullPath := filepath.Join(tempDir, path)
+		os.MkdirAll(filepath.Dir(fullPath), 0755)
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	tests := []struct {
+		name              string
+		includeSubmodules bool
+		wantFiles         []string
+		wantSkipped       []string
+	}{
+		{
+			name:        "skipped by default",
+			wantFiles:   []string{"main.go", "third_party/plain/dir.go"},
+			wantSkipped: []string{"libs/vendored/ (nested repository)", "third_party/sub/ (submodule)"},
+		},
+		{
+			name:              "included on request",
+			includeSubmodules: true,
+			wantFiles:         []string{"libs/vendored/lib.go", "main.go", "third_party/plain/dir.go", "third_party/sub/sub.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "out.txt")
+			config := cmd.Config{
+				InputDir:          tempDir,
+				OutputFile:        outputPath,
+				IncludeGlobs:      []string{"**/*.go"},
+				IncludeSubmodules: tt.includeSubmodules,
+				Verbose:           true,
+				NoGitHeader:       true,
+			}
+			if err := cmd.ProcessDirectory(config); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			data, _ := os.ReadFile(outputPath)
+			corpus := cmd.ParseCorpus(data)
+			var paths []string
+			for _, file := range corpus.Files {
+				paths = append(paths, file.Path)
+			}
+			if !sliceEqual(paths, tt.wantFiles) {
+				t.Errorf("Packed %v, want %v", paths, tt.wantFiles)
+			}
+			for _, skipped := range tt.wantSkipped {
+				assertFileContains(t, outputPath, skipped)
+			}
+		})
+	}
+}
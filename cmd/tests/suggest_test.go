@@ -0,0 +1,155 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+	"gopkg.in/yaml.v3"
+)
+
+// createSuggestFiles adds low-value content on top of the standard test tree
+func createSuggestFiles(t *testing.T, tempDir string) {
+	t.Helper()
+
+	files := map[string]string{
+		"src/testdata/big.json":  strings.Repeat(`{"k": "value"}`+"\n", 500),
+		"src/pkg1/models.pb.go":  "// Code generated by protoc-gen-go. DO NOT EDIT.\npackage pkg1\n" + strings.Repeat("var x = 1\n", 50),
+		"src/pkg2/bundle.min.js": strings.Repeat("a", 4000),
+	}
+	for path, content := range files {
+		fullPath := filepath.Join(tempDir, path)
+		os.MkdirAll(filepath.Dir(fullPath), 0755)
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+}
+
+func TestSuggestExcludes(t *testing.T) {
+	tempDir, cleanup := createTestFiles(t)
+	defer cleanup()
+	createSuggestFiles(t, tempDir)
+
+	config := cmd.Config{
+		InputDir:     tempDir,
+		IncludeGlobs: []string{"**/*.go", "**/*.py", "**/*.json", "**/*.js"},
+		ExcludeGlobs: []string{"**/vendor/**", "**/.git/**"},
+	}
+	suggestions, err := cmd.SuggestExcludes(config, cmd.SuggestOptions{MinShare: 0.05, MinLowRate: 0.5})
+	if err != nil {
+		t.Fatalf("SuggestExcludes failed: %v", err)
+	}
+
+	globs := make(map[string]string)
+	for _, s := range suggestions {
+		globs[s.Glob] = s.Reason
+	}
+
+	if _, ok := globs["src/testdata/**"]; !ok {
+		t.Errorf("Expected fixture directory suggestion, got %+v", suggestions)
+	}
+	if globs["src/pkg1/models.pb.go"] != "generated file" {
+		t.Errorf("Expected generated file suggestion, got %+v", suggestions)
+	}
+	if globs["src/pkg2/bundle.min.js"] != "minified or single-line content" {
+		t.Errorf("Expected minified file suggestion, got %+v", suggestions)
+	}
+	if _, ok := globs["src/testdata/big.json"]; ok {
+		t.Error("Files inside a suggested directory should not be listed separately")
+	}
+	if _, ok := globs["src/pkg1/**"]; ok {
+		t.Error("Directories of regular code should not be suggested")
+	}
+}
+
+func TestSuggestCommandWrite(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	tempDir, cleanup := createTestFiles(t)
+	defer cleanup()
+	createSuggestFiles(t, tempDir)
+
+	configPath := filepath.Join(tempDir, "cpack.yaml")
+	if err := os.WriteFile(configPath, []byte("# project settings\nverbose: true\nexcludeGlobs:\n  - \"**/vendor/**\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	os.Args = []string{"cpack", "suggest", tempDir, "-i", "**/*.go,**/*.json", "-x", "**/vendor/**", "--write", configPath, "--yes"}
+	output := captureStdout(t, func() {
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("suggest command failed: %v", err)
+		}
+	})
+	if !strings.Contains(string(output), "src/testdata/**") {
+		t.Errorf("Expected suggestions table, got:\n%s", output)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	if !strings.Contains(string(data), "# project settings") {
+		t.Error("Existing comments should be preserved")
+	}
+
+	var written cmd.Config
+	if err := yaml.Unmarshal(data, &written); err != nil {
+		t.Fatalf("Written config is not valid YAML: %v", err)
+	}
+	if !written.Verbose || !contains(written.ExcludeGlobs, "**/vendor/**") || !contains(written.ExcludeGlobs, "src/testdata/**") {
+		t.Errorf("Expected suggestions appended to existing settings, got %+v", written)
+	}
+}
+
+func TestSuggestLockfilesAndDumps(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	tempDir := t.TempDir()
+	writeWorkspaceFiles(t, tempDir, map[string]string{
+		"main.go":          strings.Repeat("package main\n", 200),
+		"web/app.js":       strings.Repeat("export const app = 1;\n", 200),
+		"web/yarn.lock":    strings.Repeat("left-pad@^1.0.0:\n  version \"1.3.0\"\n", 100),
+		"db/seed.sql":      "INSERT INTO users VALUES (1, 'admin');\n",
+		"db/export.sql":    strings.Repeat("INSERT INTO events VALUES (1, 'click');\n", 1000),
+		"db/schema.go":     strings.Repeat("package db\n", 200),
+		"reports/data.csv": strings.Repeat("id,name\n", 10),
+	})
+
+	// Flags keep their values between runs, so --write is cleared
+	os.Args = []string{"cpack", "suggest-excludes", tempDir, "-i", "**/*", "--min-share", "0.9", "--write", ""}
+	output := string(captureStdout(t, func() {
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("suggest-excludes command failed: %v", err)
+		}
+	}))
+
+	for _, want := range []string{
+		"db/export.sql", "data dump",
+		"web/yarn.lock", "lockfile",
+		"excludeGlobs:\n  - db/export.sql\n  - web/yarn.lock\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+	for _, unwanted := range []string{"db/seed.sql", "reports/data.csv"} {
+		if strings.Contains(output, unwanted) {
+			t.Errorf("Expected small data files not to be suggested, got:\n%s", output)
+		}
+	}
+
+	var block struct {
+		ExcludeGlobs []string `yaml:"excludeGlobs"`
+	}
+	if err := yaml.Unmarshal([]byte(output[strings.Index(output, "excludeGlobs:"):]), &block); err != nil {
+		t.Fatalf("Exclude block is not valid YAML: %v", err)
+	}
+	if !sliceEqual(block.ExcludeGlobs, []string{"db/export.sql", "web/yarn.lock"}) {
+		t.Errorf("ExcludeGlobs = %v", block.ExcludeGlobs)
+	}
+}
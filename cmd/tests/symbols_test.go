@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestSymbols(t *testing.T) {
+	tempDir := t.TempDir()
+	writeWorkspaceFiles(t, tempDir, map[string]string{
+		"server.go": `package server
+
+type Server struct{}
+
+type Handler interface {
+	Serve()
+}
+
+func New() *Server { return &Server{} }
+
+func (s *Server) Start() {}
+
+func (l List[T]) Len() int { return 0 }
+`,
+		"app.py": `class App:
+    def run(self):
+        pass
+
+async def main():
+    pass
+`,
+		"web/index.ts": `export interface Props {}
+export type ID = string;
+export default class Widget {}
+export async function render() {}
+`,
+		"README.md": "# Readme\n",
+	})
+
+	pack := func(t *testing.T, config cmd.Config) string {
+		t.Helper()
+		config.InputDir = tempDir
+		config.OutputFile = filepath.Join(t.TempDir(), "corpus.txt")
+		config.IncludeGlobs = []string{"**/*.go", "**/*.py", "**/*.ts", "**/*.md"}
+		if err := cmd.ProcessDirectory(config); err != nil {
+			t.Fatalf("ProcessDirectory failed: %v", err)
+		}
+		data, err := os.ReadFile(config.OutputFile)
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+		return string(data)
+	}
+
+	t.Run("index", func(t *testing.T) {
+		output := pack(t, cmd.Config{Symbols: true})
+		start := strings.Index(output, "--- SYMBOLS ---\n")
+		end := strings.Index(output, "--- END OF SYMBOLS ---\n")
+		if start < 0 || end < start {
+			t.Fatalf("Expected a symbols block, got:\n%s", output)
+		}
+		lines := strings.Split(strings.TrimSpace(output[start+len("--- SYMBOLS ---\n"):end]), "\n")
+		got := make(map[string]bool, len(lines))
+		for _, line := range lines {
+			got[line] = true
+		}
+		for _, want := range []string{
+			"server.go:3 type Server",
+			"server.go:5 interface Handler",
+			"server.go:9 function New",
+			"server.go:11 method Server.Start",
+			"server.go:13 method List.Len",
+			"app.py:1 class App",
+			"app.py:2 function run",
+			"app.py:5 function main",
+			"web/index.ts:1 interface Props",
+			"web/index.ts:2 type ID",
+			"web/index.ts:3 class Widget",
+			"web/index.ts:4 function render",
+		} {
+			if !got[want] {
+				t.Errorf("Expected symbol %q, got:\n%s", want, strings.Join(lines, "\n"))
+			}
+		}
+		for _, line := range lines {
+			if strings.HasPrefix(line, "README.md") {
+				t.Errorf("Expected no symbols for markdown, got %q", line)
+			}
+		}
+		if problems := cmd.ValidateCorpus([]byte(output)); len(problems) > 0 {
+			t.Errorf("Expected a valid corpus, got %v", problems)
+		}
+	})
+
+	t.Run("low memory", func(t *testing.T) {
+		output := pack(t, cmd.Config{Symbols: true, LowMemory: true})
+		if !strings.Contains(output, "server.go:11 method Server.Start\n") {
+			t.Errorf("Expected symbols in low-memory mode, got:\n%s", output)
+		}
+	})
+
+	t.Run("off by default", func(t *testing.T) {
+		if output := pack(t, cmd.Config{}); strings.Contains(output, "--- SYMBOLS ---") {
+			t.Error("Expected no symbols block without --symbols")
+		}
+	})
+}
@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestSymlinkPolicy(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need extra privileges on Windows")
+	}
+
+	base := t.TempDir()
+	tempDir := filepath.Join(base, "project")
+	files := map[string]string{
+		"project/main.go":    "package main\n",
+		"project/src/lib.go": "package src\n",
+		"shared/util.go":     "package shared\n",
+	}
+	for path, content := range files {
+		fullPath := filepath.Join(base, path)
+		os.MkdirAll(filepath.Dir(fullPath), 0755)
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+	links := map[string]string{
+		"alias.go":    "src/lib.go", // File inside the root
+		"common":      "../shared",  // Directory outside the root
+		"common2":     "../shared",  // Second link to the same directory
+		"mirror":      "src",        // Directory inside the root
+		"src/parent":  "..",         // The root itself
+		"dangling.go": "missing.go", // Broken link
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, filepath.Join(tempDir, link)); err != nil {
+			t.Fatalf("Failed to create symlink %s: %v", link, err)
+		}
+	}
+	// An ancestor of the root, reached through a followed directory
+	if err := os.Symlink("..", filepath.Join(base, "shared", "up")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		policy      string
+		included    []string
+		notIncluded []string
+		wantSkipped []string
+		wantErr     string
+	}{
+		{
+			name:        "follow",
+			policy:      cmd.SymlinkFollow,
+			included:    []string{"main.go", "src/lib.go", "alias.go", "common/util.go"},
+			notIncluded: []string{"common2/util.go", "mirror/lib.go", "src/parent/main.go", "common/up/shared/util.go"},
+			wantSkipped: []string{
+				"dangling.go (broken symlink)",
+				"common2 (duplicate symlink)",
+				"mirror (duplicate symlink)",
+				"common/up (symlink cycle)",
+				"src/parent (symlink cycle)",
+			},
+		},
+		{
+			name:        "skip",
+			policy:      cmd.SymlinkSkip,
+			included:    []string{"main.go", "src/lib.go"},
+			notIncluded: []string{"alias.go", "common/util.go"},
+			wantSkipped: []string{
+				"alias.go (symlink)",
+				"common (symlink)",
+				"common2 (symlink)",
+				"dangling.go (symlink)",
+				"mirror (symlink)",
+				"src/parent (symlink)",
+			},
+		},
+		{
+			name:    "error",
+			policy:  cmd.SymlinkError,
+			wantErr: "symlink found",
+		},
+		{
+			name:    "unknown policy",
+			policy:  "sometimes",
+			wantErr: "unsupported symlink policy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outDir := t.TempDir()
+			config := cmd.Config{
+				InputDir:      tempDir,
+				OutputFile:    filepath.Join(outDir, "out.txt"),
+				ReportFile:    filepath.Join(outDir, "report.json"),
+				IncludeGlobs:  []string{"**/*.go"},
+				SymlinkPolicy: tt.policy,
+			}
+
+			err := cmd.ProcessDirectory(config)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			for _, path := range tt.included {
+				assertFileContains(t, config.OutputFile, "--- START OF FILE: "+path+" ---")
+			}
+			for _, path := range tt.notIncluded {
+				assertFileNotContains(t, config.OutputFile, "--- START OF FILE: "+path+" ---")
+			}
+
+			data, _ := os.ReadFile(config.ReportFile)
+			var report cmd.PackReport
+			if err := json.Unmarshal(data, &report); err != nil {
+				t.Fatalf("Failed to parse report: %v", err)
+			}
+			if !sliceEqual(report.SkippedFiles, tt.wantSkipped) {
+				t.Errorf("SkippedFiles = %v, want %v", report.SkippedFiles, tt.wantSkipped)
+			}
+		})
+	}
+}
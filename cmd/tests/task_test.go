@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestTaskCommand(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	tempDir, cleanup := createTestFiles(t)
+	defer cleanup()
+
+	outputPath := filepath.Join(t.TempDir(), "explain.txt")
+	os.Args = []string{"cpack", "task", "explain", tempDir, "--focus", "src/pkg2", "-o", outputPath}
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("task command failed: %v", err)
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	// The instructions follow the version line
+	_, body, _ := strings.Cut(string(content), "\n\n")
+	if !strings.HasPrefix(body, "--- INSTRUCTIONS ---\nExplain how the code in src/pkg2 works") {
+		t.Errorf("Expected the corpus to open with explain instructions, got:\n%s", content)
+	}
+
+	order := fileOrder(t, outputPath)
+	if len(order) < 3 || !strings.HasPrefix(order[0], "src/pkg2/") || !strings.HasPrefix(order[1], "src/pkg2/") {
+		t.Errorf("Expected focus files first, got %v", order)
+	}
+	// The explain task leaves tests out
+	assertFileNotContains(t, outputPath, "--- START OF FILE: src/pkg1/file1_test.go ---")
+}
+
+func TestMaxTokens(t *testing.T) {
+	tempDir, cleanup := createTestFiles(t)
+	defer cleanup()
+
+	outDir := t.TempDir()
+	config := cmd.Config{
+		InputDir:      tempDir,
+		OutputFile:    filepath.Join(outDir, "out.txt"),
+		IncludeGlobs:  []string{"**/*.go"},
+		PriorityGlobs: []string{"src/pkg2/**"},
+		// file2.go is 4 tokens and file1.go 8, so only file2.go fits
+		MaxTokens: 10,
+		Verbose:   true,
+	}
+	if err := cmd.ProcessDirectory(config); err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+
+	assertFileContains(t, config.OutputFile, "--- START OF FILE: src/pkg2/file2.go ---")
+	assertFileNotContains(t, config.OutputFile, "--- START OF FILE: src/pkg1/file1.go ---")
+	assertFileContains(t, config.OutputFile, "src/pkg1/file1.go (over budget)")
+}
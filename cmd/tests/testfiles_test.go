@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestTestSelection(t *testing.T) {
+	tempDir := t.TempDir()
+	writeWorkspaceFiles(t, tempDir, map[string]string{
+		"main.go":                    "package main\n",
+		"main_test.go":               "package main\n",
+		"pkg/testdata/input.txt":     "fixture\n",
+		"app/models.py":              "x = 1\n",
+		"app/test_models.py":         "x = 1\n",
+		"app/conftest.py":            "x = 1\n",
+		"web/button.ts":              "export {}\n",
+		"web/button.spec.ts":         "export {}\n",
+		"web/__tests__/render.js":    "export {}\n",
+		"web/util.test.jsx":          "export {}\n",
+		"src/main/java/App.java":     "class App {}\n",
+		"src/test/java/AppTest.java": "class AppTest {}\n",
+		"lib/contest.py":             "x = 1\n",
+		"lib/testing_helpers.go":     "package lib\n",
+		"README.md":                  "# Readme\n",
+	})
+
+	pack := func(t *testing.T, config cmd.Config) []string {
+		t.Helper()
+		config.InputDir = tempDir
+		config.OutputFile = filepath.Join(t.TempDir(), "corpus.txt")
+		config.IncludeGlobs = []string{"**/*"}
+		if err := cmd.ProcessDirectory(config); err != nil {
+			t.Fatalf("ProcessDirectory failed: %v", err)
+		}
+		order := fileOrder(t, config.OutputFile)
+		sort.Strings(order)
+		return order
+	}
+
+	tests := []string{
+		"app/conftest.py", "app/test_models.py", "main_test.go", "pkg/testdata/input.txt",
+		"src/test/java/AppTest.java", "web/__tests__/render.js", "web/button.spec.ts", "web/util.test.jsx",
+	}
+	sources := []string{
+		"README.md", "app/models.py", "lib/contest.py", "lib/testing_helpers.go", "main.go",
+		"src/main/java/App.java", "web/button.ts",
+	}
+
+	t.Run("no tests", func(t *testing.T) {
+		if got := pack(t, cmd.Config{NoTests: true}); strings.Join(got, ",") != strings.Join(sources, ",") {
+			t.Errorf("Expected %v, got %v", sources, got)
+		}
+	})
+
+	t.Run("tests only", func(t *testing.T) {
+		if got := pack(t, cmd.Config{TestsOnly: true}); strings.Join(got, ",") != strings.Join(tests, ",") {
+			t.Errorf("Expected %v, got %v", tests, got)
+		}
+	})
+
+	t.Run("both", func(t *testing.T) {
+		err := cmd.ProcessDirectory(cmd.Config{
+			InputDir:   tempDir,
+			OutputFile: filepath.Join(t.TempDir(), "corpus.txt"),
+			NoTests:    true,
+			TestsOnly:  true,
+		})
+		if err == nil {
+			t.Error("Expected --no-tests and --tests-only to be rejected together")
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestEmbeddedTokenizer(t *testing.T) {
+	tokenizer, err := cmd.GetTokenizer("cl100k_base")
+	if err != nil {
+		t.Fatalf("Failed to load embedded tokenizer: %v", err)
+	}
+
+	// Expected counts from the reference tiktoken implementation
+	tests := []struct {
+		text string
+		want int
+	}{
+		{text: "hello world", want: 2},
+		{text: "Hello, world!", want: 4},
+		{text: "func main() {\n\tfmt.Println(\"hi\")\n}\n", want: 10},
+		{text: "a    b\n\n  c", want: 6},
+		{text: "naïve café 12345", want: 7},
+		{text: "    return x  \n", want: 4},
+	}
+
+	for _, tt := range tests {
+		if got := tokenizer.CountTokens([]byte(tt.text)); got != tt.want {
+			t.Errorf("CountTokens(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTokenizerErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		tokenizer string
+		cached    string // Rank file content placed in the cache, if any
+		wantErr   string
+	}{
+		{name: "unknown", tokenizer: "unknown", wantErr: "unknown tokenizer"},
+		{name: "not cached", tokenizer: "o200k_base", wantErr: "cpack tokenizer fetch o200k_base"},
+		{name: "tampered cache", tokenizer: "o200k_base", cached: "aGVsbG8= 0\n", wantErr: "failed checksum verification"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cacheDir := t.TempDir()
+			t.Setenv("CPACK_CACHE_DIR", cacheDir)
+			if tt.cached != "" {
+				writeWorkspaceFiles(t, cacheDir, map[string]string{"tokenizers/" + tt.tokenizer + ".tiktoken": tt.cached})
+			}
+
+			_, err := cmd.GetTokenizer(tt.tokenizer)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestWhitespaceTransforms(t *testing.T) {
+	files := map[string]string{
+		"main.go":  "package main\r\n\r\nfunc main() {  \r\n\tprintln(\"a\\tb\")\t\r\n}\r\n",
+		"old.txt":  "classic\rmac\rendings\r",
+		"align.go": "var (\n\ta\t= 1\n\tlong\t= 2\n)\n",
+		"Makefile": "build:\n\tgo build ./...   \n",
+		"gaps.txt": "one\n\n\n\n  \ntwo\n\t\nthree\n\n\n",
+	}
+
+	tests := []struct {
+		name   string
+		config cmd.Config
+		want   map[string]string
+	}{
+		{
+			name:   "no transforms",
+			config: cmd.Config{},
+			want: map[string]string{
+				"main.go": files["main.go"],
+			},
+		},
+		{
+			name:   "normalize to lf",
+			config: cmd.Config{NormalizeEOL: cmd.EOLLF},
+			want: map[string]string{
+				"main.go": "package main\n\nfunc main() {  \n\tprintln(\"a\\tb\")\t\n}\n",
+				"old.txt": "classic\nmac\nendings\n",
+			},
+		},
+		{
+			name:   "normalize to crlf",
+			config: cmd.Config{NormalizeEOL: cmd.EOLCRLF},
+			want: map[string]string{
+				"align.go": "var (\r\n\ta\t= 1\r\n\tlong\t= 2\r\n)\r\n",
+			},
+		},
+		{
+			name:   "strip trailing space keeps line endings",
+			config: cmd.Config{StripTrailingSpace: true},
+			want: map[string]string{
+				"main.go":  "package main\r\n\r\nfunc main() {\r\n\tprintln(\"a\\tb\")\r\n}\r\n",
+				"Makefile": "build:\n\tgo build ./...\n",
+			},
+		},
+		{
+			name:   "tabs to spaces by tab stop",
+			config: cmd.Config{TabsToSpaces: 4},
+			want: map[string]string{
+				"align.go": "var (\n    a   = 1\n    long    = 2\n)\n",
+				"Makefile": files["Makefile"],
+			},
+		},
+		{
+			name:   "collapse blank lines",
+			config: cmd.Config{CollapseBlankLines: 1},
+			want: map[string]string{
+				"gaps.txt": "one\n\ntwo\n\t\nthree\n\n",
+				"main.go":  files["main.go"],
+			},
+		},
+		{
+			name:   "collapse blank lines keeps up to n",
+			config: cmd.Config{CollapseBlankLines: 2},
+			want: map[string]string{
+				"gaps.txt": "one\n\n\ntwo\n\t\nthree\n\n\n",
+			},
+		},
+		{
+			name:   "combined",
+			config: cmd.Config{NormalizeEOL: cmd.EOLLF, StripTrailingSpace: true, TabsToSpaces: 2},
+			want: map[string]string{
+				"main.go": "package main\n\nfunc main() {\n  println(\"a\\tb\")\n}\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			writeWorkspaceFiles(t, tempDir, files)
+			outputPath := filepath.Join(t.TempDir(), "out.txt")
+
+			config := tt.config
+			config.InputDir = tempDir
+			config.OutputFile = outputPath
+			config.IncludeGlobs = []string{"**/*.go", "**/*.txt"}
+			config.IncludeNames = []string{"Makefile"}
+			config.NoGitHeader = true
+			if err := cmd.ProcessDirectory(config); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			data, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			corpus := cmd.ParseCorpus(data)
+			for path, want := range tt.want {
+				f, ok := corpus.Find(path)
+				if !ok {
+					t.Fatalf("%s missing from corpus", path)
+				}
+				if got := string(corpus.Content(f)); got != want {
+					t.Errorf("%s = %q, want %q", path, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWhitespaceTransformValidation(t *testing.T) {
+	tempDir, cleanup := createTestFiles(t)
+	defer cleanup()
+
+	tests := []struct {
+		name    string
+		config  cmd.Config
+		wantErr string
+	}{
+		{name: "unknown line ending", config: cmd.Config{NormalizeEOL: "cr"}, wantErr: "unsupported line ending"},
+		{name: "negative tab width", config: cmd.Config{TabsToSpaces: -2}, wantErr: "tabs-to-spaces"},
+		{name: "negative blank lines", config: cmd.Config{CollapseBlankLines: -1}, wantErr: "collapse-blank-lines"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.InputDir = tempDir
+			tt.config.OutputFile = filepath.Join(t.TempDir(), "out.txt")
+			err := cmd.ProcessDirectory(tt.config)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestUnicodeTransforms(t *testing.T) {
+	files := map[string]string{
+		// "café" spelled with a combining acute accent, and a Trojan Source style line
+		"cafe.txt": "cafe\u0301\n",
+		"auth.go":  "if isAdmin\u202E \u2066// check later\u2069 \u2066{\n\u200Bpanic(\"x\")\uFEFF\n}\n",
+		"tags.txt": "hello\U000E0069\U000E0067\U000E006E\n",
+		"bad.txt":  "ok\xff\u200D\n",
+	}
+
+	tests := []struct {
+		name   string
+		config cmd.Config
+		want   map[string]string
+	}{
+		{
+			name:   "no transforms",
+			config: cmd.Config{},
+			want:   map[string]string{"cafe.txt": files["cafe.txt"], "auth.go": files["auth.go"]},
+		},
+		{
+			name:   "nfc",
+			config: cmd.Config{NormalizeUnicode: true},
+			want:   map[string]string{"cafe.txt": "caf\u00E9\n", "auth.go": files["auth.go"]},
+		},
+		{
+			name:   "strip invisible",
+			config: cmd.Config{StripInvisible: true},
+			want: map[string]string{
+				"cafe.txt": files["cafe.txt"],
+				"auth.go":  "if isAdmin // check later {\npanic(\"x\")\n}\n",
+				"tags.txt": "hello\n",
+				"bad.txt":  "ok\xff\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			writeWorkspaceFiles(t, tempDir, files)
+			outputPath := filepath.Join(t.TempDir(), "out.txt")
+
+			config := tt.config
+			config.InputDir = tempDir
+			config.OutputFile = outputPath
+			config.IncludeGlobs = []string{"**/*.go", "**/*.txt"}
+			config.NoGitHeader = true
+			if err := cmd.ProcessDirectory(config); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			data, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			corpus := cmd.ParseCorpus(data)
+			for path, want := range tt.want {
+				f, ok := corpus.Find(path)
+				if !ok {
+					t.Fatalf("%s missing from corpus", path)
+				}
+				if got := string(corpus.Content(f)); got != want {
+					t.Errorf("%s = %q, want %q", path, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestUploadCorpus(t *testing.T) {
+	corpusPath := filepath.Join(t.TempDir(), "corpus.txt")
+	if err := os.WriteFile(corpusPath, []byte("packed corpus\n"), 0644); err != nil {
+		t.Fatalf("Failed to write corpus: %v", err)
+	}
+
+	// checkForm verifies the fields and file of a multipart upload
+	checkForm := func(t *testing.T, r *http.Request, wantPurpose string) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatalf("Invalid multipart form: %v", err)
+		}
+		if got := r.FormValue("purpose"); got != wantPurpose {
+			t.Errorf("Expected purpose %q, got %q", wantPurpose, got)
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Fatalf("Expected a file part: %v", err)
+		}
+		data, _ := io.ReadAll(file)
+		if header.Filename != "corpus.txt" || string(data) != "packed corpus\n" {
+			t.Errorf("Unexpected file %s with %q", header.Filename, data)
+		}
+	}
+
+	tests := []struct {
+		provider string
+		handler  func(t *testing.T, server string) http.HandlerFunc
+		wantID   string
+	}{
+		{
+			provider: cmd.UploadOpenAI,
+			wantID:   "file-abc",
+			handler: func(t *testing.T, server string) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+						t.Errorf("Expected bearer auth, got %q", got)
+					}
+					checkForm(t, r, "user_data")
+					json.NewEncoder(w).Encode(map[string]string{"id": "file-abc"})
+				}
+			},
+		},
+		{
+			provider: cmd.UploadAnthropic,
+			wantID:   "file_011",
+			handler: func(t *testing.T, server string) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					if got := r.Header.Get("x-api-key"); got != "test-key" {
+						t.Errorf("Expected x-api-key, got %q", got)
+					}
+					if r.Header.Get("anthropic-version") == "" || r.Header.Get("anthropic-beta") == "" {
+						t.Error("Expected anthropic-version and anthropic-beta headers")
+					}
+					checkForm(t, r, "")
+					json.NewEncoder(w).Encode(map[string]string{"id": "file_011"})
+				}
+			},
+		},
+		{
+			provider: cmd.UploadGemini,
+			wantID:   "files/xyz",
+			handler: func(t *testing.T, server string) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					switch r.Header.Get("X-Goog-Upload-Command") {
+					case "start":
+						if got := r.Header.Get("x-goog-api-key"); got != "test-key" {
+							t.Errorf("Expected x-goog-api-key, got %q", got)
+						}
+						if got := r.Header.Get("X-Goog-Upload-Header-Content-Length"); got != "14" {
+							t.Errorf("Expected content length 14, got %q", got)
+						}
+						w.Header().Set("X-Goog-Upload-URL", server+"/session")
+					case "upload, finalize":
+						data, _ := io.ReadAll(r.Body)
+						if r.URL.Path != "/session" || string(data) != "packed corpus\n" {
+							t.Errorf("Unexpected upload to %s with %q", r.URL.Path, data)
+						}
+						io.WriteString(w, `{"file": {"name": "files/xyz"}}`)
+					default:
+						t.Errorf("Unexpected request %s", r.URL)
+					}
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			var url string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				tt.handler(t, url)(w, r)
+			}))
+			defer server.Close()
+			url = server.URL
+
+			id, err := cmd.UploadCorpus(cmd.UploadOptions{
+				Provider: tt.provider,
+				Path:     corpusPath,
+				APIKey:   "test-key",
+				URL:      server.URL,
+			})
+			if err != nil {
+				t.Fatalf("UploadCorpus failed: %v", err)
+			}
+			if id != tt.wantID {
+				t.Errorf("Expected id %q, got %q", tt.wantID, id)
+			}
+		})
+	}
+
+	t.Run("error response", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, `{"error": "invalid key"}`, http.StatusUnauthorized)
+		}))
+		defer server.Close()
+
+		_, err := cmd.UploadCorpus(cmd.UploadOptions{Provider: cmd.UploadOpenAI, Path: corpusPath, APIKey: "bad", URL: server.URL})
+		if err == nil || !strings.Contains(err.Error(), "invalid key") {
+			t.Errorf("Expected the error body in the error, got %v", err)
+		}
+	})
+	t.Run("response without an id", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"object": "file"}`))
+		}))
+		defer server.Close()
+
+		id, err := cmd.UploadCorpus(cmd.UploadOptions{Provider: cmd.UploadOpenAI, Path: corpusPath, APIKey: "key", URL: server.URL})
+		if err == nil || !strings.Contains(err.Error(), "no file id") {
+			t.Errorf("Expected an error for a missing id, got id %q and %v", id, err)
+		}
+	})
+}
+
+func TestUploadValidation(t *testing.T) {
+	tempDir, cleanup := createTestFiles(t)
+	defer cleanup()
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("ANTHROPIC_API_KEY", "test-key")
+
+	tests := []struct {
+		name    string
+		config  cmd.Config
+		wantErr string
+	}{
+		{"unknown provider", cmd.Config{Upload: "dropbox"}, "unsupported upload provider"},
+		{"missing key", cmd.Config{Upload: cmd.UploadOpenAI}, "OPENAI_API_KEY"},
+		{"split output", cmd.Config{Upload: cmd.UploadAnthropic, SplitByDir: 1}, "single corpus file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			config.InputDir = tempDir
+			config.OutputFile = filepath.Join(t.TempDir(), "corpus.txt")
+			err := cmd.ProcessDirectory(config)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestValidateCorpus(t *testing.T) {
+	tempDir := t.TempDir()
+	writeWorkspaceFiles(t, tempDir, map[string]string{
+		"main.go":       "package main\n\nfunc main() {}\n",
+		"lib/lib.go":    "package lib\n\n// Marker is \"--- END OF FILE: fake ---\"\nconst Marker = 1\n",
+		"docs/guide.md": "# Guide\n\nTabs\tand  trailing space   \n",
+	})
+
+	pack := func(t *testing.T, config cmd.Config) string {
+		t.Helper()
+		config.InputDir = tempDir
+		config.OutputFile = filepath.Join(t.TempDir(), "corpus.txt")
+		if config.Gzip {
+			config.OutputFile += ".gz"
+		}
+		config.IncludeGlobs = []string{"**/*.go", "**/*.md"}
+		if err := cmd.ProcessDirectory(config); err != nil {
+			t.Fatalf("ProcessDirectory failed: %v", err)
+		}
+		return config.OutputFile
+	}
+	problems := func(t *testing.T, path string) []string {
+		t.Helper()
+		corpus, err := cmd.LoadCorpus(path)
+		if err != nil {
+			t.Fatalf("LoadCorpus failed: %v", err)
+		}
+		return cmd.ValidateCorpus(corpus.Data)
+	}
+
+	valid := []struct {
+		name   string
+		config cmd.Config
+	}{
+		{name: "plain"},
+		{name: "instructions and summary", config: cmd.Config{Instructions: "Review this", Verbose: true}},
+		{name: "low-memory summary", config: cmd.Config{Verbose: true, LowMemory: true}},
+		{name: "compressed", config: cmd.Config{Compress: true}},
+		{name: "whitespace transforms", config: cmd.Config{StripTrailingSpace: true, TabsToSpaces: 4}},
+		{name: "gzipped and encoded", config: cmd.Config{Gzip: true, Encoding: cmd.EncodingBase85}},
+		{name: "anonymized", config: cmd.Config{Anonymize: true}},
+	}
+	for _, tt := range valid {
+		t.Run(tt.name, func(t *testing.T) {
+			if p := problems(t, pack(t, tt.config)); len(p) > 0 {
+				t.Errorf("Expected a valid corpus, got %v", p)
+			}
+		})
+	}
+
+	t.Run("appended", func(t *testing.T) {
+		output := pack(t, cmd.Config{})
+		config := cmd.Config{InputDir: tempDir, OutputFile: output, IncludeGlobs: []string{"**/*.go"}, Append: true}
+		if err := cmd.ProcessDirectory(config); err != nil {
+			t.Fatalf("ProcessDirectory failed: %v", err)
+		}
+		if p := problems(t, output); len(p) > 0 {
+			t.Errorf("Expected the appended corpus to be valid, got %v", p)
+		}
+	})
+
+	t.Run("version line", func(t *testing.T) {
+		data, err := os.ReadFile(pack(t, cmd.Config{}))
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+		first := strings.SplitN(string(data), "\n", 2)[0]
+		if first != "--- CPACK CORPUS: format "+cmd.CorpusFormat+", cpack "+cmd.Version+" ---" {
+			t.Errorf("Unexpected version line %q", first)
+		}
+	})
+
+	corruptions := []struct {
+		name   string
+		edit   func(string) string
+		expect string
+	}{
+		{
+			name:   "edited content",
+			edit:   func(s string) string { return strings.Replace(s, "func main() {}", "func main() { panic(1) }", 1) },
+			expect: "main.go is",
+		},
+		{
+			name:   "same size edit",
+			edit:   func(s string) string { return strings.Replace(s, "func main() {}", "func mian() {}", 1) },
+			expect: "main.go does not match its index checksum",
+		},
+		{
+			name:   "missing end marker",
+			edit:   func(s string) string { return strings.Replace(s, "--- END OF FILE: main.go ---", "", 1) },
+			expect: "file main.go has no end marker",
+		},
+		{
+			name: "stray end marker",
+			edit: func(s string) string {
+				return strings.Replace(s, "--- INDEX ---", "--- END OF FILE: x ---\n\n--- INDEX ---", 1)
+			},
+			expect: "1 end markers without a start marker",
+		},
+		{
+			name:   "dropped file",
+			edit:   func(s string) string { return strings.Replace(s, "--- START OF FILE: main.go ---", "", 1) },
+			expect: "index lists 3 files but the corpus holds 2",
+		},
+		{
+			name:   "no version line",
+			edit:   func(s string) string { return s[strings.Index(s, "\n\n")+2:] },
+			expect: "no version line",
+		},
+		{
+			name:   "future format",
+			edit:   func(s string) string { return strings.Replace(s, "format "+cmd.CorpusFormat, "format 99", 1) },
+			expect: `unsupported corpus format "99"`,
+		},
+		{
+			name:   "unclosed index",
+			edit:   func(s string) string { return strings.Replace(s, "--- END OF INDEX ---\n", "", 1) },
+			expect: "index is not closed",
+		},
+	}
+	output := pack(t, cmd.Config{})
+	original, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	for _, tt := range corruptions {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "corpus.txt")
+			if err := os.WriteFile(path, []byte(tt.edit(string(original))), 0644); err != nil {
+				t.Fatalf("Failed to write corpus: %v", err)
+			}
+			p := problems(t, path)
+			if !strings.Contains(strings.Join(p, "\n"), tt.expect) {
+				t.Errorf("Expected a problem containing %q, got %v", tt.expect, p)
+			}
+		})
+	}
+}
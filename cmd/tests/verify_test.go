@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestVerify(t *testing.T) {
+	files := map[string]string{
+		"main.go":        "package main\n\nfunc main() {}   \n",
+		"lib/util.go":    "package lib\n\nfunc Util() {}\n",
+		"internal/db.go": "package db\n\n// Open opens the database\nfunc Open() {}\n",
+		"notes.txt":      "no trailing newline",
+	}
+
+	tests := []struct {
+		name    string
+		config  cmd.Config
+		wantErr string
+	}{
+		{name: "plain", config: cmd.Config{}},
+		{name: "gzip", config: cmd.Config{Gzip: true}},
+		{name: "gzip and base64", config: cmd.Config{Gzip: true, Base64: true, Wrap: true}},
+		{name: "verbose", config: cmd.Config{Verbose: true, MaxMemory: "64B"}},
+		{name: "low memory", config: cmd.Config{LowMemory: true}},
+		{name: "compressed", config: cmd.Config{Compress: true}},
+		{name: "transformed", config: cmd.Config{StripTrailingSpace: true}},
+		{name: "anonymized", config: cmd.Config{Anonymize: true, AnonymizeDirs: []string{"internal"}}},
+		{
+			name:    "other format",
+			config:  cmd.Config{OutputFormat: cmd.FormatMarkdown},
+			wantErr: "--verify checks text corpora",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputDir := t.TempDir()
+			writeWorkspaceFiles(t, inputDir, files)
+
+			config := tt.config
+			config.InputDir = inputDir
+			config.OutputFile = filepath.Join(t.TempDir(), "corpus.txt")
+			config.IncludeGlobs = []string{"**/*.go", "**/*.txt"}
+			config.Verify = true
+			err := cmd.ProcessDirectory(config)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ProcessDirectory() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ProcessDirectory() error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestBuildVocab(t *testing.T) {
+	tempDir, cleanup := createTestFiles(t)
+	defer cleanup()
+
+	config := cmd.Config{
+		InputDir:     tempDir,
+		IncludeGlobs: []string{"**/*.go"},
+		ExcludeGlobs: []string{"**/*_test.go"},
+	}
+
+	tests := []struct {
+		name     string
+		opts     cmd.VocabOptions
+		validate func(t *testing.T, report *cmd.VocabReport)
+	}{
+		{
+			name: "unigram counts",
+			opts: cmd.VocabOptions{MaxNGram: 1},
+			validate: func(t *testing.T, report *cmd.VocabReport) {
+				if report.Files != 2 {
+					t.Errorf("Expected 2 files, got %d", report.Files)
+				}
+				if len(report.Terms) == 0 || report.Terms[0].Term != "package" || report.Terms[0].Count != 2 {
+					t.Errorf("Expected 'package' to be the top term, got %+v", report.Terms)
+				}
+			},
+		},
+		{
+			name: "bigrams and top limit",
+			opts: cmd.VocabOptions{MaxNGram: 2, Top: 1},
+			validate: func(t *testing.T, report *cmd.VocabReport) {
+				if len(report.Terms) != 2 {
+					t.Fatalf("Expected one term per n-gram size, got %+v", report.Terms)
+				}
+				if report.Terms[1].N != 2 || !strings.Contains(report.Terms[1].Term, " ") {
+					t.Errorf("Expected a bigram, got %+v", report.Terms[1])
+				}
+			},
+		},
+		{
+			name: "stopwords are ignored",
+			opts: cmd.VocabOptions{MaxNGram: 1, Stopwords: map[string]bool{"package": true}},
+			validate: func(t *testing.T, report *cmd.VocabReport) {
+				for _, term := range report.Terms {
+					if term.Term == "package" {
+						t.Error("Stopword should not be counted")
+					}
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := cmd.BuildVocab(config, tt.opts)
+			if err != nil {
+				t.Fatalf("BuildVocab failed: %v", err)
+			}
+			tt.validate(t, report)
+		})
+	}
+}
+
+func TestVocabCommand(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+
+	tempDir, cleanup := createTestFiles(t)
+	defer cleanup()
+
+	outputPath := filepath.Join(tempDir, "vocab.json")
+	os.Args = []string{"cpack", "vocab", tempDir, "-i", "**/*.py", "-f", "json", "-o", outputPath}
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("vocab command failed: %v", err)
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read report: %v", err)
+	}
+
+	var report cmd.VocabReport
+	if err := json.Unmarshal(content, &report); err != nil {
+		t.Fatalf("Report is not valid JSON: %v", err)
+	}
+	if report.Files != 2 {
+		t.Errorf("Expected 2 Python files, got %d", report.Files)
+	}
+}
@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestCorpusPaths(t *testing.T) {
+	files := map[string]string{
+		"src/app/main.go": "package main\n\nfunc main() {}\n",
+		"Docs/Guide.md":   "# Guide\n",
+	}
+
+	tests := []struct {
+		name      string
+		windows   bool
+		inputDir  func(dir string) string
+		config    cmd.Config
+		want      []string
+		wantNotIn []string
+	}{
+		{
+			name:   "forward slashes in headers",
+			config: cmd.Config{IncludeGlobs: []string{"**/*.go", "**/*.md"}},
+			want:   []string{"--- START OF FILE: src/app/main.go ---", "--- START OF FILE: Docs/Guide.md ---"},
+		},
+		{
+			name:   "forward slashes when anonymized",
+			config: cmd.Config{IncludeGlobs: []string{"**/*.go"}, Anonymize: true, AnonymizeDirs: []string{"src"}},
+			want:   []string{"/app/main.go ---"},
+		},
+		{
+			name:     "long path prefix",
+			windows:  true,
+			inputDir: func(dir string) string { return `\\?\` + dir },
+			config:   cmd.Config{IncludeGlobs: []string{"**/*.go"}},
+			want:     []string{"--- START OF FILE: src/app/main.go ---"},
+		},
+		{
+			name:      "case-insensitive globs",
+			windows:   true,
+			config:    cmd.Config{IncludeGlobs: []string{"docs/*.MD"}},
+			want:      []string{"--- START OF FILE: Docs/Guide.md ---"},
+			wantNotIn: []string{"main.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.windows && runtime.GOOS != "windows" {
+				t.Skip("Windows paths are only handled on Windows")
+			}
+			inputDir := t.TempDir()
+			writeWorkspaceFiles(t, inputDir, files)
+
+			config := tt.config
+			config.InputDir = inputDir
+			if tt.inputDir != nil {
+				config.InputDir = tt.inputDir(inputDir)
+			}
+			config.OutputFile = filepath.Join(t.TempDir(), "corpus.txt")
+			if err := cmd.ProcessDirectory(config); err != nil {
+				t.Fatalf("ProcessDirectory() error = %v", err)
+			}
+
+			for _, want := range tt.want {
+				assertFileContains(t, config.OutputFile, want)
+			}
+			for _, unwanted := range tt.wantNotIn {
+				assertFileNotContains(t, config.OutputFile, unwanted)
+			}
+		})
+	}
+}
+
+func TestBackslashGlobs(t *testing.T) {
+	files := map[string]string{
+		"src/app/main.go":     "package main\n\nfunc main() {}\n",
+		"src/vendor/dep.go":   "package dep\n",
+		"docs/guide.md":       "# Guide\n",
+		"scripts/build.go":    "package scripts\n",
+		"scripts/lines/a.txt": "one\ntwo\nthree\nfour\n",
+	}
+	headLines := 1
+
+	tests := []struct {
+		name       string
+		config     cmd.Config
+		configYAML string
+		want       []string
+		wantNotIn  []string
+	}{
+		{
+			name: "include and exclude",
+			config: cmd.Config{
+				IncludeGlobs: []string{`src\**\*.go`},
+				ExcludeGlobs: []string{`src\vendor\**`},
+			},
+			want:      []string{"--- START OF FILE: src/app/main.go ---"},
+			wantNotIn: []string{"dep.go", "build.go", "guide.md"},
+		},
+		{
+			name: "rules",
+			config: cmd.Config{
+				IncludeGlobs: []string{"**/*.txt"},
+				Rules:        []cmd.Rule{{Glob: `scripts\lines\*.txt`, HeadLines: &headLines}},
+			},
+			want:      []string{"one\n... [truncated: 3 of 4 lines omitted]"},
+			wantNotIn: []string{"two"},
+		},
+		{
+			name: "config file",
+			configYAML: "includeGlobs:\n  - 'docs\\*.md'\n  - 'scripts\\**\\*.go'\n" +
+				"excludeGlobs:\n  - 'scripts\\build.go'\n",
+			want:      []string{"--- START OF FILE: docs/guide.md ---"},
+			wantNotIn: []string{"build.go", "main.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputDir := t.TempDir()
+			writeWorkspaceFiles(t, inputDir, files)
+
+			config := tt.config
+			config.InputDir = inputDir
+			config.OutputFile = filepath.Join(t.TempDir(), "corpus.txt")
+			var err error
+			if tt.configYAML != "" {
+				configPath := filepath.Join(t.TempDir(), "cpack.yaml")
+				if err := os.WriteFile(configPath, []byte(tt.configYAML), 0644); err != nil {
+					t.Fatalf("Failed to write config: %v", err)
+				}
+				err = cmd.ProcessDirectoryWithConfigFile(configPath, config)
+			} else {
+				err = cmd.ProcessDirectory(config)
+			}
+			if err != nil {
+				t.Fatalf("pack error = %v", err)
+			}
+
+			for _, want := range tt.want {
+				assertFileContains(t, config.OutputFile, want)
+			}
+			for _, unwanted := range tt.wantNotIn {
+				assertFileNotContains(t, config.OutputFile, unwanted)
+			}
+		})
+	}
+}
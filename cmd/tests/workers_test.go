@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestWorkers(t *testing.T) {
+	files := make(map[string]string)
+	for i := 0; i < 40; i++ {
+		files[fmt.Sprintf("pkg%d/file%d.go", i%5, i)] = fmt.Sprintf("package pkg%d\n\n// F%d does thing %d\nfunc F%d() int { return %d }\n%s",
+			i%5, i, i, i, i, strings.Repeat("// padding line\n", i))
+	}
+
+	tests := []struct {
+		name    string
+		config  cmd.Config
+		wantErr string
+	}{
+		{name: "verbose", config: cmd.Config{Verbose: true, IOWorkers: 4, HashWorkers: 2, TokenWorkers: 8}},
+		{name: "token budget", config: cmd.Config{MaxTokens: 900, Tokenizer: "cl100k_base", TokenWorkers: 4}},
+		{name: "verbose token budget", config: cmd.Config{Verbose: true, MaxTokens: 900, Tokenizer: "cl100k_base", IOWorkers: 3, HashWorkers: 2, TokenWorkers: 4}},
+		{name: "hashing only", config: cmd.Config{HashWorkers: 3}},
+		{name: "transformed", config: cmd.Config{Verbose: true, StripTrailingSpace: true, IOWorkers: 2, TokenWorkers: 2}},
+		{name: "streamed files", config: cmd.Config{Verbose: true, MaxMemory: "200B", IOWorkers: 2}},
+		{name: "negative", config: cmd.Config{HashWorkers: -1}, wantErr: "--hash-workers must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputDir := t.TempDir()
+			writeWorkspaceFiles(t, inputDir, files)
+			// Both packs write the same paths, which the manifest records
+			outputDir := t.TempDir()
+
+			pack := func(config cmd.Config) ([]byte, []byte, error) {
+				config.InputDir = inputDir
+				config.OutputFile = filepath.Join(outputDir, "corpus.txt")
+				config.ManifestFile = filepath.Join(outputDir, "manifest.json")
+				config.IncludeGlobs = []string{"**/*.go"}
+				config.Deterministic = true
+				if err := cmd.ProcessDirectory(config); err != nil {
+					return nil, nil, err
+				}
+				corpus, err := os.ReadFile(config.OutputFile)
+				if err != nil {
+					t.Fatalf("Failed to read output: %v", err)
+				}
+				manifest, err := os.ReadFile(config.ManifestFile)
+				if err != nil {
+					t.Fatalf("Failed to read manifest: %v", err)
+				}
+				return corpus, manifest, nil
+			}
+
+			corpus, manifest, err := pack(tt.config)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ProcessDirectory() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ProcessDirectory() error = %v", err)
+			}
+
+			inline := tt.config
+			inline.IOWorkers, inline.HashWorkers, inline.TokenWorkers = 0, 0, 0
+			wantCorpus, wantManifest, err := pack(inline)
+			if err != nil {
+				t.Fatalf("ProcessDirectory() error = %v", err)
+			}
+			if !bytes.Equal(corpus, wantCorpus) {
+				t.Errorf("corpus differs from an inline pack:\n%s\nwant:\n%s", corpus, wantCorpus)
+			}
+			if !bytes.Equal(manifest, wantManifest) {
+				t.Errorf("manifest differs from an inline pack:\n%s\nwant:\n%s", manifest, wantManifest)
+			}
+		})
+	}
+}
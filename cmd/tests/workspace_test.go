@@ -0,0 +1,178 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+func TestDetectWorkspaces(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []cmd.Workspace
+	}{
+		{
+			name: "go.work",
+			files: map[string]string{
+				"go.work":          "go 1.22\n\nuse (\n\t./api // service\n\t\"./lib\"\n)\n\nuse ./tools\n",
+				"api/go.mod":       "module api\n",
+				"lib/go.mod":       "module lib\n",
+				"tools/go.mod":     "module tools\n",
+				"unused/go.mod":    "module unused\n",
+				"api/main.go":      "package main\n",
+				"tools/gen/gen.go": "package gen\n",
+			},
+			want: []cmd.Workspace{
+				{Kind: cmd.WorkspaceGo, Path: "api"},
+				{Kind: cmd.WorkspaceGo, Path: "lib"},
+				{Kind: cmd.WorkspaceGo, Path: "tools"},
+			},
+		},
+		{
+			name: "package.json workspaces",
+			files: map[string]string{
+				"package.json":                  `{"name": "root", "workspaces": ["packages/*", "apps/web", "!packages/private"]}`,
+				"packages/ui/package.json":      `{"name": "ui"}`,
+				"packages/utils/package.json":   `{"name": "utils"}`,
+				"packages/private/package.json": `{"name": "private"}`,
+				"packages/docs/README.md":       "# Not a package\n",
+				"apps/web/package.json":         `{"name": "web"}`,
+				"node_modules/x/package.json":   `{"name": "x"}`,
+			},
+			want: []cmd.Workspace{
+				{Kind: cmd.WorkspaceNPM, Path: "apps/web"},
+				{Kind: cmd.WorkspaceNPM, Path: "packages/ui"},
+				{Kind: cmd.WorkspaceNPM, Path: "packages/utils"},
+			},
+		},
+		{
+			name: "package.json workspaces object",
+			files: map[string]string{
+				"package.json":             `{"workspaces": {"packages": ["libs/*"]}}`,
+				"libs/core/package.json":   `{"name": "core"}`,
+				"other/thing/package.json": `{"name": "thing"}`,
+			},
+			want: []cmd.Workspace{{Kind: cmd.WorkspaceNPM, Path: "libs/core"}},
+		},
+		{
+			name: "Cargo workspace",
+			files: map[string]string{
+				"Cargo.toml":               "[package]\nname = \"root\"\n\n[workspace]\nmembers = [\n    \"crates/*\", # all crates\n    \"cli\",\n]\nexclude = [\"crates/legacy\"]\n\n[dependencies]\nmembers = 1\n",
+				"crates/core/Cargo.toml":   "[package]\nname = \"core\"\n",
+				"crates/legacy/Cargo.toml": "[package]\nname = \"legacy\"\n",
+				"cli/Cargo.toml":           "[package]\nname = \"cli\"\n",
+			},
+			want: []cmd.Workspace{
+				{Kind: cmd.WorkspaceCargo, Path: "cli"},
+				{Kind: cmd.WorkspaceCargo, Path: "crates/core"},
+			},
+		},
+		{
+			name: "no workspace",
+			files: map[string]string{
+				"package.json": `{"name": "single"}`,
+				"go.mod":       "module single\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			writeWorkspaceFiles(t, tempDir, tt.files)
+
+			got, err := cmd.DetectWorkspaces(tempDir)
+			if err != nil {
+				t.Fatalf("DetectWorkspaces failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DetectWorkspaces() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPerWorkspace(t *testing.T) {
+	tempDir := t.TempDir()
+	writeWorkspaceFiles(t, tempDir, map[string]string{
+		"go.work":              "go 1.22\n\nuse ./services/api\nuse ./lib\n",
+		"services/api/go.mod":  "module api\n",
+		"services/api/main.go": "package main\n",
+		"lib/go.mod":           "module lib\n",
+		"lib/lib.go":           "package lib\n",
+		"scripts/build.go":     "package scripts\n",
+	})
+	outDir := t.TempDir()
+
+	config := cmd.Config{
+		InputDir:     tempDir,
+		OutputFile:   filepath.Join(outDir, "corpus.txt"),
+		ManifestFile: filepath.Join(outDir, "manifest.json"),
+		IncludeGlobs: []string{"**/*.go"},
+		PerWorkspace: true,
+		NoGitHeader:  true,
+	}
+	if err := cmd.ProcessDirectory(config); err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+
+	apiCorpus := filepath.Join(outDir, "corpus-services-api.txt")
+	assertFileContains(t, apiCorpus, "main.go")
+	assertFileNotContains(t, apiCorpus, "lib.go")
+	assertFileContains(t, filepath.Join(outDir, "corpus-lib.txt"), "lib.go")
+	assertFileContains(t, filepath.Join(outDir, "manifest-lib.json"), "lib.go")
+	assertFileNotExists(t, config.OutputFile)
+
+	config.InputDir = filepath.Join(tempDir, "lib")
+	if err := cmd.ProcessDirectory(config); err == nil {
+		t.Error("Expected an error for a directory without workspaces")
+	}
+}
+
+func TestPerWorkspaceRootMember(t *testing.T) {
+	tempDir := t.TempDir()
+	writeWorkspaceFiles(t, tempDir, map[string]string{
+		"go.work":    "go 1.22\n\nuse .\nuse ./lib\n",
+		"go.mod":     "module root\n",
+		"main.go":    "package main\n",
+		"lib/go.mod": "module lib\n",
+		"lib/lib.go": "package lib\n",
+	})
+
+	// The corpora are written inside the root member, which packs .txt files
+	config := cmd.Config{
+		InputDir:     tempDir,
+		OutputFile:   filepath.Join(tempDir, "context.txt"),
+		IncludeGlobs: []string{"**/*.go", "**/*.txt"},
+		PerWorkspace: true,
+		NoGitHeader:  true,
+	}
+	for run := 0; run < 2; run++ {
+		if err := cmd.ProcessDirectory(config); err != nil {
+			t.Fatalf("ProcessDirectory run %d failed: %v", run+1, err)
+		}
+	}
+
+	rootCorpus := filepath.Join(tempDir, "context-root.txt")
+	assertFileContains(t, rootCorpus, "main.go")
+	assertFileNotContains(t, rootCorpus, "context-lib.txt")
+	assertFileNotContains(t, rootCorpus, "context-root.txt")
+}
+
+// writeWorkspaceFiles creates files under dir from a path to content map
+func writeWorkspaceFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		fullPath := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", path, err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+	"gopkg.in/yaml.v3"
+)
+
+func TestYAMLFormat(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"main.go":      "package main\n\n// keep me\nfunc main() {}\n",
+		"notes.txt":    "trailing space   \n# not a yaml comment\n\n\n",
+		"no-eol.sh":    "echo hi",
+		"leading.md":   "  indented first line\nsecond\n",
+		"docs/tabs.py": "def f():\n\treturn 1\n",
+		// Invalid UTF-8 is carried as !!binary
+		"latin1.txt": "caf\xe9\n",
+	}
+	writeWorkspaceFiles(t, tempDir, files)
+	outputPath := filepath.Join(t.TempDir(), "corpus.yaml")
+
+	config := cmd.Config{
+		InputDir:     tempDir,
+		OutputFile:   outputPath,
+		OutputFormat: cmd.FormatYAML,
+		IncludeGlobs: []string{"**/*"},
+		Instructions: "Review carefully",
+		NoGitHeader:  true,
+	}
+	if err := cmd.ProcessDirectory(config); err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if strings.Contains(string(data), "--- START OF FILE") {
+		t.Error("Document should not contain text format markers")
+	}
+	if !strings.Contains(string(data), "content: |") {
+		t.Error("Expected multi-line content as block scalars")
+	}
+
+	var doc struct {
+		Header string `yaml:"header"`
+		Files  []struct {
+			Path     string `yaml:"path"`
+			Language string `yaml:"language"`
+			Content  string `yaml:"content"`
+		} `yaml:"files"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Output is not valid YAML: %v", err)
+	}
+	if !strings.Contains(doc.Header, "Review carefully") {
+		t.Errorf("Expected instructions in the header, got %q", doc.Header)
+	}
+	if len(doc.Files) != len(files) {
+		t.Fatalf("Expected %d files, got %d", len(files), len(doc.Files))
+	}
+	for _, f := range doc.Files {
+		if f.Content != files[f.Path] {
+			t.Errorf("%s: expected content %q, got %q", f.Path, files[f.Path], f.Content)
+		}
+		if f.Path == "main.go" && f.Language != "go" {
+			t.Errorf("Expected main.go to be go, got %q", f.Language)
+		}
+	}
+
+	// The document loads back as a corpus with the same files
+	corpus, err := cmd.LoadCorpus(outputPath)
+	if err != nil {
+		t.Fatalf("LoadCorpus failed: %v", err)
+	}
+	if len(corpus.Files) != len(files) {
+		t.Fatalf("Expected %d files in the loaded corpus, got %d", len(files), len(corpus.Files))
+	}
+	for path, content := range files {
+		f, ok := corpus.Find(path)
+		if !ok {
+			t.Errorf("Expected %s in the loaded corpus", path)
+			continue
+		}
+		if got := string(corpus.Content(f)); got != content {
+			t.Errorf("%s: expected loaded content %q, got %q", path, content, got)
+		}
+	}
+}
+
+func TestYAMLFormatValidation(t *testing.T) {
+	tempDir, cleanup := createTestFiles(t)
+	defer cleanup()
+
+	for _, config := range []cmd.Config{{Gzip: true}, {Append: true}} {
+		config.InputDir = tempDir
+		config.OutputFile = filepath.Join(t.TempDir(), "out.yaml")
+		config.OutputFormat = cmd.FormatYAML
+		if err := cmd.ProcessDirectory(config); err == nil {
+			t.Errorf("Expected %+v to be rejected with the yaml format", config)
+		}
+	}
+}
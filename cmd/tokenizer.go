@@ -0,0 +1,395 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"embed"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+)
+
+//go:embed tokenizers/*.tiktoken.gz
+var tokenizerFS embed.FS
+
+// TokenizerEstimate is the default tokenizer: four bytes per token
+const TokenizerEstimate = "estimate"
+
+// Tokenizer counts the tokens a model would see for some content
+type Tokenizer interface {
+	CountTokens(content []byte) int
+}
+
+// TokenizerFunc adapts a function to the Tokenizer interface
+type TokenizerFunc func(content []byte) int
+
+// CountTokens calls f(content)
+func (f TokenizerFunc) CountTokens(content []byte) int {
+	return f(content)
+}
+
+// bpeEncoding describes a tiktoken byte-pair encoding
+type bpeEncoding struct {
+	pattern string // Pre-tokenizer regex, without the trailing-whitespace lookahead
+	url     string // Where the rank file is downloaded from when not embedded
+	sha256  string // Checksum of the uncompressed rank file
+}
+
+const (
+	cl100kPattern = `(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+`
+	o200kPattern  = `[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?|` +
+		`[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?|` +
+		`\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n/]*|\s*[\r\n]+|\s+`
+)
+
+var bpeEncodings = map[string]bpeEncoding{
+	"cl100k_base": {
+		pattern: cl100kPattern,
+		url:     "https://openaipublic.blob.core.windows.net/encodings/cl100k_base.tiktoken",
+		sha256:  "223921b76ee99bde995b7ff738513eef100fb51d18c93597a113bcffe865b2a7",
+	},
+	"o200k_base": {
+		pattern: o200kPattern,
+		url:     "https://openaipublic.blob.core.windows.net/encodings/o200k_base.tiktoken",
+		sha256:  "446a9538cb6c348e3516120d7c08b09f57c36495e2acfffe59a5bf8b0cfb1a2d",
+	},
+}
+
+var (
+	tokenizersMu sync.Mutex
+	tokenizers   = map[string]Tokenizer{
+		TokenizerEstimate: TokenizerFunc(EstimateTokens),
+	}
+)
+
+// RegisterTokenizer makes a tokenizer available under name
+func RegisterTokenizer(name string, t Tokenizer) {
+	tokenizersMu.Lock()
+	defer tokenizersMu.Unlock()
+	tokenizers[name] = t
+}
+
+// GetTokenizer returns the named tokenizer, loading a BPE encoding from the
+// embedded data or the local cache the first time it is used
+func GetTokenizer(name string) (Tokenizer, error) {
+	if name == "" {
+		name = TokenizerEstimate
+	}
+
+	tokenizersMu.Lock()
+	defer tokenizersMu.Unlock()
+	if t, ok := tokenizers[name]; ok {
+		return t, nil
+	}
+
+	enc, ok := bpeEncodings[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown tokenizer: %s (available: %s)", name, strings.Join(tokenizerNames(), ", "))
+	}
+	ranks, err := loadRanks(name)
+	if err != nil {
+		return nil, err
+	}
+
+	t := newBPETokenizer(ranks, enc.pattern)
+	tokenizers[name] = t
+	return t, nil
+}
+
+// TokenizerNames lists the registered tokenizers and known BPE encodings
+func TokenizerNames() []string {
+	tokenizersMu.Lock()
+	defer tokenizersMu.Unlock()
+	return tokenizerNames()
+}
+
+func tokenizerNames() []string {
+	seen := make(map[string]bool)
+	for name := range tokenizers {
+		seen[name] = true
+	}
+	for name := range bpeEncodings {
+		seen[name] = true
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// tokenizerCacheDir is where downloaded encodings are kept, by default
+// ~/.cpack/cache/tokenizers. CPACK_CACHE_DIR overrides the cache root.
+func tokenizerCacheDir() (string, error) {
+	if dir := os.Getenv("CPACK_CACHE_DIR"); dir != "" {
+		return filepath.Join(dir, "tokenizers"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error locating tokenizer cache: %w", err)
+	}
+	return filepath.Join(home, ".cpack", "cache", "tokenizers"), nil
+}
+
+// loadRanks reads an encoding's rank file from the binary or the cache
+func loadRanks(name string) (map[string]int, error) {
+	if f, err := tokenizerFS.Open("tokenizers/" + name + ".tiktoken.gz"); err == nil {
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("error reading embedded tokenizer %s: %w", name, err)
+		}
+		return parseRanks(gz)
+	}
+
+	dir, err := tokenizerCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, name+".tiktoken"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("tokenizer %s is not cached; run 'cpack tokenizer fetch %s' or copy it into %s", name, name, dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading tokenizer %s: %w", name, err)
+	}
+	// A cached file is checked as a download is, since anything can replace it
+	if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != bpeEncodings[name].sha256 {
+		return nil, fmt.Errorf("tokenizer %s in %s failed checksum verification; run 'cpack tokenizer fetch %s' to download it again", name, dir, name)
+	}
+	return parseRanks(bytes.NewReader(data))
+}
+
+// parseRanks parses the tiktoken format: one base64 token and its rank per line
+func parseRanks(r io.Reader) (map[string]int, error) {
+	ranks := make(map[string]int, 100000)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		sep := bytes.IndexByte(line, ' ')
+		if sep < 0 {
+			return nil, fmt.Errorf("invalid tokenizer line: %q", line)
+		}
+		token, err := base64.StdEncoding.DecodeString(string(line[:sep]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid tokenizer token: %w", err)
+		}
+		rank, err := strconv.Atoi(string(line[sep+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid tokenizer rank: %w", err)
+		}
+		ranks[string(token)] = rank
+	}
+	return ranks, scanner.Err()
+}
+
+// FetchTokenizer downloads an encoding into the cache, verifying its checksum
+func FetchTokenizer(name string) (string, error) {
+	enc, ok := bpeEncodings[name]
+	if !ok {
+		return "", fmt.Errorf("unknown tokenizer: %s", name)
+	}
+
+	dir, err := tokenizerCacheDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("error creating tokenizer cache: %w", err)
+	}
+
+	resp, err := http.Get(enc.url)
+	if err != nil {
+		return "", fmt.Errorf("error downloading tokenizer %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("error downloading tokenizer %s: %s", name, resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("error downloading tokenizer %s: %w", name, err)
+	}
+	if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != enc.sha256 {
+		return "", fmt.Errorf("tokenizer %s failed checksum verification", name)
+	}
+
+	path := filepath.Join(dir, name+".tiktoken")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("error writing tokenizer %s: %w", name, err)
+	}
+	return path, nil
+}
+
+// bpeTokenizer counts tokens with a tiktoken-compatible byte-pair encoding
+type bpeTokenizer struct {
+	ranks   map[string]int
+	pattern *regexp.Regexp
+
+	mu    sync.Mutex
+	cache map[string]int // Token counts of previously seen pieces
+}
+
+func newBPETokenizer(ranks map[string]int, pattern string) *bpeTokenizer {
+	return &bpeTokenizer{
+		ranks:   ranks,
+		pattern: regexp.MustCompile(`\A(?:` + pattern + `)`),
+		cache:   make(map[string]int),
+	}
+}
+
+// CountTokens splits content into pieces and counts the BPE tokens of each
+func (t *bpeTokenizer) CountTokens(content []byte) int {
+	count := 0
+	for len(content) > 0 {
+		loc := t.pattern.FindIndex(content)
+		end := 1
+		if loc != nil && loc[1] > 0 {
+			end = loc[1]
+		}
+
+		// RE2 has no lookahead for tiktoken's `\s+(?!\S)`: a whitespace run
+		// followed by text leaves its last character to the next piece
+		if end < len(content) && isSpaceRun(content[:end]) && !isSpaceAt(content, end) {
+			if _, size := utf8.DecodeLastRune(content[:end]); end-size > 0 {
+				end -= size
+			}
+		}
+
+		count += t.countPiece(content[:end])
+		content = content[end:]
+	}
+	return count
+}
+
+func (t *bpeTokenizer) countPiece(piece []byte) int {
+	if _, ok := t.ranks[string(piece)]; ok {
+		return 1
+	}
+
+	t.mu.Lock()
+	n, ok := t.cache[string(piece)]
+	t.mu.Unlock()
+	if ok {
+		return n
+	}
+
+	n = t.mergeCount(piece)
+	t.mu.Lock()
+	t.cache[string(piece)] = n
+	t.mu.Unlock()
+	return n
+}
+
+// mergeCount applies byte-pair merges, lowest rank first, and returns how
+// many tokens are left
+func (t *bpeTokenizer) mergeCount(piece []byte) int {
+	// bounds[i] is the start of part i; the last entry is len(piece)
+	bounds := make([]int, len(piece)+1)
+	for i := range bounds {
+		bounds[i] = i
+	}
+
+	for len(bounds) > 2 {
+		best, bestRank := -1, 0
+		for i := 0; i+2 < len(bounds); i++ {
+			rank, ok := t.ranks[string(piece[bounds[i]:bounds[i+2]])]
+			if ok && (best < 0 || rank < bestRank) {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		bounds = append(bounds[:best+1], bounds[best+2:]...)
+	}
+
+	return len(bounds) - 1
+}
+
+// isSpaceRun reports whether b is all whitespace without line breaks, which
+// is what only the `\s+` alternative matches
+func isSpaceRun(b []byte) bool {
+	for _, r := range string(b) {
+		if !unicode.IsSpace(r) || r == '\n' || r == '\r' {
+			return false
+		}
+	}
+	return len(b) > 0
+}
+
+func isSpaceAt(b []byte, i int) bool {
+	r, _ := utf8.DecodeRune(b[i:])
+	return unicode.IsSpace(r)
+}
+
+var (
+	tokenizerCmd = &cobra.Command{
+		Use:   "tokenizer",
+		Short: "List and download tokenizers used for token counts",
+	}
+
+	tokenizerListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List tokenizers and whether they are available offline",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, _ := tokenizerCacheDir()
+			out := cmd.OutOrStdout()
+			for _, name := range TokenizerNames() {
+				status := "built in"
+				if _, ok := bpeEncodings[name]; ok {
+					if _, err := tokenizerFS.Open("tokenizers/" + name + ".tiktoken.gz"); err == nil {
+						status = "embedded"
+					} else if _, err := os.Stat(filepath.Join(dir, name+".tiktoken")); err == nil {
+						status = "cached"
+					} else {
+						status = "not downloaded"
+					}
+				}
+				fmt.Fprintf(out, "%-12s %s\n", name, status)
+			}
+			return nil
+		},
+	}
+
+	tokenizerFetchCmd = &cobra.Command{
+		Use:   "fetch <name>",
+		Short: "Download a tokenizer into the local cache for offline use",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := FetchTokenizer(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Saved %s to %s\n", args[0], path)
+			return nil
+		},
+	}
+)
+
+func init() {
+	tokenizerCmd.AddCommand(tokenizerListCmd, tokenizerFetchCmd)
+	rootCmd.AddCommand(tokenizerCmd)
+}
@@ -0,0 +1,16 @@
+package cmd
+
+// EstimateTokens approximates the number of LLM tokens in content using the
+// common rule of thumb of four bytes per token
+func EstimateTokens(content []byte) int {
+	return (len(content) + 3) / 4
+}
+
+// countTokens counts content with the configured tokenizer, falling back to
+// the estimate if it cannot be loaded
+func (p *fileProcessor) countTokens(content []byte) int {
+	if t, err := GetTokenizer(p.config.Tokenizer); err == nil {
+		return t.CountTokens(content)
+	}
+	return EstimateTokens(content)
+}
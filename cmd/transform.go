@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Line ending normalizations
+const (
+	EOLLF   = "lf"   // Every line ends in \n
+	EOLCRLF = "crlf" // Every line ends in \r\n
+)
+
+// tabSensitiveNames are files whose tabs carry meaning, so --tabs-to-spaces
+// leaves them alone
+var tabSensitiveNames = []string{"Makefile", "GNUmakefile", "makefile", "*.mk", "*.tsv"}
+
+// rewritesWhitespace reports whether any whitespace transform is enabled
+// for a file's config
+func rewritesWhitespace(config *Config) bool {
+	return config.NormalizeEOL != "" || config.StripTrailingSpace || config.TabsToSpaces > 0 ||
+		config.CollapseBlankLines > 0
+}
+
+// rewritesContent reports whether a file's content is changed on its way
+// into the corpus, which keeps it from being streamed
+func rewritesContent(config *Config) bool {
+	return config.Compress || config.HeadLines > 0 || config.MaxFileTokens > 0 || rewritesWhitespace(config) ||
+		config.NormalizeUnicode || config.StripInvisible || config.ExportedOnly || config.DocsOnly
+}
+
+// transformContent applies the text transforms of config to a file's
+// content: the Go API filter first, then Unicode cleanup, then whitespace
+// normalization
+func transformContent(content []byte, relPath string, config *Config) []byte {
+	if config.ExportedOnly && filepath.Ext(relPath) == ".go" {
+		content = exportedOnly(content)
+	}
+	if config.StripInvisible {
+		content = stripInvisible(content)
+	}
+	if config.NormalizeUnicode {
+		content = norm.NFC.Bytes(content)
+	}
+	return normalizeWhitespace(content, relPath, config)
+}
+
+// isInvisible reports whether r is a zero-width, bidirectional control or
+// tag character. They render as nothing, yet a model reads them, so they
+// can hide instructions or reorder what a reviewer sees.
+func isInvisible(r rune) bool {
+	switch {
+	case r >= 0x200B && r <= 0x200F: // Zero-width space, joiners and directional marks
+		return true
+	case r >= 0x202A && r <= 0x202E: // Bidirectional embeddings and overrides
+		return true
+	case r >= 0x2060 && r <= 0x2064: // Word joiner and invisible operators
+		return true
+	case r >= 0x2066 && r <= 0x2069: // Bidirectional isolates
+		return true
+	case r >= 0xE0000 && r <= 0xE007F: // Tag characters, which can spell out hidden ASCII
+		return true
+	}
+	return r == 0x061C || r == 0x180E || r == 0xFEFF
+}
+
+// stripInvisible removes invisible characters from content, copying
+// invalid UTF-8 as is
+func stripInvisible(content []byte) []byte {
+	out := make([]byte, 0, len(content))
+	for len(content) > 0 {
+		r, size := utf8.DecodeRune(content)
+		if !isInvisible(r) {
+			out = append(out, content[:size]...)
+		}
+		content = content[size:]
+	}
+	return out
+}
+
+// normalizeWhitespace applies the whitespace transforms of config to a
+// file's content. Only line endings, trailing blanks, tabs and runs of
+// blank lines change; the text itself never does.
+func normalizeWhitespace(content []byte, relPath string, config *Config) []byte {
+	if !rewritesWhitespace(config) {
+		return content
+	}
+
+	// Work on \n line endings and restore \r\n at the end when asked
+	crlf := config.NormalizeEOL == EOLCRLF
+	if config.NormalizeEOL != "" {
+		content = bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+		content = bytes.ReplaceAll(content, []byte("\r"), []byte("\n"))
+	}
+
+	tabs := config.TabsToSpaces
+	if tabs > 0 && isTabSensitive(relPath) {
+		tabs = 0
+	}
+	if config.StripTrailingSpace || tabs > 0 || config.CollapseBlankLines > 0 {
+		lines := bytes.Split(content, []byte("\n"))
+		if config.CollapseBlankLines > 0 {
+			lines = collapseBlankLines(lines, config.CollapseBlankLines)
+		}
+		for i, line := range lines {
+			if config.StripTrailingSpace {
+				// A \r left by an unnormalized CRLF ending is kept
+				cr := bytes.HasSuffix(line, []byte("\r"))
+				line = bytes.TrimRight(bytes.TrimSuffix(line, []byte("\r")), " \t")
+				if cr {
+					line = append(line, '\r')
+				}
+			}
+			if tabs > 0 {
+				line = expandTabs(line, tabs)
+			}
+			lines[i] = line
+		}
+		content = bytes.Join(lines, []byte("\n"))
+	}
+
+	if crlf {
+		content = bytes.ReplaceAll(content, []byte("\n"), []byte("\r\n"))
+	}
+	return content
+}
+
+// collapseBlankLines drops the blank lines of lines beyond the first max of
+// each run. A line of only spaces, tabs or a \r counts as blank and is kept
+// as it is.
+func collapseBlankLines(lines [][]byte, max int) [][]byte {
+	// The last element follows the final line ending, so it is never a line of a run
+	last := len(lines) - 1
+	kept := lines[:0]
+	run := 0
+	for i, line := range lines {
+		if i == last || len(bytes.Trim(line, " \t\r")) > 0 {
+			run = 0
+		} else if run++; run > max {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return kept
+}
+
+// isTabSensitive reports whether relPath is a file whose tabs are syntax
+func isTabSensitive(relPath string) bool {
+	base := filepath.Base(relPath)
+	for _, name := range tabSensitiveNames {
+		if matched, _ := filepath.Match(name, base); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// expandTabs replaces each tab in line with the spaces that reach the next
+// tab stop, so columns stay aligned
+func expandTabs(line []byte, width int) []byte {
+	if bytes.IndexByte(line, '\t') < 0 {
+		return line
+	}
+
+	var b bytes.Buffer
+	column := 0
+	for len(line) > 0 {
+		r, size := utf8.DecodeRune(line)
+		if r == '\t' {
+			spaces := width - column%width
+			b.WriteString(strings.Repeat(" ", spaces))
+			column += spaces
+		} else {
+			// Invalid UTF-8 is copied as is
+			b.Write(line[:size])
+			column++
+		}
+		line = line[size:]
+	}
+	return b.Bytes()
+}
+
+// validateWhitespace checks the whitespace transform options
+func validateWhitespace(config *Config) error {
+	switch config.NormalizeEOL {
+	case "", EOLLF, EOLCRLF:
+	default:
+		return fmt.Errorf("unsupported line ending: %s (expected lf or crlf)", config.NormalizeEOL)
+	}
+	if config.TabsToSpaces < 0 {
+		return fmt.Errorf("--tabs-to-spaces must not be negative, got %d", config.TabsToSpaces)
+	}
+	if config.CollapseBlankLines < 0 {
+		return fmt.Errorf("--collapse-blank-lines must not be negative, got %d", config.CollapseBlankLines)
+	}
+	return nil
+}
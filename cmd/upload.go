@@ -0,0 +1,264 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Providers the corpus can be uploaded to with --upload
+const (
+	UploadOpenAI    = "openai"
+	UploadAnthropic = "anthropic"
+	UploadGemini    = "gemini"
+)
+
+// uploadProviders describes each provider's files API: the endpoint and
+// the environment variables its key is read from, in order
+var uploadProviders = map[string]struct {
+	url  string
+	keys []string
+}{
+	UploadOpenAI:    {url: "https://api.openai.com/v1/files", keys: []string{"OPENAI_API_KEY"}},
+	UploadAnthropic: {url: "https://api.anthropic.com/v1/files", keys: []string{"ANTHROPIC_API_KEY"}},
+	UploadGemini:    {url: "https://generativelanguage.googleapis.com/upload/v1beta/files", keys: []string{"GEMINI_API_KEY", "GOOGLE_API_KEY"}},
+}
+
+// uploadTimeout bounds each request made by an upload
+const uploadTimeout = 10 * time.Minute
+
+// UploadOptions controls UploadCorpus
+type UploadOptions struct {
+	Provider string // openai, anthropic or gemini
+	Path     string // Corpus file to upload
+	APIKey   string // Key for the provider, read from its environment variable if empty
+	URL      string // Files API endpoint, the provider's if empty
+}
+
+// UploadCorpus uploads a corpus file through the provider's files API and
+// returns the id the provider gave it
+func UploadCorpus(opts UploadOptions) (string, error) {
+	provider, ok := uploadProviders[opts.Provider]
+	if !ok {
+		return "", fmt.Errorf("unsupported upload provider: %s (expected openai, anthropic or gemini)", opts.Provider)
+	}
+	if opts.APIKey == "" {
+		opts.APIKey = uploadAPIKey(opts.Provider)
+	}
+	if opts.APIKey == "" {
+		return "", fmt.Errorf("--upload %s requires %s to be set", opts.Provider, provider.keys[0])
+	}
+	if opts.URL == "" {
+		opts.URL = provider.url
+	}
+
+	client := &http.Client{Timeout: uploadTimeout}
+	var id string
+	var err error
+	switch opts.Provider {
+	case UploadGemini:
+		id, err = uploadGemini(client, opts)
+	default:
+		id, err = uploadMultipart(client, opts)
+	}
+	if err == nil && id == "" {
+		err = fmt.Errorf("no file id in the response")
+	}
+	if err != nil {
+		return "", fmt.Errorf("error uploading %s to %s: %w", filepath.Base(opts.Path), opts.Provider, err)
+	}
+	return id, nil
+}
+
+// uploadAPIKey returns the provider's key from the environment
+func uploadAPIKey(provider string) string {
+	for _, name := range uploadProviders[provider].keys {
+		if key := os.Getenv(name); key != "" {
+			return key
+		}
+	}
+	return ""
+}
+
+// uploadContentType returns the media type a corpus file is uploaded as
+func uploadContentType(path string) string {
+	if t := mime.TypeByExtension(filepath.Ext(path)); t != "" {
+		return t
+	}
+	return "text/plain"
+}
+
+// uploadMultipart uploads the file as a multipart form, as the OpenAI and
+// Anthropic files APIs take it, streaming it from disk
+func uploadMultipart(client *http.Client, opts UploadOptions) (string, error) {
+	file, err := os.Open(opts.Path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	body, pipe := io.Pipe()
+	form := multipart.NewWriter(pipe)
+	go func() {
+		err := writeUploadForm(form, file, opts)
+		if err == nil {
+			err = form.Close()
+		}
+		pipe.CloseWithError(err)
+	}()
+
+	req, err := http.NewRequest(http.MethodPost, opts.URL, body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	switch opts.Provider {
+	case UploadAnthropic:
+		req.Header.Set("x-api-key", opts.APIKey)
+		req.Header.Set("anthropic-version", "2023-06-01")
+		req.Header.Set("anthropic-beta", "files-api-2025-04-14")
+	default:
+		req.Header.Set("Authorization", "Bearer "+opts.APIKey)
+	}
+
+	var result struct {
+		ID string `json:"id"`
+	}
+	if err := doUpload(client, req, &result); err != nil {
+		return "", err
+	}
+	return result.ID, nil
+}
+
+// writeUploadForm writes the form fields and the file itself
+func writeUploadForm(form *multipart.Writer, file io.Reader, opts UploadOptions) error {
+	if opts.Provider == UploadOpenAI {
+		// user_data files can be passed to any model as input
+		if err := form.WriteField("purpose", "user_data"); err != nil {
+			return err
+		}
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename=%q`, filepath.Base(opts.Path)))
+	header.Set("Content-Type", uploadContentType(opts.Path))
+	part, err := form.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(part, file)
+	return err
+}
+
+// uploadGemini uploads the file with the Gemini files API's resumable
+// protocol: one request to start the upload, one to send the file
+func uploadGemini(client *http.Client, opts UploadOptions) (string, error) {
+	file, err := os.Open(opts.Path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"file": map[string]string{"display_name": filepath.Base(opts.Path)},
+	})
+	start, err := http.NewRequest(http.MethodPost, opts.URL, bytes.NewReader(metadata))
+	if err != nil {
+		return "", err
+	}
+	start.Header.Set("x-goog-api-key", opts.APIKey)
+	start.Header.Set("Content-Type", "application/json")
+	start.Header.Set("X-Goog-Upload-Protocol", "resumable")
+	start.Header.Set("X-Goog-Upload-Command", "start")
+	start.Header.Set("X-Goog-Upload-Header-Content-Length", strconv.FormatInt(info.Size(), 10))
+	start.Header.Set("X-Goog-Upload-Header-Content-Type", uploadContentType(opts.Path))
+
+	resp, err := client.Do(start)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return "", fmt.Errorf("unexpected status %s starting the upload", resp.Status)
+	}
+	uploadURL := resp.Header.Get("X-Goog-Upload-URL")
+	if uploadURL == "" {
+		return "", fmt.Errorf("no upload URL in the response")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, uploadURL, file)
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = info.Size()
+	req.Header.Set("X-Goog-Upload-Offset", "0")
+	req.Header.Set("X-Goog-Upload-Command", "upload, finalize")
+
+	var result struct {
+		File struct {
+			Name string `json:"name"`
+		} `json:"file"`
+	}
+	if err := doUpload(client, req, &result); err != nil {
+		return "", err
+	}
+	return result.File.Name, nil
+}
+
+// doUpload sends an upload request and decodes its JSON response. Error
+// responses are reported with the start of their body, which names the
+// problem.
+func doUpload(client *http.Client, req *http.Request, result interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		if len(data) > 512 {
+			data = data[:512]
+		}
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, bytes.TrimSpace(data))
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("error parsing response: %w", err)
+	}
+	return nil
+}
+
+// validateUpload checks that --upload names a provider whose key is set,
+// and that a single corpus file is written to upload
+func validateUpload(config *Config) error {
+	if config.Upload == "" {
+		return nil
+	}
+	provider, ok := uploadProviders[config.Upload]
+	if !ok {
+		return fmt.Errorf("unsupported upload provider: %s (expected openai, anthropic or gemini)", config.Upload)
+	}
+	if config.OutputFormat == FormatLinkFarm || config.PerWorkspace || config.SplitByDir > 0 {
+		return fmt.Errorf("--upload requires a single corpus file; it cannot be combined with linkfarm, --per-workspace or --split-by-dir")
+	}
+	if uploadAPIKey(config.Upload) == "" {
+		return fmt.Errorf("--upload %s requires %s to be set", config.Upload, provider.keys[0])
+	}
+	return nil
+}
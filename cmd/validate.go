@@ -0,0 +1,254 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// CorpusFormat is the version of the text corpus layout, stamped on the
+// first line of every text corpus. It changes only when a separator or
+// block changes meaning.
+const CorpusFormat = "1"
+
+// Markers of the version line and of the index that closes a text corpus
+const (
+	versionMarker  = "--- CPACK CORPUS: "
+	indexMarker    = "--- INDEX ---\n"
+	endIndexMarker = "--- END OF INDEX ---\n"
+)
+
+// corpusBlocks are the opening and closing markers of the blocks outside
+// files, without their line endings, which --compress may have replaced
+var corpusBlocks = [][2]string{
+	{"--- INSTRUCTIONS ---", "--- END OF INSTRUCTIONS ---"},
+	{"--- GIT ---", "--- END OF GIT ---"},
+	{"--- GIT LOG ---", "--- END OF GIT LOG ---"},
+	{"--- CORPUS PACKER SUMMARY ---", "--- END OF SUMMARY ---"},
+	{"--- INDEX ---", "--- END OF INDEX ---"},
+	{"--- SYMBOLS ---", "--- END OF SYMBOLS ---"},
+	{"--- IMPORT GRAPH ---", "--- END OF IMPORT GRAPH ---"},
+}
+
+// indexEntry is one file of a corpus index: its content as packed, which
+// the manifest does not describe once transforms have changed it
+type indexEntry struct {
+	path   string
+	size   int
+	sha256 string
+}
+
+// newIndexEntry indexes a file's content as it appears in the corpus
+func newIndexEntry(path string, content []byte) indexEntry {
+	sum := sha256.Sum256(content)
+	return indexEntry{path: path, size: len(content), sha256: hex.EncodeToString(sum[:])}
+}
+
+// stampsCorpus reports whether a config's output is a text corpus, which
+// opens with the version line and closes with the index
+func stampsCorpus(config *Config) bool {
+	return config.OutputFormat == "" || config.OutputFormat == FormatText
+}
+
+// formatVersionLine returns the line that opens a text corpus
+func formatVersionLine() string {
+	return fmt.Sprintf("%sformat %s, cpack %s%s\n\n", versionMarker, CorpusFormat, Version, markerClose)
+}
+
+// formatIndex returns the block that closes a text corpus, one file per
+// line as "sha256 size path", in corpus order
+func formatIndex(entries []indexEntry) string {
+	var b strings.Builder
+	b.WriteString(indexMarker)
+	for _, e := range entries {
+		fmt.Fprintf(&b, "%s %d %s\n", e.sha256, e.size, e.path)
+	}
+	b.WriteString(endIndexMarker)
+	return b.String()
+}
+
+// stripBookkeeping removes the frontmatter and version line from the head
+// of a corpus and the index from the start of its tail, which renderers
+// leave out
+func stripBookkeeping(head, tail []byte) ([]byte, []byte) {
+	head = stripFrontmatter(head)
+	if bytes.HasPrefix(head, []byte(versionMarker)) {
+		if i := bytes.IndexByte(head, '\n'); i >= 0 {
+			head = bytes.TrimLeft(head[i+1:], "\n")
+		}
+	}
+	if rest := bytes.TrimLeft(tail, "\n"); bytes.HasPrefix(rest, []byte(indexMarker)) {
+		if i := bytes.Index(rest, []byte(endIndexMarker)); i >= 0 {
+			tail = rest[i+len(endIndexMarker):]
+		}
+	}
+	return head, tail
+}
+
+// ValidateCorpus checks a decoded text corpus and returns its problems: a
+// missing or unknown version line, unbalanced separators, and files that
+// do not match the index. A corpus without problems, or an empty one from a
+// pack that selected no files, returns nil.
+func ValidateCorpus(data []byte) []string {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+
+	var problems []string
+	problem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	// Any frontmatter comes before the version line
+	data = stripFrontmatter(data)
+	if !bytes.HasPrefix(data, []byte(versionMarker)) {
+		problem("no version line; the corpus is from an older release or was edited")
+	} else {
+		line := string(data[len(versionMarker):])
+		if i := strings.IndexByte(line, '\n'); i >= 0 {
+			line = line[:i]
+		}
+		format := strings.TrimPrefix(strings.SplitN(strings.TrimSuffix(line, markerClose), ",", 2)[0], "format ")
+		if format != CorpusFormat {
+			problem("unsupported corpus format %q (this cpack reads format %s)", format, CorpusFormat)
+		}
+	}
+
+	// Text between files holds the blocks; file content is never searched
+	var outside bytes.Buffer
+	pos := 0
+	for {
+		start := bytes.Index(data[pos:], []byte(startMarker))
+		if start < 0 {
+			break
+		}
+		start += pos
+		outside.Write(data[pos:start])
+
+		nameStart := start + len(startMarker)
+		nameLen := bytes.Index(data[nameStart:], []byte(markerClose))
+		if nameLen < 0 || bytes.IndexByte(data[nameStart:nameStart+nameLen], '\n') >= 0 {
+			problem("start marker at byte %d has no file name", start)
+			pos = nameStart
+			continue
+		}
+		path := string(data[nameStart : nameStart+nameLen])
+		closing := []byte(endMarker + path + markerClose)
+		end := bytes.Index(data[nameStart+nameLen:], closing)
+		if end < 0 {
+			problem("file %s has no end marker", path)
+			pos = nameStart + nameLen
+			continue
+		}
+		pos = nameStart + nameLen + end + len(closing)
+	}
+	outside.Write(data[pos:])
+
+	between := outside.Bytes()
+	if n := bytes.Count(between, []byte(endMarker)); n > 0 {
+		problem("%d end markers without a start marker", n)
+	}
+	for _, block := range corpusBlocks {
+		opened, closed := bytes.Count(between, []byte(block[0])), bytes.Count(between, []byte(block[1]))
+		if opened != closed {
+			problem("%d %q blocks but %d %q", opened, block[0], closed, block[1])
+		}
+	}
+
+	problems = append(problems, checkIndex(data)...)
+	return problems
+}
+
+// checkIndex compares the files of a corpus with the index after them
+func checkIndex(data []byte) []string {
+	start := bytes.LastIndex(data, []byte(indexMarker))
+	if start < 0 {
+		return []string{"no index"}
+	}
+	body := data[start+len(indexMarker):]
+	end := bytes.Index(body, []byte(endIndexMarker))
+	if end < 0 {
+		return []string{"index is not closed"}
+	}
+	body = body[:end]
+
+	var entries []indexEntry
+	for i, line := range strings.Split(strings.TrimSuffix(string(body), "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) != 3 {
+			return []string{fmt.Sprintf("index line %d is malformed: %q", i+1, line)}
+		}
+		size, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return []string{fmt.Sprintf("index line %d is malformed: %q", i+1, line)}
+		}
+		entries = append(entries, indexEntry{path: fields[2], size: size, sha256: fields[0]})
+	}
+
+	var problems []string
+	corpus := ParseCorpus(data[:start])
+	if len(corpus.Files) != len(entries) {
+		problems = append(problems, fmt.Sprintf("index lists %d files but the corpus holds %d", len(entries), len(corpus.Files)))
+	}
+	for i, f := range corpus.Files {
+		if i >= len(entries) {
+			break
+		}
+		want := entries[i]
+		got := newIndexEntry(f.Path, corpus.Content(f))
+		switch {
+		case got.path != want.path:
+			problems = append(problems, fmt.Sprintf("file %d is %s but the index lists %s", i+1, got.path, want.path))
+		case got.size != want.size:
+			problems = append(problems, fmt.Sprintf("%s is %d bytes but the index lists %d", got.path, got.size, want.size))
+		case got.sha256 != want.sha256:
+			problems = append(problems, fmt.Sprintf("%s does not match its index checksum", got.path))
+		}
+	}
+	return problems
+}
+
+var validateCmd = &cobra.Command{
+	Use:   "validate corpus...",
+	Short: "Check that corpora are intact",
+	Long: `Check that text corpora are intact: the version line names a format this
+cpack reads, every file and block separator is balanced, and every file
+matches the size and SHA-256 checksum in the index that closes the corpus.
+Corpora may be plain, gzipped, zstd-compressed or base64- or base85-encoded.
+Problems are printed per corpus, and the command fails if any are found.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		failed := 0
+		for _, path := range args {
+			corpus, err := LoadCorpus(path)
+			if err != nil {
+				return err
+			}
+			problems := ValidateCorpus(corpus.Data)
+			if len(problems) == 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s: ok, %d files\n", path, len(corpus.Files))
+				continue
+			}
+			failed++
+			for _, p := range problems {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s: %s\n", path, p)
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d corpora failed validation", failed, len(args))
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+// packsVerbatim reports whether a file's bytes reach the corpus unchanged
+func (p *fileProcessor) packsVerbatim(relPath string, fileConfig *Config) bool {
+	return !rewritesContent(fileConfig) && !fileConfig.Anonymize && !isDocument(relPath) &&
+		!p.minified[relPath] && p.config.Hooks.PreFile == "" && len(p.config.Plugins) == 0
+}
+
+// verifyOutput re-reads the corpus just written through its encoding and
+// compression, validates its separators and index, and compares every file
+// packed verbatim with its source. Problems are printed to stderr and fail
+// the pack, so a truncated or mis-encoded corpus is never shipped.
+func (p *fileProcessor) verifyOutput() error {
+	output := p.config.OutputFile
+	corpus, err := LoadCorpus(output)
+	if err != nil {
+		return fmt.Errorf("error verifying %s: %w", output, err)
+	}
+
+	problems := ValidateCorpus(corpus.Data)
+	packed := make(map[string]CorpusFile, len(corpus.Files))
+	for _, f := range corpus.Files {
+		packed[f.Path] = f
+	}
+	for _, entry := range p.files {
+		if !p.packsVerbatim(entry.relPath, p.configFor(entry.relPath)) {
+			continue
+		}
+		name := p.displayPath(entry.relPath)
+		f, ok := packed[name]
+		if !ok {
+			// Skipped while packing, such as for a read error
+			continue
+		}
+		source, err := os.ReadFile(entry.absPath)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("error reading source of %s: %v", name, err))
+			continue
+		}
+		if !bytes.Equal(corpus.Content(f), source) {
+			problems = append(problems, fmt.Sprintf("%s does not match its source", name))
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	for _, problem := range problems {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", output, problem)
+	}
+	return fmt.Errorf("verification of %s failed: %d problems", output, len(problems))
+}
+
+// validateVerify checks that --verify can read back a text corpus
+func validateVerify(config *Config) error {
+	if !config.Verify {
+		return nil
+	}
+	switch {
+	case !stampsCorpus(config):
+		return fmt.Errorf("--verify checks text corpora; it cannot be combined with --format %s", config.OutputFormat)
+	case isObjectURL(config.OutputFile) || isPipeOutput(config.OutputFile):
+		return fmt.Errorf("--verify cannot read back %s", config.OutputFile)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// VocabOptions controls identifier frequency extraction
+type VocabOptions struct {
+	Top       int             // Number of terms to keep per n-gram size (0 keeps all)
+	MaxNGram  int             // Largest n-gram size to count
+	Stopwords map[string]bool // Terms ignored when counting
+}
+
+// VocabTerm is a single n-gram and its frequency
+type VocabTerm struct {
+	Term  string `json:"term"`
+	N     int    `json:"n"`
+	Count int    `json:"count"`
+}
+
+// VocabReport holds identifier frequency statistics for the selected files
+type VocabReport struct {
+	Files       int         `json:"files"`
+	TotalTokens int         `json:"totalTokens"`
+	UniqueTerms int         `json:"uniqueTerms"`
+	Terms       []VocabTerm `json:"terms"`
+}
+
+var identifierRegex = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+
+var (
+	vocabConfig    Config
+	vocabFormat    string
+	vocabOutput    string
+	vocabStopwords string
+	vocabOptions   = VocabOptions{Top: 50, MaxNGram: 1}
+
+	vocabCmd = &cobra.Command{
+		Use:   "vocab [directory]",
+		Short: "Report identifier and n-gram frequencies for the selected files",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				vocabConfig.InputDir = args[0]
+			}
+			applySelectionFlags(cmd, &vocabConfig)
+
+			opts := vocabOptions
+			if vocabStopwords != "" {
+				stopwords, err := loadStopwords(vocabStopwords)
+				if err != nil {
+					return err
+				}
+				opts.Stopwords = stopwords
+			}
+
+			report, err := BuildVocab(vocabConfig, opts)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			if vocabOutput != "" {
+				f, err := os.Create(vocabOutput)
+				if err != nil {
+					return fmt.Errorf("error creating output file: %w", err)
+				}
+				defer f.Close()
+				out = f
+			}
+
+			return writeVocabReport(out, report, vocabFormat)
+		},
+	}
+)
+
+func init() {
+	addSelectionFlags(vocabCmd, &vocabConfig)
+	vocabCmd.Flags().StringVarP(&vocabOutput, "output", "o", "",
+		"Write the report to a file instead of stdout")
+	vocabCmd.Flags().StringVarP(&vocabFormat, "format", "f", "csv",
+		"Report format: csv or json")
+	vocabCmd.Flags().IntVar(&vocabOptions.Top, "top", vocabOptions.Top,
+		"Number of terms to report per n-gram size (0 for all)")
+	vocabCmd.Flags().IntVar(&vocabOptions.MaxNGram, "ngram", vocabOptions.MaxNGram,
+		"Count n-grams of identifiers up to this size")
+	vocabCmd.Flags().StringVar(&vocabStopwords, "stopwords", "",
+		"File of terms to ignore, one per line")
+
+	rootCmd.AddCommand(vocabCmd)
+}
+
+// BuildVocab counts identifier n-gram frequencies across the files selected by config
+func BuildVocab(config Config, opts VocabOptions) (*VocabReport, error) {
+	if opts.MaxNGram < 1 {
+		return nil, fmt.Errorf("n-gram size must be at least 1, got %d", opts.MaxNGram)
+	}
+
+	processor, err := selectFiles(config)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]map[string]int, opts.MaxNGram+1)
+	for n := 1; n <= opts.MaxNGram; n++ {
+		counts[n] = make(map[string]int)
+	}
+
+	report := &VocabReport{}
+	for _, entry := range processor.files {
+		content, err := os.ReadFile(entry.absPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", entry.absPath, err)
+			continue
+		}
+		report.Files++
+
+		var tokens []string
+		for _, token := range identifierRegex.FindAllString(string(content), -1) {
+			if !opts.Stopwords[token] {
+				tokens = append(tokens, token)
+			}
+		}
+		report.TotalTokens += len(tokens)
+
+		for n := 1; n <= opts.MaxNGram; n++ {
+			for i := 0; i+n <= len(tokens); i++ {
+				counts[n][strings.Join(tokens[i:i+n], " ")]++
+			}
+		}
+	}
+
+	report.UniqueTerms = len(counts[1])
+
+	for n := 1; n <= opts.MaxNGram; n++ {
+		terms := make([]VocabTerm, 0, len(counts[n]))
+		for term, count := range counts[n] {
+			terms = append(terms, VocabTerm{Term: term, N: n, Count: count})
+		}
+
+		// Most frequent first, ties broken alphabetically for stable output
+		sort.Slice(terms, func(i, j int) bool {
+			if terms[i].Count != terms[j].Count {
+				return terms[i].Count > terms[j].Count
+			}
+			return terms[i].Term < terms[j].Term
+		})
+
+		if opts.Top > 0 && len(terms) > opts.Top {
+			terms = terms[:opts.Top]
+		}
+		report.Terms = append(report.Terms, terms...)
+	}
+
+	return report, nil
+}
+
+// writeVocabReport writes the report as CSV or JSON
+func writeVocabReport(w io.Writer, report *VocabReport, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case "csv", "":
+		writer := csv.NewWriter(w)
+		if err := writer.Write([]string{"term", "n", "count"}); err != nil {
+			return err
+		}
+		for _, term := range report.Terms {
+			record := []string{term.Term, strconv.Itoa(term.N), strconv.Itoa(term.Count)}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	default:
+		return fmt.Errorf("unsupported vocab format: %s", format)
+	}
+}
+
+// loadStopwords reads a stopword list, one term per line
+func loadStopwords(path string) (map[string]bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading stopwords file: %w", err)
+	}
+	defer f.Close()
+
+	stopwords := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if word := strings.TrimSpace(scanner.Text()); word != "" {
+			stopwords[word] = true
+		}
+	}
+
+	return stopwords, scanner.Err()
+}
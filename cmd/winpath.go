@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// caseInsensitivePaths is set where the filesystem ignores the case of
+// file names, so globs match them case-insensitively
+var caseInsensitivePaths = runtime.GOOS == "windows"
+
+// stripLongPathPrefix removes the \\?\ prefix of a Windows long path,
+// turning \\?\UNC\server\share into \\server\share. Go adds the prefix
+// itself where a path needs it, and without it the path compares equal
+// to the walked paths and the output.
+func stripLongPathPrefix(path string) string {
+	switch {
+	case strings.HasPrefix(path, `\\?\UNC\`):
+		return `\\` + path[len(`\\?\UNC\`):]
+	case strings.HasPrefix(path, `\\?\`):
+		return path[len(`\\?\`):]
+	}
+	return path
+}
+
+// resolvePath makes a config path absolute against cwd. A path with a
+// drive letter, such as C:src, is relative to that drive's working
+// directory rather than to cwd.
+func resolvePath(cwd, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	if filepath.VolumeName(path) != "" {
+		if abs, err := filepath.Abs(path); err == nil {
+			return abs
+		}
+		return path
+	}
+	return filepath.Join(cwd, path)
+}
+
+// normalizeGlob turns the backslashes of a Windows-style glob such as
+// src\**\*.go into the forward slashes corpus paths use
+func normalizeGlob(pattern string) string {
+	return strings.ReplaceAll(pattern, `\`, "/")
+}
+
+// normalizeGlobList returns globs with normalized separators, leaving the
+// caller's slice untouched
+func normalizeGlobList(globs []string) []string {
+	if globs == nil {
+		return nil
+	}
+	normalized := make([]string, len(globs))
+	for i, glob := range globs {
+		normalized[i] = normalizeGlob(glob)
+	}
+	return normalized
+}
+
+// normalizeGlobKeys returns a map keyed by globs with normalized separators
+func normalizeGlobKeys(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	normalized := make(map[string]string, len(m))
+	for glob, value := range m {
+		normalized[normalizeGlob(glob)] = value
+	}
+	return normalized
+}
+
+// normalizeGlobs accepts both / and \ as separators in every glob of a
+// config, so a cpack.yaml written on Windows selects the same files
+// everywhere
+func normalizeGlobs(config Config) Config {
+	config.IncludeGlobs = normalizeGlobList(config.IncludeGlobs)
+	config.ExcludeGlobs = normalizeGlobList(config.ExcludeGlobs)
+	config.PriorityGlobs = normalizeGlobList(config.PriorityGlobs)
+	config.LockfileGlobs = normalizeGlobList(config.LockfileGlobs)
+	config.AnonymizeDirs = normalizeGlobList(config.AnonymizeDirs)
+	config.EntryFiles = normalizeGlobList(config.EntryFiles)
+	config.Budget = normalizeGlobKeys(config.Budget)
+	config.FenceLanguages = normalizeGlobKeys(config.FenceLanguages)
+
+	if config.Rules != nil {
+		rules := make([]Rule, len(config.Rules))
+		for i, rule := range config.Rules {
+			rule.Glob = normalizeGlob(rule.Glob)
+			rules[i] = rule
+		}
+		config.Rules = rules
+	}
+	return config
+}
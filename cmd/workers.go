@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// fileDigest is what the worker stages work out for a file ahead of packing
+type fileDigest struct {
+	path    string
+	content []byte // Held from the read until the packer takes it
+	size    int
+	modTime time.Time
+	read    bool
+	sha256  string
+	tokens  int
+	counted bool
+	done    chan struct{} // Closed once every stage has seen the file
+	passed  bool          // Taken off the queue by the packer
+}
+
+// precountTokens counts the tokens of the selected files on TokenWorkers
+// goroutines, reading them on IOWorkers, when the budget needs every count
+// before packing starts. The content is not kept, so those files are read
+// again as they are packed.
+func (p *fileProcessor) precountTokens() {
+	if !p.usesWorkers() || p.config.MaxTokens <= 0 || p.config.Tokenizer == "" || p.config.Tokenizer == TokenizerEstimate {
+		return
+	}
+
+	p.digests = make(map[string]*fileDigest, len(p.files))
+	var digests []*fileDigest
+	for _, entry := range p.files {
+		// Files streamed for --max-memory are never held whole
+		if _, ok := p.digests[entry.absPath]; ok || p.exceedsMemory(entry.absPath) {
+			continue
+		}
+		digest := &fileDigest{path: entry.absPath}
+		p.digests[entry.absPath] = digest
+		digests = append(digests, digest)
+	}
+
+	paths := make(chan *fileDigest)
+	go func() {
+		defer close(paths)
+		for _, digest := range digests {
+			paths <- digest
+		}
+	}()
+	read := runStage(p.config.IOWorkers, paths, p.readDigest)
+	counted := runStage(p.config.TokenWorkers, read, func(d *fileDigest) {
+		if d.read {
+			d.tokens, d.counted = p.countTokens(d.content), true
+		}
+		d.content = nil
+	})
+	for range counted {
+	}
+}
+
+// prefetch starts reading the selected files on IOWorkers goroutines,
+// hashing them on HashWorkers and tokenizing them on TokenWorkers, in
+// corpus order, so slow disks and expensive tokenizers keep every core busy
+// while the corpus is written. The packer takes each file's content off a
+// queue a few files long, which bounds the memory held ahead of it. Without
+// any worker counts, or in low-memory mode, files are handled one at a time
+// as they are packed. The returned function stops the workers early.
+func (p *fileProcessor) prefetch() func() {
+	if !p.usesWorkers() {
+		return func() {}
+	}
+
+	if p.digests == nil {
+		p.digests = make(map[string]*fileDigest, len(p.files))
+	}
+	var digests []*fileDigest
+	for _, entry := range p.files {
+		if p.exceedsMemory(entry.absPath) {
+			continue
+		}
+		digest, ok := p.digests[entry.absPath]
+		if !ok {
+			digest = &fileDigest{path: entry.absPath}
+			p.digests[entry.absPath] = digest
+		} else if digest.done != nil {
+			continue
+		}
+		digest.done = make(chan struct{})
+		digests = append(digests, digest)
+	}
+
+	queueLen := max(p.config.IOWorkers, p.config.HashWorkers, p.config.TokenWorkers, 1) * 2
+	p.queue = make(chan *fileDigest, queueLen)
+	stop := make(chan struct{})
+	paths := make(chan *fileDigest)
+	go func() {
+		defer close(paths)
+		defer close(p.queue)
+		for _, digest := range digests {
+			// A file enters the pipeline only once it has a place in the queue
+			select {
+			case p.queue <- digest:
+			case <-stop:
+				return
+			}
+			paths <- digest
+		}
+	}()
+
+	read := runStage(p.config.IOWorkers, paths, p.readDigest)
+	hashed := runStage(p.config.HashWorkers, read, func(d *fileDigest) {
+		if d.read {
+			sum := sha256.Sum256(d.content)
+			d.sha256 = hex.EncodeToString(sum[:])
+		}
+	})
+	countTokens := p.config.Verbose || (p.config.MaxTokens > 0 && p.config.Tokenizer != "" && p.config.Tokenizer != TokenizerEstimate)
+	counted := runStage(p.config.TokenWorkers, hashed, func(d *fileDigest) {
+		if d.read && countTokens && !d.counted {
+			d.tokens, d.counted = p.countTokens(d.content), true
+		}
+		close(d.done)
+	})
+	go func() {
+		for range counted {
+		}
+	}()
+
+	return func() { close(stop) }
+}
+
+// usesWorkers reports whether any worker count turns the pipeline on
+func (p *fileProcessor) usesWorkers() bool {
+	return !p.config.LowMemory && (p.config.IOWorkers > 0 || p.config.HashWorkers > 0 || p.config.TokenWorkers > 0)
+}
+
+// readDigest reads a file for the worker stages
+func (p *fileProcessor) readDigest(d *fileDigest) {
+	info, err := os.Stat(d.path)
+	if err != nil {
+		// Packing reads the file again and reports the error
+		return
+	}
+	content, err := os.ReadFile(d.path)
+	if err != nil {
+		return
+	}
+	d.content, d.size, d.modTime, d.read = content, len(content), info.ModTime(), true
+}
+
+// takeContent returns the content prefetched for a file and lets the next
+// file into the queue, or nil when the file has to be read by the packer.
+// Files the packer passes over lose their content.
+func (p *fileProcessor) takeContent(path string) []byte {
+	d, ok := p.digests[path]
+	if !ok || d.done == nil || d.passed {
+		return nil
+	}
+	for next := range p.queue {
+		<-next.done
+		next.passed = true
+		if next == d {
+			content := d.content
+			d.content = nil
+			return content
+		}
+		next.content = nil
+	}
+	return nil
+}
+
+// runStage calls fn on n goroutines for the digests from in, passing each
+// on once done, and closes the returned channel when in is drained
+func runStage(n int, in <-chan *fileDigest, fn func(*fileDigest)) <-chan *fileDigest {
+	if n < 1 {
+		n = 1
+	}
+	out := make(chan *fileDigest, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for d := range in {
+				fn(d)
+				out <- d
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// digest returns the precomputed digest of a file, if the packer has
+// taken it and the file still has the size and modification time it was
+// read with
+func (p *fileProcessor) digest(path string, size int) (*fileDigest, bool) {
+	d, ok := p.digests[path]
+	if !ok || !d.read || d.size != size || (d.done != nil && !d.passed) {
+		return nil, false
+	}
+	info, err := os.Stat(path)
+	if err != nil || !info.ModTime().Equal(d.modTime) {
+		return nil, false
+	}
+	return d, true
+}
+
+// sourceSHA256 returns the hex SHA-256 of a file's content
+func (p *fileProcessor) sourceSHA256(path string, content []byte) string {
+	if d, ok := p.digest(path, len(content)); ok {
+		return d.sha256
+	}
+	return p.config.cache.hash(path, content, func() string {
+		sum := sha256.Sum256(content)
+		return hex.EncodeToString(sum[:])
+	})
+}
+
+// sourceTokens returns the token count of a file's content
+func (p *fileProcessor) sourceTokens(path string, content []byte) int {
+	if d, ok := p.digest(path, len(content)); ok && d.counted {
+		return d.tokens
+	}
+	tokens, _ := p.config.cache.count(path, p.config.Tokenizer, func() (int, bool) {
+		return p.countTokens(content), true
+	})
+	return tokens
+}
+
+// validateWorkers checks that worker counts are not negative
+func validateWorkers(config *Config) error {
+	for _, workers := range []struct {
+		flag string
+		n    int
+	}{
+		{"--io-workers", config.IOWorkers},
+		{"--hash-workers", config.HashWorkers},
+		{"--token-workers", config.TokenWorkers},
+	} {
+		if workers.n < 0 {
+			return fmt.Errorf("%s must not be negative, got %d", workers.flag, workers.n)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,366 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Workspace kinds
+const (
+	WorkspaceGo    = "go"    // A module listed in go.work
+	WorkspaceNPM   = "npm"   // A package matched by package.json workspaces
+	WorkspaceCargo = "cargo" // A crate matched by Cargo.toml [workspace] members
+)
+
+// Workspace is a member of a monorepo workspace
+type Workspace struct {
+	Kind string
+	Path string // Slash-separated and relative to the workspace root, "." for the root itself
+}
+
+// workspaceSkipDirs are never searched for workspace members
+var workspaceSkipDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+	"target":       true,
+	"vendor":       true,
+}
+
+// DetectWorkspaces returns the members of the go.work, package.json
+// workspaces and Cargo.toml workspace declared in dir, sorted by path. A
+// member must hold its own go.mod, package.json or Cargo.toml; a directory
+// listed by more than one tool is returned once, for the first tool in that
+// order.
+func DetectWorkspaces(dir string) ([]Workspace, error) {
+	detectors := []struct {
+		kind     string
+		manifest string
+		members  func(dir string) (include, exclude []string, err error)
+	}{
+		{WorkspaceGo, "go.mod", goWorkMembers},
+		{WorkspaceNPM, "package.json", npmWorkspaceMembers},
+		{WorkspaceCargo, "Cargo.toml", cargoWorkspaceMembers},
+	}
+
+	seen := make(map[string]bool)
+	var workspaces []Workspace
+	for _, d := range detectors {
+		include, exclude, err := d.members(dir)
+		if err != nil {
+			return nil, err
+		}
+		if len(include) == 0 {
+			continue
+		}
+
+		paths, err := expandWorkspaceGlobs(dir, include, exclude, d.manifest)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			if !seen[path] {
+				seen[path] = true
+				workspaces = append(workspaces, Workspace{Kind: d.kind, Path: path})
+			}
+		}
+	}
+
+	sort.Slice(workspaces, func(i, j int) bool { return workspaces[i].Path < workspaces[j].Path })
+	return workspaces, nil
+}
+
+// goWorkMembers returns the directories named by the use directives of
+// dir/go.work
+func goWorkMembers(dir string) ([]string, []string, error) {
+	data, err := readWorkspaceFile(dir, "go.work")
+	if data == nil {
+		return nil, nil, err
+	}
+
+	var members []string
+	inBlock := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case inBlock && fields[0] == ")":
+			inBlock = false
+		case inBlock:
+			members = append(members, unquote(fields[0]))
+		case fields[0] == "use" && len(fields) > 1 && fields[1] == "(":
+			inBlock = true
+		case fields[0] == "use" && len(fields) > 1:
+			members = append(members, unquote(fields[1]))
+		}
+	}
+	return members, nil, nil
+}
+
+// npmWorkspaceMembers returns the workspaces globs of dir/package.json,
+// given either as a list or as the packages field of an object. Globs
+// starting with ! exclude packages.
+func npmWorkspaceMembers(dir string) ([]string, []string, error) {
+	data, err := readWorkspaceFile(dir, "package.json")
+	if data == nil {
+		return nil, nil, err
+	}
+
+	var pkg struct {
+		Workspaces json.RawMessage `json:"workspaces"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, nil, fmt.Errorf("error parsing package.json: %w", err)
+	}
+	if len(pkg.Workspaces) == 0 {
+		return nil, nil, nil
+	}
+
+	var globs []string
+	if err := json.Unmarshal(pkg.Workspaces, &globs); err != nil {
+		var object struct {
+			Packages []string `json:"packages"`
+		}
+		if err := json.Unmarshal(pkg.Workspaces, &object); err != nil {
+			return nil, nil, fmt.Errorf("error parsing package.json workspaces: %w", err)
+		}
+		globs = object.Packages
+	}
+
+	var include, exclude []string
+	for _, glob := range globs {
+		if negated, ok := strings.CutPrefix(glob, "!"); ok {
+			exclude = append(exclude, negated)
+		} else {
+			include = append(include, glob)
+		}
+	}
+	return include, exclude, nil
+}
+
+// cargoWorkspaceMembers returns the members and exclude lists of the
+// [workspace] table in dir/Cargo.toml
+func cargoWorkspaceMembers(dir string) ([]string, []string, error) {
+	data, err := readWorkspaceFile(dir, "Cargo.toml")
+	if data == nil {
+		return nil, nil, err
+	}
+
+	// Only the string arrays of one table are needed, so the file is
+	// scanned rather than fully parsed as TOML
+	var table strings.Builder
+	inWorkspace := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") && !strings.HasPrefix(line, "[[") && strings.HasSuffix(line, "]") &&
+			!strings.Contains(line, "=") {
+			inWorkspace = line == "[workspace]"
+			continue
+		}
+		if inWorkspace {
+			table.WriteString(line + "\n")
+		}
+	}
+
+	members, err := tomlStringArray(table.String(), "members")
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing Cargo.toml: %w", err)
+	}
+	exclude, err := tomlStringArray(table.String(), "exclude")
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing Cargo.toml: %w", err)
+	}
+	return members, exclude, nil
+}
+
+// tomlStringArray returns the strings of the array assigned to key in a
+// TOML table body, which may span several lines
+func tomlStringArray(table, key string) ([]string, error) {
+	var value string
+	lines := strings.Split(table, "\n")
+	for i, line := range lines {
+		name, rest, ok := strings.Cut(line, "=")
+		if ok && strings.TrimSpace(name) == key {
+			value = strings.TrimSpace(strings.Join(append([]string{rest}, lines[i+1:]...), "\n"))
+			break
+		}
+	}
+	if value == "" {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(value, "[") {
+		return nil, fmt.Errorf("%s must be an array", key)
+	}
+	end := strings.Index(value, "]")
+	if end < 0 {
+		return nil, fmt.Errorf("unterminated %s array", key)
+	}
+
+	var items []string
+	for _, line := range strings.Split(value[1:end], "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		for _, item := range strings.Split(line, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, unquote(item))
+			}
+		}
+	}
+	return items, nil
+}
+
+// readWorkspaceFile reads a workspace manifest from dir, returning nil
+// without an error when there is none
+func readWorkspaceFile(dir, name string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", name, err)
+	}
+	return data, nil
+}
+
+// unquote strips the quotes around a workspace path, if it has them
+func unquote(s string) string {
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		return unquoted
+	}
+	return strings.Trim(s, `'`)
+}
+
+// expandWorkspaceGlobs returns the directories under dir that match one of
+// the include globs and none of the exclude globs and hold manifest
+func expandWorkspaceGlobs(dir string, include, exclude []string, manifest string) ([]string, error) {
+	clean := func(globs []string) []string {
+		cleaned := make([]string, 0, len(globs))
+		for _, glob := range globs {
+			cleaned = append(cleaned, filepath.ToSlash(filepath.Clean(filepath.FromSlash(glob))))
+		}
+		return cleaned
+	}
+	include, exclude = clean(include), clean(exclude)
+
+	matchAny := func(globs []string, path string) bool {
+		for _, glob := range globs {
+			if matched, _ := matchGlobPattern(glob, path); matched {
+				return true
+			}
+		}
+		return false
+	}
+
+	var members []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if workspaceSkipDirs[d.Name()] && path != dir {
+			return filepath.SkipDir
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !matchAny(include, rel) || matchAny(exclude, rel) {
+			return nil
+		}
+		if _, err := os.Stat(filepath.Join(path, manifest)); err == nil {
+			members = append(members, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error finding workspace members: %w", err)
+	}
+	return members, nil
+}
+
+// workspaceName names a member's outputs after its path, "root" for the
+// workspace root itself
+func workspaceName(path string) string {
+	if path == "." {
+		return "root"
+	}
+	return strings.ReplaceAll(path, "/", "-")
+}
+
+// outputVariant inserts -name into an output path before its extensions,
+// so corpus-out.txt.gz becomes corpus-out-name.txt.gz
+func outputVariant(path, name string) string {
+	if path == "" {
+		return ""
+	}
+	dir, base := filepath.Split(path)
+	stem, ext := base, ""
+	if i := strings.Index(base[1:], "."); i >= 0 {
+		stem, ext = base[:i+1], base[i+1:]
+	}
+	return filepath.Join(dir, stem+"-"+name+ext)
+}
+
+// processWorkspaces packs each workspace member of the input directory into
+// its own output, named after the member's path
+func processWorkspaces(config Config) error {
+	workspaces, err := DetectWorkspaces(config.InputDir)
+	if err != nil {
+		return err
+	}
+	if len(workspaces) == 0 {
+		return fmt.Errorf("no workspace found in %s (looked for go.work, package.json workspaces and a Cargo.toml [workspace])",
+			config.InputDir)
+	}
+
+	// A member at the root would otherwise walk into the other members' corpora
+	outputs := make([]string, 0, len(workspaces))
+	for _, workspace := range workspaces {
+		outputs = append(outputs, outputVariant(config.OutputFile, workspaceName(workspace.Path)))
+	}
+
+	for _, workspace := range workspaces {
+		member := config
+		member.runOutputs = outputs
+		member.PerWorkspace = false
+		member.InputDir = filepath.Join(config.InputDir, filepath.FromSlash(workspace.Path))
+
+		name := workspaceName(workspace.Path)
+		member.OutputFile = outputVariant(config.OutputFile, name)
+		member.ReportFile = outputVariant(config.ReportFile, name)
+		member.ManifestFile = outputVariant(config.ManifestFile, name)
+		member.AnonymizeMapFile = outputVariant(config.AnonymizeMapFile, name)
+
+		// The member's own config file may add to the run's settings, but
+		// the profile and metrics stay with the run
+		member, err := prepareConfig(member)
+		if err == nil {
+			member.Pprof = config.Pprof
+			member.MetricsFile = config.MetricsFile
+			err = packDirectory(member)
+		}
+		if err != nil {
+			return fmt.Errorf("error packing workspace %s: %w", workspace.Path, err)
+		}
+	}
+	return nil
+}
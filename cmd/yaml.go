@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlCorpusMarker opens every YAML corpus, so it can be told from a text one
+const yamlCorpusMarker = "# cpack corpus\n"
+
+// yamlCorpus is the document written by --format yaml and read back by
+// LoadCorpus. Header and footer hold the text before the first file and
+// after the last, such as the instructions, summary and git log.
+type yamlCorpus struct {
+	Header string     `yaml:"header,omitempty"`
+	Files  []yamlFile `yaml:"files"`
+	Footer string     `yaml:"footer,omitempty"`
+}
+
+// yamlFile is one file of a YAML corpus
+type yamlFile struct {
+	Path       string `yaml:"path"`
+	Language   string `yaml:"language,omitempty"`
+	Note       string `yaml:"note,omitempty"`
+	LastCommit string `yaml:"lastCommit,omitempty"`
+	Content    string `yaml:"content"`
+}
+
+// renderYAML writes a corpus as a YAML document with one files: entry per
+// file. Multi-line text is written as block scalars where YAML allows it
+// and quoted otherwise, so content always reads back byte for byte.
+func renderYAML(w io.Writer, corpus *Corpus, config *Config, title string) error {
+	head, tail := corpusMeta(corpus)
+	files := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, f := range corpus.Files {
+		content := corpus.Content(f)
+		files.Content = append(files.Content, yamlMapping(
+			"path", f.Path,
+			"language", DetectLanguage(f.Path, content),
+			"note", f.Note,
+			"lastCommit", f.LastCommit,
+			"content", string(content),
+		))
+	}
+	doc := yamlMapping("header", metaText(head))
+	doc.Content = append(doc.Content, yamlScalar("files"), files)
+	doc.Content = append(doc.Content, yamlMapping("footer", metaText(tail)).Content...)
+
+	if _, err := io.WriteString(w, yamlCorpusMarker); err != nil {
+		return fmt.Errorf("error writing output file: %w", err)
+	}
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("error writing output file: %w", err)
+	}
+	return encoder.Close()
+}
+
+// metaText returns text outside any file, or "" when it is only blank lines
+func metaText(text []byte) string {
+	if len(bytes.TrimSpace(text)) == 0 {
+		return ""
+	}
+	return string(text)
+}
+
+// yamlMapping builds a mapping from key, value pairs, leaving out empty
+// values other than content
+func yamlMapping(pairs ...string) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		key, value := pairs[i], pairs[i+1]
+		if value == "" && key != "content" {
+			continue
+		}
+		node.Content = append(node.Content, yamlScalar(key), yamlScalar(value))
+	}
+	return node
+}
+
+// yamlScalar builds a string scalar, asking for a block scalar when it
+// spans lines. The encoder falls back to quoting where a block scalar
+// cannot hold the text exactly. Invalid UTF-8 cannot be a YAML string at
+// all, so it is written as !!binary, which decodes back to the same bytes.
+func yamlScalar(value string) *yaml.Node {
+	if !utf8.ValidString(value) {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!binary", Value: base64.StdEncoding.EncodeToString([]byte(value))}
+	}
+	node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+	if strings.Contains(value, "\n") {
+		node.Style = yaml.LiteralStyle
+	}
+	return node
+}
+
+// isYAMLCorpus reports whether decoded corpus data is a YAML corpus
+func isYAMLCorpus(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(yamlCorpusMarker))
+}
+
+// yamlToText converts a YAML corpus back to the text format it was
+// rendered from
+func yamlToText(data []byte) ([]byte, error) {
+	var doc yamlCorpus
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("error parsing yaml corpus: %w", err)
+	}
+
+	files := make([]corpusEntry, 0, len(doc.Files))
+	for _, f := range doc.Files {
+		files = append(files, corpusEntry{path: f.Path, note: f.Note, lastCommit: f.LastCommit, content: []byte(f.Content)})
+	}
+	return textCorpus(doc.Header, files, doc.Footer), nil
+}
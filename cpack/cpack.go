@@ -0,0 +1,109 @@
+// Package cpack packs source trees into corpora for LLMs. It is the library
+// counterpart of the cpack command: a Packer built from options runs the
+// same selection, transforms and output formats.
+//
+//	packer := cpack.New(cpack.WithInclude("**/*.go"), cpack.WithGzip())
+//	if err := packer.Pack("./service", "corpus.txt.gz"); err != nil {
+//		log.Fatal(err)
+//	}
+package cpack
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+// Packer packs directories with a fixed set of options
+type Packer struct {
+	config cmd.Config
+}
+
+// New returns a Packer with the given options, applied in order. Options
+// are checked when packing, so an invalid one is reported by Pack.
+func New(opts ...Option) *Packer {
+	p := &Packer{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Pack packs dir into output, which may be anything the cpack command
+// accepts as --output, such as a file path or an object-store URL. A cpack
+// config file in dir fills any options left unset, as it does for the
+// command.
+func (p *Packer) Pack(dir, output string) error {
+	config := p.configFor(dir, output)
+	return cmd.ProcessDirectory(config)
+}
+
+// PackFS packs the files of fsys into output. The files are copied to a
+// temporary directory first, so git metadata is not available.
+func (p *Packer) PackFS(fsys fs.FS, output string) error {
+	dir, err := os.MkdirTemp("", "cpack-fs-*")
+	if err != nil {
+		return fmt.Errorf("error creating temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	root := filepath.Join(dir, "root")
+	if err := os.CopyFS(root, fsys); err != nil {
+		return fmt.Errorf("error copying files: %w", err)
+	}
+	return p.Pack(root, output)
+}
+
+// PackTo packs dir and writes the corpus to w. The corpus is packed into a
+// temporary file first, so it must be a single file: link farms and
+// per-workspace or per-directory output are rejected.
+func (p *Packer) PackTo(w io.Writer, dir string) error {
+	switch {
+	case p.config.OutputFormat == cmd.FormatLinkFarm:
+		return fmt.Errorf("PackTo cannot write a link farm, which is a directory")
+	case p.config.PerWorkspace || p.config.SplitByDir > 0:
+		return fmt.Errorf("PackTo writes a single corpus, so it cannot be combined with per-workspace or per-directory output")
+	}
+
+	tmp, err := os.MkdirTemp("", "cpack-out-*")
+	if err != nil {
+		return fmt.Errorf("error creating temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	// Gzipped output is named .gz, so the name is not changed under us
+	output := filepath.Join(tmp, "corpus")
+	if p.config.Gzip {
+		output += ".gz"
+	}
+	if err := p.Pack(dir, output); err != nil {
+		return err
+	}
+
+	f, err := os.Open(output)
+	if err != nil {
+		return fmt.Errorf("error reading corpus: %w", err)
+	}
+	defer f.Close()
+	if _, err := io.Copy(w, f); err != nil {
+		return fmt.Errorf("error writing corpus: %w", err)
+	}
+	return nil
+}
+
+// configFor returns the packer's config for one run. Packing cleans glob
+// patterns in place, so the lists are copied to keep runs independent.
+func (p *Packer) configFor(dir, output string) cmd.Config {
+	config := p.config
+	config.InputDir = dir
+	config.OutputFile = output
+	config.IncludeGlobs = append([]string(nil), config.IncludeGlobs...)
+	config.ExcludeGlobs = append([]string(nil), config.ExcludeGlobs...)
+	config.PriorityGlobs = append([]string(nil), config.PriorityGlobs...)
+	config.Rules = append([]cmd.Rule(nil), config.Rules...)
+	return config
+}
@@ -0,0 +1,34 @@
+package cpack
+
+import (
+	"context"
+	"iter"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
+
+// FileEntry is a selected file with its content as it would be packed
+type FileEntry = cmd.PackedFile
+
+// Files yields the files a pack of dir would hold, in corpus order, with
+// their content after every transform, so callers can build their own
+// output formats. Output options such as the format and gzip are ignored.
+//
+// A file that cannot be read is yielded with its error and iteration goes
+// on; an error selecting files, secrets found when scanning in fail mode,
+// or ctx being done, is yielded last.
+//
+//	for file, err := range packer.Files(ctx, "./service") {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(file.Path, len(file.Content))
+//	}
+func (p *Packer) Files(ctx context.Context, dir string) iter.Seq2[FileEntry, error] {
+	return func(yield func(FileEntry, error) bool) {
+		// EachFile returns nil once yield stops it
+		if err := cmd.EachFile(ctx, p.configFor(dir, ""), yield); err != nil {
+			yield(FileEntry{}, err)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package cpack
+
+import "github.com/oreofeolurin/corpus-packer/cpack/cmd"
+
+// Option configures a Packer. New options are added as functions, so code
+// written against this package keeps compiling as cpack grows.
+type Option func(*Packer)
+
+// WithConfig starts from a full command config, for settings without an
+// option of their own. Options after it override its fields.
+func WithConfig(config cmd.Config) Option {
+	return func(p *Packer) {
+		p.config = config
+	}
+}
+
+// WithConfigFile reads options from a cpack config file instead of looking
+// for one in the packed directory
+func WithConfigFile(path string) Option {
+	return func(p *Packer) {
+		p.config.ConfigFile = path
+	}
+}
+
+// WithPreset selects files with a curated preset, such as "go"
+func WithPreset(name string) Option {
+	return func(p *Packer) {
+		p.config.Preset = name
+	}
+}
+
+// WithInclude adds glob patterns of files to pack
+func WithInclude(globs ...string) Option {
+	return func(p *Packer) {
+		p.config.IncludeGlobs = append(p.config.IncludeGlobs, globs...)
+	}
+}
+
+// WithExclude adds glob patterns of files to leave out
+func WithExclude(globs ...string) Option {
+	return func(p *Packer) {
+		p.config.ExcludeGlobs = append(p.config.ExcludeGlobs, globs...)
+	}
+}
+
+// WithLanguages packs only files detected as one of the given languages
+func WithLanguages(languages ...string) Option {
+	return func(p *Packer) {
+		p.config.IncludeLangs = append(p.config.IncludeLangs, languages...)
+	}
+}
+
+// WithPriority moves files matching the given globs to the front, in
+// pattern order
+func WithPriority(globs ...string) Option {
+	return func(p *Packer) {
+		p.config.PriorityGlobs = append(p.config.PriorityGlobs, globs...)
+	}
+}
+
+// WithInstructions opens the corpus with an instructions block
+func WithInstructions(text string) Option {
+	return func(p *Packer) {
+		p.config.Instructions = text
+	}
+}
+
+// WithFormat sets the output format, one of the cmd.Format constants
+func WithFormat(format string) Option {
+	return func(p *Packer) {
+		p.config.OutputFormat = format
+	}
+}
+
+// WithCompress strips unnecessary whitespace from packed files
+func WithCompress() Option {
+	return func(p *Packer) {
+		p.config.Compress = true
+	}
+}
+
+// WithGzip gzips the corpus
+func WithGzip() Option {
+	return func(p *Packer) {
+		p.config.Gzip = true
+	}
+}
+
+// WithEncoding encodes the corpus as text, one of the cmd.Encoding constants
+func WithEncoding(encoding string) Option {
+	return func(p *Packer) {
+		p.config.Encoding = encoding
+	}
+}
+
+// WithTokenizer counts tokens with the named tokenizer
+func WithTokenizer(name string) Option {
+	return func(p *Packer) {
+		p.config.Tokenizer = name
+	}
+}
+
+// WithMaxTokens leaves out files once the corpus reaches a token budget
+func WithMaxTokens(n int) Option {
+	return func(p *Packer) {
+		p.config.MaxTokens = n
+	}
+}
+
+// WithMaxFileTokens truncates files after n tokens, keeping their beginning
+func WithMaxFileTokens(n int) Option {
+	return func(p *Packer) {
+		p.config.MaxFileTokens = n
+	}
+}
+
+// WithVerbose adds the summary of packed and skipped files
+func WithVerbose() Option {
+	return func(p *Packer) {
+		p.config.Verbose = true
+	}
+}
+
+// WithHooks runs hook commands around packing, as the hooks section of a
+// config file does. It also allows the hooks of a config file in the
+// packed directory, which otherwise fail the pack.
+func WithHooks(hooks cmd.Hooks) Option {
+	return func(p *Packer) {
+		p.config.Hooks = hooks
+		p.config.AllowHooks = true
+	}
+}
+
+// WithPlugins loads transformer plugins, Go plugins exporting a
+// cmd.TransformFunc named Transform. Like WithHooks, it also allows the
+// hooks and plugins of a config file in the packed directory.
+func WithPlugins(paths ...string) Option {
+	return func(p *Packer) {
+		p.config.Plugins = append(p.config.Plugins, paths...)
+		p.config.AllowHooks = true
+	}
+}
+
+// WithDeterministic makes packs of an identical tree byte-identical
+func WithDeterministic() Option {
+	return func(p *Packer) {
+		p.config.Deterministic = true
+	}
+}
@@ -3,11 +3,15 @@ module github.com/oreofeolurin/corpus-packer/cpack
 go 1.23.4
 
 require (
+	github.com/alecthomas/chroma/v2 v2.20.0
+	github.com/klauspost/compress v1.18.0
 	github.com/spf13/cobra v1.9.1
+	github.com/spf13/pflag v1.0.6
+	golang.org/x/text v0.28.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
+	github.com/dlclark/regexp2 v1.11.5 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
-	github.com/spf13/pflag v1.0.6 // indirect
 )
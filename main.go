@@ -1,7 +1,14 @@
 package main
 
-import "github.com/oreofeolurin/corpus-packer/cpack/cmd"
+import (
+	"os"
+
+	"github.com/oreofeolurin/corpus-packer/cpack/cmd"
+)
 
 func main() {
-	cmd.Execute()
+	// Cobra has printed the error; scripts and CI need the exit status
+	if err := cmd.Execute(); err != nil {
+		os.Exit(1)
+	}
 }